@@ -7,16 +7,23 @@ import (
 )
 
 type Config struct {
+	STORAGE string `mapstructure:"STORAGE"`
+
 	POSTGRES_DB_USERNAME string `mapstructure:"POSTGRES_DB_USERNAME"`
 	POSTGRES_DB_PASSWORD string `mapstructure:"POSTGRES_DB_PASSWORD"`
 	POSTGRES_DB_HOST     string `mapstructure:"POSTGRES_DB_HOST"`
 	POSTGRES_DB_NAME     string `mapstructure:"POSTGRES_DB_NAME"`
 	MIGRATE_PATH         string `mapstructure:"MIGRATE_PATH"`
 
+	SQLITE_DB_PATH      string `mapstructure:"SQLITE_DB_PATH"`
+	SQLITE_MIGRATE_PATH string `mapstructure:"SQLITE_MIGRATE_PATH"`
+
 	SERVER_HOST string `mapstructure:"SERVER_HOST"`
 
 	SALT string `mapstructure:"SALT"`
 
+	PHONE_REGION string `mapstructure:"PHONE_REGION"`
+
 	ACCESS_TOKEN_EXP  int    `mapstructure:"ACCESS_TOKEN_EXP"`
 	REFRESH_TOKEN_EXP int    `mapstructure:"REFRESH_TOKEN_EXP"`
 	HS256_SECRET      string `mapstructure:"HS256_SECRET"`
@@ -25,9 +32,62 @@ type Config struct {
 	REDIS_DB_PASSWORD string `mapstructure:"REDIS_DB_PASSWORD"`
 	REDIS_DB_NAME     int    `mapstructure:"REDIS_DB_NAME"`
 
+	USER_CACHE_ENABLED bool `mapstructure:"USER_CACHE_ENABLED"`
+	USER_CACHE_TTL     int  `mapstructure:"USER_CACHE_TTL"`
+
+	USER_RESTORE_DAYS     int `mapstructure:"USER_RESTORE_DAYS"`
+	USER_PURGE_INTERVAL   int `mapstructure:"USER_PURGE_INTERVAL"`
+	USER_PURGE_BATCH_SIZE int `mapstructure:"USER_PURGE_BATCH_SIZE"`
+
+	IDEMPOTENCY_TTL int `mapstructure:"IDEMPOTENCY_TTL"`
+
 	MONGO_DB_HOST     string `mapstructure:"MONGO_DB_HOST"`
 	MONGO_DB_USERNAME string `mapstructure:"MONGO_DB_USERNAME"`
 	MONGO_DB_PASSWORD string `mapstructure:"MONGO_DB_PASSWORD"`
+
+	LOG_BUFFER_SIZE     int    `mapstructure:"LOG_BUFFER_SIZE"`
+	LOG_BATCH_SIZE      int    `mapstructure:"LOG_BATCH_SIZE"`
+	LOG_FLUSH_INTERVAL  int    `mapstructure:"LOG_FLUSH_INTERVAL"`
+	LOG_OVERFLOW_POLICY string `mapstructure:"LOG_OVERFLOW_POLICY"`
+	LOG_SPILL_PATH      string `mapstructure:"LOG_SPILL_PATH"`
+
+	LOG_RETENTION_DAYS    int    `mapstructure:"LOG_RETENTION_DAYS"`
+	LOG_RETENTION_LEVELS  string `mapstructure:"LOG_RETENTION_LEVELS"`
+	LOG_ARCHIVE_DIR       string `mapstructure:"LOG_ARCHIVE_DIR"`
+	LOG_ARCHIVE_INTERVAL  int    `mapstructure:"LOG_ARCHIVE_INTERVAL"`
+	LOG_ARCHIVE_FILE_SIZE int    `mapstructure:"LOG_ARCHIVE_FILE_SIZE"`
+
+	LOG_REDACT_MODE string `mapstructure:"LOG_REDACT_MODE"`
+	LOG_REDACT_KEYS string `mapstructure:"LOG_REDACT_KEYS"`
+
+	SAVED_PLACES_LIMIT int `mapstructure:"SAVED_PLACES_LIMIT"`
+
+	ADMIN_API_KEY string `mapstructure:"ADMIN_API_KEY"`
+
+	PAYMENT_GATEWAY         string `mapstructure:"PAYMENT_GATEWAY"`
+	PAYMENTS_WEBHOOK_SECRET string `mapstructure:"PAYMENTS_WEBHOOK_SECRET"`
+	PAYMENTS_WEBHOOK_URL    string `mapstructure:"PAYMENTS_WEBHOOK_URL"`
+	PAYMENTS_FAKE_DELAY     int    `mapstructure:"PAYMENTS_FAKE_DELAY"`
+
+	REFERRAL_REFERRER_REWARD int64 `mapstructure:"REFERRAL_REFERRER_REWARD"`
+	REFERRAL_INVITEE_REWARD  int64 `mapstructure:"REFERRAL_INVITEE_REWARD"`
+
+	EVENTS_PUBLISHER     string `mapstructure:"EVENTS_PUBLISHER"`
+	NATS_URL             string `mapstructure:"NATS_URL"`
+	NATS_SUBJECT_PREFIX  string `mapstructure:"NATS_SUBJECT_PREFIX"`
+	OUTBOX_POLL_INTERVAL int    `mapstructure:"OUTBOX_POLL_INTERVAL"`
+	OUTBOX_BATCH_SIZE    int    `mapstructure:"OUTBOX_BATCH_SIZE"`
+	OUTBOX_MAX_ATTEMPTS  int    `mapstructure:"OUTBOX_MAX_ATTEMPTS"`
+
+	WEBHOOKS_ENABLED      bool `mapstructure:"WEBHOOKS_ENABLED"`
+	WEBHOOK_MAX_ATTEMPTS  int  `mapstructure:"WEBHOOK_MAX_ATTEMPTS"`
+	WEBHOOK_RETRY_BACKOFF int  `mapstructure:"WEBHOOK_RETRY_BACKOFF"`
+	WEBHOOK_TIMEOUT       int  `mapstructure:"WEBHOOK_TIMEOUT"`
+
+	TRACING_EXPORTER     string `mapstructure:"TRACING_EXPORTER"`
+	TRACING_SERVICE_NAME string `mapstructure:"TRACING_SERVICE_NAME"`
+	OTLP_ENDPOINT        string `mapstructure:"OTLP_ENDPOINT"`
+	OTLP_INSECURE        bool   `mapstructure:"OTLP_INSECURE"`
 }
 
 func New() (*Config, error) {
@@ -1,17 +1,35 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/RipperAcskt/innotaxi/config"
+	"github.com/RipperAcskt/innotaxi/internal/events"
+	eventsmemory "github.com/RipperAcskt/innotaxi/internal/events/memory"
+	"github.com/RipperAcskt/innotaxi/internal/events/nats"
 	"github.com/RipperAcskt/innotaxi/internal/handler"
+	"github.com/RipperAcskt/innotaxi/internal/metrics"
+	"github.com/RipperAcskt/innotaxi/internal/payments"
+	"github.com/RipperAcskt/innotaxi/internal/payments/fake"
+	"github.com/RipperAcskt/innotaxi/internal/redact"
+	"github.com/RipperAcskt/innotaxi/internal/repo/cache"
+	"github.com/RipperAcskt/innotaxi/internal/repo/memory"
 	"github.com/RipperAcskt/innotaxi/internal/repo/mongo"
 	"github.com/RipperAcskt/innotaxi/internal/repo/postgres"
 	"github.com/RipperAcskt/innotaxi/internal/repo/redis"
+	"github.com/RipperAcskt/innotaxi/internal/repo/sqlite"
+	"github.com/RipperAcskt/innotaxi/internal/requestid"
+	"github.com/RipperAcskt/innotaxi/internal/retention"
 	"github.com/RipperAcskt/innotaxi/internal/server"
 	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/RipperAcskt/innotaxi/internal/tracing"
+	"github.com/RipperAcskt/innotaxi/internal/validate"
+	"github.com/RipperAcskt/innotaxi/internal/webhooks"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -24,41 +42,139 @@ func Run() error {
 	if err != nil {
 		return fmt.Errorf("config new failed: %w", err)
 	}
-
-	postgres, err := postgres.New(cfg)
-	if err != nil {
-		return fmt.Errorf("postgres new failed: %w", err)
+	if !validate.KnownRegion(cfg.PHONE_REGION) {
+		return fmt.Errorf("unknown phone region: %v", cfg.PHONE_REGION)
 	}
-	defer postgres.Close()
 
-	err = postgres.Migrate.Up()
-	if err != migrate.ErrNoChange && err != nil {
-		return fmt.Errorf("migrate up failed: %w", err)
+	config := zap.NewProductionEncoderConfig()
+	config.EncodeTime = zapcore.ISO8601TimeEncoder
+	defaultLogLevel := zapcore.DebugLevel
+	cores := []zapcore.Core{
+		zapcore.NewCore(zapcore.NewConsoleEncoder(config), zapcore.AddSync(os.Stdout), defaultLogLevel),
 	}
 
-	redis, err := redis.New(cfg)
-	if err != nil {
-		return fmt.Errorf("redis new failed: %w", err)
+	m := metrics.New()
+
+	var (
+		repo        service.Repo
+		tokens      service.TokenRepo
+		logs        service.LogRepo
+		archive     mongo.ArchiveStore
+		idempotency handler.IdempotencyStore
+	)
+	switch cfg.STORAGE {
+	case "", "postgres":
+		postgres, err := postgres.New(cfg)
+		if err != nil {
+			return fmt.Errorf("postgres new failed: %w", err)
+		}
+		defer postgres.Close()
+
+		err = postgres.Migrate.Up()
+		if err != migrate.ErrNoChange && err != nil {
+			return fmt.Errorf("migrate up failed: %w", err)
+		}
+
+		err = m.RegisterDB("postgres", postgres.DB)
+		if err != nil {
+			return fmt.Errorf("register db failed: %w", err)
+		}
+
+		redis, err := redis.New(cfg)
+		if err != nil {
+			return fmt.Errorf("redis new failed: %w", err)
+		}
+		defer redis.Close()
+
+		mongoDB, err := mongo.New(cfg)
+		if err != nil {
+			return fmt.Errorf("mongo new failed: %w", err)
+		}
+		defer mongoDB.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = mongoDB.CreateLogIndexes(ctx)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("create log indexes failed: %w", err)
+		}
+
+		retention, err := mongo.ParseRetention(cfg.LOG_RETENTION_DAYS, cfg.LOG_RETENTION_LEVELS)
+		if err != nil {
+			return fmt.Errorf("parse retention failed: %w", err)
+		}
+
+		mongoLogs := metrics.NewLogs(mongoDB, m, "mongo")
+		sink, err := mongo.NewSink(mongoLogs, mongo.SinkConfig{
+			BufferSize:    cfg.LOG_BUFFER_SIZE,
+			BatchSize:     cfg.LOG_BATCH_SIZE,
+			FlushInterval: time.Duration(cfg.LOG_FLUSH_INTERVAL) * time.Millisecond,
+			Overflow:      cfg.LOG_OVERFLOW_POLICY,
+			SpillPath:     cfg.LOG_SPILL_PATH,
+			Retention:     retention,
+		})
+		if err != nil {
+			return fmt.Errorf("sink new failed: %w", err)
+		}
+		defer sink.Close()
+
+		err = m.RegisterLogSink(
+			func() uint64 { return sink.Stats().Dropped },
+			func() uint64 { return sink.Stats().Spilled },
+		)
+		if err != nil {
+			return fmt.Errorf("register log sink failed: %w", err)
+		}
+
+		cores = append(cores, zapcore.NewCore(zapcore.NewJSONEncoder(config), sink, defaultLogLevel))
+		store := metrics.NewStore(redis, m, "redis")
+		repo, tokens, logs, idempotency = metrics.NewRepo(postgres, m, "postgres"), store, mongoLogs, store
+		archive = mongoDB
+		if cfg.USER_CACHE_ENABLED {
+			cached := cache.NewRepo(repo, store, time.Duration(cfg.USER_CACHE_TTL)*time.Second)
+			err = m.RegisterUserCache(
+				func() uint64 { return cached.Users.Stats().Hits },
+				func() uint64 { return cached.Users.Stats().Misses },
+			)
+			if err != nil {
+				return fmt.Errorf("register user cache failed: %w", err)
+			}
+			repo = cached
+		}
+	case "sqlite":
+		sqlite, err := sqlite.New(cfg)
+		if err != nil {
+			return fmt.Errorf("sqlite new failed: %w", err)
+		}
+		defer sqlite.Close()
+
+		err = sqlite.Migrate.Up()
+		if err != migrate.ErrNoChange && err != nil {
+			return fmt.Errorf("migrate up failed: %w", err)
+		}
+
+		err = m.RegisterDB("sqlite", sqlite.DB)
+		if err != nil {
+			return fmt.Errorf("register db failed: %w", err)
+		}
+
+		repo, tokens, idempotency = metrics.NewRepo(sqlite, m, "sqlite"), memory.NewTokens(), memory.NewStore()
+	case "memory":
+		repo, tokens, idempotency = metrics.NewRepo(memory.New(), m, "memory"), memory.NewTokens(), memory.NewStore()
+	default:
+		return fmt.Errorf("unknown storage: %v", cfg.STORAGE)
 	}
-	defer redis.Close()
 
-	mongo, err := mongo.New(cfg)
+	redactor, err := redact.New(cfg.LOG_REDACT_MODE, strings.Split(cfg.LOG_REDACT_KEYS, ","))
 	if err != nil {
-		return fmt.Errorf("mongo new failed: %w", err)
+		return fmt.Errorf("redact new failed: %w", err)
+	}
+	for i := range cores {
+		cores[i] = redact.NewCore(cores[i], redactor)
 	}
-	defer mongo.Close()
 
-	config := zap.NewProductionEncoderConfig()
-	config.EncodeTime = zapcore.ISO8601TimeEncoder
-	fileEncoder := zapcore.NewJSONEncoder(config)
-	consoleEncoder := zapcore.NewConsoleEncoder(config)
-	writer := zapcore.AddSync(mongo)
-	defaultLogLevel := zapcore.DebugLevel
-	core := zapcore.NewTee(
-		zapcore.NewCore(fileEncoder, writer, defaultLogLevel),
-		zapcore.NewCore(consoleEncoder, zapcore.AddSync(os.Stdout), defaultLogLevel),
-	)
-	log := zap.New(core, zap.AddCaller())
+	log := zap.New(zapcore.NewTee(cores...), zap.AddCaller())
+	zap.ReplaceGlobals(log)
 	defer func() {
 		err := log.Sync()
 		if err != nil {
@@ -66,8 +182,92 @@ func Run() error {
 		}
 	}()
 
-	service := service.New(postgres, redis, cfg.SALT, cfg)
-	handler := handler.New(service, cfg, log)
+	var gateway payments.PaymentGateway
+	switch cfg.PAYMENT_GATEWAY {
+	case "", "fake":
+		gateway = fake.New(time.Duration(cfg.PAYMENTS_FAKE_DELAY)*time.Millisecond, cfg.PAYMENTS_WEBHOOK_SECRET, cfg.PAYMENTS_WEBHOOK_URL)
+	default:
+		return fmt.Errorf("unknown payment gateway: %v", cfg.PAYMENT_GATEWAY)
+	}
+
+	var publishers events.Fanout
+	switch cfg.EVENTS_PUBLISHER {
+	case "":
+	case "memory":
+		publishers = append(publishers, eventsmemory.New())
+	case "nats":
+		nats, err := nats.New(cfg.NATS_URL, cfg.NATS_SUBJECT_PREFIX)
+		if err != nil {
+			return fmt.Errorf("nats new failed: %w", err)
+		}
+		defer nats.Close()
+
+		publishers = append(publishers, nats)
+	default:
+		return fmt.Errorf("unknown events publisher: %v", cfg.EVENTS_PUBLISHER)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	shutdown, err := tracing.New(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("tracing new failed: %w", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		err := shutdown(ctx)
+		if err != nil {
+			log.Error("tracing shut down failed", zap.Error(err))
+		}
+	}()
+
+	if cfg.WEBHOOKS_ENABLED {
+		publishers = append(publishers, webhooks.NewPublisher(repo))
+
+		client := &http.Client{
+			Transport: tracing.NewTransport(requestid.NewTransport(nil)),
+			Timeout:   time.Duration(cfg.WEBHOOK_TIMEOUT) * time.Second,
+		}
+		dispatcher := webhooks.NewDispatcher(repo, client, log, time.Duration(cfg.OUTBOX_POLL_INTERVAL)*time.Millisecond, cfg.OUTBOX_BATCH_SIZE, cfg.WEBHOOK_MAX_ATTEMPTS, time.Duration(cfg.WEBHOOK_RETRY_BACKOFF)*time.Second)
+		go dispatcher.Run(ctx)
+	}
+
+	if archive != nil && cfg.LOG_ARCHIVE_DIR != "" {
+		interval := time.Duration(cfg.LOG_ARCHIVE_INTERVAL) * time.Minute
+		if interval <= 0 {
+			interval = time.Hour
+		}
+		archiver := mongo.NewArchiver(archive, cfg.LOG_ARCHIVE_DIR, log, interval, cfg.LOG_ARCHIVE_FILE_SIZE)
+		go archiver.Run(ctx)
+	}
+
+	// Without a publisher events stay in the outbox until one is configured.
+	if len(publishers) > 0 {
+		maxAttempts := cfg.OUTBOX_MAX_ATTEMPTS
+		if maxAttempts <= 0 {
+			maxAttempts = 10
+		}
+		relay := events.NewRelay(repo, publishers, log, time.Duration(cfg.OUTBOX_POLL_INTERVAL)*time.Millisecond, cfg.OUTBOX_BATCH_SIZE, maxAttempts)
+		go relay.Run(ctx)
+	}
+
+	service := service.New(repo, tokens, logs, gateway, cfg.SALT, cfg)
+
+	purgeInterval := time.Duration(cfg.USER_PURGE_INTERVAL) * time.Minute
+	if purgeInterval <= 0 {
+		purgeInterval = time.Hour
+	}
+	purgeBatch := cfg.USER_PURGE_BATCH_SIZE
+	if purgeBatch <= 0 {
+		purgeBatch = 100
+	}
+	purger := retention.NewPurger(service.RetentionService, log, purgeInterval, purgeBatch)
+	go purger.Run(ctx)
+
+	handler := handler.New(service, cfg, log, idempotency, m)
 	server := &server.Server{
 		Log: log,
 	}
@@ -0,0 +1,112 @@
+// Package apperror is the error model of the service. Errors clients may see
+// have a kind, which decides the HTTP status, a stable machine-readable code
+// and a message without user data.
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+type Kind int
+
+const (
+	Internal Kind = iota
+	Invalid
+	Unauthenticated
+	PermissionDenied
+	NotFound
+	Conflict
+	FailedPrecondition
+	PaymentRequired
+	NotImplemented
+	UnsupportedMediaType
+	PreconditionFailed
+	PreconditionRequired
+)
+
+// ErrInternal is what clients see of errors that aren't an *Error.
+var ErrInternal = New(Internal, "internal_error", "internal error")
+
+// Error is an error clients may see. Sentinels are declared with New and
+// wrapped with fmt.Errorf like any other error, the wrapping text only goes
+// to the logs. Detail and Details describe the particular occurrence.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Detail  string
+	Details map[string]interface{}
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Detail != "" {
+		return e.Message + ": " + e.Detail
+	}
+	return e.Message
+}
+
+// Is matches errors with the same code, so a sentinel matches its copies
+// with details.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithDetail returns a copy of e with detail, which is shown to clients.
+func (e *Error) WithDetail(format string, a ...interface{}) *Error {
+	copy := *e
+	copy.Detail = fmt.Sprintf(format, a...)
+	return &copy
+}
+
+// WithDetails returns a copy of e with details, which are shown to clients.
+func (e *Error) WithDetails(details map[string]interface{}) *Error {
+	copy := *e
+	copy.Details = details
+	return &copy
+}
+
+// Status is the HTTP status of e.
+func (e *Error) Status() int {
+	switch e.Kind {
+	case Invalid:
+		return http.StatusBadRequest
+	case Unauthenticated:
+		return http.StatusUnauthorized
+	case PermissionDenied:
+		return http.StatusForbidden
+	case NotFound:
+		return http.StatusNotFound
+	case Conflict:
+		return http.StatusConflict
+	case FailedPrecondition:
+		return http.StatusUnprocessableEntity
+	case PaymentRequired:
+		return http.StatusPaymentRequired
+	case NotImplemented:
+		return http.StatusNotImplemented
+	case UnsupportedMediaType:
+		return http.StatusUnsupportedMediaType
+	case PreconditionFailed:
+		return http.StatusPreconditionFailed
+	case PreconditionRequired:
+		return http.StatusPreconditionRequired
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// From returns the *Error in err's chain, or ErrInternal if there is none.
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return ErrInternal
+}
@@ -0,0 +1,37 @@
+package apperror_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/RipperAcskt/innotaxi/internal/apperror"
+	"github.com/go-playground/assert/v2"
+)
+
+var errNotFound = apperror.New(apperror.NotFound, "thing_does_not_exist", "thing does not exist")
+
+func TestFrom(t *testing.T) {
+	err := fmt.Errorf("get thing failed: %w", errNotFound.WithDetail("thing %d", 42))
+
+	assert.Equal(t, errors.Is(err, errNotFound), true)
+	assert.Equal(t, apperror.From(err).Status(), http.StatusNotFound)
+	assert.Equal(t, apperror.From(err).Error(), "thing does not exist: thing 42")
+	assert.Equal(t, errNotFound.Detail, "")
+
+	assert.Equal(t, apperror.From(fmt.Errorf("select failed")), apperror.ErrInternal)
+	assert.Equal(t, apperror.From(fmt.Errorf("select failed")).Status(), http.StatusInternalServerError)
+}
+
+func TestProblem(t *testing.T) {
+	problem := errNotFound.WithDetails(map[string]interface{}{"id": 42}).Problem("/things/42", "req-42")
+
+	assert.Equal(t, problem.Type, "urn:innotaxi:error:thing_does_not_exist")
+	assert.Equal(t, problem.Title, "thing does not exist")
+	assert.Equal(t, problem.Status, http.StatusNotFound)
+	assert.Equal(t, problem.Instance, "/things/42")
+	assert.Equal(t, problem.Code, "thing_does_not_exist")
+	assert.Equal(t, problem.RequestID, "req-42")
+	assert.Equal(t, problem.Details["id"], 42)
+}
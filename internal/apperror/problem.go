@@ -0,0 +1,33 @@
+package apperror
+
+// ContentType is the media type of Problem responses.
+const ContentType = "application/problem+json"
+
+const typePrefix = "urn:innotaxi:error:"
+
+// Problem is an RFC 7807 problem details response. Type is the code as a
+// URN, Code repeats it for clients that don't parse URNs.
+type Problem struct {
+	Type      string                 `json:"type" example:"urn:innotaxi:error:user_already_exists"`
+	Title     string                 `json:"title" example:"user already exists"`
+	Status    int                    `json:"status" example:"409"`
+	Detail    string                 `json:"detail,omitempty"`
+	Instance  string                 `json:"instance,omitempty" example:"/users/auth/sing-up"`
+	Code      string                 `json:"code" example:"user_already_exists"`
+	RequestID string                 `json:"request_id,omitempty" example:"9f3c2a4e-8f0b-4a53-9b61-0c6d1f1a2b3c"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// Problem describes e to clients. instance is the request path.
+func (e *Error) Problem(instance, requestID string) Problem {
+	return Problem{
+		Type:      typePrefix + e.Code,
+		Title:     e.Message,
+		Status:    e.Status(),
+		Detail:    e.Detail,
+		Instance:  instance,
+		Code:      e.Code,
+		RequestID: requestID,
+		Details:   e.Details,
+	}
+}
@@ -0,0 +1,119 @@
+// Package events delivers the domain events stored in the outbox to other
+// services.
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"go.uber.org/zap"
+)
+
+// maxBackoff caps the pause between retries while the publisher is failing.
+const maxBackoff = time.Minute
+
+// Publisher delivers an event to a broker. Publish returns nil only once
+// the broker has accepted the event.
+type Publisher interface {
+	Publish(ctx context.Context, event *model.Event) error
+}
+
+// Fanout publishes every event to all of its publishers in order. An event
+// is published again to all of them if any fails, so each publisher has to
+// tolerate duplicates.
+type Fanout []Publisher
+
+func (f Fanout) Publish(ctx context.Context, event *model.Event) error {
+	for _, publisher := range f {
+		err := publisher.Publish(ctx, event)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Relay moves events from the outbox to a Publisher. Delivery is at least
+// once: an event is marked published only after Publish succeeded, so a
+// crash in between publishes it again. An event that fails maxAttempts times
+// is marked dead, so it doesn't hold up the events after it.
+type Relay struct {
+	outbox      service.OutboxRepo
+	publisher   Publisher
+	log         *zap.Logger
+	interval    time.Duration
+	batch       int
+	maxAttempts int
+}
+
+func NewRelay(outbox service.OutboxRepo, publisher Publisher, log *zap.Logger, interval time.Duration, batch, maxAttempts int) *Relay {
+	return &Relay{outbox, publisher, log, interval, batch, maxAttempts}
+}
+
+// Run flushes the outbox every interval until ctx is done. While the
+// publisher fails, the pause doubles up to maxBackoff.
+func (r *Relay) Run(ctx context.Context) {
+	delay := r.interval
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		n, err := r.Flush(ctx)
+		switch {
+		case err != nil:
+			r.log.Error("outbox relay", zap.Error(fmt.Errorf("flush failed: %w", err)))
+			delay *= 2
+			if delay > maxBackoff {
+				delay = maxBackoff
+			}
+		case n == r.batch:
+			// There may be more pending events, don't wait.
+			delay = 0
+		default:
+			delay = r.interval
+		}
+	}
+}
+
+// Flush publishes one batch of pending events and returns how many left the
+// outbox, published or dead. It stops at the first failure of an event that
+// has attempts left, so events are delivered in the order they were stored,
+// except for dead ones.
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	events, err := r.outbox.GetPendingEvents(ctx, r.batch)
+	if err != nil {
+		return 0, fmt.Errorf("get pending events failed: %w", err)
+	}
+
+	for i, event := range events {
+		err := r.publisher.Publish(ctx, event)
+		if err != nil && event.Attempts+1 < r.maxAttempts {
+			markErr := r.outbox.MarkEventFailed(ctx, event.ID, err.Error())
+			if markErr != nil {
+				r.log.Error("outbox relay", zap.Error(fmt.Errorf("mark event failed failed: %w", markErr)))
+			}
+			return i, fmt.Errorf("event: %v: publish failed: %w", event.ID, err)
+		}
+		if err != nil {
+			r.log.Error("outbox relay", zap.Error(fmt.Errorf("event: %v: publish failed, event is dead: %w", event.ID, err)))
+
+			err = r.outbox.MarkEventDead(ctx, event.ID, err.Error())
+			if err != nil {
+				return i, fmt.Errorf("mark event dead failed: %w", err)
+			}
+			continue
+		}
+
+		err = r.outbox.MarkEventPublished(ctx, event.ID)
+		if err != nil {
+			return i, fmt.Errorf("mark event published failed: %w", err)
+		}
+	}
+	return len(events), nil
+}
@@ -0,0 +1,144 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/events"
+	eventsmemory "github.com/RipperAcskt/innotaxi/internal/events/memory"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/repo/memory"
+	"github.com/go-playground/assert/v2"
+	"go.uber.org/zap"
+)
+
+func addEvents(t *testing.T, repo *memory.Memory, users ...string) {
+	for _, user := range users {
+		err := repo.AddEvent(context.Background(), &model.Event{Type: model.EventUserDeleted, AggregateID: user})
+		if err != nil {
+			t.Fatalf("add event failed: %v", err)
+		}
+	}
+}
+
+func TestFlush(t *testing.T) {
+	repo := memory.New()
+	publisher := eventsmemory.New()
+	relay := events.NewRelay(repo, publisher, zap.NewNop(), time.Second, 2, 10)
+
+	addEvents(t, repo, "1", "2", "3")
+
+	n, err := relay.Flush(context.Background())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, n, 2)
+
+	n, err = relay.Flush(context.Background())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, n, 1)
+
+	published := publisher.Events()
+	assert.Equal(t, len(published), 3)
+	for i, user := range []string{"1", "2", "3"} {
+		assert.Equal(t, published[i].AggregateID, user)
+	}
+
+	pending, err := repo.GetPendingEvents(context.Background(), 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(pending), 0)
+}
+
+func TestFlushRetry(t *testing.T) {
+	repo := memory.New()
+	publisher := eventsmemory.New()
+	relay := events.NewRelay(repo, publisher, zap.NewNop(), time.Second, 10, 10)
+
+	addEvents(t, repo, "1", "2")
+
+	errDown := errors.New("broker is down")
+	publisher.Fail(errDown)
+
+	n, err := relay.Flush(context.Background())
+	assert.Equal(t, errors.Is(err, errDown), true)
+	assert.Equal(t, n, 0)
+
+	pending, err := repo.GetPendingEvents(context.Background(), 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(pending), 2)
+	assert.Equal(t, pending[0].Attempts, 1)
+	assert.Equal(t, pending[1].Attempts, 0)
+
+	publisher.Fail(nil)
+
+	n, err = relay.Flush(context.Background())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, n, 2)
+	assert.Equal(t, len(publisher.Events()), 2)
+}
+
+// poisonPublisher refuses the events of one user.
+type poisonPublisher struct {
+	*eventsmemory.Publisher
+	user string
+}
+
+var errPoison = errors.New("message too large")
+
+func (p poisonPublisher) Publish(ctx context.Context, event *model.Event) error {
+	if event.AggregateID == p.user {
+		return errPoison
+	}
+	return p.Publisher.Publish(ctx, event)
+}
+
+func TestFlushDead(t *testing.T) {
+	repo := memory.New()
+	publisher := poisonPublisher{eventsmemory.New(), "2"}
+	relay := events.NewRelay(repo, publisher, zap.NewNop(), time.Second, 10, 3)
+
+	addEvents(t, repo, "1", "2", "3")
+
+	for i := 0; i < 2; i++ {
+		_, err := relay.Flush(context.Background())
+		assert.Equal(t, errors.Is(err, errPoison), true)
+	}
+	assert.Equal(t, len(publisher.Events()), 1)
+
+	n, err := relay.Flush(context.Background())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, n, 2)
+
+	published := publisher.Events()
+	assert.Equal(t, len(published), 2)
+	assert.Equal(t, published[0].AggregateID, "1")
+	assert.Equal(t, published[1].AggregateID, "3")
+
+	pending, err := repo.GetPendingEvents(context.Background(), 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(pending), 0)
+}
+
+func TestRun(t *testing.T) {
+	repo := memory.New()
+	publisher := eventsmemory.New()
+	relay := events.NewRelay(repo, publisher, zap.NewNop(), 10*time.Millisecond, 10, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		relay.Run(ctx)
+		close(done)
+	}()
+
+	addEvents(t, repo, "1")
+
+	deadline := time.Now().Add(time.Second)
+	for len(publisher.Events()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, len(publisher.Events()), 1)
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,46 @@
+// Package memory is an in-memory events.Publisher for local runs and tests.
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+)
+
+type Publisher struct {
+	mu     sync.Mutex
+	events []model.Event
+	err    error
+}
+
+func New() *Publisher {
+	return &Publisher{}
+}
+
+func (p *Publisher) Publish(ctx context.Context, event *model.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+	p.events = append(p.events, *event)
+	return nil
+}
+
+// Events returns everything published so far.
+func (p *Publisher) Events() []model.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]model.Event(nil), p.events...)
+}
+
+// Fail makes every following Publish return err, nil makes it succeed again.
+func (p *Publisher) Fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.err = err
+}
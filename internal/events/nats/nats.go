@@ -0,0 +1,54 @@
+// Package nats publishes events to NATS JetStream. Each event goes to the
+// subject prefix + "." + event type, which must be bound to a stream.
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/nats-io/nats.go"
+)
+
+type Publisher struct {
+	conn   *nats.Conn
+	js     nats.JetStreamContext
+	prefix string
+}
+
+func New(url, prefix string) (*Publisher, error) {
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("connect failed: %w", err)
+	}
+
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("jet stream failed: %w", err)
+	}
+
+	return &Publisher{conn, js, prefix}, nil
+}
+
+// Publish waits for the stream to acknowledge the event. The event id is
+// used as the message id, so JetStream drops redelivered copies within its
+// duplicate window.
+func (p *Publisher) Publish(ctx context.Context, event *model.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal failed: %w", err)
+	}
+
+	_, err = p.js.Publish(p.prefix+"."+event.Type, data, nats.MsgId(strconv.FormatUint(event.ID, 10)), nats.Context(ctx))
+	if err != nil {
+		return fmt.Errorf("publish failed: %w", err)
+	}
+	return nil
+}
+
+func (p *Publisher) Close() {
+	p.conn.Close()
+}
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyAdmin guards admin routes with the static ADMIN_API_KEY. Admin routes
+// are disabled when the key is not configured.
+func (h *Handler) VerifyAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(token) < 2 {
+			abort(c, errAdminKeyRequired)
+			return
+		}
+
+		if h.Cfg.ADMIN_API_KEY == "" || subtle.ConstantTimeCompare([]byte(token[1]), []byte(h.Cfg.ADMIN_API_KEY)) != 1 {
+			abort(c, errWrongAdminKey)
+			return
+		}
+
+		c.Set("admin", true)
+		c.Next()
+	}
+}
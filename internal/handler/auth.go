@@ -9,9 +9,10 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
-	"go.uber.org/zap"
 
+	"github.com/RipperAcskt/innotaxi/internal/metrics"
 	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/RipperAcskt/innotaxi/internal/validate"
 )
 
 // @Summary registrate user
@@ -19,79 +20,87 @@ import (
 // @Param user body service.UserSingUp true "account info"
 // @Accept json
 // @Success 200
-// @Failure 400 {object} error "error: err"
-// @Failure 500 {object} error "error: err"
+// @Failure 400 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /users/auth/sing-up [POST]
 func (h *Handler) SingUp(c *gin.Context) {
-	logger := getLogger(c)
-
 	var user service.UserSingUp
 
-	if err := c.BindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+	if err := c.ShouldBindJSON(&user); err != nil {
+		h.metrics.Auth(metrics.SignUp, metrics.ResultBadRequest)
+		abort(c, invalidRequest(err))
 		return
 	}
+	h.normalizePhone(&user.PhoneNumber)
 
 	err := h.s.SingUp(c.Request.Context(), user)
 	if err != nil {
-		if errors.Is(err, service.ErrUserAlreadyExists) {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
-			return
+		switch {
+		case errors.Is(err, service.ErrUserAlreadyExists):
+			h.metrics.Auth(metrics.SignUp, metrics.ResultUserExists)
+		case errors.Is(err, service.ErrReferralCodeDoesNotExists):
+			h.metrics.Auth(metrics.SignUp, metrics.ResultBadRequest)
+		default:
+			h.metrics.Auth(metrics.SignUp, metrics.ResultError)
 		}
-
-		logger.Error("/users/auth/sing-up", zap.Error(fmt.Errorf("service sing up failed: %w", err)))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		abort(c, fmt.Errorf("service sing up failed: %w", err))
 		return
 	}
 
+	h.metrics.Auth(metrics.SignUp, metrics.ResultSuccess)
 	c.Status(http.StatusCreated)
 }
 
 // @Summary user authentication
+// @Description A deleted account can be restored during the restore period by signing in with restore set to true. Without it the sign-in fails with user_deleted.
 // @Tags auth
 // @Param input body service.UserSingIn true "phone number and password"
 // @Accept json
 // @Produce json
 // @Success 200 {object} string "access_token: token"
-// @Failure 403 {object} error "error: err"
-// @Failure 500 {object} error "error: err"
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 422 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /users/auth/sing-in [POST]
 func (h *Handler) SingIn(c *gin.Context) {
-	logger := getLogger(c)
-
 	var user service.UserSingIn
 
-	if err := c.BindJSON(&user); err != nil {
-
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+	if err := c.ShouldBindJSON(&user); err != nil {
+		h.metrics.Auth(metrics.SignIn, metrics.ResultBadRequest)
+		abort(c, invalidRequest(err))
 		return
 	}
+	h.normalizePhone(&user.PhoneNumber)
+
 	token, err := h.s.SingIn(c.Request.Context(), user)
 	if err != nil {
-		if errors.Is(err, service.ErrUserDoesNotExists) || errors.Is(err, service.ErrIncorrectPassword) {
-
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": err.Error(),
-			})
-			return
+		// Both are answered the same, so sign-in doesn't tell which phone
+		// numbers are registered.
+		switch {
+		case errors.Is(err, service.ErrUserDoesNotExists):
+			h.metrics.Auth(metrics.SignIn, metrics.ResultUserNotFound)
+			err = fmt.Errorf("%w: %v", errInvalidCredentials, err)
+		case errors.Is(err, service.ErrIncorrectPassword):
+			h.metrics.Auth(metrics.SignIn, metrics.ResultIncorrectPassword)
+			err = fmt.Errorf("%w: %v", errInvalidCredentials, err)
+		case errors.Is(err, service.ErrUserDeleted):
+			h.metrics.Auth(metrics.SignIn, metrics.ResultUserDeleted)
+		case errors.Is(err, service.ErrUserAlreadyExists):
+			h.metrics.Auth(metrics.SignIn, metrics.ResultUserExists)
+		default:
+			h.metrics.Auth(metrics.SignIn, metrics.ResultError)
 		}
-		logger.Error("/users/auth/sing-in", zap.Error(fmt.Errorf("service sing in failed: %w", err)))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		abort(c, fmt.Errorf("service sing in failed: %w", err))
 		return
 	}
 
 	exp := int((time.Duration(h.Cfg.REFRESH_TOKEN_EXP) * time.Hour * 24).Seconds())
 	c.SetCookie("refresh_token", token.RT, exp, "/users/auth", "", false, true)
+	h.metrics.Auth(metrics.SignIn, metrics.ResultSuccess)
 	c.JSON(http.StatusOK, gin.H{
 		"access_token": token.Access,
 	})
@@ -99,46 +108,29 @@ func (h *Handler) SingIn(c *gin.Context) {
 
 func (h *Handler) VerifyToken() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		logger := getLogger(c)
-
 		token := strings.Split(c.GetHeader("Authorization"), " ")
 		if len(token) < 2 {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": fmt.Errorf("access token required").Error(),
-			})
+			abort(c, errAccessTokenRequired)
 			return
 		}
 		accessToken := token[1]
 
 		id, err := service.Verify(accessToken, h.Cfg)
 		if err != nil {
-			if errors.Is(err, service.ErrTokenExpired) {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-					"error": err.Error(),
-				})
-				return
-			}
 			if strings.Contains(err.Error(), jwt.ErrSignatureInvalid.Error()) {
-				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-					"error": fmt.Errorf("wrong signature").Error(),
-				})
-				return
+				err = fmt.Errorf("%w: %v", errWrongSignature, err)
 			}
-
-			logger.Error("service verify failed", zap.Error(err))
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-				"error": fmt.Errorf("verify failed: %w", err).Error(),
-			})
+			abort(c, fmt.Errorf("service verify failed: %w", err))
 			return
 		}
 
-		if !h.s.CheckToken(accessToken) {
-			c.AbortWithStatus(http.StatusForbidden)
+		if !h.s.CheckToken(c.Request.Context(), accessToken) || !h.s.CheckUser(c.Request.Context(), fmt.Sprint(id)) {
+			abort(c, errAccessDenied)
 			return
 		}
 		c.Set("id", fmt.Sprint(id))
 		if c.Param("id") != "" && fmt.Sprint(id) != c.Param("id") {
-			c.AbortWithStatus(http.StatusForbidden)
+			abort(c, errAccessDenied)
 			return
 		}
 
@@ -151,50 +143,40 @@ func (h *Handler) VerifyToken() gin.HandlerFunc {
 // @Tags auth
 // @Produce json
 // @Success 200 {object} string "accept token: token"
-// @Failure 401 {object} error  "error: err"
-// @Failure 403 {object} error  "error: err"
-// @Failure 500 {object} error  "error: err"
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /users/auth/refresh [GET]
 func (h *Handler) Refresh(c *gin.Context) {
-	logger := getLogger(c)
-
 	refresh, err := c.Cookie("refresh_token")
 	if err != nil {
 		if err == http.ErrNoCookie {
-
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-				"error": fmt.Errorf("bad refresh token").Error(),
-			})
+			h.metrics.Auth(metrics.Refresh, metrics.ResultInvalidToken)
+			abort(c, errBadRefreshToken)
 			return
 		}
-		logger.Error("/users/auth/refresh", zap.Error(fmt.Errorf("get cookie failed: %w", err)))
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		h.metrics.Auth(metrics.Refresh, metrics.ResultError)
+		abort(c, fmt.Errorf("get cookie failed: %w", err))
 		return
 	}
 
 	id, err := service.Verify(refresh, h.Cfg)
 	if err != nil {
-		if errors.Is(err, service.ErrTokenExpired) {
-
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": err.Error(),
-			})
-			return
+		switch {
+		case errors.Is(err, service.ErrTokenExpired):
+			h.metrics.Auth(metrics.Refresh, metrics.ResultTokenExpired)
+		case strings.Contains(err.Error(), jwt.ErrSignatureInvalid.Error()):
+			h.metrics.Auth(metrics.Refresh, metrics.ResultInvalidToken)
+			err = fmt.Errorf("%w: %v", errWrongSignature, err)
+		default:
+			h.metrics.Auth(metrics.Refresh, metrics.ResultError)
 		}
-		if strings.Contains(err.Error(), jwt.ErrSignatureInvalid.Error()) {
-
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-				"error": fmt.Errorf("wrong signature").Error(),
-			})
-			return
-		}
-
-		logger.Error("/users/auth/refresh", zap.Error(fmt.Errorf("verify failed: %w", err)))
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Errorf("verify rt failed: %w", err).Error(),
-		})
+		abort(c, fmt.Errorf("verify failed: %w", err))
+		return
+	}
+	if !h.s.CheckUser(c.Request.Context(), fmt.Sprint(id)) {
+		h.metrics.Auth(metrics.Refresh, metrics.ResultInvalidToken)
+		abort(c, errBadRefreshToken)
 		return
 	}
 
@@ -208,21 +190,14 @@ func (h *Handler) Refresh(c *gin.Context) {
 
 	token, err := service.NewToken(params)
 	if err != nil {
-		if errors.Is(err, service.ErrUnknownType) {
-			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
-		}
-
-		logger.Error("/users/auth/refresh", zap.Error(fmt.Errorf("new token failed: %w", err)))
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		h.metrics.Auth(metrics.Refresh, metrics.ResultError)
+		abort(c, fmt.Errorf("new token failed: %w", err))
 		return
 	}
 
 	exp := int((time.Duration(h.Cfg.REFRESH_TOKEN_EXP) * time.Hour * 24).Seconds())
 	c.SetCookie("refresh_token", token.RT, exp, "/users/auth", "", false, true)
+	h.metrics.Auth(metrics.Refresh, metrics.ResultSuccess)
 	c.JSON(http.StatusOK, gin.H{
 		"access_token": token.Access,
 	})
@@ -232,42 +207,43 @@ func (h *Handler) Refresh(c *gin.Context) {
 // @Tags auth
 // @Accept json
 // @Success 200
-// @Failure 401 {object} error  "error: err"
-// @Failure 403 {object} error  "error: err"
-// @Failure 500 {object} error  "error: err"
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /users/auth/logout [GET]
 // @Security Bearer
 func (h *Handler) Logout(c *gin.Context) {
-	logger := getLogger(c)
-
 	id, ok := c.Get("id")
 	if !ok {
-
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error": "can't get id",
-		})
+		abort(c, fmt.Errorf("can't get id"))
 		return
 	}
 
 	exp := time.Duration(h.Cfg.ACCESS_TOKEN_EXP) * time.Minute
 	token := strings.Split(c.GetHeader("Authorization"), " ")
 	if len(token) < 2 {
-
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-			"error": fmt.Errorf("access token required").Error(),
-		})
+		abort(c, errAccessTokenRequired)
 		return
 	}
 	accessToken := token[1]
 
-	err := h.s.Logout(id.(string), accessToken, exp)
+	err := h.s.Logout(c.Request.Context(), id.(string), accessToken, exp)
 	if err != nil {
-		logger.Error("/users/auth/logout", zap.Error(fmt.Errorf("logout failed: %w", err)))
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		h.metrics.Auth(metrics.Logout, metrics.ResultError)
+		abort(c, fmt.Errorf("logout failed: %w", err))
 		return
 	}
 	c.SetCookie("refresh_token", "", time.Now().Second(), "/users/auth", "", false, true)
+	h.metrics.Auth(metrics.Logout, metrics.ResultSuccess)
 	c.Status(http.StatusOK)
 }
+
+// normalizePhone rewrites a phone number to E.164, so a number is stored and
+// looked up the same way however it was typed. Numbers that don't parse are
+// left as they are, binding has rejected them where they are stored.
+func (h *Handler) normalizePhone(phone *string) {
+	normalized, err := validate.Phone(*phone, h.Cfg.PHONE_REGION)
+	if err == nil {
+		*phone = normalized
+	}
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/RipperAcskt/innotaxi/internal/apperror"
+	"github.com/RipperAcskt/innotaxi/internal/logger"
+	"github.com/RipperAcskt/innotaxi/internal/requestid"
+	"github.com/RipperAcskt/innotaxi/internal/validate"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Errors of the handlers themselves.
+var (
+	errInvalidRequest        = apperror.New(apperror.Invalid, "invalid_request", "invalid request")
+	errUnsupportedMediaType  = apperror.New(apperror.UnsupportedMediaType, "unsupported_media_type", "unsupported media type")
+	errIfMatchRequired       = apperror.New(apperror.PreconditionRequired, "if_match_required", "If-Match header required")
+	errAccessTokenRequired   = apperror.New(apperror.Unauthenticated, "access_token_required", "access token required")
+	errWrongSignature        = apperror.New(apperror.PermissionDenied, "wrong_signature", "wrong signature")
+	errAccessDenied          = apperror.New(apperror.PermissionDenied, "access_denied", "access denied")
+	errInvalidCredentials    = apperror.New(apperror.PermissionDenied, "invalid_credentials", "wrong phone number or password")
+	errBadRefreshToken       = apperror.New(apperror.PermissionDenied, "bad_refresh_token", "bad refresh token")
+	errAdminKeyRequired      = apperror.New(apperror.Unauthenticated, "admin_key_required", "admin key required")
+	errWrongAdminKey         = apperror.New(apperror.PermissionDenied, "wrong_admin_key", "wrong admin key")
+	errIdempotencyKeyTooLong = apperror.New(apperror.Invalid, "idempotency_key_too_long", fmt.Sprintf("idempotency key is longer than %d characters", maxIdempotencyKeyLength))
+	errIdempotencyKeyReused  = apperror.New(apperror.FailedPrecondition, "idempotency_key_reused", "idempotency key reused with a different request")
+	errIdempotencyInProgress = apperror.New(apperror.Conflict, "idempotency_key_in_progress", "request with the same idempotency key is in progress")
+)
+
+// invalidRequest marks err as a problem with the request itself, e.g. a body
+// that doesn't bind. Its message describes the client's own input, so it is
+// returned as the detail. Fields that fail validation are listed by their
+// JSON names in details.
+func invalidRequest(err error) error {
+	if fields := validate.Fields(err); fields != nil {
+		return invalidFields(fields)
+	}
+	return errInvalidRequest.WithDetail("%v", err)
+}
+
+// invalidFields is an invalid request with a message for every invalid
+// field by its JSON name.
+func invalidFields(fields map[string]string) error {
+	return errInvalidRequest.
+		WithDetail("some fields are invalid").
+		WithDetails(map[string]interface{}{"fields": fields})
+}
+
+// abort stops the chain with err, Errors writes the response. Wrap err with
+// whatever helps in the logs, clients only see the *apperror.Error in it.
+func abort(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// Errors answers requests aborted with an error with an
+// application/problem+json body. The status and the code come from the
+// *apperror.Error in the error. Anything else is an internal error, it is
+// logged and clients only get the request id to report it. Routes outside
+// the groups with Log, like /metrics, log with the handler's logger.
+func (h *Handler) Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fields := []zap.Field{
+			zap.String("url", c.Request.URL.Path),
+			zap.String("method", c.Request.Method),
+		}
+		log := h.log.With(append(fields, traceFields(c)...)...)
+		c.Request = c.Request.WithContext(logger.With(c.Request.Context(), log))
+		c.Next()
+		renderErrors(c)
+	}
+}
+
+// renderErrors writes the last error of c unless a response is already
+// written. Middlewares that need the final response, like Idempotency, call
+// it themselves after the handlers.
+func renderErrors(c *gin.Context) {
+	last := c.Errors.Last()
+	if last == nil || c.Writer.Written() {
+		return
+	}
+
+	appErr := apperror.From(last.Err)
+	if appErr.Kind == apperror.Internal {
+		getLogger(c).Error(c.FullPath(), zap.Error(last.Err))
+	}
+
+	problem := appErr.Problem(c.Request.URL.Path, requestid.From(c.Request.Context()))
+	c.Header("Content-Type", apperror.ContentType)
+	c.JSON(problem.Status, problem)
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// etag is the strong entity tag of the profile of user, its version.
+func etag(user *model.User) string {
+	return strconv.Quote(strconv.FormatUint(user.Version, 10))
+}
+
+// ifMatchVersion returns the version of the profile If-Match requires, 0 if
+// any version will do. Updates must send If-Match, with the ETag of the
+// profile they change or *. Only a single ETag is accepted, and weak ones
+// never match since a profile is compared byte for byte.
+func ifMatchVersion(c *gin.Context) (uint64, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		return 0, errIfMatchRequired
+	}
+	if header == "*" {
+		return 0, nil
+	}
+
+	tags := entityTags(header)
+	if len(tags) != 1 {
+		return 0, errInvalidRequest.WithDetail("If-Match must be a single ETag or *")
+	}
+	if strings.HasPrefix(tags[0], "W/") {
+		return 0, service.ErrUserModified
+	}
+
+	version, err := strconv.ParseUint(strings.Trim(tags[0], `"`), 10, 64)
+	if err != nil || version == 0 {
+		return 0, fmt.Errorf("if-match %q: %w", tags[0], service.ErrUserModified)
+	}
+	return version, nil
+}
+
+// noneMatch reports whether the If-None-Match header matches etag. Entity
+// tags are compared weakly, as RFC 9110 requires for If-None-Match.
+func noneMatch(header, etag string) bool {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return true
+	}
+	for _, tag := range entityTags(header) {
+		if strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func entityTags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -1,43 +1,116 @@
 package handler
 
 import (
+	"fmt"
+
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"go.uber.org/zap"
 
 	"github.com/RipperAcskt/innotaxi/config"
 	_ "github.com/RipperAcskt/innotaxi/docs"
+	"github.com/RipperAcskt/innotaxi/internal/metrics"
 	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/RipperAcskt/innotaxi/internal/validate"
 )
 
 type Handler struct {
-	s   *service.Service
-	Cfg *config.Config
-	log *zap.Logger
+	s           *service.Service
+	Cfg         *config.Config
+	log         *zap.Logger
+	idempotency IdempotencyStore
+	metrics     *metrics.Metrics
 }
 
-func New(s *service.Service, cfg *config.Config, log *zap.Logger) *Handler {
-	return &Handler{s, cfg, log}
+func New(s *service.Service, cfg *config.Config, log *zap.Logger, idempotency IdempotencyStore, metrics *metrics.Metrics) *Handler {
+	// Binding uses one validator for every handler.
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		err := validate.Register(v, cfg.PHONE_REGION)
+		if err != nil {
+			log.Error("handler new", zap.Error(fmt.Errorf("register validators failed: %w", err)))
+		}
+	}
+	return &Handler{s, cfg, log, idempotency, metrics}
 }
 
 func (h *Handler) InitRouters() *gin.Engine {
 	router := gin.New()
+	router.Use(h.Trace(), h.Metrics(), h.Errors())
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	router.GET("/metrics", gin.WrapH(h.metrics.Handler()))
 
 	users := router.Group("/users")
 	users.Use(h.Log())
 
 	auth := users.Group("/auth")
-	auth.POST("sing-up", h.SingUp)
+	auth.POST("sing-up", h.Idempotency(), h.SingUp)
 	auth.POST("sing-in", h.SingIn)
 	auth.GET("refresh", h.Refresh)
 	auth.GET("logout", h.VerifyToken(), h.Logout)
 
 	users.GET("/profile/:id", h.VerifyToken(), h.GetProfile)
-	users.PUT("/profile/:id", h.VerifyToken(), h.UpdateProfile)
-	users.DELETE("/:id", h.VerifyToken(), h.DeleteUser)
+	users.PUT("/profile/:id", h.VerifyToken(), h.Idempotency(), h.UpdateProfile)
+	users.PATCH("/profile/:id", h.VerifyToken(), h.Idempotency(), h.PatchProfile)
+	users.DELETE("/:id", h.VerifyToken(), h.Idempotency(), h.DeleteUser)
+
+	places := users.Group("/profile/:id/places", h.VerifyToken(), h.Idempotency())
+	places.POST("", h.CreatePlace)
+	places.GET("", h.GetPlaces)
+	places.GET("/:place_id", h.GetPlace)
+	places.PUT("/:place_id", h.UpdatePlace)
+	places.DELETE("/:place_id", h.DeletePlace)
+
+	users.POST("/profile/:id/promotions/apply", h.VerifyToken(), h.Idempotency(), h.ApplyPromotion)
+
+	users.GET("/profile/:id/wallet", h.VerifyToken(), h.GetWallet)
+
+	methods := users.Group("/profile/:id/payment-methods", h.VerifyToken(), h.Idempotency())
+	methods.POST("", h.AddPaymentMethod)
+	methods.GET("", h.GetPaymentMethods)
+	methods.DELETE("/:method_id", h.DeletePaymentMethod)
+
+	users.POST("/profile/:id/payments", h.VerifyToken(), h.Idempotency(), h.PreAuthorizePayment)
+
+	users.GET("/profile/:id/referrals", h.VerifyToken(), h.GetReferralStats)
+
+	payments := router.Group("/payments")
+	payments.Use(h.Log())
+	payments.POST("/webhook", h.PaymentWebhook)
+
+	admin := router.Group("/admin")
+	admin.Use(h.Log(), h.VerifyAdmin(), h.Idempotency())
+
+	admin.POST("/promotions", h.CreatePromotion)
+	admin.GET("/promotions", h.GetPromotions)
+	admin.PUT("/promotions/:id/deactivate", h.DeactivatePromotion)
+
+	admin.POST("/wallets/:id/top-up", h.TopUpWallet)
+	admin.POST("/wallets/:id/charge", h.ChargeWallet)
+	admin.POST("/wallets/:id/refund", h.RefundWallet)
+
+	admin.POST("/payments/:order_id/capture", h.CapturePayment)
+	admin.POST("/payments/:order_id/void", h.VoidPayment)
+	admin.POST("/payments/:order_id/refund", h.RefundPayment)
+	admin.POST("/payments/:order_id/promotion", h.RedeemPromotion)
+
+	admin.POST("/referrals/:id/trip-completed", h.CompleteReferralTrip)
+
+	admin.POST("/webhooks", h.CreateWebhook)
+	admin.GET("/webhooks", h.GetWebhooks)
+	admin.GET("/webhooks/:id", h.GetWebhook)
+	admin.PUT("/webhooks/:id", h.UpdateWebhook)
+	admin.DELETE("/webhooks/:id", h.DeleteWebhook)
+	admin.GET("/webhooks/:id/deliveries", h.GetWebhookDeliveries)
+	admin.POST("/webhooks/:id/deliveries/:delivery_id/retry", h.RetryWebhookDelivery)
+
+	admin.PUT("/partners/:id/employees/:user_id", h.AddPartnerEmployee)
+	admin.DELETE("/partners/:id/employees/:user_id", h.RemovePartnerEmployee)
+
+	admin.GET("/logs", h.GetLogs)
 
 	return router
 }
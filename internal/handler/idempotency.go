@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/repo/cache"
+	"github.com/RipperAcskt/innotaxi/internal/requestid"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayHeader is set on responses replayed from the store.
+	IdempotentReplayHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// inFlightTTL releases the key of a request that never finished, e.g.
+	// because the instance crashed.
+	inFlightTTL = time.Minute
+	// defaultIdempotencyTTL is used when IDEMPOTENCY_TTL is not set.
+	defaultIdempotencyTTL = 24 * time.Hour
+)
+
+// IdempotencyStore keeps idempotent responses. SetNX stores value only if
+// key doesn't exist yet and reports whether it did.
+type IdempotencyStore interface {
+	cache.Store
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+}
+
+// idempotentResponse is stored under the key as soon as a request takes it.
+// Done is false until the response is recorded.
+type idempotentResponse struct {
+	Fingerprint string      `json:"fingerprint"`
+	Done        bool        `json:"done"`
+	Status      int         `json:"status"`
+	Header      http.Header `json:"header"`
+	Body        []byte      `json:"body"`
+}
+
+// responseRecorder copies everything written to the client.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotentMethods are the methods Idempotency guards.
+var idempotentMethods = map[string]bool{
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// Idempotency makes POST, PUT, PATCH and DELETE requests with an Idempotency-Key
+// header safe to retry. The first response is stored for IDEMPOTENCY_TTL
+// hours under the key and the caller, later requests with the same key get
+// it replayed. Reusing a key for a different request is refused, and so is
+// a retry while the first request is still running. Server errors aren't
+// stored, so they can be retried. If the store is down requests are served
+// without the guarantee.
+func (h *Handler) Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || !idempotentMethods[c.Request.Method] {
+			c.Next()
+			return
+		}
+
+		logger := getLogger(c)
+
+		if len(key) > maxIdempotencyKeyLength {
+			abort(c, errIdempotencyKeyTooLong)
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			body, err = io.ReadAll(c.Request.Body)
+			if err != nil {
+				abort(c, invalidRequest(fmt.Errorf("read body failed: %w", err)))
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		storeKey := "idempotency:" + principal(c) + ":" + key
+		fingerprint := requestFingerprint(c.Request.Method, c.Request.URL.Path, body)
+
+		pending, err := json.Marshal(idempotentResponse{Fingerprint: fingerprint})
+		if err != nil {
+			logger.Error("idempotency", zap.Error(fmt.Errorf("marshal failed: %w", err)))
+			c.Next()
+			return
+		}
+
+		ok, err := h.idempotency.SetNX(c.Request.Context(), storeKey, pending, inFlightTTL)
+		if err != nil {
+			logger.Error("idempotency", zap.Error(fmt.Errorf("set nx failed: %w", err)))
+			c.Next()
+			return
+		}
+		if !ok {
+			h.replay(c, storeKey, fingerprint)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+		// The response has to be written before it can be stored.
+		renderErrors(c)
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			err = h.idempotency.Del(c.Request.Context(), storeKey)
+			if err != nil {
+				logger.Error("idempotency", zap.Error(fmt.Errorf("del failed: %w", err)))
+			}
+			return
+		}
+
+		// Replays get their own request id.
+		header := recorder.Header().Clone()
+		header.Del(requestid.Header)
+		done, err := json.Marshal(idempotentResponse{
+			Fingerprint: fingerprint,
+			Done:        true,
+			Status:      status,
+			Header:      header,
+			Body:        recorder.body.Bytes(),
+		})
+		if err == nil {
+			ttl := time.Duration(h.Cfg.IDEMPOTENCY_TTL) * time.Hour
+			if ttl <= 0 {
+				ttl = defaultIdempotencyTTL
+			}
+			err = h.idempotency.Set(c.Request.Context(), storeKey, done, ttl)
+		}
+		if err != nil {
+			logger.Error("idempotency", zap.Error(fmt.Errorf("store response failed: %w", err)))
+		}
+	}
+}
+
+// replay answers a request whose key is already taken.
+func (h *Handler) replay(c *gin.Context, storeKey, fingerprint string) {
+	data, err := h.idempotency.Get(c.Request.Context(), storeKey)
+	if err != nil {
+		if errors.Is(err, cache.ErrMiss) {
+			// The first request failed or expired in between, let the
+			// client try again.
+			abort(c, errIdempotencyInProgress)
+			return
+		}
+		abort(c, fmt.Errorf("get failed: %w", err))
+		return
+	}
+
+	var stored idempotentResponse
+	err = json.Unmarshal(data, &stored)
+	if err != nil {
+		abort(c, fmt.Errorf("unmarshal failed: %w", err))
+		return
+	}
+
+	if stored.Fingerprint != fingerprint {
+		abort(c, errIdempotencyKeyReused)
+		return
+	}
+	if !stored.Done {
+		abort(c, errIdempotencyInProgress)
+		return
+	}
+
+	for name, values := range stored.Header {
+		for _, value := range values {
+			c.Writer.Header().Add(name, value)
+		}
+	}
+	c.Writer.Header().Set(IdempotentReplayHeader, "true")
+	c.Writer.WriteHeader(stored.Status)
+	_, _ = c.Writer.Write(stored.Body)
+	c.Abort()
+}
+
+// principal scopes idempotency keys to the caller, so clients can't replay
+// each other's responses.
+func principal(c *gin.Context) string {
+	if id, ok := c.Get("id"); ok {
+		return "user:" + fmt.Sprint(id)
+	}
+	if c.GetBool("admin") {
+		return "admin"
+	}
+	return "anonymous"
+}
+
+func requestFingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
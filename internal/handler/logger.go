@@ -3,24 +3,42 @@ package handler
 import (
 	"time"
 
+	"github.com/RipperAcskt/innotaxi/internal/logger"
+	"github.com/RipperAcskt/innotaxi/internal/requestid"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
+// Log gives every request its own logger with the request's fields and puts
+// it in the request context. The request id is taken from the X-Request-ID
+// header, or generated if the header is missing or invalid, and echoed in
+// the response.
 func (h *Handler) Log() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
-		h.log = h.log.WithOptions(zap.Fields(zap.String("url", c.Request.URL.Path), zap.String("method", c.Request.Method), zap.Any("uuid", uuid.New()), zap.String("request time", time.Now().String())))
-		c.Set("logger", *h.log)
+
+		id := c.GetHeader(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		c.Header(requestid.Header, id)
+
+		fields := []zap.Field{
+			zap.String("url", c.Request.URL.Path),
+			zap.String("method", c.Request.Method),
+			zap.String("uuid", id),
+			zap.String("request time", start.String()),
+		}
+		log := h.log.With(append(fields, traceFields(c)...)...)
+
+		ctx := requestid.With(c.Request.Context(), id)
+		c.Request = c.Request.WithContext(logger.With(ctx, log))
 		c.Next()
 
-		h.log.Info("request", zap.String("time", time.Since(start).String()))
+		log.Info("request", zap.String("time", time.Since(start).String()))
 	}
 }
 
 func getLogger(c *gin.Context) *zap.Logger {
-	tmp, _ := c.Get("logger")
-	logger := tmp.(zap.Logger)
-	return &logger
+	return logger.From(c.Request.Context())
 }
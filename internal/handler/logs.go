@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary search logs
+// @Tags admin
+// @Param level query string false "debug, info, warn, error, dpanic, panic or fatal"
+// @Param from query string false "RFC3339 time of the oldest log"
+// @Param to query string false "RFC3339 time of the newest log"
+// @Param method query string false "HTTP method"
+// @Param url query string false "request path"
+// @Param uuid query string false "request id"
+// @Param error query string false "error substring"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param limit query int false "logs per page"
+// @Produce json
+// @Success 200 {object} model.LogPage
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Failure 501 {object} apperror.Problem
+// @Router /admin/logs [GET]
+// @Security Bearer
+func (h *Handler) GetLogs(c *gin.Context) {
+	filter := model.LogFilter{
+		Level:  c.Query("level"),
+		Method: c.Query("method"),
+		Url:    c.Query("url"),
+		Uuid:   c.Query("uuid"),
+		Error:  c.Query("error"),
+		Cursor: c.Query("cursor"),
+	}
+
+	var err error
+	filter.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		abort(c, invalidRequest(fmt.Errorf("bad limit")))
+		return
+	}
+	if from := c.Query("from"); from != "" {
+		filter.From, err = time.Parse(time.RFC3339, from)
+		if err != nil {
+			abort(c, invalidRequest(fmt.Errorf("bad from")))
+			return
+		}
+	}
+	if to := c.Query("to"); to != "" {
+		filter.To, err = time.Parse(time.RFC3339, to)
+		if err != nil {
+			abort(c, invalidRequest(fmt.Errorf("bad to")))
+			return
+		}
+	}
+
+	page, err := h.s.SearchLogs(c.Request.Context(), filter)
+	if err != nil {
+		abort(c, fmt.Errorf("search logs failed: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
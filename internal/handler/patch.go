@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const mergePatchContentType = "application/merge-patch+json"
+
+// bindMergePatch binds a JSON Merge Patch (RFC 7396) body to patch, a
+// pointer to a struct of pointers whose JSON fields are the fields that can
+// be changed. Other fields are refused, and so is null, which would remove
+// a field: the fields patches can change are all required.
+func bindMergePatch(c *gin.Context, patch interface{}) error {
+	body, err := c.GetRawData()
+	if err != nil {
+		return invalidRequest(fmt.Errorf("read body failed: %w", err))
+	}
+
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(body, &fields)
+	if err != nil {
+		return invalidRequest(err)
+	}
+	if fields == nil {
+		return invalidRequest(fmt.Errorf("patch must be an object"))
+	}
+
+	mutable := jsonFields(reflect.TypeOf(patch).Elem())
+	invalid := make(map[string]string)
+	for name, value := range fields {
+		switch {
+		case !mutable[name]:
+			invalid[name] = "can't be changed"
+		case string(value) == "null":
+			invalid[name] = "can't be removed"
+		}
+	}
+	if len(invalid) > 0 {
+		return invalidFields(invalid)
+	}
+
+	err = json.Unmarshal(body, patch)
+	if err != nil {
+		return invalidRequest(err)
+	}
+	err = binding.Validator.ValidateStruct(patch)
+	if err != nil {
+		return invalidRequest(err)
+	}
+	return nil
+}
+
+// jsonFields returns the JSON names of the fields of struct type t.
+func jsonFields(t reflect.Type) map[string]bool {
+	fields := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.SplitN(t.Field(i).Tag.Get("json"), ",", 2)[0]
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}
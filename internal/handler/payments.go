@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/RipperAcskt/innotaxi/internal/payments"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary add card as payment method
+// @Tags payments
+// @Param id path int true "user's id"
+// @Param input body payments.Card true "card info"
+// @Accept json
+// @Produce json
+// @Success 201 {object} model.PaymentMethod
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/profile/{id}/payment-methods [POST]
+// @Security Bearer
+func (h *Handler) AddPaymentMethod(c *gin.Context) {
+	var card payments.Card
+
+	if err := c.ShouldBindJSON(&card); err != nil {
+		abort(c, invalidRequest(err))
+		return
+	}
+
+	method, err := h.s.AddPaymentMethod(c.Request.Context(), c.Param("id"), card)
+	if err != nil {
+		abort(c, fmt.Errorf("add payment method failed: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, method)
+}
+
+// @Summary get payment methods
+// @Tags payments
+// @Param id path int true "user's id"
+// @Produce json
+// @Success 200 {array} model.PaymentMethod
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/profile/{id}/payment-methods [GET]
+// @Security Bearer
+func (h *Handler) GetPaymentMethods(c *gin.Context) {
+	methods, err := h.s.GetPaymentMethods(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abort(c, fmt.Errorf("get payment methods failed: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, methods)
+}
+
+// @Summary delete payment method
+// @Tags payments
+// @Param id path int true "user's id"
+// @Param method_id path int true "payment method's id"
+// @Success 200
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/profile/{id}/payment-methods/{method_id} [DELETE]
+// @Security Bearer
+func (h *Handler) DeletePaymentMethod(c *gin.Context) {
+	err := h.s.DeletePaymentMethod(c.Request.Context(), c.Param("id"), c.Param("method_id"))
+	if err != nil {
+		abort(c, fmt.Errorf("delete payment method failed: %w", err))
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary pre-authorize order payment
+// @Tags payments
+// @Param id path int true "user's id"
+// @Param input body service.PreAuthorize true "order, payment method and estimated fare"
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Payment
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 402 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/profile/{id}/payments [POST]
+// @Security Bearer
+func (h *Handler) PreAuthorizePayment(c *gin.Context) {
+	var pre service.PreAuthorize
+
+	if err := c.ShouldBindJSON(&pre); err != nil {
+		abort(c, invalidRequest(err))
+		return
+	}
+
+	payment, err := h.s.PreAuthorizePayment(c.Request.Context(), c.Param("id"), pre)
+	h.paymentResponse(c, payment, err)
+}
+
+// @Summary capture order payment on completion
+// @Tags admin
+// @Param order_id path string true "order's id"
+// @Param input body service.PaymentAmount true "final fare"
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Payment
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /admin/payments/{order_id}/capture [POST]
+// @Security Bearer
+func (h *Handler) CapturePayment(c *gin.Context) {
+	var amount service.PaymentAmount
+
+	if err := c.ShouldBindJSON(&amount); err != nil {
+		abort(c, invalidRequest(err))
+		return
+	}
+
+	payment, err := h.s.CapturePayment(c.Request.Context(), c.Param("order_id"), amount.Amount)
+	h.paymentResponse(c, payment, err)
+}
+
+// @Summary void order payment on cancellation
+// @Tags admin
+// @Param order_id path string true "order's id"
+// @Produce json
+// @Success 200 {object} model.Payment
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /admin/payments/{order_id}/void [POST]
+// @Security Bearer
+func (h *Handler) VoidPayment(c *gin.Context) {
+	payment, err := h.s.VoidPayment(c.Request.Context(), c.Param("order_id"))
+	h.paymentResponse(c, payment, err)
+}
+
+// @Summary refund order payment
+// @Tags admin
+// @Param order_id path string true "order's id"
+// @Param input body service.PaymentAmount true "amount to refund"
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Payment
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 422 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /admin/payments/{order_id}/refund [POST]
+// @Security Bearer
+func (h *Handler) RefundPayment(c *gin.Context) {
+	var amount service.PaymentAmount
+
+	if err := c.ShouldBindJSON(&amount); err != nil {
+		abort(c, invalidRequest(err))
+		return
+	}
+
+	payment, err := h.s.RefundPayment(c.Request.Context(), c.Param("order_id"), amount.Amount)
+	h.paymentResponse(c, payment, err)
+}
+
+// @Summary payment gateway webhook
+// @Tags payments
+// @Accept json
+// @Success 200
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /payments/webhook [POST]
+func (h *Handler) PaymentWebhook(c *gin.Context) {
+	payload, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		abort(c, invalidRequest(err))
+		return
+	}
+
+	err = h.s.HandlePaymentWebhook(c.Request.Context(), payload, c.GetHeader(payments.SignatureHeader))
+	if err != nil {
+		abort(c, fmt.Errorf("handle payment webhook failed: %w", err))
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) paymentResponse(c *gin.Context, payment any, err error) {
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
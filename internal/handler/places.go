@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary add saved place
+// @Tags places
+// @Param id path int true "user's id"
+// @Param input body model.Place true "place info"
+// @Accept json
+// @Produce json
+// @Success 201 {object} model.Place
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 422 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/profile/{id}/places [POST]
+// @Security Bearer
+func (h *Handler) CreatePlace(c *gin.Context) {
+	var place model.Place
+
+	if err := c.ShouldBindJSON(&place); err != nil {
+		abort(c, invalidRequest(err))
+		return
+	}
+
+	err := h.s.AddPlace(c.Request.Context(), c.Param("id"), &place)
+	if err != nil {
+		abort(c, fmt.Errorf("add place failed: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, place)
+}
+
+// @Summary get saved places
+// @Tags places
+// @Param id path int true "user's id"
+// @Produce json
+// @Success 200 {array} model.Place
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/profile/{id}/places [GET]
+// @Security Bearer
+func (h *Handler) GetPlaces(c *gin.Context) {
+	places, err := h.s.GetPlaces(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abort(c, fmt.Errorf("get places failed: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, places)
+}
+
+// @Summary get saved place
+// @Tags places
+// @Param id path int true "user's id"
+// @Param place_id path int true "place's id"
+// @Produce json
+// @Success 200 {object} model.Place
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/profile/{id}/places/{place_id} [GET]
+// @Security Bearer
+func (h *Handler) GetPlace(c *gin.Context) {
+	place, err := h.s.GetPlace(c.Request.Context(), c.Param("id"), c.Param("place_id"))
+	if err != nil {
+		abort(c, fmt.Errorf("get place failed: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, place)
+}
+
+// @Summary update saved place
+// @Tags places
+// @Param id path int true "user's id"
+// @Param place_id path int true "place's id"
+// @Param input body model.Place true "place info"
+// @Accept json
+// @Success 200
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/profile/{id}/places/{place_id} [PUT]
+// @Security Bearer
+func (h *Handler) UpdatePlace(c *gin.Context) {
+	var place model.Place
+
+	if err := c.ShouldBindJSON(&place); err != nil {
+		abort(c, invalidRequest(err))
+		return
+	}
+
+	err := h.s.UpdatePlace(c.Request.Context(), c.Param("id"), c.Param("place_id"), &place)
+	if err != nil {
+		abort(c, fmt.Errorf("update place failed: %w", err))
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary delete saved place
+// @Tags places
+// @Param id path int true "user's id"
+// @Param place_id path int true "place's id"
+// @Success 200
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/profile/{id}/places/{place_id} [DELETE]
+// @Security Bearer
+func (h *Handler) DeletePlace(c *gin.Context) {
+	err := h.s.DeletePlace(c.Request.Context(), c.Param("id"), c.Param("place_id"))
+	if err != nil {
+		abort(c, fmt.Errorf("delete place failed: %w", err))
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary create promotion campaign
+// @Tags admin
+// @Param input body model.Promotion true "promotion info"
+// @Accept json
+// @Produce json
+// @Success 201 {object} model.Promotion
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /admin/promotions [POST]
+// @Security Bearer
+func (h *Handler) CreatePromotion(c *gin.Context) {
+	var promotion model.Promotion
+
+	if err := c.ShouldBindJSON(&promotion); err != nil {
+		abort(c, invalidRequest(err))
+		return
+	}
+
+	err := h.s.AddPromotion(c.Request.Context(), &promotion)
+	if err != nil {
+		abort(c, fmt.Errorf("add promotion failed: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, promotion)
+}
+
+// @Summary get promotion campaigns
+// @Tags admin
+// @Produce json
+// @Success 200 {array} model.Promotion
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /admin/promotions [GET]
+// @Security Bearer
+func (h *Handler) GetPromotions(c *gin.Context) {
+	promotions, err := h.s.GetAllPromotions(c.Request.Context())
+	if err != nil {
+		abort(c, fmt.Errorf("get promotions failed: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, promotions)
+}
+
+// @Summary deactivate promotion campaign
+// @Tags admin
+// @Param id path int true "promotion's id"
+// @Success 200
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /admin/promotions/{id}/deactivate [PUT]
+// @Security Bearer
+func (h *Handler) DeactivatePromotion(c *gin.Context) {
+	err := h.s.DeactivatePromotion(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abort(c, fmt.Errorf("deactivate promotion failed: %w", err))
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary apply promo code to fare estimate
+// @Tags promotions
+// @Param id path int true "user's id"
+// @Param input body service.PromotionApply true "code and fare estimate"
+// @Accept json
+// @Produce json
+// @Success 200 {object} service.Discount
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 422 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/profile/{id}/promotions/apply [POST]
+// @Security Bearer
+func (h *Handler) ApplyPromotion(c *gin.Context) {
+	var apply service.PromotionApply
+
+	if err := c.ShouldBindJSON(&apply); err != nil {
+		abort(c, invalidRequest(err))
+		return
+	}
+
+	discount, err := h.s.ApplyPromotion(c.Request.Context(), c.Param("id"), apply)
+	if err != nil {
+		abort(c, fmt.Errorf("apply promotion failed: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, discount)
+}
+
+// @Summary redeem promo code on order completion
+// @Tags admin
+// @Param order_id path string true "order's id"
+// @Param input body service.PromotionRedeem true "code the rider entered"
+// @Accept json
+// @Produce json
+// @Success 200 {object} service.Discount
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 422 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /admin/payments/{order_id}/promotion [POST]
+// @Security Bearer
+func (h *Handler) RedeemPromotion(c *gin.Context) {
+	var redeem service.PromotionRedeem
+
+	if err := c.ShouldBindJSON(&redeem); err != nil {
+		abort(c, invalidRequest(err))
+		return
+	}
+
+	discount, err := h.s.Redeem(c.Request.Context(), c.Param("order_id"), redeem)
+	if err != nil {
+		abort(c, fmt.Errorf("redeem promotion failed: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, discount)
+}
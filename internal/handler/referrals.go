@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary get referral code and stats
+// @Tags referrals
+// @Param id path int true "user's id"
+// @Produce json
+// @Success 200 {object} model.ReferralStats
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/profile/{id}/referrals [GET]
+// @Security Bearer
+func (h *Handler) GetReferralStats(c *gin.Context) {
+	stats, err := h.s.GetReferralStats(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abort(c, fmt.Errorf("get referral stats failed: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// @Summary notify about user's completed trip to reward a pending referral
+// @Tags admin
+// @Param id path int true "invitee's id"
+// @Produce json
+// @Success 200 {object} model.Referral
+// @Success 204
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /admin/referrals/{id}/trip-completed [POST]
+// @Security Bearer
+func (h *Handler) CompleteReferralTrip(c *gin.Context) {
+	referral, err := h.s.CompleteFirstTrip(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abort(c, fmt.Errorf("complete first trip failed: %w", err))
+		return
+	}
+	if referral == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.JSON(http.StatusOK, referral)
+}
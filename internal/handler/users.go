@@ -1,87 +1,135 @@
 package handler
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
 
-	"github.com/RipperAcskt/innotaxi/internal/model"
 	"github.com/RipperAcskt/innotaxi/internal/service"
 	"github.com/gin-gonic/gin"
-	"go.uber.org/zap"
 )
 
 // @Summary get user profile
 // @Tags user
 // @Param id path int true "user's id"
+// @Param If-None-Match header string false "ETag of a cached profile"
 // @Produce json
 // @Success 200 {object} model.User
-// @Failure 401 {object} error "error: err"
-// @Failure 403 {object} error "error: err"
-// @Failure 500 {object} error "error: err"
+// @Header 200 {string} ETag "version of the profile"
+// @Success 304 "profile not modified"
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /users/profile/{id} [GET]
 // @Security Bearer
 func (h *Handler) GetProfile(c *gin.Context) {
-	logger := getLogger(c)
-
 	user, err := h.s.GetProfile(c.Request.Context(), c.Param("id"))
 	if err != nil {
-		if errors.Is(err, service.ErrUserDoesNotExists) {
-			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
-		logger.Error("/users/profile/{id}", zap.Error(fmt.Errorf("get profile failed: %w", err)))
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Errorf("get profile failed: %w", err).Error(),
-		})
+		abort(c, fmt.Errorf("get profile failed: %w", err))
 		return
 	}
 
+	c.Header("ETag", etag(user))
+	if noneMatch(c.GetHeader("If-None-Match"), etag(user)) {
+		c.Status(http.StatusNotModified)
+		return
+	}
 	c.JSON(http.StatusOK, user)
 }
 
-// @Summary update user profile
+// @Summary replace user profile
 // @Tags user
-// @Param input body model.User false "rows to update"
+// @Param input body service.UserUpdate true "profile"
 // @Param id path int true "user's id"
+// @Param If-Match header string true "ETag of the profile, or *"
 // @Accept json
 // @Produce json
 // @Success 200 {object} model.User
-// @Failure 401 {object} error "error: err"
-// @Failure 403 {object} error "error: err"
-// @Failure 500 {object} error "error: err"
+// @Header 200 {string} ETag "version of the profile"
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 412 {object} apperror.Problem
+// @Failure 428 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /users/profile/{id} [PUT]
 // @Security Bearer
 func (h *Handler) UpdateProfile(c *gin.Context) {
-	logger := getLogger(c)
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		abort(c, err)
+		return
+	}
 
-	var user model.User
+	var update service.UserUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		abort(c, invalidRequest(err))
+		return
+	}
+	h.normalizePhone(&update.PhoneNumber)
 
-	if err := c.BindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+	user, err := h.s.UpdateProfile(c.Request.Context(), c.Param("id"), version, update)
+	if err != nil {
+		abort(c, fmt.Errorf("update profile failed: %w", err))
 		return
 	}
 
-	err := h.s.UpdateProfile(c.Request.Context(), c.Param("id"), &user)
+	c.Header("ETag", etag(user))
+	c.JSON(http.StatusOK, user)
+}
+
+// @Summary update user profile fields
+// @Description JSON Merge Patch (RFC 7396) of the profile. Only name, phone_number and email can be changed, none of them can be removed.
+// @Tags user
+// @Param input body service.UserPatch true "fields to change"
+// @Param id path int true "user's id"
+// @Param If-Match header string true "ETag of the profile, or *"
+// @Accept application/merge-patch+json
+// @Produce json
+// @Success 200 {object} model.User
+// @Header 200 {string} ETag "version of the profile"
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 412 {object} apperror.Problem
+// @Failure 415 {object} apperror.Problem
+// @Failure 428 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/profile/{id} [PATCH]
+// @Security Bearer
+func (h *Handler) PatchProfile(c *gin.Context) {
+	if c.ContentType() != mergePatchContentType {
+		abort(c, errUnsupportedMediaType.WithDetail("use %s", mergePatchContentType))
+		return
+	}
+	version, err := ifMatchVersion(c)
 	if err != nil {
-		if errors.Is(err, service.ErrUserDoesNotExists) {
-			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
-		logger.Error("/users/profile/{id}", zap.Error(fmt.Errorf("update profile failed: %w", err)))
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		abort(c, err)
 		return
 	}
 
-	c.Status(200)
+	var patch service.UserPatch
+	err = bindMergePatch(c, &patch)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	if patch.PhoneNumber != nil {
+		h.normalizePhone(patch.PhoneNumber)
+	}
+
+	user, err := h.s.PatchProfile(c.Request.Context(), c.Param("id"), version, patch)
+	if err != nil {
+		abort(c, fmt.Errorf("patch profile failed: %w", err))
+		return
+	}
+
+	c.Header("ETag", etag(user))
+	c.JSON(http.StatusOK, user)
 }
 
 // @Summary delete user
@@ -89,26 +137,16 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 // @Param id path int false "user's id to delete"
 // @Accept json
 // @Success 200
-// @Failure 401 {object} error "error: err"
-// @Failure 403 {object} error "error: err"
-// @Failure 500 {object} error "error: err"
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /users/{id} [DELETE]
 // @Security Bearer
 func (h *Handler) DeleteUser(c *gin.Context) {
-	logger := getLogger(c)
-
 	err := h.s.DeleteUser(c.Request.Context(), c.Param("id"))
 	if err != nil {
-		if errors.Is(err, service.ErrUserDoesNotExists) {
-			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
-		logger.Error("/users/{id}", zap.Error(fmt.Errorf("delete user failed: %w", err)))
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		abort(c, fmt.Errorf("delete user failed: %w", err))
 		return
 	}
 	c.Status(200)
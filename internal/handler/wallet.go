@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary get wallet balance and transaction history
+// @Tags wallet
+// @Param id path int true "user's id"
+// @Param limit query int false "transactions per page"
+// @Param offset query int false "transactions to skip"
+// @Produce json
+// @Success 200 {object} model.Wallet
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/profile/{id}/wallet [GET]
+// @Security Bearer
+func (h *Handler) GetWallet(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		abort(c, invalidRequest(fmt.Errorf("bad limit")))
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		abort(c, invalidRequest(fmt.Errorf("bad offset")))
+		return
+	}
+
+	wallet, err := h.s.GetWallet(c.Request.Context(), c.Param("id"), limit, offset)
+	if err != nil {
+		abort(c, fmt.Errorf("get wallet failed: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, wallet)
+}
+
+// @Summary top up user's wallet
+// @Tags admin
+// @Param id path int true "user's id"
+// @Param input body service.WalletOperation true "amount and idempotency key"
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Transaction
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /admin/wallets/{id}/top-up [POST]
+// @Security Bearer
+func (h *Handler) TopUpWallet(c *gin.Context) {
+	var op service.WalletOperation
+
+	if err := c.ShouldBindJSON(&op); err != nil {
+		abort(c, invalidRequest(err))
+		return
+	}
+
+	entry, err := h.s.TopUp(c.Request.Context(), c.Param("id"), op)
+	h.walletResponse(c, entry, op.Amount, err)
+}
+
+// @Summary charge trip fare or cancellation fee from user's wallet
+// @Tags admin
+// @Param id path int true "user's id"
+// @Param input body service.WalletCharge true "charge info"
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Transaction
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 422 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /admin/wallets/{id}/charge [POST]
+// @Security Bearer
+func (h *Handler) ChargeWallet(c *gin.Context) {
+	var charge service.WalletCharge
+
+	if err := c.ShouldBindJSON(&charge); err != nil {
+		abort(c, invalidRequest(err))
+		return
+	}
+
+	entry, err := h.s.Charge(c.Request.Context(), c.Param("id"), charge)
+	h.walletResponse(c, entry, -charge.Amount, err)
+}
+
+// @Summary refund to user's wallet
+// @Tags admin
+// @Param id path int true "user's id"
+// @Param input body service.WalletOperation true "refund info"
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Transaction
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /admin/wallets/{id}/refund [POST]
+// @Security Bearer
+func (h *Handler) RefundWallet(c *gin.Context) {
+	var op service.WalletOperation
+
+	if err := c.ShouldBindJSON(&op); err != nil {
+		abort(c, invalidRequest(err))
+		return
+	}
+
+	entry, err := h.s.Refund(c.Request.Context(), c.Param("id"), op)
+	h.walletResponse(c, entry, op.Amount, err)
+}
+
+func (h *Handler) walletResponse(c *gin.Context, entry *model.JournalEntry, amount int64, err error) {
+	if err != nil {
+		abort(c, fmt.Errorf("post entry failed: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Transaction{
+		ID:          entry.ID,
+		Kind:        entry.Kind,
+		Description: entry.Description,
+		Amount:      amount,
+		CreatedAt:   entry.CreatedAt,
+	})
+}
@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary create webhook subscription
+// @Description The webhook gets the events of the employees of its partner only.
+// @Tags admin
+// @Param input body model.Webhook true "partner, url, event types and optional secret"
+// @Accept json
+// @Produce json
+// @Success 201 {object} model.Webhook
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /admin/webhooks [POST]
+// @Security Bearer
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	var webhook model.Webhook
+
+	if err := c.ShouldBindJSON(&webhook); err != nil {
+		abort(c, invalidRequest(err))
+		return
+	}
+
+	err := h.s.AddWebhook(c.Request.Context(), &webhook)
+	if err != nil {
+		abort(c, fmt.Errorf("add webhook failed: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhook)
+}
+
+// @Summary get webhook subscriptions
+// @Tags admin
+// @Produce json
+// @Success 200 {array} model.Webhook
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /admin/webhooks [GET]
+// @Security Bearer
+func (h *Handler) GetWebhooks(c *gin.Context) {
+	webhooks, err := h.s.GetAllWebhooks(c.Request.Context())
+	if err != nil {
+		abort(c, fmt.Errorf("get webhooks failed: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// @Summary get webhook subscription
+// @Tags admin
+// @Param id path int true "webhook's id"
+// @Produce json
+// @Success 200 {object} model.Webhook
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /admin/webhooks/{id} [GET]
+// @Security Bearer
+func (h *Handler) GetWebhook(c *gin.Context) {
+	webhook, err := h.s.GetWebhook(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abort(c, fmt.Errorf("get webhook failed: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// @Summary update webhook subscription
+// @Tags admin
+// @Param id path int true "webhook's id"
+// @Param input body model.Webhook true "webhook info"
+// @Accept json
+// @Success 200
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /admin/webhooks/{id} [PUT]
+// @Security Bearer
+func (h *Handler) UpdateWebhook(c *gin.Context) {
+	var webhook model.Webhook
+
+	if err := c.ShouldBindJSON(&webhook); err != nil {
+		abort(c, invalidRequest(err))
+		return
+	}
+
+	err := h.s.EditWebhook(c.Request.Context(), c.Param("id"), &webhook)
+	if err != nil {
+		abort(c, fmt.Errorf("edit webhook failed: %w", err))
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary delete webhook subscription and its delivery log
+// @Tags admin
+// @Param id path int true "webhook's id"
+// @Success 200
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /admin/webhooks/{id} [DELETE]
+// @Security Bearer
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	err := h.s.DeleteWebhook(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abort(c, fmt.Errorf("delete webhook failed: %w", err))
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary get webhook delivery log
+// @Tags admin
+// @Param id path int true "webhook's id"
+// @Param status query string false "pending, delivered or dead"
+// @Param limit query int false "deliveries per page"
+// @Param offset query int false "deliveries to skip"
+// @Produce json
+// @Success 200 {array} model.WebhookDelivery
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /admin/webhooks/{id}/deliveries [GET]
+// @Security Bearer
+func (h *Handler) GetWebhookDeliveries(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		abort(c, invalidRequest(fmt.Errorf("bad limit")))
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		abort(c, invalidRequest(fmt.Errorf("bad offset")))
+		return
+	}
+
+	deliveries, err := h.s.GetDeliveries(c.Request.Context(), c.Param("id"), c.Query("status"), limit, offset)
+	if err != nil {
+		abort(c, fmt.Errorf("get deliveries failed: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// @Summary retry dead webhook delivery
+// @Tags admin
+// @Param id path int true "webhook's id"
+// @Param delivery_id path int true "delivery's id"
+// @Success 200
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /admin/webhooks/{id}/deliveries/{delivery_id}/retry [POST]
+// @Security Bearer
+func (h *Handler) RetryWebhookDelivery(c *gin.Context) {
+	err := h.s.RetryDelivery(c.Request.Context(), c.Param("id"), c.Param("delivery_id"))
+	if err != nil {
+		abort(c, fmt.Errorf("retry delivery failed: %w", err))
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary add partner employee
+// @Description Subscribes the partner's webhooks to the events of the user. A user is an employee of one partner at a time.
+// @Tags admin
+// @Param id path int true "partner's id"
+// @Param user_id path int true "user's id"
+// @Success 200
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /admin/partners/{id}/employees/{user_id} [PUT]
+// @Security Bearer
+func (h *Handler) AddPartnerEmployee(c *gin.Context) {
+	partnerId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || partnerId == 0 {
+		abort(c, invalidRequest(fmt.Errorf("bad partner id")))
+		return
+	}
+
+	err = h.s.AddEmployee(c.Request.Context(), partnerId, c.Param("user_id"))
+	if err != nil {
+		abort(c, fmt.Errorf("add employee failed: %w", err))
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary remove partner employee
+// @Tags admin
+// @Param id path int true "partner's id"
+// @Param user_id path int true "user's id"
+// @Success 200
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /admin/partners/{id}/employees/{user_id} [DELETE]
+// @Security Bearer
+func (h *Handler) RemovePartnerEmployee(c *gin.Context) {
+	partnerId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || partnerId == 0 {
+		abort(c, invalidRequest(fmt.Errorf("bad partner id")))
+		return
+	}
+
+	err = h.s.RemoveEmployee(c.Request.Context(), partnerId, c.Param("user_id"))
+	if err != nil {
+		abort(c, fmt.Errorf("remove employee failed: %w", err))
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,24 @@
+// Package logger keeps the request-scoped logger in a context.Context.
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type key struct{}
+
+func With(ctx context.Context, log *zap.Logger) context.Context {
+	return context.WithValue(ctx, key{}, log)
+}
+
+// From returns the logger in ctx, or the global zap logger, which the app
+// replaces with its own, if there is none.
+func From(ctx context.Context) *zap.Logger {
+	log, ok := ctx.Value(key{}).(*zap.Logger)
+	if !ok {
+		return zap.L()
+	}
+	return log
+}
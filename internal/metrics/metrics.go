@@ -0,0 +1,174 @@
+// Package metrics collects Prometheus metrics of the service.
+package metrics
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "innotaxi"
+
+// Auth actions.
+const (
+	SignUp  string = "sign_up"
+	SignIn  string = "sign_in"
+	Refresh string = "refresh"
+	Logout  string = "logout"
+)
+
+// Auth results. Failures that aren't listed are counted as ResultError.
+const (
+	ResultSuccess           string = "success"
+	ResultBadRequest        string = "bad_request"
+	ResultUserExists        string = "user_exists"
+	ResultUserNotFound      string = "user_not_found"
+	ResultIncorrectPassword string = "incorrect_password"
+	ResultUserDeleted       string = "user_deleted"
+	ResultTokenExpired      string = "token_expired"
+	ResultInvalidToken      string = "invalid_token"
+	ResultError             string = "error"
+)
+
+// Metrics holds the collectors. Every Metrics has its own registry, so
+// tests can create as many as they need.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	repoDuration    *prometheus.HistogramVec
+	repoErrors      *prometheus.CounterVec
+	auth            *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route template and status.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route template and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		repoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repo_call_duration_seconds",
+			Help:      "Repository call latency by storage and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"repo", "method"}),
+		repoErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "repo_errors_total",
+			Help:      "Repository calls that returned an error by storage and method.",
+		}, []string{"repo", "method"}),
+		auth: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "auth_total",
+			Help:      "Sign-ups, sign-ins, refreshes and logouts by result.",
+		}, []string{"action", "result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.repoDuration,
+		m.repoErrors,
+		m.auth,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// RegisterDB exports the connection pool stats of db as go_sql_* gauges
+// labeled with name.
+func (m *Metrics) RegisterDB(name string, db *sql.DB) error {
+	err := m.registry.Register(collectors.NewDBStatsCollector(db, name))
+	if err != nil {
+		return fmt.Errorf("register failed: %w", err)
+	}
+	return nil
+}
+
+// RegisterLogSink exports the numbers of log entries the sink dropped and
+// spilled to disk.
+func (m *Metrics) RegisterLogSink(dropped, spilled func() uint64) error {
+	err := m.registry.Register(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "log_entries_dropped_total",
+		Help:      "Log entries lost to a full buffer or failed inserts.",
+	}, func() float64 { return float64(dropped()) }))
+	if err != nil {
+		return fmt.Errorf("register dropped failed: %w", err)
+	}
+
+	err = m.registry.Register(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "log_entries_spilled_total",
+		Help:      "Log entries written to the spill file instead of the log store.",
+	}, func() float64 { return float64(spilled()) }))
+	if err != nil {
+		return fmt.Errorf("register spilled failed: %w", err)
+	}
+	return nil
+}
+
+// RegisterUserCache exports the hits and misses of the user profile cache.
+func (m *Metrics) RegisterUserCache(hits, misses func() uint64) error {
+	err := m.registry.Register(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "user_cache_hits_total",
+		Help:      "Profile reads answered from the user cache.",
+	}, func() float64 { return float64(hits()) }))
+	if err != nil {
+		return fmt.Errorf("register hits failed: %w", err)
+	}
+
+	err = m.registry.Register(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "user_cache_misses_total",
+		Help:      "Profile reads the user cache passed on to the repository.",
+	}, func() float64 { return float64(misses()) }))
+	if err != nil {
+		return fmt.Errorf("register misses failed: %w", err)
+	}
+	return nil
+}
+
+// ObserveRequest records a served request. route is the route template,
+// e.g. /users/profile/:id, so ids don't blow up the number of series.
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(method, route, code).Inc()
+	m.requestDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// ObserveRepo records a repository call that started at start.
+func (m *Metrics) ObserveRepo(repo, method string, start time.Time, err error) {
+	m.repoDuration.WithLabelValues(repo, method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.repoErrors.WithLabelValues(repo, method).Inc()
+	}
+}
+
+// Auth counts an auth action with its result.
+func (m *Metrics) Auth(action, result string) {
+	m.auth.WithLabelValues(action, result).Inc()
+}
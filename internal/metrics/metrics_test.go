@@ -0,0 +1,109 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/metrics"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/repo/cache"
+	"github.com/RipperAcskt/innotaxi/internal/repo/memory"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/go-playground/assert/v2"
+)
+
+type store struct {
+	*memory.Tokens
+	*memory.Store
+}
+
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	return w.Body.String()
+}
+
+// failingRepo is a storage that is down for GetPromotions.
+type failingRepo struct {
+	*memory.Memory
+}
+
+func (failingRepo) GetPromotions(ctx context.Context) ([]*model.Promotion, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestRepo(t *testing.T) {
+	m := metrics.New()
+	repo := metrics.NewRepo(failingRepo{memory.New()}, m, "memory")
+
+	_, err := repo.GetUserById(context.Background(), "1")
+	assert.Equal(t, service.ErrUserDoesNotExists, err)
+	err = repo.AddGatewayEvent(context.Background(), "evt_1")
+	assert.Equal(t, nil, err)
+	err = repo.AddGatewayEvent(context.Background(), "evt_1")
+	assert.Equal(t, service.ErrGatewayEventHandled, err)
+	_, err = repo.GetPromotions(context.Background())
+	assert.NotEqual(t, nil, err)
+	_, err = repo.GetPaymentMethodsByUserId(context.Background(), "1")
+	assert.Equal(t, nil, err)
+
+	body := scrape(t, m)
+	assert.Equal(t, true, strings.Contains(body, `innotaxi_repo_call_duration_seconds_count{method="GetUserById",repo="memory"} 1`))
+	assert.Equal(t, false, strings.Contains(body, `innotaxi_repo_errors_total{method="GetUserById"`))
+	assert.Equal(t, false, strings.Contains(body, `innotaxi_repo_errors_total{method="AddGatewayEvent"`))
+	assert.Equal(t, true, strings.Contains(body, `innotaxi_repo_errors_total{method="GetPromotions",repo="memory"} 1`))
+	assert.Equal(t, true, strings.Contains(body, `innotaxi_repo_call_duration_seconds_count{method="GetPaymentMethodsByUserId",repo="memory"} 1`))
+	assert.Equal(t, false, strings.Contains(body, `innotaxi_repo_errors_total{method="GetPaymentMethodsByUserId"`))
+}
+
+func TestStore(t *testing.T) {
+	m := metrics.New()
+	s := metrics.NewStore(store{memory.NewTokens(), memory.NewStore()}, m, "redis")
+
+	_, err := s.Get(context.Background(), "missing")
+	assert.NotEqual(t, nil, err)
+	err = s.Set(context.Background(), "key", []byte("value"), time.Minute)
+	assert.Equal(t, nil, err)
+	s.GetToken(context.Background(), "token")
+
+	body := scrape(t, m)
+	assert.Equal(t, true, strings.Contains(body, `innotaxi_repo_call_duration_seconds_count{method="Get",repo="redis"} 1`))
+	assert.Equal(t, true, strings.Contains(body, `innotaxi_repo_call_duration_seconds_count{method="Set",repo="redis"} 1`))
+	assert.Equal(t, true, strings.Contains(body, `innotaxi_repo_call_duration_seconds_count{method="GetToken",repo="redis"} 1`))
+	assert.Equal(t, false, strings.Contains(body, `innotaxi_repo_errors_total`))
+}
+
+func TestObserve(t *testing.T) {
+	m := metrics.New()
+	m.ObserveRequest(http.MethodGet, "/users/profile/:id", http.StatusOK, 10*time.Millisecond)
+	m.ObserveRequest(http.MethodGet, "/users/profile/:id", http.StatusOK, 20*time.Millisecond)
+	m.Auth(metrics.SignIn, metrics.ResultIncorrectPassword)
+
+	body := scrape(t, m)
+	assert.Equal(t, true, strings.Contains(body, `innotaxi_http_requests_total{method="GET",route="/users/profile/:id",status="200"} 2`))
+	assert.Equal(t, true, strings.Contains(body, `innotaxi_http_request_duration_seconds_count{method="GET",route="/users/profile/:id",status="200"} 2`))
+	assert.Equal(t, true, strings.Contains(body, `innotaxi_auth_total{action="sign_in",result="incorrect_password"} 1`))
+}
+
+func TestUserCache(t *testing.T) {
+	m := metrics.New()
+	users := cache.NewUserCache(memory.New(), memory.NewStore(), time.Minute)
+	err := m.RegisterUserCache(
+		func() uint64 { return users.Stats().Hits },
+		func() uint64 { return users.Stats().Misses },
+	)
+	assert.Equal(t, nil, err)
+
+	_, err = users.GetUserById(context.Background(), "1")
+	assert.NotEqual(t, nil, err)
+
+	body := scrape(t, m)
+	assert.Equal(t, true, strings.Contains(body, "innotaxi_user_cache_hits_total 0"))
+	assert.Equal(t, true, strings.Contains(body, "innotaxi_user_cache_misses_total 1"))
+}
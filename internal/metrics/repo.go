@@ -0,0 +1,327 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/apperror"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+// Repo is a service.Repo that records the latency and errors of every call
+// under the storage name. Transactions aren't timed, the calls made inside
+// them are.
+type Repo struct {
+	repo    service.Repo
+	metrics *Metrics
+	name    string
+}
+
+func NewRepo(repo service.Repo, metrics *Metrics, name string) *Repo {
+	return &Repo{
+		repo:    repo,
+		metrics: metrics,
+		name:    name,
+	}
+}
+
+// observe counts only failures of the storage as errors. Errors with a kind
+// clients may see, like a missing user or a taken phone number, are outcomes
+// of the call.
+func (r *Repo) observe(method string, start time.Time, err *error) {
+	if *err != nil && apperror.From(*err).Kind != apperror.Internal {
+		r.metrics.ObserveRepo(r.name, method, start, nil)
+		return
+	}
+	r.metrics.ObserveRepo(r.name, method, start, *err)
+}
+
+func (r *Repo) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.repo.WithinTransaction(ctx, fn)
+}
+
+func (r *Repo) CreateUser(ctx context.Context, user service.UserSingUp) (err error) {
+	defer r.observe("CreateUser", time.Now(), &err)
+	return r.repo.CreateUser(ctx, user)
+}
+
+func (r *Repo) CheckUserByPhoneNumber(ctx context.Context, phone string) (user *service.UserSingIn, err error) {
+	defer r.observe("CheckUserByPhoneNumber", time.Now(), &err)
+	return r.repo.CheckUserByPhoneNumber(ctx, phone)
+}
+
+func (r *Repo) GetUserByReferralCode(ctx context.Context, code string) (user *model.User, err error) {
+	defer r.observe("GetUserByReferralCode", time.Now(), &err)
+	return r.repo.GetUserByReferralCode(ctx, code)
+}
+
+func (r *Repo) CountReferralsByPhoneNumber(ctx context.Context, phone string) (count int, err error) {
+	defer r.observe("CountReferralsByPhoneNumber", time.Now(), &err)
+	return r.repo.CountReferralsByPhoneNumber(ctx, phone)
+}
+
+func (r *Repo) CountReferralsByDeviceId(ctx context.Context, deviceId string) (count int, err error) {
+	defer r.observe("CountReferralsByDeviceId", time.Now(), &err)
+	return r.repo.CountReferralsByDeviceId(ctx, deviceId)
+}
+
+func (r *Repo) GetDeletedUserByPhoneNumber(ctx context.Context, phone string, since time.Time) (user *service.UserSingIn, err error) {
+	defer r.observe("GetDeletedUserByPhoneNumber", time.Now(), &err)
+	return r.repo.GetDeletedUserByPhoneNumber(ctx, phone, since)
+}
+
+func (r *Repo) RestoreUserById(ctx context.Context, id string) (err error) {
+	defer r.observe("RestoreUserById", time.Now(), &err)
+	return r.repo.RestoreUserById(ctx, id)
+}
+
+func (r *Repo) GetUserById(ctx context.Context, id string) (user *model.User, err error) {
+	defer r.observe("GetUserById", time.Now(), &err)
+	return r.repo.GetUserById(ctx, id)
+}
+
+func (r *Repo) UpdateUserById(ctx context.Context, id string, user *model.User) (err error) {
+	defer r.observe("UpdateUserById", time.Now(), &err)
+	return r.repo.UpdateUserById(ctx, id, user)
+}
+
+func (r *Repo) DeleteUserById(ctx context.Context, id string) (err error) {
+	defer r.observe("DeleteUserById", time.Now(), &err)
+	return r.repo.DeleteUserById(ctx, id)
+}
+
+func (r *Repo) GetPurgeableUsers(ctx context.Context, before time.Time, limit int) (ids []uint64, err error) {
+	defer r.observe("GetPurgeableUsers", time.Now(), &err)
+	return r.repo.GetPurgeableUsers(ctx, before, limit)
+}
+
+func (r *Repo) PurgeUserById(ctx context.Context, id string) (err error) {
+	defer r.observe("PurgeUserById", time.Now(), &err)
+	return r.repo.PurgeUserById(ctx, id)
+}
+
+func (r *Repo) CreatePlace(ctx context.Context, userId string, place *model.Place, limit int) (err error) {
+	defer r.observe("CreatePlace", time.Now(), &err)
+	return r.repo.CreatePlace(ctx, userId, place, limit)
+}
+
+func (r *Repo) GetPlacesByUserId(ctx context.Context, userId string) (places []*model.Place, err error) {
+	defer r.observe("GetPlacesByUserId", time.Now(), &err)
+	return r.repo.GetPlacesByUserId(ctx, userId)
+}
+
+func (r *Repo) GetPlaceById(ctx context.Context, userId, placeId string) (place *model.Place, err error) {
+	defer r.observe("GetPlaceById", time.Now(), &err)
+	return r.repo.GetPlaceById(ctx, userId, placeId)
+}
+
+func (r *Repo) UpdatePlaceById(ctx context.Context, userId, placeId string, place *model.Place) (err error) {
+	defer r.observe("UpdatePlaceById", time.Now(), &err)
+	return r.repo.UpdatePlaceById(ctx, userId, placeId, place)
+}
+
+func (r *Repo) DeletePlaceById(ctx context.Context, userId, placeId string) (err error) {
+	defer r.observe("DeletePlaceById", time.Now(), &err)
+	return r.repo.DeletePlaceById(ctx, userId, placeId)
+}
+
+func (r *Repo) CreatePromotion(ctx context.Context, promotion *model.Promotion) (err error) {
+	defer r.observe("CreatePromotion", time.Now(), &err)
+	return r.repo.CreatePromotion(ctx, promotion)
+}
+
+func (r *Repo) GetPromotions(ctx context.Context) (promotions []*model.Promotion, err error) {
+	defer r.observe("GetPromotions", time.Now(), &err)
+	return r.repo.GetPromotions(ctx)
+}
+
+func (r *Repo) GetPromotionByCode(ctx context.Context, code string) (promotion *model.Promotion, err error) {
+	defer r.observe("GetPromotionByCode", time.Now(), &err)
+	return r.repo.GetPromotionByCode(ctx, code)
+}
+
+func (r *Repo) DeactivatePromotionById(ctx context.Context, id string) (err error) {
+	defer r.observe("DeactivatePromotionById", time.Now(), &err)
+	return r.repo.DeactivatePromotionById(ctx, id)
+}
+
+func (r *Repo) CountRedemptions(ctx context.Context, promotionId uint64, userId string) (count int, err error) {
+	defer r.observe("CountRedemptions", time.Now(), &err)
+	return r.repo.CountRedemptions(ctx, promotionId, userId)
+}
+
+func (r *Repo) RedeemPromotion(ctx context.Context, redemption *model.Redemption) (err error) {
+	defer r.observe("RedeemPromotion", time.Now(), &err)
+	return r.repo.RedeemPromotion(ctx, redemption)
+}
+
+func (r *Repo) PostEntry(ctx context.Context, entry *model.JournalEntry) (err error) {
+	defer r.observe("PostEntry", time.Now(), &err)
+	return r.repo.PostEntry(ctx, entry)
+}
+
+func (r *Repo) GetBalance(ctx context.Context, userId string) (balance int64, err error) {
+	defer r.observe("GetBalance", time.Now(), &err)
+	return r.repo.GetBalance(ctx, userId)
+}
+
+func (r *Repo) GetTransactions(ctx context.Context, userId string, limit, offset int) (transactions []*model.Transaction, err error) {
+	defer r.observe("GetTransactions", time.Now(), &err)
+	return r.repo.GetTransactions(ctx, userId, limit, offset)
+}
+
+func (r *Repo) CreatePaymentMethod(ctx context.Context, userId string, method *model.PaymentMethod) (err error) {
+	defer r.observe("CreatePaymentMethod", time.Now(), &err)
+	return r.repo.CreatePaymentMethod(ctx, userId, method)
+}
+
+func (r *Repo) GetPaymentMethodsByUserId(ctx context.Context, userId string) (methods []*model.PaymentMethod, err error) {
+	defer r.observe("GetPaymentMethodsByUserId", time.Now(), &err)
+	return r.repo.GetPaymentMethodsByUserId(ctx, userId)
+}
+
+func (r *Repo) GetPaymentMethodById(ctx context.Context, userId, methodId string) (method *model.PaymentMethod, err error) {
+	defer r.observe("GetPaymentMethodById", time.Now(), &err)
+	return r.repo.GetPaymentMethodById(ctx, userId, methodId)
+}
+
+func (r *Repo) DeletePaymentMethodById(ctx context.Context, userId, methodId string) (err error) {
+	defer r.observe("DeletePaymentMethodById", time.Now(), &err)
+	return r.repo.DeletePaymentMethodById(ctx, userId, methodId)
+}
+
+func (r *Repo) CreatePayment(ctx context.Context, payment *model.Payment) (err error) {
+	defer r.observe("CreatePayment", time.Now(), &err)
+	return r.repo.CreatePayment(ctx, payment)
+}
+
+func (r *Repo) GetPaymentByOrderId(ctx context.Context, orderId string) (payment *model.Payment, err error) {
+	defer r.observe("GetPaymentByOrderId", time.Now(), &err)
+	return r.repo.GetPaymentByOrderId(ctx, orderId)
+}
+
+func (r *Repo) GetPaymentByAuthorizationId(ctx context.Context, authorizationId string) (payment *model.Payment, err error) {
+	defer r.observe("GetPaymentByAuthorizationId", time.Now(), &err)
+	return r.repo.GetPaymentByAuthorizationId(ctx, authorizationId)
+}
+
+func (r *Repo) UpdatePayment(ctx context.Context, payment *model.Payment) (err error) {
+	defer r.observe("UpdatePayment", time.Now(), &err)
+	return r.repo.UpdatePayment(ctx, payment)
+}
+
+func (r *Repo) AddCapture(ctx context.Context, orderId string, amount int64) (payment *model.Payment, err error) {
+	defer r.observe("AddCapture", time.Now(), &err)
+	return r.repo.AddCapture(ctx, orderId, amount)
+}
+
+func (r *Repo) AddRefund(ctx context.Context, orderId string, amount int64) (payment *model.Payment, err error) {
+	defer r.observe("AddRefund", time.Now(), &err)
+	return r.repo.AddRefund(ctx, orderId, amount)
+}
+
+func (r *Repo) AddGatewayEvent(ctx context.Context, id string) (err error) {
+	defer r.observe("AddGatewayEvent", time.Now(), &err)
+	return r.repo.AddGatewayEvent(ctx, id)
+}
+
+func (r *Repo) GetPendingReferralByInviteeId(ctx context.Context, inviteeId string) (referral *model.Referral, err error) {
+	defer r.observe("GetPendingReferralByInviteeId", time.Now(), &err)
+	return r.repo.GetPendingReferralByInviteeId(ctx, inviteeId)
+}
+
+func (r *Repo) CompleteReferralById(ctx context.Context, referral *model.Referral) (err error) {
+	defer r.observe("CompleteReferralById", time.Now(), &err)
+	return r.repo.CompleteReferralById(ctx, referral)
+}
+
+func (r *Repo) GetReferralStatsByUserId(ctx context.Context, userId string) (stats *model.ReferralStats, err error) {
+	defer r.observe("GetReferralStatsByUserId", time.Now(), &err)
+	return r.repo.GetReferralStatsByUserId(ctx, userId)
+}
+
+func (r *Repo) AddEvent(ctx context.Context, event *model.Event) (err error) {
+	defer r.observe("AddEvent", time.Now(), &err)
+	return r.repo.AddEvent(ctx, event)
+}
+
+func (r *Repo) GetPendingEvents(ctx context.Context, limit int) (events []*model.Event, err error) {
+	defer r.observe("GetPendingEvents", time.Now(), &err)
+	return r.repo.GetPendingEvents(ctx, limit)
+}
+
+func (r *Repo) MarkEventPublished(ctx context.Context, id uint64) (err error) {
+	defer r.observe("MarkEventPublished", time.Now(), &err)
+	return r.repo.MarkEventPublished(ctx, id)
+}
+
+func (r *Repo) MarkEventFailed(ctx context.Context, id uint64, reason string) (err error) {
+	defer r.observe("MarkEventFailed", time.Now(), &err)
+	return r.repo.MarkEventFailed(ctx, id, reason)
+}
+
+func (r *Repo) MarkEventDead(ctx context.Context, id uint64, reason string) (err error) {
+	defer r.observe("MarkEventDead", time.Now(), &err)
+	return r.repo.MarkEventDead(ctx, id, reason)
+}
+
+func (r *Repo) CreateWebhook(ctx context.Context, webhook *model.Webhook) (err error) {
+	defer r.observe("CreateWebhook", time.Now(), &err)
+	return r.repo.CreateWebhook(ctx, webhook)
+}
+
+func (r *Repo) GetWebhooks(ctx context.Context) (webhooks []*model.Webhook, err error) {
+	defer r.observe("GetWebhooks", time.Now(), &err)
+	return r.repo.GetWebhooks(ctx)
+}
+
+func (r *Repo) GetWebhookById(ctx context.Context, id string) (webhook *model.Webhook, err error) {
+	defer r.observe("GetWebhookById", time.Now(), &err)
+	return r.repo.GetWebhookById(ctx, id)
+}
+
+func (r *Repo) UpdateWebhook(ctx context.Context, webhook *model.Webhook) (err error) {
+	defer r.observe("UpdateWebhook", time.Now(), &err)
+	return r.repo.UpdateWebhook(ctx, webhook)
+}
+
+func (r *Repo) DeleteWebhookById(ctx context.Context, id string) (err error) {
+	defer r.observe("DeleteWebhookById", time.Now(), &err)
+	return r.repo.DeleteWebhookById(ctx, id)
+}
+
+func (r *Repo) AddPartnerEmployee(ctx context.Context, partnerId uint64, userId string) (err error) {
+	defer r.observe("AddPartnerEmployee", time.Now(), &err)
+	return r.repo.AddPartnerEmployee(ctx, partnerId, userId)
+}
+
+func (r *Repo) RemovePartnerEmployee(ctx context.Context, partnerId uint64, userId string) (err error) {
+	defer r.observe("RemovePartnerEmployee", time.Now(), &err)
+	return r.repo.RemovePartnerEmployee(ctx, partnerId, userId)
+}
+
+func (r *Repo) AddWebhookDeliveries(ctx context.Context, eventId uint64, eventType, userId string, body []byte) (err error) {
+	defer r.observe("AddWebhookDeliveries", time.Now(), &err)
+	return r.repo.AddWebhookDeliveries(ctx, eventId, eventType, userId, body)
+}
+
+func (r *Repo) GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) (deliveries []*model.WebhookDelivery, err error) {
+	defer r.observe("GetDueWebhookDeliveries", time.Now(), &err)
+	return r.repo.GetDueWebhookDeliveries(ctx, now, limit)
+}
+
+func (r *Repo) UpdateWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) (err error) {
+	defer r.observe("UpdateWebhookDelivery", time.Now(), &err)
+	return r.repo.UpdateWebhookDelivery(ctx, delivery)
+}
+
+func (r *Repo) GetWebhookDeliveries(ctx context.Context, webhookId, status string, limit, offset int) (deliveries []*model.WebhookDelivery, err error) {
+	defer r.observe("GetWebhookDeliveries", time.Now(), &err)
+	return r.repo.GetWebhookDeliveries(ctx, webhookId, status, limit, offset)
+}
+
+func (r *Repo) RetryWebhookDelivery(ctx context.Context, webhookId, deliveryId string, now time.Time) (err error) {
+	defer r.observe("RetryWebhookDelivery", time.Now(), &err)
+	return r.repo.RetryWebhookDelivery(ctx, webhookId, deliveryId, now)
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/repo/cache"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+// KeyValueStore is what Redis is used for: revoked tokens, cached profiles
+// and idempotent responses.
+type KeyValueStore interface {
+	service.TokenRepo
+	cache.Store
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+}
+
+// Store is a KeyValueStore that records the latency and errors of every
+// call. Cache misses aren't errors.
+type Store struct {
+	store   KeyValueStore
+	metrics *Metrics
+	name    string
+}
+
+func NewStore(store KeyValueStore, metrics *Metrics, name string) *Store {
+	return &Store{
+		store:   store,
+		metrics: metrics,
+		name:    name,
+	}
+}
+
+func (s *Store) observe(method string, start time.Time, err *error) {
+	if errors.Is(*err, cache.ErrMiss) {
+		s.metrics.ObserveRepo(s.name, method, start, nil)
+		return
+	}
+	s.metrics.ObserveRepo(s.name, method, start, *err)
+}
+
+func (s *Store) AddToken(ctx context.Context, token string, expired time.Duration) (err error) {
+	defer s.observe("AddToken", time.Now(), &err)
+	return s.store.AddToken(ctx, token, expired)
+}
+
+func (s *Store) GetToken(ctx context.Context, token string) bool {
+	var err error
+	defer s.observe("GetToken", time.Now(), &err)
+	return s.store.GetToken(ctx, token)
+}
+
+func (s *Store) Get(ctx context.Context, key string) (value []byte, err error) {
+	defer s.observe("Get", time.Now(), &err)
+	return s.store.Get(ctx, key)
+}
+
+func (s *Store) Set(ctx context.Context, key string, value []byte, ttl time.Duration) (err error) {
+	defer s.observe("Set", time.Now(), &err)
+	return s.store.Set(ctx, key, value, ttl)
+}
+
+func (s *Store) Del(ctx context.Context, key string) (err error) {
+	defer s.observe("Del", time.Now(), &err)
+	return s.store.Del(ctx, key)
+}
+
+func (s *Store) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (ok bool, err error) {
+	defer s.observe("SetNX", time.Now(), &err)
+	return s.store.SetNX(ctx, key, value, ttl)
+}
+
+// LogStore is where log entries are stored and searched, e.g. Mongo.
+type LogStore interface {
+	service.LogRepo
+	InsertLogs(ctx context.Context, logs []interface{}) error
+}
+
+// Logs is a LogStore that records the latency and errors of every call.
+type Logs struct {
+	store   LogStore
+	metrics *Metrics
+	name    string
+}
+
+func NewLogs(store LogStore, metrics *Metrics, name string) *Logs {
+	return &Logs{
+		store:   store,
+		metrics: metrics,
+		name:    name,
+	}
+}
+
+func (l *Logs) InsertLogs(ctx context.Context, logs []interface{}) (err error) {
+	defer func(start time.Time) {
+		l.metrics.ObserveRepo(l.name, "InsertLogs", start, err)
+	}(time.Now())
+	return l.store.InsertLogs(ctx, logs)
+}
+
+func (l *Logs) GetLogs(ctx context.Context, filter *model.LogFilter) (logs []*model.Log, err error) {
+	defer func(start time.Time) {
+		l.metrics.ObserveRepo(l.name, "GetLogs", start, err)
+	}(time.Now())
+	return l.store.GetLogs(ctx, filter)
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventUserSignedUp     string = "user.signed_up"
+	EventUserPhoneChanged string = "user.phone_changed"
+	EventUserDeleted      string = "user.deleted"
+	EventUserRestored     string = "user.restored"
+	EventUserPurged       string = "user.purged"
+)
+
+// Event is a domain event stored in the outbox. AggregateID is the id of
+// the user the event is about, consumers can use it to keep per-user order.
+// Delivery is at least once, so consumers should dedupe by ID.
+type Event struct {
+	ID          uint64          `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID string          `json:"aggregate_id"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"created_at"`
+	Attempts    int             `json:"-"`
+}
+
+type UserSignedUp struct {
+	UserID      string `json:"user_id"`
+	Name        string `json:"name"`
+	PhoneNumber string `json:"phone_number"`
+	Email       string `json:"email"`
+}
+
+type UserPhoneChanged struct {
+	UserID      string `json:"user_id"`
+	PhoneNumber string `json:"phone_number"`
+}
+
+type UserDeleted struct {
+	UserID string `json:"user_id"`
+}
+
+type UserRestored struct {
+	UserID string `json:"user_id"`
+}
+
+// UserPurged is published when the personal data of a deleted user is
+// erased. Consumers holding copies of it should erase them too.
+type UserPurged struct {
+	UserID string `json:"user_id"`
+}
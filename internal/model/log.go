@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+// Log is a stored log entry. Time is the request duration of request
+// entries, Ts is when the entry was written.
+type Log struct {
+	ID      string    `json:"id"`
+	Ts      time.Time `json:"ts"`
+	Level   string    `json:"level"`
+	Caller  string    `json:"caller,omitempty"`
+	Msg     string    `json:"msg"`
+	Method  string    `json:"method,omitempty"`
+	Url     string    `json:"url,omitempty"`
+	Uuid    string    `json:"uuid,omitempty"`
+	Error   string    `json:"error,omitempty"`
+	Time    string    `json:"time,omitempty"`
+	TraceId string    `json:"trace_id,omitempty"`
+	SpanId  string    `json:"span_id,omitempty"`
+}
+
+// LogFilter selects logs. Empty fields match everything, Error matches a
+// case-insensitive substring of the error. Cursor is the NextCursor of the
+// previous page.
+type LogFilter struct {
+	Level  string
+	From   time.Time
+	To     time.Time
+	Method string
+	Url    string
+	Uuid   string
+	Error  string
+	Cursor string
+	Limit  int
+}
+
+// LogPage is a page of logs, newest first. NextCursor is empty on the last
+// page.
+type LogPage struct {
+	Logs       []*Log `json:"logs"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
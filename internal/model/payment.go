@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+const (
+	PaymentAuthorized string = "authorized"
+	PaymentCaptured   string = "captured"
+	PaymentVoided     string = "voided"
+	PaymentRefunded   string = "refunded"
+)
+
+// PaymentMethod is a card stored as the gateway token only.
+type PaymentMethod struct {
+	ID       uint64 `json:"id"`
+	Token    string `json:"-"`
+	Brand    string `json:"brand"`
+	Last4    string `json:"last4"`
+	ExpMonth int    `json:"exp_month"`
+	ExpYear  int    `json:"exp_year"`
+}
+
+type Payment struct {
+	ID              uint64    `json:"id"`
+	UserID          string    `json:"-"`
+	OrderID         string    `json:"order_id"`
+	PaymentMethodID uint64    `json:"payment_method_id"`
+	AuthorizationID string    `json:"-"`
+	Amount          int64     `json:"amount"`
+	CapturedAmount  int64     `json:"captured_amount"`
+	RefundedAmount  int64     `json:"refunded_amount"`
+	Status          string    `json:"status"`
+	CreatedAt       time.Time `json:"created_at"`
+}
@@ -0,0 +1,10 @@
+package model
+
+type Place struct {
+	ID        uint64  `json:"id"`
+	Label     string  `json:"label" binding:"required,max=30"`
+	Latitude  float64 `json:"latitude" binding:"min=-90,max=90"`
+	Longitude float64 `json:"longitude" binding:"min=-180,max=180"`
+	Address   string  `json:"address" binding:"required,max=255"`
+	Notes     string  `json:"notes" binding:"max=255"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+const (
+	DiscountPercent string = "percent"
+	DiscountFixed   string = "fixed"
+)
+
+// Promotion amounts are stored in minor currency units; DiscountValue is a
+// percentage up to 100 for DiscountPercent and an amount for DiscountFixed.
+// Zero MaxDiscount, UsageLimit and PerUserLimit mean "no limit", empty
+// TaxiClasses and Zones mean "any".
+type Promotion struct {
+	ID            uint64    `json:"id"`
+	Code          string    `json:"code" binding:"required,max=30"`
+	DiscountType  string    `json:"discount_type" binding:"required,oneof=percent fixed"`
+	DiscountValue int64     `json:"discount_value" binding:"required,gt=0"`
+	MaxDiscount   int64     `json:"max_discount" binding:"min=0"`
+	ValidFrom     time.Time `json:"valid_from" binding:"required"`
+	ValidUntil    time.Time `json:"valid_until" binding:"required,gtfield=ValidFrom"`
+	UsageLimit    int       `json:"usage_limit" binding:"min=0"`
+	PerUserLimit  int       `json:"per_user_limit" binding:"min=0"`
+	UsageCount    int       `json:"usage_count"`
+	TaxiClasses   []string  `json:"taxi_classes"`
+	Zones         []string  `json:"zones"`
+	Active        bool      `json:"active"`
+}
+
+type Redemption struct {
+	PromotionID uint64
+	UserID      string
+	OrderID     string
+	Discount    int64
+}
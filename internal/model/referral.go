@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+const (
+	ReferralPending  string = "pending"
+	ReferralRewarded string = "rewarded"
+	ReferralRejected string = "rejected"
+)
+
+const (
+	ReasonSelfReferral  string = "self_referral"
+	ReasonSameDevice    string = "same_device"
+	ReasonPhoneReferred string = "phone_already_referred"
+)
+
+// Referral links an invitee to the user whose code they signed up with.
+// Rejected referrals are kept to make repeated abuse visible but never
+// rewarded.
+type Referral struct {
+	ID             uint64     `json:"id"`
+	ReferrerID     uint64     `json:"referrer_id"`
+	InviteeID      uint64     `json:"invitee_id"`
+	PhoneNumber    string     `json:"-"`
+	DeviceID       string     `json:"-"`
+	Status         string     `json:"status"`
+	Reason         string     `json:"reason,omitempty"`
+	ReferrerReward int64      `json:"referrer_reward"`
+	InviteeReward  int64      `json:"invitee_reward"`
+	CreatedAt      time.Time  `json:"created_at"`
+	RewardedAt     *time.Time `json:"rewarded_at,omitempty"`
+}
+
+type ReferralStats struct {
+	Code     string `json:"code"`
+	Invited  int    `json:"invited"`
+	Pending  int    `json:"pending"`
+	Rewarded int    `json:"rewarded"`
+	Rejected int    `json:"rejected"`
+	Earned   int64  `json:"earned"`
+}
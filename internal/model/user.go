@@ -5,6 +5,8 @@ const (
 	StatusDeleted string = "deleted"
 )
 
+// User is a user's profile. Version is incremented by every update of the
+// profile, it is the ETag of the profile in the API.
 type User struct {
 	ID          uint64  `json:"-"`
 	Name        string  `json:"name"`
@@ -12,4 +14,6 @@ type User struct {
 	Email       string  `json:"email"`
 	Raiting     float64 `json:"raiting"`
 	Status      string  `json:"-"`
+	DeviceID    string  `json:"-"`
+	Version     uint64  `json:"-"`
 }
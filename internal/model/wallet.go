@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+const (
+	AccountWallet    string = "wallet"
+	AccountClearing  string = "clearing"
+	AccountRevenue   string = "revenue"
+	AccountMarketing string = "marketing"
+)
+
+const (
+	EntryTopUp           string = "top_up"
+	EntryTripFare        string = "trip_fare"
+	EntryCancellationFee string = "cancellation_fee"
+	EntryRefund          string = "refund"
+	EntryCredit          string = "credit"
+)
+
+// JournalEntry is an immutable ledger record. Amounts of its postings are in
+// minor currency units and always sum up to zero.
+type JournalEntry struct {
+	ID             uint64
+	IdempotencyKey string
+	Kind           string
+	Description    string
+	CreatedAt      time.Time
+	Postings       []Posting
+}
+
+// Posting moves Amount into the account of AccountType. UserID is set for
+// wallet accounts and empty for system accounts.
+type Posting struct {
+	AccountType string
+	UserID      string
+	Amount      int64
+}
+
+type Transaction struct {
+	ID          uint64    `json:"id"`
+	Kind        string    `json:"kind"`
+	Description string    `json:"description"`
+	Amount      int64     `json:"amount"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Wallet struct {
+	Balance      int64          `json:"balance"`
+	Transactions []*Transaction `json:"transactions"`
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	DeliveryPending   string = "pending"
+	DeliveryDelivered string = "delivered"
+	DeliveryDead      string = "dead"
+)
+
+// Webhook is a partner's subscription to domain events about the
+// partner's employees. Deliveries are signed with Secret, which is only
+// shown when the webhook is created.
+type Webhook struct {
+	ID         uint64    `json:"id"`
+	PartnerID  uint64    `json:"partner_id" binding:"required"`
+	URL        string    `json:"url" binding:"required,url,max=2048"`
+	Secret     string    `json:"secret,omitempty" binding:"max=128"`
+	EventTypes []string  `json:"event_types" binding:"required,min=1"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookDelivery is one event queued for one webhook. Payload is the exact
+// body sent on every attempt. Pending deliveries are retried at
+// NextAttemptAt until they are delivered or dead.
+type WebhookDelivery struct {
+	ID             uint64          `json:"id"`
+	WebhookID      uint64          `json:"webhook_id"`
+	EventID        uint64          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastError      string          `json:"last_error,omitempty"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
@@ -0,0 +1,303 @@
+package fake
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RipperAcskt/innotaxi/internal/payments"
+)
+
+// Cards that the fake gateway tokenizes but declines on authorization.
+const (
+	DeclinedCard          = "4000000000000002"
+	InsufficientFundsCard = "4000000000009995"
+)
+
+type card struct {
+	number string
+}
+
+type authorization struct {
+	token    string
+	amount   int64
+	captured int64
+	refunded int64
+	voided   bool
+}
+
+// Gateway is an in-memory PaymentGateway for local runs and tests. It
+// declines DeclinedCard and InsufficientFundsCard, waits delay before every
+// call and, if webhookURL is set, posts signed events to it.
+type Gateway struct {
+	mu             sync.Mutex
+	cards          map[string]card
+	authorizations map[string]*authorization
+	keys           map[string]string
+	refunds        map[string]bool
+
+	delay      time.Duration
+	secret     string
+	webhookURL string
+	client     *http.Client
+}
+
+func New(delay time.Duration, secret, webhookURL string) *Gateway {
+	return &Gateway{
+		cards:          make(map[string]card),
+		authorizations: make(map[string]*authorization),
+		keys:           make(map[string]string),
+		refunds:        make(map[string]bool),
+		delay:          delay,
+		secret:         secret,
+		webhookURL:     webhookURL,
+		client:         &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (g *Gateway) TokenizeCard(ctx context.Context, c payments.Card) (*payments.CardToken, error) {
+	err := g.wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !luhn(c.Number) {
+		return nil, payments.ErrInvalidCard
+	}
+	if time.Date(c.ExpYear, time.Month(c.ExpMonth)+1, 1, 0, 0, 0, 0, time.UTC).Before(time.Now()) {
+		return nil, fmt.Errorf("card expired: %w", payments.ErrInvalidCard)
+	}
+
+	token := "tok_" + uuid.NewString()
+
+	g.mu.Lock()
+	g.cards[token] = card{c.Number}
+	g.mu.Unlock()
+
+	return &payments.CardToken{
+		Token:    token,
+		Brand:    brand(c.Number),
+		Last4:    c.Number[len(c.Number)-4:],
+		ExpMonth: c.ExpMonth,
+		ExpYear:  c.ExpYear,
+	}, nil
+}
+
+func (g *Gateway) Authorize(ctx context.Context, token string, amount int64, idempotencyKey string) (*payments.Authorization, error) {
+	err := g.wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if amount <= 0 {
+		return nil, payments.ErrInvalidAmount
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if id, ok := g.keys[idempotencyKey]; ok {
+		return &payments.Authorization{ID: id, Amount: g.authorizations[id].amount}, nil
+	}
+
+	c, ok := g.cards[token]
+	if !ok {
+		return nil, payments.ErrInvalidCard
+	}
+	switch c.number {
+	case DeclinedCard:
+		return nil, fmt.Errorf("generic decline: %w", payments.ErrCardDeclined)
+	case InsufficientFundsCard:
+		return nil, fmt.Errorf("insufficient funds: %w", payments.ErrCardDeclined)
+	}
+
+	id := "auth_" + uuid.NewString()
+	g.authorizations[id] = &authorization{token: token, amount: amount}
+	g.keys[idempotencyKey] = id
+
+	return &payments.Authorization{ID: id, Amount: amount}, nil
+}
+
+func (g *Gateway) Capture(ctx context.Context, authorizationId string, amount int64) error {
+	err := g.wait(ctx)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	auth, ok := g.authorizations[authorizationId]
+	if !ok || auth.voided {
+		return payments.ErrAuthorizationNotFound
+	}
+	if amount <= 0 || auth.captured+amount > auth.amount {
+		return payments.ErrInvalidAmount
+	}
+	auth.captured += amount
+
+	g.notify(payments.EventCaptured, authorizationId, amount)
+	return nil
+}
+
+func (g *Gateway) Void(ctx context.Context, authorizationId string) error {
+	err := g.wait(ctx)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	auth, ok := g.authorizations[authorizationId]
+	if !ok || auth.captured > 0 {
+		return payments.ErrAuthorizationNotFound
+	}
+	auth.voided = true
+
+	g.notify(payments.EventVoided, authorizationId, auth.amount)
+	return nil
+}
+
+func (g *Gateway) Refund(ctx context.Context, authorizationId string, amount int64, idempotencyKey string) error {
+	err := g.wait(ctx)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	auth, ok := g.authorizations[authorizationId]
+	if !ok {
+		return payments.ErrAuthorizationNotFound
+	}
+	if g.refunds[idempotencyKey] {
+		return nil
+	}
+	if amount <= 0 || auth.refunded+amount > auth.captured {
+		return payments.ErrInvalidAmount
+	}
+	auth.refunded += amount
+	g.refunds[idempotencyKey] = true
+
+	g.notify(payments.EventRefunded, authorizationId, amount)
+	return nil
+}
+
+func (g *Gateway) ParseWebhook(payload []byte, signature string) (*payments.Event, error) {
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, g.sign(payload)) {
+		return nil, payments.ErrInvalidSignature
+	}
+
+	var event payments.Event
+	err = json.Unmarshal(payload, &event)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal failed: %w", err)
+	}
+	return &event, nil
+}
+
+// Sign returns the hex signature the gateway puts into payments.SignatureHeader.
+func (g *Gateway) Sign(payload []byte) string {
+	return hex.EncodeToString(g.sign(payload))
+}
+
+func (g *Gateway) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(g.secret))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func (g *Gateway) notify(eventType, authorizationId string, amount int64) {
+	if g.webhookURL == "" {
+		return
+	}
+
+	payload, err := json.Marshal(payments.Event{
+		ID:              "evt_" + uuid.NewString(),
+		Type:            eventType,
+		AuthorizationID: authorizationId,
+		Amount:          amount,
+	})
+	if err != nil {
+		return
+	}
+
+	go func() {
+		time.Sleep(g.delay)
+
+		req, err := http.NewRequest(http.MethodPost, g.webhookURL, bytes.NewReader(payload))
+		if err != nil {
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(payments.SignatureHeader, g.Sign(payload))
+
+		resp, err := g.client.Do(req)
+		if err != nil {
+			return
+		}
+		resp.Body.Close()
+	}()
+}
+
+func (g *Gateway) wait(ctx context.Context) error {
+	if g.delay == 0 {
+		return nil
+	}
+
+	select {
+	case <-time.After(g.delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func brand(number string) string {
+	switch {
+	case strings.HasPrefix(number, "4"):
+		return "visa"
+	case strings.HasPrefix(number, "5"):
+		return "mastercard"
+	case strings.HasPrefix(number, "34"), strings.HasPrefix(number, "37"):
+		return "amex"
+	default:
+		return "unknown"
+	}
+}
+
+func luhn(number string) bool {
+	if len(number) < 12 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,191 @@
+package fake_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/payments"
+	"github.com/RipperAcskt/innotaxi/internal/payments/fake"
+	"github.com/go-playground/assert/v2"
+)
+
+func card(number string) payments.Card {
+	return payments.Card{
+		Number:   number,
+		ExpMonth: 12,
+		ExpYear:  time.Now().Year() + 2,
+		CVC:      "123",
+		Holder:   "IVAN IVANOV",
+	}
+}
+
+func TestTokenizeCard(t *testing.T) {
+	test := []struct {
+		name  string
+		card  payments.Card
+		brand string
+		err   error
+	}{
+		{
+			name:  "visa",
+			card:  card("4242424242424242"),
+			brand: "visa",
+			err:   nil,
+		},
+		{
+			name: "wrong checksum",
+			card: card("4242424242424241"),
+			err:  payments.ErrInvalidCard,
+		},
+		{
+			name: "expired",
+			card: payments.Card{Number: "4242424242424242", ExpMonth: 1, ExpYear: 2020},
+			err:  payments.ErrInvalidCard,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := fake.New(0, "secret", "")
+
+			token, err := gateway.TokenizeCard(context.Background(), tt.card)
+			assert.Equal(t, errors.Is(err, tt.err), true)
+			if tt.err == nil {
+				assert.Equal(t, token.Brand, tt.brand)
+				assert.Equal(t, token.Last4, "4242")
+			}
+		})
+	}
+}
+
+func TestAuthorizeAndCapture(t *testing.T) {
+	test := []struct {
+		name    string
+		number  string
+		capture int64
+		authErr error
+		err     error
+	}{
+		{
+			name:    "capture less than authorized",
+			number:  "4242424242424242",
+			capture: 800,
+			authErr: nil,
+			err:     nil,
+		},
+		{
+			name:    "capture more than authorized",
+			number:  "4242424242424242",
+			capture: 1200,
+			authErr: nil,
+			err:     payments.ErrInvalidAmount,
+		},
+		{
+			name:    "declined",
+			number:  fake.DeclinedCard,
+			authErr: payments.ErrCardDeclined,
+		},
+		{
+			name:    "insufficient funds",
+			number:  fake.InsufficientFundsCard,
+			authErr: payments.ErrCardDeclined,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := fake.New(0, "secret", "")
+
+			token, err := gateway.TokenizeCard(context.Background(), card(tt.number))
+			assert.Equal(t, err, nil)
+
+			auth, err := gateway.Authorize(context.Background(), token.Token, 1000, "order-1")
+			assert.Equal(t, errors.Is(err, tt.authErr), true)
+			if tt.authErr != nil {
+				return
+			}
+
+			retry, err := gateway.Authorize(context.Background(), token.Token, 1000, "order-1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, retry.ID, auth.ID)
+
+			err = gateway.Capture(context.Background(), auth.ID, tt.capture)
+			assert.Equal(t, err, tt.err)
+		})
+	}
+}
+
+func TestDelay(t *testing.T) {
+	gateway := fake.New(time.Second, "secret", "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := gateway.TokenizeCard(ctx, card("4242424242424242"))
+	assert.Equal(t, err, context.DeadlineExceeded)
+}
+
+func TestWebhook(t *testing.T) {
+	received := make(chan *payments.Event, 1)
+	var gateway *fake.Gateway
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		event, err := gateway.ParseWebhook(payload, r.Header.Get(payments.SignatureHeader))
+		if err != nil {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		received <- event
+	}))
+	defer server.Close()
+
+	gateway = fake.New(0, "secret", server.URL)
+
+	token, err := gateway.TokenizeCard(context.Background(), card("4242424242424242"))
+	assert.Equal(t, err, nil)
+	auth, err := gateway.Authorize(context.Background(), token.Token, 1000, "order-1")
+	assert.Equal(t, err, nil)
+	err = gateway.Capture(context.Background(), auth.ID, 900)
+	assert.Equal(t, err, nil)
+
+	select {
+	case event := <-received:
+		assert.Equal(t, event.Type, payments.EventCaptured)
+		assert.Equal(t, event.AuthorizationID, auth.ID)
+		assert.Equal(t, event.Amount, int64(900))
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+
+	_, err = gateway.ParseWebhook([]byte(`{"type":"payment.captured"}`), "00")
+	assert.Equal(t, err, payments.ErrInvalidSignature)
+}
+
+func TestRefund(t *testing.T) {
+	gateway := fake.New(0, "secret", "")
+
+	token, err := gateway.TokenizeCard(context.Background(), card("4242424242424242"))
+	assert.Equal(t, err, nil)
+	auth, err := gateway.Authorize(context.Background(), token.Token, 1000, "order-1")
+	assert.Equal(t, err, nil)
+	err = gateway.Capture(context.Background(), auth.ID, 900)
+	assert.Equal(t, err, nil)
+
+	err = gateway.Refund(context.Background(), auth.ID, 600, "order-1:refund:0:600")
+	assert.Equal(t, err, nil)
+
+	// A retry with the same key isn't refunded twice.
+	err = gateway.Refund(context.Background(), auth.ID, 600, "order-1:refund:0:600")
+	assert.Equal(t, err, nil)
+
+	err = gateway.Refund(context.Background(), auth.ID, 300, "order-1:refund:600:300")
+	assert.Equal(t, err, nil)
+	err = gateway.Refund(context.Background(), auth.ID, 1, "order-1:refund:900:1")
+	assert.Equal(t, err, payments.ErrInvalidAmount)
+}
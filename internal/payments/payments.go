@@ -0,0 +1,65 @@
+package payments
+
+import (
+	"context"
+
+	"github.com/RipperAcskt/innotaxi/internal/apperror"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of a webhook payload.
+const SignatureHeader = "X-Signature"
+
+const (
+	EventCaptured = "payment.captured"
+	EventRefunded = "payment.refunded"
+	EventVoided   = "payment.voided"
+)
+
+var (
+	ErrInvalidCard           = apperror.New(apperror.Invalid, "invalid_card", "invalid card")
+	ErrCardDeclined          = apperror.New(apperror.PaymentRequired, "card_declined", "card declined")
+	ErrAuthorizationNotFound = apperror.New(apperror.NotFound, "authorization_not_found", "authorization not found")
+	ErrInvalidAmount         = apperror.New(apperror.Invalid, "invalid_amount", "invalid amount")
+	ErrInvalidSignature      = apperror.New(apperror.PermissionDenied, "invalid_signature", "invalid webhook signature")
+)
+
+// Card holds raw card details. It is passed straight to the gateway and must
+// never be stored or logged.
+type Card struct {
+	Number   string `json:"number" binding:"required,numeric,min=12,max=19"`
+	ExpMonth int    `json:"exp_month" binding:"required,min=1,max=12"`
+	ExpYear  int    `json:"exp_year" binding:"required"`
+	CVC      string `json:"cvc" binding:"required,numeric,min=3,max=4"`
+	Holder   string `json:"holder" binding:"required"`
+}
+
+type CardToken struct {
+	Token    string
+	Brand    string
+	Last4    string
+	ExpMonth int
+	ExpYear  int
+}
+
+type Authorization struct {
+	ID     string
+	Amount int64
+}
+
+type Event struct {
+	ID              string `json:"id"`
+	Type            string `json:"type"`
+	AuthorizationID string `json:"authorization_id"`
+	Amount          int64  `json:"amount"`
+}
+
+// PaymentGateway is implemented by card payment providers. Amounts are in
+// minor currency units. Authorize and Refund are idempotent by key.
+type PaymentGateway interface {
+	TokenizeCard(ctx context.Context, card Card) (*CardToken, error)
+	Authorize(ctx context.Context, token string, amount int64, idempotencyKey string) (*Authorization, error)
+	Capture(ctx context.Context, authorizationId string, amount int64) error
+	Void(ctx context.Context, authorizationId string) error
+	Refund(ctx context.Context, authorizationId string, amount int64, idempotencyKey string) error
+	ParseWebhook(payload []byte, signature string) (*Event, error)
+}
@@ -0,0 +1,32 @@
+package redact
+
+import "go.uber.org/zap/zapcore"
+
+type core struct {
+	zapcore.Core
+	r *Redactor
+}
+
+// NewCore wraps core, so entries are redacted by r before core writes them.
+func NewCore(c zapcore.Core, r *Redactor) zapcore.Core {
+	if r.mode == Off {
+		return c
+	}
+	return core{c, r}
+}
+
+func (c core) With(fields []zapcore.Field) zapcore.Core {
+	return core{c.Core.With(c.r.Fields(fields)), c.r}
+}
+
+func (c core) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c core) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = c.r.String(entry.Message)
+	return c.Core.Write(entry, c.r.Fields(fields))
+}
@@ -0,0 +1,183 @@
+// Package redact masks personal data and secrets in log entries before they
+// are written.
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Masking modes.
+const (
+	// Full replaces the value with [REDACTED].
+	Full string = "full"
+	// Partial keeps the last two characters of values longer than six.
+	Partial string = "partial"
+	// Hash replaces the value with a short SHA-256 hash, so entries of the
+	// same value can still be matched.
+	Hash string = "hash"
+	// Off disables redaction.
+	Off string = "off"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are parts of field keys whose values are always masked.
+var sensitiveKeys = []string{"phone", "email", "password", "token", "secret", "authorization", "card"}
+
+// patterns find personal data and secrets in free text: messages, errors and
+// string fields.
+var patterns = []*regexp.Regexp{
+	// JWTs.
+	regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+	// Authorization header values.
+	regexp.MustCompile(`(?i)\b(?:bearer|basic)\s+[A-Za-z0-9._~+/=-]+`),
+	// Emails.
+	regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	// Phone numbers in international format, with spaces, hyphens or
+	// parentheses between the digits.
+	regexp.MustCompile(`\+\d(?:[ ()-]*\d){6,14}`),
+	// Phone numbers dialed with a trunk prefix or 00, e.g. 80291234567 or
+	// 8 (029) 123-45-67. Nine digits at least, so dates don't match.
+	regexp.MustCompile(`\(?\b[08](?:[ ()-]*\d){8,14}`),
+}
+
+// Redactor masks the values of sensitive fields and the personal data found
+// in strings.
+type Redactor struct {
+	mode string
+	keys map[string]bool
+}
+
+// New returns a Redactor masking with mode. keys are field keys to mask on
+// top of the built-in ones, matched case-insensitively.
+func New(mode string, keys []string) (*Redactor, error) {
+	switch mode {
+	case "":
+		mode = Full
+	case Full, Partial, Hash, Off:
+	default:
+		return nil, fmt.Errorf("unknown redaction mode: %v", mode)
+	}
+
+	r := &Redactor{mode: mode, keys: make(map[string]bool)}
+	for _, key := range keys {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key != "" {
+			r.keys[key] = true
+		}
+	}
+	return r, nil
+}
+
+// Mask masks a whole value.
+func (r *Redactor) Mask(value string) string {
+	switch r.mode {
+	case Off:
+		return value
+	case Partial:
+		if len(value) > 6 {
+			return "***" + value[len(value)-2:]
+		}
+		return redacted
+	case Hash:
+		sum := sha256.Sum256([]byte(value))
+		return "sha256:" + hex.EncodeToString(sum[:6])
+	default:
+		return redacted
+	}
+}
+
+// String masks the personal data and secrets found in s.
+func (r *Redactor) String(s string) string {
+	if r.mode == Off {
+		return s
+	}
+	for _, pattern := range patterns {
+		s = pattern.ReplaceAllStringFunc(s, r.Mask)
+	}
+	return s
+}
+
+// Sensitive reports whether values of the field key are masked whole.
+func (r *Redactor) Sensitive(key string) bool {
+	key = strings.ToLower(key)
+	if r.keys[key] {
+		return true
+	}
+	for _, part := range sensitiveKeys {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// Field returns f with its value redacted. Values of sensitive fields are
+// masked whole, strings, errors and stringers are searched for personal
+// data. Other values are left as they are.
+func (r *Redactor) Field(f zapcore.Field) zapcore.Field {
+	if r.mode == Off {
+		return f
+	}
+
+	if r.Sensitive(f.Key) {
+		if f.Type == zapcore.SkipType {
+			return f
+		}
+		return zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: r.Mask(fieldString(f))}
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		f.String = r.String(f.String)
+	case zapcore.ByteStringType:
+		f.Interface = []byte(r.String(string(f.Interface.([]byte))))
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok && err != nil {
+			return zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: r.String(err.Error())}
+		}
+	case zapcore.StringerType:
+		if s, ok := f.Interface.(fmt.Stringer); ok && s != nil {
+			return zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: r.String(s.String())}
+		}
+	}
+	return f
+}
+
+// Fields redacts every field of fields, which isn't modified.
+func (r *Redactor) Fields(fields []zapcore.Field) []zapcore.Field {
+	if r.mode == Off || len(fields) == 0 {
+		return fields
+	}
+
+	redacted := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		redacted[i] = r.Field(f)
+	}
+	return redacted
+}
+
+// fieldString is the value of f as it would be logged, close enough to be
+// masked or hashed.
+func fieldString(f zapcore.Field) string {
+	switch f.Type {
+	case zapcore.StringType:
+		return f.String
+	case zapcore.ByteStringType:
+		return string(f.Interface.([]byte))
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok && err != nil {
+			return err.Error()
+		}
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	return fmt.Sprint(enc.Fields[f.Key])
+}
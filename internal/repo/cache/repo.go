@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+// Repo is a service.Repo whose user methods go through Users.
+type Repo struct {
+	service.Repo
+	Users *UserCache
+}
+
+func NewRepo(repo service.Repo, store Store, ttl time.Duration) *Repo {
+	return &Repo{
+		Repo:  repo,
+		Users: NewUserCache(repo, store, ttl),
+	}
+}
+
+// WithinTransaction drops the users changed in the transaction from the
+// cache once it commits.
+func (r *Repo) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.Users.Within(ctx, r.Repo, fn)
+}
+
+func (r *Repo) GetUserById(ctx context.Context, id string) (*model.User, error) {
+	return r.Users.GetUserById(ctx, id)
+}
+
+func (r *Repo) UpdateUserById(ctx context.Context, id string, user *model.User) error {
+	return r.Users.UpdateUserById(ctx, id, user)
+}
+
+func (r *Repo) DeleteUserById(ctx context.Context, id string) error {
+	return r.Users.DeleteUserById(ctx, id)
+}
@@ -0,0 +1,218 @@
+// Package cache holds read-through caches that decorate repositories.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"golang.org/x/sync/singleflight"
+)
+
+var ErrMiss = fmt.Errorf("cache miss")
+
+// Store keeps serialized values. Get returns ErrMiss for absent or expired
+// keys.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Del(ctx context.Context, key string) error
+}
+
+type Stats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+// cachedUser mirrors model.User, whose json tags hide fields the cache has
+// to keep.
+type cachedUser struct {
+	ID          uint64  `json:"id"`
+	Name        string  `json:"name"`
+	PhoneNumber string  `json:"phone_number"`
+	Email       string  `json:"email"`
+	Raiting     float64 `json:"raiting"`
+	Version     uint64  `json:"version"`
+}
+
+// UserCache is a read-through cache around service.UserRepo. Profiles are
+// kept for ttl and dropped on update and delete, or once the transaction
+// started with Within commits. Reads in such a transaction bypass the
+// cache. Concurrent misses for the same user are collapsed into one
+// repository call. Store errors on reads are treated as misses, so a broken
+// store only costs performance. A load racing with an update can still
+// cache the old profile until ttl runs out.
+type UserCache struct {
+	service.UserRepo
+	store Store
+	ttl   time.Duration
+	group singleflight.Group
+
+	hits   uint64
+	misses uint64
+}
+
+func NewUserCache(repo service.UserRepo, store Store, ttl time.Duration) *UserCache {
+	return &UserCache{
+		UserRepo: repo,
+		store:    store,
+		ttl:      ttl,
+	}
+}
+
+type changesKey struct{}
+
+// changes are the users updated or deleted in a transaction.
+type changes struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func (ch *changes) add(id string) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.ids = append(ch.ids, id)
+}
+
+// Within runs fn as tx.WithinTransaction does and drops the users fn
+// updated or deleted from the cache after the transaction commits.
+// Dropping them before would let a concurrent read cache the old profile
+// again. Nested calls join the outer transaction.
+func (c *UserCache) Within(ctx context.Context, tx service.Transactor, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(changesKey{}).(*changes); ok {
+		return tx.WithinTransaction(ctx, fn)
+	}
+
+	changed := &changes{}
+	err := tx.WithinTransaction(context.WithValue(ctx, changesKey{}, changed), fn)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range changed.ids {
+		err := c.invalidate(ctx, id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *UserCache) GetUserById(ctx context.Context, id string) (*model.User, error) {
+	// The transaction may have changed the user, or be about to.
+	if _, ok := ctx.Value(changesKey{}).(*changes); ok {
+		return c.UserRepo.GetUserById(ctx, id)
+	}
+
+	user, err := c.get(ctx, id)
+	if err == nil {
+		atomic.AddUint64(&c.hits, 1)
+		return user, nil
+	}
+	atomic.AddUint64(&c.misses, 1)
+
+	v, err, _ := c.group.Do(id, func() (any, error) {
+		user, err := c.UserRepo.GetUserById(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		c.set(ctx, id, user)
+		return user, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Callers sharing a load get the same pointer, each one gets a copy.
+	user = &model.User{}
+	*user = *v.(*model.User)
+	return user, nil
+}
+
+func (c *UserCache) UpdateUserById(ctx context.Context, id string, user *model.User) error {
+	err := c.UserRepo.UpdateUserById(ctx, id, user)
+	if err != nil {
+		return err
+	}
+	return c.changed(ctx, id)
+}
+
+func (c *UserCache) DeleteUserById(ctx context.Context, id string) error {
+	err := c.UserRepo.DeleteUserById(ctx, id)
+	if err != nil {
+		return err
+	}
+	return c.changed(ctx, id)
+}
+
+func (c *UserCache) Stats() Stats {
+	return Stats{
+		Hits:   atomic.LoadUint64(&c.hits),
+		Misses: atomic.LoadUint64(&c.misses),
+	}
+}
+
+func (c *UserCache) get(ctx context.Context, id string) (*model.User, error) {
+	data, err := c.store.Get(ctx, key(id))
+	if err != nil {
+		return nil, err
+	}
+
+	var cached cachedUser
+	err = json.Unmarshal(data, &cached)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal failed: %w", err)
+	}
+
+	return &model.User{
+		ID:          cached.ID,
+		Name:        cached.Name,
+		PhoneNumber: cached.PhoneNumber,
+		Email:       cached.Email,
+		Raiting:     cached.Raiting,
+		Version:     cached.Version,
+	}, nil
+}
+
+func (c *UserCache) set(ctx context.Context, id string, user *model.User) {
+	data, err := json.Marshal(cachedUser{
+		ID:          user.ID,
+		Name:        user.Name,
+		PhoneNumber: user.PhoneNumber,
+		Email:       user.Email,
+		Raiting:     user.Raiting,
+		Version:     user.Version,
+	})
+	if err != nil {
+		return
+	}
+	_ = c.store.Set(ctx, key(id), data, c.ttl)
+}
+
+// changed drops the user from the cache, after the commit if ctx is in a
+// transaction.
+func (c *UserCache) changed(ctx context.Context, id string) error {
+	if changed, ok := ctx.Value(changesKey{}).(*changes); ok {
+		changed.add(id)
+		return nil
+	}
+	return c.invalidate(ctx, id)
+}
+
+func (c *UserCache) invalidate(ctx context.Context, id string) error {
+	err := c.store.Del(ctx, key(id))
+	if err != nil && !errors.Is(err, ErrMiss) {
+		return fmt.Errorf("del failed: %w", err)
+	}
+	return nil
+}
+
+func key(id string) string {
+	return "user:" + id
+}
@@ -0,0 +1,264 @@
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/repo/cache"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/RipperAcskt/innotaxi/internal/service/mocks"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+type store struct {
+	mu     sync.Mutex
+	values map[string][]byte
+	err    error
+}
+
+func newStore() *store {
+	return &store{values: make(map[string][]byte)}
+}
+
+func (s *store) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+	value, ok := s.values[key]
+	if !ok {
+		return nil, cache.ErrMiss
+	}
+	return value, nil
+}
+
+func (s *store) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values[key] = value
+	return nil
+}
+
+func (s *store) Del(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.values, key)
+	return nil
+}
+
+var ivan = &model.User{ID: 1, Name: "Ivan", PhoneNumber: "+7455456", Email: "ripper@algsdh", Raiting: 4.5, Version: 3}
+
+func TestGetUserById(t *testing.T) {
+	test := []struct {
+		name         string
+		storeErr     error
+		mockBehavior func(r *mocks.MockUserRepo)
+		stats        cache.Stats
+	}{
+		{
+			name: "miss then hit",
+			mockBehavior: func(r *mocks.MockUserRepo) {
+				r.EXPECT().GetUserById(gomock.Any(), "1").Return(ivan, nil).Times(1)
+			},
+			stats: cache.Stats{Hits: 1, Misses: 1},
+		},
+		{
+			name:     "broken store",
+			storeErr: fmt.Errorf("connection refused"),
+			mockBehavior: func(r *mocks.MockUserRepo) {
+				r.EXPECT().GetUserById(gomock.Any(), "1").Return(ivan, nil).Times(2)
+			},
+			stats: cache.Stats{Hits: 0, Misses: 2},
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockUserRepo(ctrl)
+			tt.mockBehavior(repo)
+
+			store := newStore()
+			store.err = tt.storeErr
+			users := cache.NewUserCache(repo, store, time.Minute)
+
+			for i := 0; i < 2; i++ {
+				user, err := users.GetUserById(context.Background(), "1")
+				assert.Equal(t, err, nil)
+				assert.Equal(t, user, ivan)
+			}
+			assert.Equal(t, users.Stats(), tt.stats)
+		})
+	}
+}
+
+func TestGetUserByIdNotCached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockUserRepo(ctrl)
+	repo.EXPECT().GetUserById(gomock.Any(), "2").Return(nil, service.ErrUserDoesNotExists).Times(2)
+
+	users := cache.NewUserCache(repo, newStore(), time.Minute)
+	for i := 0; i < 2; i++ {
+		_, err := users.GetUserById(context.Background(), "2")
+		assert.Equal(t, err, service.ErrUserDoesNotExists)
+	}
+}
+
+func TestGetUserByIdSingleFlight(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	release := make(chan struct{})
+	repo := mocks.NewMockUserRepo(ctrl)
+	repo.EXPECT().GetUserById(gomock.Any(), "1").DoAndReturn(
+		func(ctx context.Context, id string) (*model.User, error) {
+			<-release
+			return ivan, nil
+		}).Times(1)
+
+	users := cache.NewUserCache(repo, newStore(), time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user, err := users.GetUserById(context.Background(), "1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, user, ivan)
+		}()
+	}
+
+	// Every caller has missed, give the last ones time to join the load.
+	for users.Stats().Misses < 10 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+}
+
+func TestInvalidation(t *testing.T) {
+	test := []struct {
+		name   string
+		change func(users *cache.UserCache) error
+		repo   func(r *mocks.MockUserRepo)
+	}{
+		{
+			name: "update",
+			change: func(users *cache.UserCache) error {
+				return users.UpdateUserById(context.Background(), "1", &model.User{Name: "Petr"})
+			},
+			repo: func(r *mocks.MockUserRepo) {
+				r.EXPECT().UpdateUserById(gomock.Any(), "1", &model.User{Name: "Petr"}).Return(nil)
+			},
+		},
+		{
+			name: "delete",
+			change: func(users *cache.UserCache) error {
+				return users.DeleteUserById(context.Background(), "1")
+			},
+			repo: func(r *mocks.MockUserRepo) {
+				r.EXPECT().DeleteUserById(gomock.Any(), "1").Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockUserRepo(ctrl)
+			repo.EXPECT().GetUserById(gomock.Any(), "1").Return(ivan, nil).Times(2)
+			tt.repo(repo)
+
+			users := cache.NewUserCache(repo, newStore(), time.Minute)
+
+			_, err := users.GetUserById(context.Background(), "1")
+			assert.Equal(t, err, nil)
+
+			err = tt.change(users)
+			assert.Equal(t, err, nil)
+
+			_, err = users.GetUserById(context.Background(), "1")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, users.Stats(), cache.Stats{Hits: 0, Misses: 2})
+		})
+	}
+}
+
+func TestInvalidationAfterCommit(t *testing.T) {
+	errRollback := fmt.Errorf("rollback")
+
+	test := []struct {
+		name   string
+		err    error
+		cached bool
+	}{
+		{
+			name:   "commit",
+			err:    nil,
+			cached: false,
+		},
+		{
+			name:   "rollback",
+			err:    errRollback,
+			cached: true,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockUserRepo(ctrl)
+			repo.EXPECT().GetUserById(gomock.Any(), "1").Return(ivan, nil).Times(2)
+			repo.EXPECT().UpdateUserById(gomock.Any(), "1", &model.User{Name: "Petr"}).Return(nil)
+			tx := mocks.NewMockTransactor(ctrl)
+			tx.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, fn func(ctx context.Context) error) error {
+					return fn(ctx)
+				})
+
+			s := newStore()
+			users := cache.NewUserCache(repo, s, time.Minute)
+			_, err := users.GetUserById(context.Background(), "1")
+			assert.Equal(t, err, nil)
+
+			err = users.Within(context.Background(), tx, func(ctx context.Context) error {
+				// Reads in the transaction go to the repository.
+				_, err := users.GetUserById(ctx, "1")
+				assert.Equal(t, err, nil)
+
+				err = users.UpdateUserById(ctx, "1", &model.User{Name: "Petr"})
+				assert.Equal(t, err, nil)
+
+				// Until the commit others still read the old profile from
+				// the cache, dropping it now would let them cache it again.
+				_, err = s.Get(ctx, "user:1")
+				assert.Equal(t, err, nil)
+				return tt.err
+			})
+			assert.Equal(t, err, tt.err)
+
+			_, err = s.Get(context.Background(), "user:1")
+			assert.Equal(t, err == nil, tt.cached)
+			assert.Equal(t, users.Stats(), cache.Stats{Hits: 0, Misses: 1})
+		})
+	}
+}
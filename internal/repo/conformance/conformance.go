@@ -0,0 +1,888 @@
+// Package conformance holds behaviour tests shared by all repository
+// implementations. Every backend registers its constructor in its own tests,
+// so a backend that behaves differently fails the same test as the others.
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/go-playground/assert/v2"
+)
+
+// UserStore is the part of service.Repo that keeps users.
+type UserStore interface {
+	service.Transactor
+	service.AuthRepo
+	service.UserRepo
+}
+
+// UserRepo runs the AuthRepo and UserRepo suite. newRepo must return an
+// empty repository on every call.
+func UserRepo(t *testing.T, newRepo func(t *testing.T) UserStore) {
+	test := []struct {
+		name string
+		run  func(t *testing.T, repo UserStore)
+	}{
+		{"create user", testCreateUser},
+		{"duplicate phone number", testDuplicatePhoneNumber},
+		{"duplicate email", testDuplicateEmail},
+		{"duplicate referral code", testDuplicateReferralCode},
+		{"check user by phone number", testCheckUserByPhoneNumber},
+		{"user does not exist", testUserDoesNotExist},
+		{"partial update", testPartialUpdate},
+		{"versioned update", testVersionedUpdate},
+		{"soft delete", testSoftDelete},
+		{"sign up after delete", testSignUpAfterDelete},
+		{"update to taken phone number", testUpdateToTakenPhoneNumber},
+		{"restore", testRestore},
+		{"restore taken phone number", testRestoreTakenPhoneNumber},
+		{"purge", testPurge},
+		{"transaction commit", testTransactionCommit},
+		{"transaction rollback", testTransactionRollback},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newRepo(t))
+		})
+	}
+}
+
+// OutboxStore is the part of service.Repo that keeps domain events.
+type OutboxStore interface {
+	service.Transactor
+	service.OutboxRepo
+}
+
+// OutboxRepo runs the OutboxRepo suite. newRepo must return an empty
+// repository on every call.
+func OutboxRepo(t *testing.T, newRepo func(t *testing.T) OutboxStore) {
+	test := []struct {
+		name string
+		run  func(t *testing.T, repo OutboxStore)
+	}{
+		{"pending events in order", testPendingEventsInOrder},
+		{"published event", testPublishedEvent},
+		{"failed event", testFailedEvent},
+		{"dead event", testDeadEvent},
+		{"event rolled back", testEventRolledBack},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newRepo(t))
+		})
+	}
+}
+
+// WebhookStore is the part of service.Repo that keeps webhooks and the
+// users and events they are about.
+type WebhookStore interface {
+	UserStore
+	service.OutboxRepo
+	service.WebhookRepo
+}
+
+// WebhookRepo runs the WebhookRepo suite. newRepo must return an empty
+// repository on every call.
+func WebhookRepo(t *testing.T, newRepo func(t *testing.T) WebhookStore) {
+	test := []struct {
+		name string
+		run  func(t *testing.T, repo WebhookStore)
+	}{
+		{"update webhook", testUpdateWebhook},
+		{"deliveries for subscribed webhooks", testSubscribedDeliveries},
+		{"deliveries for partner employees", testPartnerDeliveries},
+		{"partner employees", testPartnerEmployees},
+		{"purge scrubs events", testPurgeEvents},
+		{"due deliveries", testDueDeliveries},
+		{"delivery log", testDeliveryLog},
+		{"retry dead delivery", testRetryDeadDelivery},
+		{"delete webhook", testDeleteWebhook},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newRepo(t))
+		})
+	}
+}
+
+// TokenRepo runs the TokenRepo suite.
+func TokenRepo(t *testing.T, newRepo func(t *testing.T) service.TokenRepo) {
+	test := []struct {
+		name string
+		run  func(t *testing.T, repo service.TokenRepo)
+	}{
+		{"unknown token", testUnknownToken},
+		{"revoked token", testRevokedToken},
+		{"expired token", testExpiredToken},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newRepo(t))
+		})
+	}
+}
+
+var ivan = service.UserSingUp{
+	Name:        "Ivan",
+	PhoneNumber: "+7455456",
+	Email:       "ripper@algsdh",
+	Password:    "\x8c\x12hash",
+	Code:        "K7QX2M9A",
+}
+
+// createUser stores the user and returns the id it got.
+func createUser(t *testing.T, repo UserStore, user service.UserSingUp) string {
+	t.Helper()
+
+	err := repo.CreateUser(context.Background(), user)
+	if err != nil {
+		t.Fatalf("create user failed: %v", err)
+	}
+
+	stored, err := repo.CheckUserByPhoneNumber(context.Background(), user.PhoneNumber)
+	if err != nil {
+		t.Fatalf("check user by phone number failed: %v", err)
+	}
+	return strconv.FormatUint(stored.ID, 10)
+}
+
+func testCreateUser(t *testing.T, repo UserStore) {
+	id := createUser(t, repo, ivan)
+
+	user, err := repo.GetUserById(context.Background(), id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, strconv.FormatUint(user.ID, 10), id)
+	assert.Equal(t, user.Name, ivan.Name)
+	assert.Equal(t, user.PhoneNumber, ivan.PhoneNumber)
+	assert.Equal(t, user.Email, ivan.Email)
+	assert.Equal(t, user.Raiting, 0.0)
+}
+
+func testDuplicatePhoneNumber(t *testing.T, repo UserStore) {
+	createUser(t, repo, ivan)
+
+	duplicate := ivan
+	duplicate.Email = "other@algsdh"
+	duplicate.Code = "P3WN8R2C"
+	err := repo.CreateUser(context.Background(), duplicate)
+	assert.Equal(t, errors.Is(err, service.ErrUserAlreadyExists), true)
+	assert.Equal(t, strings.Contains(err.Error(), ivan.Name), false)
+}
+
+func testDuplicateEmail(t *testing.T, repo UserStore) {
+	createUser(t, repo, ivan)
+
+	duplicate := ivan
+	duplicate.PhoneNumber = "+7455457"
+	duplicate.Code = "P3WN8R2C"
+	err := repo.CreateUser(context.Background(), duplicate)
+	assert.Equal(t, errors.Is(err, service.ErrUserAlreadyExists), true)
+}
+
+func testDuplicateReferralCode(t *testing.T, repo UserStore) {
+	id := createUser(t, repo, ivan)
+	err := repo.DeleteUserById(context.Background(), id)
+	assert.Equal(t, err, nil)
+
+	// Codes of deleted users stay taken.
+	petr := service.UserSingUp{Name: "Petr", PhoneNumber: "+7455457", Email: "petr@algsdh", Password: "hash", Code: ivan.Code}
+	err = repo.CreateUser(context.Background(), petr)
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, errors.Is(err, service.ErrUserAlreadyExists), false)
+
+	_, err = repo.CheckUserByPhoneNumber(context.Background(), petr.PhoneNumber)
+	assert.Equal(t, err, service.ErrUserDoesNotExists)
+}
+
+func testCheckUserByPhoneNumber(t *testing.T, repo UserStore) {
+	createUser(t, repo, ivan)
+
+	user, err := repo.CheckUserByPhoneNumber(context.Background(), ivan.PhoneNumber)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, user.PhoneNumber, ivan.PhoneNumber)
+	assert.Equal(t, user.Password, ivan.Password)
+
+	_, err = repo.CheckUserByPhoneNumber(context.Background(), "+7000000")
+	assert.Equal(t, err, service.ErrUserDoesNotExists)
+}
+
+func testUserDoesNotExist(t *testing.T, repo UserStore) {
+	_, err := repo.GetUserById(context.Background(), "42")
+	assert.Equal(t, err, service.ErrUserDoesNotExists)
+
+	err = repo.UpdateUserById(context.Background(), "42", &model.User{Name: "Petr"})
+	assert.Equal(t, err, service.ErrUserDoesNotExists)
+
+	err = repo.DeleteUserById(context.Background(), "42")
+	assert.Equal(t, err, service.ErrUserDoesNotExists)
+}
+
+func testPartialUpdate(t *testing.T, repo UserStore) {
+	id := createUser(t, repo, ivan)
+
+	err := repo.UpdateUserById(context.Background(), id, &model.User{Name: "Petr"})
+	assert.Equal(t, err, nil)
+
+	user, err := repo.GetUserById(context.Background(), id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, user.Name, "Petr")
+	assert.Equal(t, user.PhoneNumber, ivan.PhoneNumber)
+	assert.Equal(t, user.Email, ivan.Email)
+
+	err = repo.UpdateUserById(context.Background(), id, &model.User{Email: "petr@algsdh"})
+	assert.Equal(t, err, nil)
+
+	user, err = repo.GetUserById(context.Background(), id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, user.Name, "Petr")
+	assert.Equal(t, user.Email, "petr@algsdh")
+}
+
+func testVersionedUpdate(t *testing.T, repo UserStore) {
+	id := createUser(t, repo, ivan)
+
+	user, err := repo.GetUserById(context.Background(), id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, user.Version, uint64(1))
+
+	err = repo.UpdateUserById(context.Background(), id, &model.User{Name: "Petr", Version: 1})
+	assert.Equal(t, err, nil)
+
+	err = repo.UpdateUserById(context.Background(), id, &model.User{Name: "Oleg", Version: 1})
+	assert.Equal(t, err, service.ErrUserModified)
+
+	user, err = repo.GetUserById(context.Background(), id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, user.Name, "Petr")
+	assert.Equal(t, user.Version, uint64(2))
+
+	err = repo.UpdateUserById(context.Background(), id, &model.User{Email: "petr@algsdh"})
+	assert.Equal(t, err, nil)
+
+	user, err = repo.GetUserById(context.Background(), id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, user.Version, uint64(3))
+
+	err = repo.UpdateUserById(context.Background(), "42", &model.User{Name: "Petr", Version: 1})
+	assert.Equal(t, err, service.ErrUserDoesNotExists)
+}
+
+func testSoftDelete(t *testing.T, repo UserStore) {
+	id := createUser(t, repo, ivan)
+
+	err := repo.DeleteUserById(context.Background(), id)
+	assert.Equal(t, err, nil)
+
+	_, err = repo.GetUserById(context.Background(), id)
+	assert.Equal(t, err, service.ErrUserDoesNotExists)
+
+	_, err = repo.CheckUserByPhoneNumber(context.Background(), ivan.PhoneNumber)
+	assert.Equal(t, err, service.ErrUserDoesNotExists)
+
+	err = repo.UpdateUserById(context.Background(), id, &model.User{Name: "Petr"})
+	assert.Equal(t, err, service.ErrUserDoesNotExists)
+
+	err = repo.DeleteUserById(context.Background(), id)
+	assert.Equal(t, err, service.ErrUserDoesNotExists)
+}
+
+func testSignUpAfterDelete(t *testing.T, repo UserStore) {
+	id := createUser(t, repo, ivan)
+
+	err := repo.DeleteUserById(context.Background(), id)
+	assert.Equal(t, err, nil)
+
+	again := ivan
+	again.Code = "P3WN8R2C"
+	newId := createUser(t, repo, again)
+	assert.NotEqual(t, newId, id)
+}
+
+func testUpdateToTakenPhoneNumber(t *testing.T, repo UserStore) {
+	createUser(t, repo, ivan)
+
+	petr := service.UserSingUp{Name: "Petr", PhoneNumber: "+7455457", Email: "petr@algsdh", Password: "hash", Code: "P3WN8R2C"}
+	id := createUser(t, repo, petr)
+
+	err := repo.UpdateUserById(context.Background(), id, &model.User{PhoneNumber: ivan.PhoneNumber})
+	assert.Equal(t, errors.Is(err, service.ErrUserAlreadyExists), true)
+	assert.Equal(t, strings.Contains(err.Error(), petr.Name), false)
+
+	user, err := repo.GetUserById(context.Background(), id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, user.PhoneNumber, petr.PhoneNumber)
+}
+
+func testRestore(t *testing.T, repo UserStore) {
+	id := createUser(t, repo, ivan)
+
+	_, err := repo.GetDeletedUserByPhoneNumber(context.Background(), ivan.PhoneNumber, time.Now().Add(-time.Hour))
+	assert.Equal(t, err, service.ErrUserDoesNotExists)
+
+	err = repo.DeleteUserById(context.Background(), id)
+	assert.Equal(t, err, nil)
+
+	_, err = repo.GetDeletedUserByPhoneNumber(context.Background(), ivan.PhoneNumber, time.Now().Add(time.Hour))
+	assert.Equal(t, err, service.ErrUserDoesNotExists)
+
+	deleted, err := repo.GetDeletedUserByPhoneNumber(context.Background(), ivan.PhoneNumber, time.Now().Add(-time.Hour))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, strconv.FormatUint(deleted.ID, 10), id)
+	assert.Equal(t, deleted.Password, ivan.Password)
+	assert.Equal(t, deleted.DeletedAt.IsZero(), false)
+
+	err = repo.RestoreUserById(context.Background(), id)
+	assert.Equal(t, err, nil)
+
+	user, err := repo.GetUserById(context.Background(), id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, user.PhoneNumber, ivan.PhoneNumber)
+
+	err = repo.RestoreUserById(context.Background(), id)
+	assert.Equal(t, err, service.ErrUserDoesNotExists)
+}
+
+func testRestoreTakenPhoneNumber(t *testing.T, repo UserStore) {
+	id := createUser(t, repo, ivan)
+
+	err := repo.DeleteUserById(context.Background(), id)
+	assert.Equal(t, err, nil)
+
+	again := ivan
+	again.Code = "P3WN8R2C"
+	createUser(t, repo, again)
+
+	err = repo.RestoreUserById(context.Background(), id)
+	assert.Equal(t, errors.Is(err, service.ErrUserAlreadyExists), true)
+}
+
+func testPurge(t *testing.T, repo UserStore) {
+	ivanId := createUser(t, repo, ivan)
+	petr := service.UserSingUp{Name: "Petr", PhoneNumber: "+7455457", Email: "petr@algsdh", Password: "hash", Code: "P3WN8R2C"}
+	petrId := createUser(t, repo, petr)
+
+	err := repo.PurgeUserById(context.Background(), ivanId)
+	assert.Equal(t, err, service.ErrUserDoesNotExists)
+
+	for _, id := range []string{ivanId, petrId} {
+		err := repo.DeleteUserById(context.Background(), id)
+		assert.Equal(t, err, nil)
+	}
+
+	ids, err := repo.GetPurgeableUsers(context.Background(), time.Now().Add(-time.Hour), 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(ids), 0)
+
+	ids, err = repo.GetPurgeableUsers(context.Background(), time.Now().Add(time.Hour), 1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(ids), 1)
+	assert.Equal(t, strconv.FormatUint(ids[0], 10), ivanId)
+
+	err = repo.PurgeUserById(context.Background(), ivanId)
+	assert.Equal(t, err, nil)
+
+	err = repo.PurgeUserById(context.Background(), ivanId)
+	assert.Equal(t, err, service.ErrUserDoesNotExists)
+
+	ids, err = repo.GetPurgeableUsers(context.Background(), time.Now().Add(time.Hour), 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(ids), 1)
+	assert.Equal(t, strconv.FormatUint(ids[0], 10), petrId)
+
+	_, err = repo.GetDeletedUserByPhoneNumber(context.Background(), ivan.PhoneNumber, time.Time{})
+	assert.Equal(t, err, service.ErrUserDoesNotExists)
+
+	err = repo.RestoreUserById(context.Background(), ivanId)
+	assert.Equal(t, err, service.ErrUserDoesNotExists)
+}
+
+func testTransactionCommit(t *testing.T, repo UserStore) {
+	err := repo.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		err := repo.CreateUser(ctx, ivan)
+		if err != nil {
+			return err
+		}
+		_, err = repo.CheckUserByPhoneNumber(ctx, ivan.PhoneNumber)
+		return err
+	})
+	assert.Equal(t, err, nil)
+
+	_, err = repo.CheckUserByPhoneNumber(context.Background(), ivan.PhoneNumber)
+	assert.Equal(t, err, nil)
+}
+
+func testTransactionRollback(t *testing.T, repo UserStore) {
+	errFailed := errors.New("failed")
+
+	err := repo.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		err := repo.CreateUser(ctx, ivan)
+		if err != nil {
+			return err
+		}
+		return errFailed
+	})
+	assert.Equal(t, err, errFailed)
+
+	_, err = repo.CheckUserByPhoneNumber(context.Background(), ivan.PhoneNumber)
+	assert.Equal(t, err, service.ErrUserDoesNotExists)
+}
+
+// addEvent stores a user.deleted event for userId.
+func addEvent(t *testing.T, ctx context.Context, repo OutboxStore, userId string) *model.Event {
+	t.Helper()
+
+	event := &model.Event{
+		Type:        model.EventUserDeleted,
+		AggregateID: userId,
+		Payload:     []byte(`{"user_id":"` + userId + `"}`),
+	}
+	err := repo.AddEvent(ctx, event)
+	if err != nil {
+		t.Fatalf("add event failed: %v", err)
+	}
+	return event
+}
+
+func testPendingEventsInOrder(t *testing.T, repo OutboxStore) {
+	first := addEvent(t, context.Background(), repo, "1")
+	second := addEvent(t, context.Background(), repo, "2")
+	addEvent(t, context.Background(), repo, "3")
+
+	events, err := repo.GetPendingEvents(context.Background(), 2)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(events), 2)
+	assert.Equal(t, events[0].ID, first.ID)
+	assert.Equal(t, events[0].Type, model.EventUserDeleted)
+	assert.Equal(t, events[0].AggregateID, "1")
+	assert.Equal(t, string(events[0].Payload), string(first.Payload))
+	assert.Equal(t, events[1].ID, second.ID)
+}
+
+func testPublishedEvent(t *testing.T, repo OutboxStore) {
+	first := addEvent(t, context.Background(), repo, "1")
+	second := addEvent(t, context.Background(), repo, "2")
+
+	err := repo.MarkEventPublished(context.Background(), first.ID)
+	assert.Equal(t, err, nil)
+
+	events, err := repo.GetPendingEvents(context.Background(), 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].ID, second.ID)
+}
+
+func testFailedEvent(t *testing.T, repo OutboxStore) {
+	event := addEvent(t, context.Background(), repo, "1")
+
+	for i := 0; i < 2; i++ {
+		err := repo.MarkEventFailed(context.Background(), event.ID, "broker is down")
+		assert.Equal(t, err, nil)
+	}
+
+	events, err := repo.GetPendingEvents(context.Background(), 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].Attempts, 2)
+}
+
+func testDeadEvent(t *testing.T, repo OutboxStore) {
+	dead := addEvent(t, context.Background(), repo, "1")
+	next := addEvent(t, context.Background(), repo, "2")
+
+	err := repo.MarkEventDead(context.Background(), dead.ID, "message too large")
+	assert.Equal(t, err, nil)
+
+	events, err := repo.GetPendingEvents(context.Background(), 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].ID, next.ID)
+}
+
+func testEventRolledBack(t *testing.T, repo OutboxStore) {
+	errFailed := errors.New("failed")
+
+	err := repo.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		addEvent(t, ctx, repo, "1")
+		return errFailed
+	})
+	assert.Equal(t, err, errFailed)
+
+	events, err := repo.GetPendingEvents(context.Background(), 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(events), 0)
+}
+
+// createWebhook stores an active webhook subscribed to eventTypes.
+// partner is the partner the webhooks of the suite belong to.
+const partner = 3
+
+func createWebhook(t *testing.T, repo WebhookStore, eventTypes ...string) *model.Webhook {
+	t.Helper()
+
+	webhook := &model.Webhook{PartnerID: partner, URL: "https://partner.example/hook", Secret: "s3cret", EventTypes: eventTypes, Active: true}
+	err := repo.CreateWebhook(context.Background(), webhook)
+	if err != nil {
+		t.Fatalf("create webhook failed: %v", err)
+	}
+	return webhook
+}
+
+// createEmployee stores the user as an employee of partnerId and returns
+// the id it got.
+func createEmployee(t *testing.T, repo WebhookStore, user service.UserSingUp, partnerId uint64) string {
+	t.Helper()
+
+	id := createUser(t, repo, user)
+	err := repo.AddPartnerEmployee(context.Background(), partnerId, id)
+	if err != nil {
+		t.Fatalf("add partner employee failed: %v", err)
+	}
+	return id
+}
+
+func getDeliveries(t *testing.T, repo WebhookStore, webhook *model.Webhook, status string) []*model.WebhookDelivery {
+	t.Helper()
+
+	deliveries, err := repo.GetWebhookDeliveries(context.Background(), strconv.FormatUint(webhook.ID, 10), status, 10, 0)
+	if err != nil {
+		t.Fatalf("get webhook deliveries failed: %v", err)
+	}
+	return deliveries
+}
+
+func testUpdateWebhook(t *testing.T, repo WebhookStore) {
+	webhook := createWebhook(t, repo, model.EventUserDeleted)
+
+	webhook.URL = "https://partner.example/v2"
+	webhook.EventTypes = []string{model.EventUserSignedUp, model.EventUserPhoneChanged}
+	webhook.Active = false
+	err := repo.UpdateWebhook(context.Background(), webhook)
+	assert.Equal(t, err, nil)
+
+	stored, err := repo.GetWebhookById(context.Background(), strconv.FormatUint(webhook.ID, 10))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, stored.PartnerID, uint64(partner))
+	assert.Equal(t, stored.URL, webhook.URL)
+	assert.Equal(t, stored.Secret, webhook.Secret)
+	assert.Equal(t, stored.EventTypes, webhook.EventTypes)
+	assert.Equal(t, stored.Active, false)
+}
+
+func testSubscribedDeliveries(t *testing.T, repo WebhookStore) {
+	subscribed := createWebhook(t, repo, model.EventUserSignedUp, model.EventUserDeleted)
+	other := createWebhook(t, repo, model.EventUserSignedUp)
+	inactive := createWebhook(t, repo, model.EventUserDeleted)
+	inactive.Active = false
+	err := repo.UpdateWebhook(context.Background(), inactive)
+	assert.Equal(t, err, nil)
+
+	userId := createEmployee(t, repo, ivan, partner)
+
+	for i := 0; i < 2; i++ {
+		err = repo.AddWebhookDeliveries(context.Background(), 7, model.EventUserDeleted, userId, []byte(`{"id":7}`))
+		assert.Equal(t, err, nil)
+	}
+
+	deliveries := getDeliveries(t, repo, subscribed, "")
+	assert.Equal(t, len(deliveries), 1)
+	assert.Equal(t, deliveries[0].EventID, uint64(7))
+	assert.Equal(t, deliveries[0].EventType, model.EventUserDeleted)
+	assert.Equal(t, string(deliveries[0].Payload), `{"id":7}`)
+	assert.Equal(t, deliveries[0].Status, model.DeliveryPending)
+	assert.Equal(t, len(getDeliveries(t, repo, other, "")), 0)
+	assert.Equal(t, len(getDeliveries(t, repo, inactive, "")), 0)
+}
+
+func testPartnerDeliveries(t *testing.T, repo WebhookStore) {
+	own := createWebhook(t, repo, model.EventUserSignedUp)
+	other := &model.Webhook{PartnerID: partner + 1, URL: "https://other.example/hook", Secret: "s3cret", EventTypes: []string{model.EventUserSignedUp}, Active: true}
+	err := repo.CreateWebhook(context.Background(), other)
+	assert.Equal(t, err, nil)
+
+	employeeId := createEmployee(t, repo, ivan, partner)
+	petr := service.UserSingUp{Name: "Petr", PhoneNumber: "+7455457", Email: "petr@algsdh", Password: "hash", Code: "P3WN8R2C"}
+	otherId := createUser(t, repo, petr)
+
+	err = repo.AddWebhookDeliveries(context.Background(), 7, model.EventUserSignedUp, employeeId, []byte(`{}`))
+	assert.Equal(t, err, nil)
+	err = repo.AddWebhookDeliveries(context.Background(), 8, model.EventUserSignedUp, otherId, []byte(`{}`))
+	assert.Equal(t, err, nil)
+
+	// The other partner gets nothing about the employee, and nobody gets
+	// events about users without a partner.
+	deliveries := getDeliveries(t, repo, own, "")
+	assert.Equal(t, len(deliveries), 1)
+	assert.Equal(t, deliveries[0].EventID, uint64(7))
+	assert.Equal(t, len(getDeliveries(t, repo, other, "")), 0)
+
+	err = repo.AddPartnerEmployee(context.Background(), partner+1, employeeId)
+	assert.Equal(t, err, nil)
+	err = repo.AddWebhookDeliveries(context.Background(), 9, model.EventUserSignedUp, employeeId, []byte(`{}`))
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, len(getDeliveries(t, repo, own, "")), 1)
+	deliveries = getDeliveries(t, repo, other, "")
+	assert.Equal(t, len(deliveries), 1)
+	assert.Equal(t, deliveries[0].EventID, uint64(9))
+}
+
+func testPartnerEmployees(t *testing.T, repo WebhookStore) {
+	webhook := createWebhook(t, repo, model.EventUserDeleted)
+	userId := createEmployee(t, repo, ivan, partner)
+
+	err := repo.RemovePartnerEmployee(context.Background(), partner+1, userId)
+	assert.Equal(t, err, service.ErrEmployeeDoesNotExists)
+
+	err = repo.RemovePartnerEmployee(context.Background(), partner, userId)
+	assert.Equal(t, err, nil)
+
+	err = repo.RemovePartnerEmployee(context.Background(), partner, userId)
+	assert.Equal(t, err, service.ErrEmployeeDoesNotExists)
+
+	err = repo.AddWebhookDeliveries(context.Background(), 7, model.EventUserDeleted, userId, []byte(`{}`))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(getDeliveries(t, repo, webhook, "")), 0)
+
+	err = repo.AddPartnerEmployee(context.Background(), partner, "100")
+	assert.Equal(t, err, service.ErrUserDoesNotExists)
+}
+
+// publish stores the event about userId and queues it for the webhooks
+// like the outbox relay does.
+func publish(t *testing.T, repo WebhookStore, eventType, userId string, payload interface{}) *model.Event {
+	t.Helper()
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	event := &model.Event{Type: eventType, AggregateID: userId, Payload: body}
+	err = repo.AddEvent(context.Background(), event)
+	if err != nil {
+		t.Fatalf("add event failed: %v", err)
+	}
+
+	body, err = json.Marshal(event)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	err = repo.AddWebhookDeliveries(context.Background(), event.ID, event.Type, userId, body)
+	if err != nil {
+		t.Fatalf("add webhook deliveries failed: %v", err)
+	}
+	err = repo.MarkEventPublished(context.Background(), event.ID)
+	if err != nil {
+		t.Fatalf("mark event published failed: %v", err)
+	}
+	return event
+}
+
+func testPurgeEvents(t *testing.T, repo WebhookStore) {
+	webhook := createWebhook(t, repo, model.EventUserSignedUp, model.EventUserPhoneChanged)
+	ivanId := createEmployee(t, repo, ivan, partner)
+	petr := service.UserSingUp{Name: "Petr", PhoneNumber: "+7455457", Email: "petr@algsdh", Password: "hash", Code: "P3WN8R2C"}
+	petrId := createEmployee(t, repo, petr, partner)
+
+	signedUp := publish(t, repo, model.EventUserSignedUp, ivanId, model.UserSignedUp{UserID: ivanId, Name: ivan.Name, PhoneNumber: ivan.PhoneNumber, Email: ivan.Email})
+	changed := publish(t, repo, model.EventUserPhoneChanged, ivanId, model.UserPhoneChanged{UserID: ivanId, PhoneNumber: "+7455458"})
+	other := publish(t, repo, model.EventUserSignedUp, petrId, model.UserSignedUp{UserID: petrId, Name: petr.Name, PhoneNumber: petr.PhoneNumber, Email: petr.Email})
+	pending := &model.Event{Type: model.EventUserPhoneChanged, AggregateID: ivanId, Payload: []byte(`{"user_id":"` + ivanId + `","phone_number":"+7455459"}`)}
+	err := repo.AddEvent(context.Background(), pending)
+	assert.Equal(t, err, nil)
+
+	for _, delivery := range getDeliveries(t, repo, webhook, "") {
+		if delivery.EventID == signedUp.ID {
+			delivery.Status = model.DeliveryDelivered
+			err := repo.UpdateWebhookDelivery(context.Background(), delivery)
+			assert.Equal(t, err, nil)
+		}
+	}
+
+	err = repo.DeleteUserById(context.Background(), ivanId)
+	assert.Equal(t, err, nil)
+	err = repo.PurgeUserById(context.Background(), ivanId)
+	assert.Equal(t, err, nil)
+
+	scrubbed := map[string]string{"user_id": ivanId}
+
+	events, err := repo.GetPendingEvents(context.Background(), 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].ID, pending.ID)
+	assert.Equal(t, events[0].Type, model.EventUserPhoneChanged)
+	assert.Equal(t, payloadOf(t, events[0].Payload), scrubbed)
+
+	// The delivered event is gone, the pending one is sent without the
+	// phone number and the other user's event is untouched.
+	deliveries := getDeliveries(t, repo, webhook, "")
+	assert.Equal(t, len(deliveries), 2)
+	for _, delivery := range deliveries {
+		var event model.Event
+		err := json.Unmarshal(delivery.Payload, &event)
+		assert.Equal(t, err, nil)
+
+		switch delivery.EventID {
+		case changed.ID:
+			assert.Equal(t, event.ID, changed.ID)
+			assert.Equal(t, event.Type, model.EventUserPhoneChanged)
+			assert.Equal(t, event.AggregateID, ivanId)
+			assert.Equal(t, payloadOf(t, event.Payload), scrubbed)
+		case other.ID:
+			assert.Equal(t, payloadOf(t, event.Payload)["name"], petr.Name)
+		default:
+			t.Errorf("unexpected delivery of event %v", delivery.EventID)
+		}
+	}
+}
+
+func payloadOf(t *testing.T, payload []byte) map[string]string {
+	t.Helper()
+
+	var fields map[string]string
+	err := json.Unmarshal(payload, &fields)
+	if err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	return fields
+}
+
+func testDueDeliveries(t *testing.T, repo WebhookStore) {
+	createWebhook(t, repo, model.EventUserDeleted)
+	userId := createEmployee(t, repo, ivan, partner)
+	err := repo.AddWebhookDeliveries(context.Background(), 7, model.EventUserDeleted, userId, []byte(`{"id":7}`))
+	assert.Equal(t, err, nil)
+
+	now := time.Now()
+	due, err := repo.GetDueWebhookDeliveries(context.Background(), now, 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(due), 1)
+
+	delivery := due[0]
+	delivery.Attempts = 1
+	delivery.NextAttemptAt = now.Add(time.Minute)
+	delivery.LastError = "unexpected status: 500"
+	delivery.ResponseStatus = 500
+	err = repo.UpdateWebhookDelivery(context.Background(), delivery)
+	assert.Equal(t, err, nil)
+
+	due, err = repo.GetDueWebhookDeliveries(context.Background(), now, 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(due), 0)
+
+	due, err = repo.GetDueWebhookDeliveries(context.Background(), now.Add(time.Minute), 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(due), 1)
+	assert.Equal(t, due[0].Attempts, 1)
+	assert.Equal(t, due[0].LastError, "unexpected status: 500")
+	assert.Equal(t, due[0].ResponseStatus, 500)
+}
+
+func testDeliveryLog(t *testing.T, repo WebhookStore) {
+	webhook := createWebhook(t, repo, model.EventUserDeleted)
+	userId := createEmployee(t, repo, ivan, partner)
+	for _, eventId := range []uint64{7, 8} {
+		err := repo.AddWebhookDeliveries(context.Background(), eventId, model.EventUserDeleted, userId, []byte(`{}`))
+		assert.Equal(t, err, nil)
+	}
+
+	deliveries := getDeliveries(t, repo, webhook, "")
+	assert.Equal(t, len(deliveries), 2)
+	assert.Equal(t, deliveries[0].EventID, uint64(8))
+
+	delivered := deliveries[0]
+	deliveredAt := time.Now()
+	delivered.Status = model.DeliveryDelivered
+	delivered.Attempts = 1
+	delivered.DeliveredAt = &deliveredAt
+	err := repo.UpdateWebhookDelivery(context.Background(), delivered)
+	assert.Equal(t, err, nil)
+
+	deliveries = getDeliveries(t, repo, webhook, model.DeliveryDelivered)
+	assert.Equal(t, len(deliveries), 1)
+	assert.Equal(t, deliveries[0].EventID, uint64(8))
+	assert.NotEqual(t, deliveries[0].DeliveredAt, nil)
+
+	deliveries = getDeliveries(t, repo, webhook, model.DeliveryPending)
+	assert.Equal(t, len(deliveries), 1)
+	assert.Equal(t, deliveries[0].EventID, uint64(7))
+}
+
+func testRetryDeadDelivery(t *testing.T, repo WebhookStore) {
+	webhook := createWebhook(t, repo, model.EventUserDeleted)
+	webhookId := strconv.FormatUint(webhook.ID, 10)
+	userId := createEmployee(t, repo, ivan, partner)
+	err := repo.AddWebhookDeliveries(context.Background(), 7, model.EventUserDeleted, userId, []byte(`{}`))
+	assert.Equal(t, err, nil)
+
+	delivery := getDeliveries(t, repo, webhook, "")[0]
+	deliveryId := strconv.FormatUint(delivery.ID, 10)
+
+	err = repo.RetryWebhookDelivery(context.Background(), webhookId, deliveryId, time.Now())
+	assert.Equal(t, err, service.ErrWebhookDeliveryDoesNotExists)
+
+	delivery.Status = model.DeliveryDead
+	delivery.Attempts = 8
+	err = repo.UpdateWebhookDelivery(context.Background(), delivery)
+	assert.Equal(t, err, nil)
+
+	err = repo.RetryWebhookDelivery(context.Background(), webhookId, deliveryId, time.Now())
+	assert.Equal(t, err, nil)
+
+	delivery = getDeliveries(t, repo, webhook, "")[0]
+	assert.Equal(t, delivery.Status, model.DeliveryPending)
+	assert.Equal(t, delivery.Attempts, 0)
+}
+
+func testDeleteWebhook(t *testing.T, repo WebhookStore) {
+	webhook := createWebhook(t, repo, model.EventUserDeleted)
+	webhookId := strconv.FormatUint(webhook.ID, 10)
+	userId := createEmployee(t, repo, ivan, partner)
+	err := repo.AddWebhookDeliveries(context.Background(), 7, model.EventUserDeleted, userId, []byte(`{}`))
+	assert.Equal(t, err, nil)
+
+	err = repo.DeleteWebhookById(context.Background(), webhookId)
+	assert.Equal(t, err, nil)
+
+	_, err = repo.GetWebhookById(context.Background(), webhookId)
+	assert.Equal(t, err, service.ErrWebhookDoesNotExists)
+
+	due, err := repo.GetDueWebhookDeliveries(context.Background(), time.Now(), 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(due), 0)
+
+	err = repo.DeleteWebhookById(context.Background(), webhookId)
+	assert.Equal(t, err, service.ErrWebhookDoesNotExists)
+}
+
+func testUnknownToken(t *testing.T, repo service.TokenRepo) {
+	assert.Equal(t, repo.GetToken(context.Background(), t.Name()), true)
+}
+
+func testRevokedToken(t *testing.T, repo service.TokenRepo) {
+	err := repo.AddToken(context.Background(), t.Name(), time.Minute)
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, repo.GetToken(context.Background(), t.Name()), false)
+}
+
+func testExpiredToken(t *testing.T, repo service.TokenRepo) {
+	err := repo.AddToken(context.Background(), t.Name(), 100*time.Millisecond)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, repo.GetToken(context.Background(), t.Name()), false)
+
+	time.Sleep(200 * time.Millisecond)
+
+	assert.Equal(t, repo.GetToken(context.Background(), t.Name()), true)
+}
@@ -0,0 +1,33 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/RipperAcskt/innotaxi/internal/repo/conformance"
+	"github.com/RipperAcskt/innotaxi/internal/repo/memory"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+func TestUserRepoConformance(t *testing.T) {
+	conformance.UserRepo(t, func(t *testing.T) conformance.UserStore {
+		return memory.New()
+	})
+}
+
+func TestOutboxRepoConformance(t *testing.T) {
+	conformance.OutboxRepo(t, func(t *testing.T) conformance.OutboxStore {
+		return memory.New()
+	})
+}
+
+func TestWebhookRepoConformance(t *testing.T) {
+	conformance.WebhookRepo(t, func(t *testing.T) conformance.WebhookStore {
+		return memory.New()
+	})
+}
+
+func TestTokenRepoConformance(t *testing.T) {
+	conformance.TokenRepo(t, func(t *testing.T) service.TokenRepo {
+		return memory.NewTokens()
+	})
+}
@@ -0,0 +1,222 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+// Memory is a thread-safe in-memory implementation of service.Repo for local
+// runs and hermetic tests. It mirrors the Postgres behaviour: users are soft
+// deleted and active users can't share a phone number or an email.
+type Memory struct {
+	mu sync.RWMutex
+	state
+}
+
+// state holds everything a failed transaction has to roll back.
+type state struct {
+	users  map[uint64]*user
+	userId uint64
+
+	places  map[uint64]*place
+	placeId uint64
+
+	promotions  map[uint64]*model.Promotion
+	promotionId uint64
+	redemptions []model.Redemption
+
+	entries map[string]*model.JournalEntry
+	entryId uint64
+
+	methods   map[uint64]*paymentMethod
+	methodId  uint64
+	payments  map[uint64]*model.Payment
+	paymentId uint64
+
+	gatewayEvents map[string]bool
+
+	referrals  map[uint64]*model.Referral
+	referralId uint64
+
+	events  map[uint64]*outboxEvent
+	eventId uint64
+
+	webhooks   map[uint64]*model.Webhook
+	webhookId  uint64
+	deliveries map[uint64]*model.WebhookDelivery
+	deliveryId uint64
+}
+
+type user struct {
+	model.User
+	password  string
+	code      string
+	partnerID uint64
+	deletedAt time.Time
+	purgedAt  time.Time
+}
+
+func New() *Memory {
+	return &Memory{
+		state: state{
+			users:         make(map[uint64]*user),
+			places:        make(map[uint64]*place),
+			promotions:    make(map[uint64]*model.Promotion),
+			entries:       make(map[string]*model.JournalEntry),
+			methods:       make(map[uint64]*paymentMethod),
+			payments:      make(map[uint64]*model.Payment),
+			gatewayEvents: make(map[string]bool),
+			referrals:     make(map[uint64]*model.Referral),
+			events:        make(map[uint64]*outboxEvent),
+			webhooks:      make(map[uint64]*model.Webhook),
+			deliveries:    make(map[uint64]*model.WebhookDelivery),
+		},
+	}
+}
+
+func (m *Memory) CreateUser(ctx context.Context, u service.UserSingUp) error {
+	defer m.lock(ctx)()
+
+	for _, stored := range m.users {
+		if stored.Status == model.StatusCreated && (stored.PhoneNumber == u.PhoneNumber || stored.Email == u.Email) {
+			return service.ErrUserAlreadyExists
+		}
+	}
+	// Like the unique index of the databases, codes of deleted users stay
+	// taken.
+	for _, stored := range m.users {
+		if stored.code == u.Code {
+			return fmt.Errorf("referral code %v is taken", u.Code)
+		}
+	}
+
+	m.userId++
+	m.users[m.userId] = &user{
+		User: model.User{
+			ID:          m.userId,
+			Name:        u.Name,
+			PhoneNumber: u.PhoneNumber,
+			Email:       u.Email,
+			Status:      model.StatusCreated,
+			DeviceID:    u.DeviceID,
+			Version:     1,
+		},
+		password: u.Password,
+		code:     u.Code,
+	}
+
+	if u.Referral != nil {
+		m.referralId++
+		referral := *u.Referral
+		referral.ID = m.referralId
+		referral.InviteeID = m.userId
+		referral.PhoneNumber = u.PhoneNumber
+		referral.DeviceID = u.DeviceID
+		m.referrals[referral.ID] = &referral
+	}
+	return nil
+}
+
+func (m *Memory) CheckUserByPhoneNumber(ctx context.Context, phone string) (*service.UserSingIn, error) {
+	defer m.rlock(ctx)()
+
+	for _, stored := range m.users {
+		if stored.Status == model.StatusCreated && stored.PhoneNumber == phone {
+			return &service.UserSingIn{
+				ID:          stored.ID,
+				PhoneNumber: stored.PhoneNumber,
+				Password:    stored.password,
+			}, nil
+		}
+	}
+	return nil, service.ErrUserDoesNotExists
+}
+
+func (m *Memory) GetUserById(ctx context.Context, id string) (*model.User, error) {
+	defer m.rlock(ctx)()
+
+	stored, ok := m.activeUser(id)
+	if !ok {
+		return nil, service.ErrUserDoesNotExists
+	}
+
+	return &model.User{
+		ID:          stored.ID,
+		Name:        stored.Name,
+		PhoneNumber: stored.PhoneNumber,
+		Email:       stored.Email,
+		Raiting:     stored.Raiting,
+		Version:     stored.Version,
+	}, nil
+}
+
+func (m *Memory) UpdateUserById(ctx context.Context, id string, u *model.User) error {
+	defer m.lock(ctx)()
+
+	stored, ok := m.activeUser(id)
+	if !ok {
+		return service.ErrUserDoesNotExists
+	}
+	if u.Version != 0 && u.Version != stored.Version {
+		return service.ErrUserModified
+	}
+
+	for _, other := range m.users {
+		if other == stored || other.Status != model.StatusCreated {
+			continue
+		}
+		if (u.PhoneNumber != "" && other.PhoneNumber == u.PhoneNumber) || (u.Email != "" && other.Email == u.Email) {
+			return fmt.Errorf("user: %v: %w", id, service.ErrUserAlreadyExists)
+		}
+	}
+
+	if u.Name != "" {
+		stored.Name = u.Name
+	}
+	if u.PhoneNumber != "" {
+		stored.PhoneNumber = u.PhoneNumber
+	}
+	if u.Email != "" {
+		stored.Email = u.Email
+	}
+	stored.Version++
+	return nil
+}
+
+func (m *Memory) DeleteUserById(ctx context.Context, id string) error {
+	defer m.lock(ctx)()
+
+	stored, ok := m.activeUser(id)
+	if !ok {
+		return service.ErrUserDoesNotExists
+	}
+
+	stored.Status = model.StatusDeleted
+	stored.deletedAt = time.Now()
+	return nil
+}
+
+// activeUser must be called with the lock held.
+func (m *Memory) activeUser(id string) (*user, bool) {
+	stored, ok := m.users[parseId(id)]
+	if !ok || stored.Status != model.StatusCreated {
+		return nil, false
+	}
+	return stored, true
+}
+
+// parseId returns 0, which is never assigned, for malformed ids so they are
+// reported as not found.
+func parseId(id string) uint64 {
+	num, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return num
+}
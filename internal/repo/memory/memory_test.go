@@ -0,0 +1,66 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/repo/memory"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestCreateUserConcurrently(t *testing.T) {
+	m := memory.New()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		created int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			err := m.CreateUser(context.Background(), service.UserSingUp{
+				Name:        "Ivan",
+				PhoneNumber: "+7455456",
+				Email:       fmt.Sprintf("ripper%d@algsdh", i),
+				Password:    "12345",
+			})
+			if err == nil {
+				mu.Lock()
+				created++
+				mu.Unlock()
+				return
+			}
+			assert.Equal(t, errors.Is(err, service.ErrUserAlreadyExists), true)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, created, 1)
+}
+
+func TestTokens(t *testing.T) {
+	tokens := memory.NewTokens()
+
+	assert.Equal(t, tokens.GetToken(context.Background(), "access"), true)
+
+	err := tokens.AddToken(context.Background(), "access", 50*time.Millisecond)
+	assert.Equal(t, err, nil)
+	err = tokens.AddToken(context.Background(), "forever", 0)
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, tokens.GetToken(context.Background(), "access"), false)
+	assert.Equal(t, tokens.GetToken(context.Background(), "forever"), false)
+
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, tokens.GetToken(context.Background(), "access"), true)
+	assert.Equal(t, tokens.GetToken(context.Background(), "forever"), false)
+}
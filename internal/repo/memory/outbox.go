@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+)
+
+type outboxEvent struct {
+	model.Event
+	published bool
+	dead      bool
+	lastError string
+}
+
+func (m *Memory) AddEvent(ctx context.Context, event *model.Event) error {
+	defer m.lock(ctx)()
+
+	m.eventId++
+	event.ID = m.eventId
+	event.CreatedAt = time.Now()
+	m.events[m.eventId] = &outboxEvent{Event: *event}
+	return nil
+}
+
+func (m *Memory) GetPendingEvents(ctx context.Context, limit int) ([]*model.Event, error) {
+	defer m.rlock(ctx)()
+
+	events := make([]*model.Event, 0)
+	for _, stored := range m.events {
+		if !stored.published && !stored.dead {
+			event := stored.Event
+			events = append(events, &event)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ID < events[j].ID
+	})
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (m *Memory) MarkEventPublished(ctx context.Context, id uint64) error {
+	defer m.lock(ctx)()
+
+	if stored, ok := m.events[id]; ok {
+		stored.published = true
+	}
+	return nil
+}
+
+func (m *Memory) MarkEventFailed(ctx context.Context, id uint64, reason string) error {
+	defer m.lock(ctx)()
+
+	if stored, ok := m.events[id]; ok {
+		stored.Attempts++
+		stored.lastError = reason
+	}
+	return nil
+}
+
+func (m *Memory) MarkEventDead(ctx context.Context, id uint64, reason string) error {
+	defer m.lock(ctx)()
+
+	if stored, ok := m.events[id]; ok {
+		stored.Attempts++
+		stored.lastError = reason
+		stored.dead = true
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+type paymentMethod struct {
+	model.PaymentMethod
+	userId string
+}
+
+func (m *Memory) CreatePaymentMethod(ctx context.Context, userId string, method *model.PaymentMethod) error {
+	defer m.lock(ctx)()
+
+	m.methodId++
+	method.ID = m.methodId
+	m.methods[method.ID] = &paymentMethod{*method, userId}
+	return nil
+}
+
+func (m *Memory) GetPaymentMethodsByUserId(ctx context.Context, userId string) ([]*model.PaymentMethod, error) {
+	defer m.rlock(ctx)()
+
+	methods := make([]*model.PaymentMethod, 0)
+	for _, stored := range m.methods {
+		if stored.userId == userId {
+			method := stored.PaymentMethod
+			methods = append(methods, &method)
+		}
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].ID < methods[j].ID })
+
+	return methods, nil
+}
+
+func (m *Memory) GetPaymentMethodById(ctx context.Context, userId, methodId string) (*model.PaymentMethod, error) {
+	defer m.rlock(ctx)()
+
+	stored, ok := m.methods[parseId(methodId)]
+	if !ok || stored.userId != userId {
+		return nil, service.ErrPaymentMethodDoesNotExists
+	}
+
+	method := stored.PaymentMethod
+	return &method, nil
+}
+
+func (m *Memory) DeletePaymentMethodById(ctx context.Context, userId, methodId string) error {
+	defer m.lock(ctx)()
+
+	stored, ok := m.methods[parseId(methodId)]
+	if !ok || stored.userId != userId {
+		return service.ErrPaymentMethodDoesNotExists
+	}
+
+	delete(m.methods, stored.ID)
+	for _, payment := range m.payments {
+		if payment.PaymentMethodID == stored.ID {
+			payment.PaymentMethodID = 0
+		}
+	}
+	return nil
+}
+
+func (m *Memory) CreatePayment(ctx context.Context, payment *model.Payment) error {
+	defer m.lock(ctx)()
+
+	for _, stored := range m.payments {
+		if stored.OrderID == payment.OrderID || stored.AuthorizationID == payment.AuthorizationID {
+			return fmt.Errorf("order: %v: %w", payment.OrderID, service.ErrPaymentAlreadyExists)
+		}
+	}
+
+	m.paymentId++
+	payment.ID = m.paymentId
+	payment.CreatedAt = time.Now()
+
+	stored := *payment
+	m.payments[payment.ID] = &stored
+	return nil
+}
+
+func (m *Memory) GetPaymentByOrderId(ctx context.Context, orderId string) (*model.Payment, error) {
+	return m.getPayment(ctx, func(payment *model.Payment) bool { return payment.OrderID == orderId })
+}
+
+func (m *Memory) GetPaymentByAuthorizationId(ctx context.Context, authorizationId string) (*model.Payment, error) {
+	return m.getPayment(ctx, func(payment *model.Payment) bool { return payment.AuthorizationID == authorizationId })
+}
+
+func (m *Memory) getPayment(ctx context.Context, match func(*model.Payment) bool) (*model.Payment, error) {
+	defer m.rlock(ctx)()
+
+	for _, stored := range m.payments {
+		if match(stored) {
+			payment := *stored
+			return &payment, nil
+		}
+	}
+	return nil, service.ErrPaymentDoesNotExists
+}
+
+func (m *Memory) UpdatePayment(ctx context.Context, payment *model.Payment) error {
+	defer m.lock(ctx)()
+
+	stored, ok := m.payments[payment.ID]
+	if !ok {
+		return service.ErrPaymentDoesNotExists
+	}
+
+	stored.CapturedAmount = payment.CapturedAmount
+	stored.RefundedAmount = payment.RefundedAmount
+	stored.Status = payment.Status
+	return nil
+}
+
+func (m *Memory) AddCapture(ctx context.Context, orderId string, amount int64) (*model.Payment, error) {
+	return m.changePayment(ctx, orderId, func(payment *model.Payment) error {
+		return service.ApplyCapture(payment, amount)
+	})
+}
+
+func (m *Memory) AddRefund(ctx context.Context, orderId string, amount int64) (*model.Payment, error) {
+	return m.changePayment(ctx, orderId, func(payment *model.Payment) error {
+		return service.ApplyRefund(payment, amount)
+	})
+}
+
+func (m *Memory) AddGatewayEvent(ctx context.Context, id string) error {
+	defer m.lock(ctx)()
+
+	if m.gatewayEvents[id] {
+		return service.ErrGatewayEventHandled
+	}
+	m.gatewayEvents[id] = true
+	return nil
+}
+
+func (m *Memory) changePayment(ctx context.Context, orderId string, change func(payment *model.Payment) error) (*model.Payment, error) {
+	defer m.lock(ctx)()
+
+	for _, stored := range m.payments {
+		if stored.OrderID != orderId {
+			continue
+		}
+
+		payment := *stored
+		err := change(&payment)
+		if err != nil {
+			return nil, err
+		}
+
+		*stored = payment
+		return &payment, nil
+	}
+	return nil, service.ErrPaymentDoesNotExists
+}
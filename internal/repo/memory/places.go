@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+type place struct {
+	model.Place
+	userId string
+}
+
+func (m *Memory) CreatePlace(ctx context.Context, userId string, p *model.Place, limit int) error {
+	defer m.lock(ctx)()
+
+	count := 0
+	for _, stored := range m.places {
+		if stored.userId != userId {
+			continue
+		}
+		if stored.Label == p.Label {
+			return fmt.Errorf("place: %v: %w", p.Label, service.ErrPlaceAlreadyExists)
+		}
+		count++
+	}
+	if count >= limit {
+		return service.ErrPlacesLimitExceeded
+	}
+
+	m.placeId++
+	p.ID = m.placeId
+	m.places[p.ID] = &place{*p, userId}
+	return nil
+}
+
+func (m *Memory) GetPlacesByUserId(ctx context.Context, userId string) ([]*model.Place, error) {
+	defer m.rlock(ctx)()
+
+	places := make([]*model.Place, 0)
+	for _, stored := range m.places {
+		if stored.userId == userId {
+			p := stored.Place
+			places = append(places, &p)
+		}
+	}
+	sort.Slice(places, func(i, j int) bool { return places[i].ID < places[j].ID })
+
+	return places, nil
+}
+
+func (m *Memory) GetPlaceById(ctx context.Context, userId, placeId string) (*model.Place, error) {
+	defer m.rlock(ctx)()
+
+	stored, ok := m.places[parseId(placeId)]
+	if !ok || stored.userId != userId {
+		return nil, service.ErrPlaceDoesNotExists
+	}
+
+	p := stored.Place
+	return &p, nil
+}
+
+func (m *Memory) UpdatePlaceById(ctx context.Context, userId, placeId string, p *model.Place) error {
+	defer m.lock(ctx)()
+
+	stored, ok := m.places[parseId(placeId)]
+	if !ok || stored.userId != userId {
+		return service.ErrPlaceDoesNotExists
+	}
+	for _, other := range m.places {
+		if other.userId == userId && other.ID != stored.ID && other.Label == p.Label {
+			return fmt.Errorf("place: %v: %w", p.Label, service.ErrPlaceAlreadyExists)
+		}
+	}
+
+	id := stored.ID
+	stored.Place = *p
+	stored.ID = id
+	return nil
+}
+
+func (m *Memory) DeletePlaceById(ctx context.Context, userId, placeId string) error {
+	defer m.lock(ctx)()
+
+	stored, ok := m.places[parseId(placeId)]
+	if !ok || stored.userId != userId {
+		return service.ErrPlaceDoesNotExists
+	}
+
+	delete(m.places, stored.ID)
+	return nil
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+func (m *Memory) CreatePromotion(ctx context.Context, promotion *model.Promotion) error {
+	defer m.lock(ctx)()
+
+	for _, stored := range m.promotions {
+		if stored.Code == promotion.Code {
+			return fmt.Errorf("promotion: %v: %w", promotion.Code, service.ErrPromotionAlreadyExists)
+		}
+	}
+
+	m.promotionId++
+	promotion.ID = m.promotionId
+	m.promotions[promotion.ID] = copyPromotion(promotion)
+	return nil
+}
+
+func (m *Memory) GetPromotions(ctx context.Context) ([]*model.Promotion, error) {
+	defer m.rlock(ctx)()
+
+	promotions := make([]*model.Promotion, 0, len(m.promotions))
+	for _, stored := range m.promotions {
+		promotions = append(promotions, copyPromotion(stored))
+	}
+	sort.Slice(promotions, func(i, j int) bool { return promotions[i].ID < promotions[j].ID })
+
+	return promotions, nil
+}
+
+func (m *Memory) GetPromotionByCode(ctx context.Context, code string) (*model.Promotion, error) {
+	defer m.rlock(ctx)()
+
+	for _, stored := range m.promotions {
+		if stored.Code == code {
+			return copyPromotion(stored), nil
+		}
+	}
+	return nil, service.ErrPromotionDoesNotExists
+}
+
+func (m *Memory) DeactivatePromotionById(ctx context.Context, id string) error {
+	defer m.lock(ctx)()
+
+	stored, ok := m.promotions[parseId(id)]
+	if !ok {
+		return service.ErrPromotionDoesNotExists
+	}
+
+	stored.Active = false
+	return nil
+}
+
+func (m *Memory) CountRedemptions(ctx context.Context, promotionId uint64, userId string) (int, error) {
+	defer m.rlock(ctx)()
+
+	return m.countRedemptions(promotionId, userId), nil
+}
+
+func (m *Memory) RedeemPromotion(ctx context.Context, redemption *model.Redemption) error {
+	defer m.lock(ctx)()
+
+	stored, ok := m.promotions[redemption.PromotionID]
+	if !ok {
+		return service.ErrPromotionDoesNotExists
+	}
+
+	if !stored.Active {
+		return service.ErrPromotionNotApplicable
+	}
+	if stored.UsageLimit > 0 && stored.UsageCount >= stored.UsageLimit {
+		return service.ErrPromotionUsageExceeded
+	}
+	if stored.PerUserLimit > 0 && m.countRedemptions(stored.ID, redemption.UserID) >= stored.PerUserLimit {
+		return service.ErrPromotionUsageExceeded
+	}
+
+	for _, r := range m.redemptions {
+		if r.PromotionID == redemption.PromotionID && r.OrderID == redemption.OrderID {
+			return fmt.Errorf("order: %v: %w", redemption.OrderID, service.ErrPromotionAlreadyRedeemed)
+		}
+	}
+
+	m.redemptions = append(m.redemptions, *redemption)
+	stored.UsageCount++
+	return nil
+}
+
+// countRedemptions must be called with the lock held.
+func (m *Memory) countRedemptions(promotionId uint64, userId string) int {
+	count := 0
+	for _, r := range m.redemptions {
+		if r.PromotionID == promotionId && r.UserID == userId {
+			count++
+		}
+	}
+	return count
+}
+
+func copyPromotion(promotion *model.Promotion) *model.Promotion {
+	p := *promotion
+	p.TaxiClasses = append([]string{}, promotion.TaxiClasses...)
+	p.Zones = append([]string{}, promotion.Zones...)
+	return &p
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+func (m *Memory) GetUserByReferralCode(ctx context.Context, code string) (*model.User, error) {
+	defer m.rlock(ctx)()
+
+	for _, stored := range m.users {
+		if stored.Status == model.StatusCreated && stored.code == code {
+			return &model.User{
+				ID:          stored.ID,
+				Name:        stored.Name,
+				PhoneNumber: stored.PhoneNumber,
+				Email:       stored.Email,
+				DeviceID:    stored.DeviceID,
+			}, nil
+		}
+	}
+	return nil, service.ErrReferralCodeDoesNotExists
+}
+
+func (m *Memory) CountReferralsByPhoneNumber(ctx context.Context, phone string) (int, error) {
+	return m.countReferrals(ctx, func(referral *model.Referral) bool { return referral.PhoneNumber == phone }), nil
+}
+
+func (m *Memory) CountReferralsByDeviceId(ctx context.Context, deviceId string) (int, error) {
+	return m.countReferrals(ctx, func(referral *model.Referral) bool { return referral.DeviceID == deviceId }), nil
+}
+
+func (m *Memory) countReferrals(ctx context.Context, match func(*model.Referral) bool) int {
+	defer m.rlock(ctx)()
+
+	count := 0
+	for _, referral := range m.referrals {
+		if match(referral) {
+			count++
+		}
+	}
+	return count
+}
+
+func (m *Memory) GetPendingReferralByInviteeId(ctx context.Context, inviteeId string) (*model.Referral, error) {
+	defer m.rlock(ctx)()
+
+	for _, stored := range m.referrals {
+		if strconv.FormatUint(stored.InviteeID, 10) == inviteeId && stored.Status == model.ReferralPending {
+			referral := *stored
+			return &referral, nil
+		}
+	}
+	return nil, service.ErrReferralDoesNotExists
+}
+
+func (m *Memory) CompleteReferralById(ctx context.Context, referral *model.Referral) error {
+	defer m.lock(ctx)()
+
+	stored, ok := m.referrals[referral.ID]
+	if !ok || stored.Status != model.ReferralPending {
+		return service.ErrReferralDoesNotExists
+	}
+
+	rewardedAt := time.Now()
+	stored.Status = model.ReferralRewarded
+	stored.ReferrerReward = referral.ReferrerReward
+	stored.InviteeReward = referral.InviteeReward
+	stored.RewardedAt = &rewardedAt
+
+	referral.Status = stored.Status
+	referral.RewardedAt = &rewardedAt
+	return nil
+}
+
+func (m *Memory) GetReferralStatsByUserId(ctx context.Context, userId string) (*model.ReferralStats, error) {
+	defer m.rlock(ctx)()
+
+	stored, ok := m.activeUser(userId)
+	if !ok {
+		return nil, service.ErrUserDoesNotExists
+	}
+
+	stats := &model.ReferralStats{Code: stored.code}
+	for _, referral := range m.referrals {
+		if referral.ReferrerID != stored.ID {
+			continue
+		}
+		stats.Invited++
+		stats.Earned += referral.ReferrerReward
+		switch referral.Status {
+		case model.ReferralPending:
+			stats.Pending++
+		case model.ReferralRewarded:
+			stats.Rewarded++
+		case model.ReferralRejected:
+			stats.Rejected++
+		}
+	}
+	return stats, nil
+}
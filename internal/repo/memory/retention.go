@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+func (m *Memory) GetDeletedUserByPhoneNumber(ctx context.Context, phone string, since time.Time) (*service.UserSingIn, error) {
+	defer m.rlock(ctx)()
+
+	var last *user
+	for _, stored := range m.users {
+		if !restorable(stored) || stored.PhoneNumber != phone || stored.deletedAt.Before(since) {
+			continue
+		}
+		if last == nil || stored.deletedAt.After(last.deletedAt) {
+			last = stored
+		}
+	}
+	if last == nil {
+		return nil, service.ErrUserDoesNotExists
+	}
+
+	return &service.UserSingIn{
+		ID:          last.ID,
+		PhoneNumber: last.PhoneNumber,
+		Password:    last.password,
+		DeletedAt:   last.deletedAt,
+	}, nil
+}
+
+func (m *Memory) RestoreUserById(ctx context.Context, id string) error {
+	defer m.lock(ctx)()
+
+	stored, ok := m.users[parseId(id)]
+	if !ok || !restorable(stored) {
+		return service.ErrUserDoesNotExists
+	}
+
+	for _, other := range m.users {
+		if other.Status == model.StatusCreated && (other.PhoneNumber == stored.PhoneNumber || other.Email == stored.Email) {
+			return fmt.Errorf("user: %v: %w", id, service.ErrUserAlreadyExists)
+		}
+	}
+
+	stored.Status = model.StatusCreated
+	stored.deletedAt = time.Time{}
+	return nil
+}
+
+func (m *Memory) GetPurgeableUsers(ctx context.Context, before time.Time, limit int) ([]uint64, error) {
+	defer m.rlock(ctx)()
+
+	var purgeable []*user
+	for _, stored := range m.users {
+		if restorable(stored) && stored.deletedAt.Before(before) {
+			purgeable = append(purgeable, stored)
+		}
+	}
+	sort.Slice(purgeable, func(i, j int) bool {
+		if purgeable[i].deletedAt.Equal(purgeable[j].deletedAt) {
+			return purgeable[i].ID < purgeable[j].ID
+		}
+		return purgeable[i].deletedAt.Before(purgeable[j].deletedAt)
+	})
+	if len(purgeable) > limit {
+		purgeable = purgeable[:limit]
+	}
+
+	ids := make([]uint64, 0, len(purgeable))
+	for _, stored := range purgeable {
+		ids = append(ids, stored.ID)
+	}
+	return ids, nil
+}
+
+func (m *Memory) PurgeUserById(ctx context.Context, id string) error {
+	defer m.lock(ctx)()
+
+	stored, ok := m.users[parseId(id)]
+	if !ok || !restorable(stored) {
+		return service.ErrUserDoesNotExists
+	}
+
+	stored.Name = ""
+	stored.PhoneNumber = ""
+	stored.Email = ""
+	stored.DeviceID = ""
+	stored.password = ""
+	stored.purgedAt = time.Now()
+
+	for placeId, place := range m.places {
+		if place.userId == id {
+			delete(m.places, placeId)
+		}
+	}
+	for methodId, method := range m.methods {
+		if method.userId != id {
+			continue
+		}
+		delete(m.methods, methodId)
+		// Payments keep their amounts, like ON DELETE SET NULL does.
+		for _, payment := range m.payments {
+			if payment.PaymentMethodID == methodId {
+				payment.PaymentMethodID = 0
+			}
+		}
+	}
+	for _, referral := range m.referrals {
+		if referral.InviteeID == stored.ID {
+			referral.PhoneNumber = ""
+			referral.DeviceID = ""
+		}
+	}
+	return m.scrubEvents(id)
+}
+
+// scrubEvents leaves the events of the user with their type and user id
+// only. Published events and finished deliveries are dropped, pending ones
+// are sent without the rest of their payload.
+func (m *Memory) scrubEvents(id string) error {
+	payload, err := json.Marshal(map[string]string{"user_id": id})
+	if err != nil {
+		return fmt.Errorf("marshal failed: %w", err)
+	}
+
+	for eventId, event := range m.events {
+		if event.AggregateID != id {
+			continue
+		}
+
+		for deliveryId, delivery := range m.deliveries {
+			if delivery.EventID != eventId {
+				continue
+			}
+			if delivery.Status != model.DeliveryPending {
+				delete(m.deliveries, deliveryId)
+				continue
+			}
+
+			var body model.Event
+			err := json.Unmarshal(delivery.Payload, &body)
+			if err != nil {
+				return fmt.Errorf("unmarshal failed: %w", err)
+			}
+			body.Payload = payload
+			delivery.Payload, err = json.Marshal(body)
+			if err != nil {
+				return fmt.Errorf("marshal failed: %w", err)
+			}
+		}
+
+		if event.published {
+			delete(m.events, eventId)
+			continue
+		}
+		event.Payload = payload
+	}
+	return nil
+}
+
+// restorable reports whether the user is deleted but not purged.
+func restorable(stored *user) bool {
+	return stored.Status == model.StatusDeleted && stored.purgedAt.IsZero()
+}
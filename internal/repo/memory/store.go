@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/repo/cache"
+)
+
+// Store is an in-memory key-value store with the semantics of the Redis
+// one: expired keys are missing and a non-positive ttl keeps the key forever.
+type Store struct {
+	mu     sync.Mutex
+	values map[string]storedValue
+}
+
+type storedValue struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+func NewStore() *Store {
+	return &Store{
+		values: make(map[string]storedValue),
+	}
+}
+
+func (s *Store) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.get(key)
+	if !ok {
+		return nil, cache.ErrMiss
+	}
+	return append([]byte(nil), stored.value...), nil
+}
+
+func (s *Store) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.set(key, value, ttl)
+	return nil
+}
+
+func (s *Store) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.get(key); ok {
+		return false, nil
+	}
+	s.set(key, value, ttl)
+	return true, nil
+}
+
+func (s *Store) Del(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.values, key)
+	return nil
+}
+
+func (s *Store) get(key string) (storedValue, bool) {
+	stored, ok := s.values[key]
+	if !ok {
+		return storedValue{}, false
+	}
+	if !stored.expiresAt.IsZero() && !time.Now().Before(stored.expiresAt) {
+		delete(s.values, key)
+		return storedValue{}, false
+	}
+	return stored, true
+}
+
+func (s *Store) set(key string, value []byte, ttl time.Duration) {
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+	s.values[key] = storedValue{append([]byte(nil), value...), expiresAt}
+}
@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Tokens is an in-memory implementation of service.TokenRepo that keeps
+// revoked tokens until their expiration. Like in Redis, a non-positive
+// expiration keeps the token forever.
+type Tokens struct {
+	mu     sync.Mutex
+	tokens map[string]time.Time
+}
+
+func NewTokens() *Tokens {
+	return &Tokens{
+		tokens: make(map[string]time.Time),
+	}
+}
+
+func (t *Tokens) AddToken(ctx context.Context, token string, expired time.Duration) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var expiresAt time.Time
+	if expired > 0 {
+		expiresAt = time.Now().Add(expired)
+	}
+	t.tokens[token] = expiresAt
+	return nil
+}
+
+// GetToken reports whether the token is not revoked, the same way the Redis
+// implementation does.
+func (t *Tokens) GetToken(ctx context.Context, token string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	expiresAt, ok := t.tokens[token]
+	if !ok {
+		return true
+	}
+	if !expiresAt.IsZero() && !time.Now().Before(expiresAt) {
+		delete(t.tokens, token)
+		return true
+	}
+	return false
+}
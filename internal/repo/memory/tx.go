@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+)
+
+type txKey struct{}
+
+// WithinTransaction runs fn holding the write lock, so every other call
+// waits until the transaction is over. Calls made with the ctx passed to fn
+// don't lock again, nested transactions join the outer one. If fn fails the
+// state is restored from a copy taken before it ran.
+func (m *Memory) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if m.inTransaction(ctx) {
+		return fn(ctx)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := m.state.clone()
+	err := fn(context.WithValue(ctx, txKey{}, m))
+	if err != nil {
+		m.state = snapshot
+		return err
+	}
+	return nil
+}
+
+func (m *Memory) inTransaction(ctx context.Context) bool {
+	tx, ok := ctx.Value(txKey{}).(*Memory)
+	return ok && tx == m
+}
+
+// lock takes the write lock unless ctx belongs to a transaction that
+// already holds it and returns the matching unlock.
+func (m *Memory) lock(ctx context.Context) func() {
+	if m.inTransaction(ctx) {
+		return func() {}
+	}
+	m.mu.Lock()
+	return m.mu.Unlock
+}
+
+func (m *Memory) rlock(ctx context.Context) func() {
+	if m.inTransaction(ctx) {
+		return func() {}
+	}
+	m.mu.RLock()
+	return m.mu.RUnlock
+}
+
+func (s state) clone() state {
+	s.users = cloneMap(s.users)
+	s.places = cloneMap(s.places)
+	s.promotions = cloneMap(s.promotions)
+	s.redemptions = append([]model.Redemption(nil), s.redemptions...)
+	s.entries = cloneMap(s.entries)
+	s.methods = cloneMap(s.methods)
+	s.payments = cloneMap(s.payments)
+	gatewayEvents := make(map[string]bool, len(s.gatewayEvents))
+	for id := range s.gatewayEvents {
+		gatewayEvents[id] = true
+	}
+	s.gatewayEvents = gatewayEvents
+	s.referrals = cloneMap(s.referrals)
+	s.events = cloneMap(s.events)
+	s.webhooks = cloneMap(s.webhooks)
+	s.deliveries = cloneMap(s.deliveries)
+	return s
+}
+
+// cloneMap copies the values as well, stored values are updated in place.
+func cloneMap[K comparable, V any](src map[K]*V) map[K]*V {
+	dst := make(map[K]*V, len(src))
+	for k, v := range src {
+		copied := *v
+		dst[k] = &copied
+	}
+	return dst
+}
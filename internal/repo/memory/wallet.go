@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+func (m *Memory) PostEntry(ctx context.Context, entry *model.JournalEntry) error {
+	var sum int64
+	for _, posting := range entry.Postings {
+		sum += posting.Amount
+	}
+	if sum != 0 {
+		return fmt.Errorf("entry %v is unbalanced by %d", entry.IdempotencyKey, sum)
+	}
+
+	defer m.lock(ctx)()
+
+	if stored, ok := m.entries[entry.IdempotencyKey]; ok {
+		if !sameEntry(entry, stored) {
+			return fmt.Errorf("key: %v: %w", entry.IdempotencyKey, service.ErrIdempotencyKeyReused)
+		}
+		entry.ID = stored.ID
+		entry.CreatedAt = stored.CreatedAt
+		return nil
+	}
+
+	for _, posting := range entry.Postings {
+		if posting.AccountType == model.AccountWallet && posting.Amount < 0 && m.balance(posting.UserID)+posting.Amount < 0 {
+			return service.ErrInsufficientFunds
+		}
+	}
+
+	m.entryId++
+	entry.ID = m.entryId
+	entry.CreatedAt = time.Now()
+
+	stored := *entry
+	stored.Postings = append([]model.Posting{}, entry.Postings...)
+	m.entries[entry.IdempotencyKey] = &stored
+	return nil
+}
+
+func sameEntry(a, b *model.JournalEntry) bool {
+	if a.Kind != b.Kind || len(a.Postings) != len(b.Postings) {
+		return false
+	}
+	for i := range a.Postings {
+		if a.Postings[i] != b.Postings[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Memory) GetBalance(ctx context.Context, userId string) (int64, error) {
+	defer m.rlock(ctx)()
+
+	return m.balance(userId), nil
+}
+
+// balance must be called with the lock held.
+func (m *Memory) balance(userId string) int64 {
+	var balance int64
+	for _, entry := range m.entries {
+		for _, posting := range entry.Postings {
+			if posting.AccountType == model.AccountWallet && posting.UserID == userId {
+				balance += posting.Amount
+			}
+		}
+	}
+	return balance
+}
+
+func (m *Memory) GetTransactions(ctx context.Context, userId string, limit, offset int) ([]*model.Transaction, error) {
+	defer m.rlock(ctx)()
+
+	transactions := make([]*model.Transaction, 0)
+	for _, entry := range m.entries {
+		for _, posting := range entry.Postings {
+			if posting.AccountType == model.AccountWallet && posting.UserID == userId {
+				transactions = append(transactions, &model.Transaction{
+					ID:          entry.ID,
+					Kind:        entry.Kind,
+					Description: entry.Description,
+					Amount:      posting.Amount,
+					CreatedAt:   entry.CreatedAt,
+				})
+			}
+		}
+	}
+	sort.SliceStable(transactions, func(i, j int) bool { return transactions[i].ID > transactions[j].ID })
+
+	if offset >= len(transactions) {
+		return []*model.Transaction{}, nil
+	}
+	transactions = transactions[offset:]
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+	}
+	return transactions, nil
+}
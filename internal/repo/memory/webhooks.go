@@ -0,0 +1,226 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+func (m *Memory) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	defer m.lock(ctx)()
+
+	m.webhookId++
+	webhook.ID = m.webhookId
+	webhook.CreatedAt = time.Now()
+	m.webhooks[webhook.ID] = copyWebhook(webhook)
+	return nil
+}
+
+func (m *Memory) GetWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	defer m.rlock(ctx)()
+
+	webhooks := make([]*model.Webhook, 0, len(m.webhooks))
+	for _, stored := range m.webhooks {
+		webhooks = append(webhooks, copyWebhook(stored))
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+
+	return webhooks, nil
+}
+
+func (m *Memory) GetWebhookById(ctx context.Context, id string) (*model.Webhook, error) {
+	defer m.rlock(ctx)()
+
+	stored, ok := m.webhooks[parseId(id)]
+	if !ok {
+		return nil, service.ErrWebhookDoesNotExists
+	}
+	return copyWebhook(stored), nil
+}
+
+func (m *Memory) UpdateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	defer m.lock(ctx)()
+
+	stored, ok := m.webhooks[webhook.ID]
+	if !ok {
+		return service.ErrWebhookDoesNotExists
+	}
+
+	updated := copyWebhook(webhook)
+	updated.CreatedAt = stored.CreatedAt
+	m.webhooks[webhook.ID] = updated
+	return nil
+}
+
+func (m *Memory) DeleteWebhookById(ctx context.Context, id string) error {
+	defer m.lock(ctx)()
+
+	webhookId := parseId(id)
+	if _, ok := m.webhooks[webhookId]; !ok {
+		return service.ErrWebhookDoesNotExists
+	}
+
+	delete(m.webhooks, webhookId)
+	for deliveryId, delivery := range m.deliveries {
+		if delivery.WebhookID == webhookId {
+			delete(m.deliveries, deliveryId)
+		}
+	}
+	return nil
+}
+
+func (m *Memory) AddPartnerEmployee(ctx context.Context, partnerId uint64, userId string) error {
+	defer m.lock(ctx)()
+
+	u, ok := m.users[parseId(userId)]
+	if !ok || u.Status != model.StatusCreated {
+		return service.ErrUserDoesNotExists
+	}
+	u.partnerID = partnerId
+	return nil
+}
+
+func (m *Memory) RemovePartnerEmployee(ctx context.Context, partnerId uint64, userId string) error {
+	defer m.lock(ctx)()
+
+	u, ok := m.users[parseId(userId)]
+	if !ok || u.partnerID == 0 || u.partnerID != partnerId {
+		return service.ErrEmployeeDoesNotExists
+	}
+	u.partnerID = 0
+	return nil
+}
+
+func (m *Memory) AddWebhookDeliveries(ctx context.Context, eventId uint64, eventType, userId string, body []byte) error {
+	defer m.lock(ctx)()
+
+	u, ok := m.users[parseId(userId)]
+	if !ok || u.partnerID == 0 {
+		return nil
+	}
+
+	queued := make(map[uint64]bool)
+	for _, delivery := range m.deliveries {
+		if delivery.EventID == eventId {
+			queued[delivery.WebhookID] = true
+		}
+	}
+
+	ids := make([]uint64, 0, len(m.webhooks))
+	for id := range m.webhooks {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	now := time.Now()
+	for _, id := range ids {
+		webhook := m.webhooks[id]
+		if !webhook.Active || webhook.PartnerID != u.partnerID || queued[id] || !contains(webhook.EventTypes, eventType) {
+			continue
+		}
+
+		m.deliveryId++
+		m.deliveries[m.deliveryId] = &model.WebhookDelivery{
+			ID:            m.deliveryId,
+			WebhookID:     id,
+			EventID:       eventId,
+			EventType:     eventType,
+			Payload:       append([]byte(nil), body...),
+			Status:        model.DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		}
+	}
+	return nil
+}
+
+func (m *Memory) GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	defer m.rlock(ctx)()
+
+	deliveries := make([]*model.WebhookDelivery, 0)
+	for _, stored := range m.deliveries {
+		if stored.Status == model.DeliveryPending && !stored.NextAttemptAt.After(now) {
+			delivery := *stored
+			deliveries = append(deliveries, &delivery)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID < deliveries[j].ID })
+
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+func (m *Memory) UpdateWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	defer m.lock(ctx)()
+
+	stored, ok := m.deliveries[delivery.ID]
+	if !ok {
+		return nil
+	}
+
+	stored.Status = delivery.Status
+	stored.Attempts = delivery.Attempts
+	stored.NextAttemptAt = delivery.NextAttemptAt
+	stored.LastError = delivery.LastError
+	stored.ResponseStatus = delivery.ResponseStatus
+	stored.DeliveredAt = delivery.DeliveredAt
+	return nil
+}
+
+func (m *Memory) GetWebhookDeliveries(ctx context.Context, webhookId, status string, limit, offset int) ([]*model.WebhookDelivery, error) {
+	defer m.rlock(ctx)()
+
+	id := parseId(webhookId)
+	deliveries := make([]*model.WebhookDelivery, 0)
+	for _, stored := range m.deliveries {
+		if stored.WebhookID == id && (status == "" || stored.Status == status) {
+			delivery := *stored
+			deliveries = append(deliveries, &delivery)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID > deliveries[j].ID })
+
+	if offset >= len(deliveries) {
+		return []*model.WebhookDelivery{}, nil
+	}
+	deliveries = deliveries[offset:]
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+func (m *Memory) RetryWebhookDelivery(ctx context.Context, webhookId, deliveryId string, now time.Time) error {
+	defer m.lock(ctx)()
+
+	stored, ok := m.deliveries[parseId(deliveryId)]
+	if !ok || stored.WebhookID != parseId(webhookId) || stored.Status != model.DeliveryDead {
+		return service.ErrWebhookDeliveryDoesNotExists
+	}
+
+	stored.Status = model.DeliveryPending
+	stored.Attempts = 0
+	stored.NextAttemptAt = now
+	stored.LastError = ""
+	return nil
+}
+
+func copyWebhook(webhook *model.Webhook) *model.Webhook {
+	copied := *webhook
+	copied.EventTypes = append([]string(nil), webhook.EventTypes...)
+	return &copied
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS saved_places (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    label VARCHAR(30) NOT NULL,
+    latitude DOUBLE PRECISION NOT NULL,
+    longitude DOUBLE PRECISION NOT NULL,
+    address VARCHAR(255) NOT NULL,
+    notes VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS saved_places_user_id_label_idx ON saved_places (user_id, label);
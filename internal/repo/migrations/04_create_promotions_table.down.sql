@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS promotion_redemptions;
+DROP TABLE IF EXISTS promotions;
+DROP TYPE IF EXISTS discount_types;
@@ -0,0 +1,29 @@
+DROP TYPE IF EXISTS discount_types;CREATE TYPE discount_types as enum ('percent', 'fixed');
+
+CREATE TABLE IF NOT EXISTS promotions (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(30) NOT NULL UNIQUE,
+    discount_type discount_types NOT NULL,
+    discount_value BIGINT NOT NULL,
+    max_discount BIGINT NOT NULL DEFAULT 0,
+    valid_from TIMESTAMPTZ NOT NULL,
+    valid_until TIMESTAMPTZ NOT NULL,
+    usage_limit INTEGER NOT NULL DEFAULT 0,
+    per_user_limit INTEGER NOT NULL DEFAULT 0,
+    usage_count INTEGER NOT NULL DEFAULT 0,
+    taxi_classes TEXT[] NOT NULL DEFAULT '{}',
+    zones TEXT[] NOT NULL DEFAULT '{}',
+    active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+CREATE TABLE IF NOT EXISTS promotion_redemptions (
+    id SERIAL PRIMARY KEY,
+    promotion_id INTEGER NOT NULL REFERENCES promotions (id),
+    user_id INTEGER NOT NULL REFERENCES users (id),
+    order_id VARCHAR(64) NOT NULL,
+    discount BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (promotion_id, order_id)
+);
+
+CREATE INDEX IF NOT EXISTS promotion_redemptions_user_id_idx ON promotion_redemptions (promotion_id, user_id);
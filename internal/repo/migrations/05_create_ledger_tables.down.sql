@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS postings;
+DROP TABLE IF EXISTS journal_entries;
+DROP FUNCTION IF EXISTS forbid_ledger_changes;
+DROP TABLE IF EXISTS accounts;
+DROP TYPE IF EXISTS entry_kinds;
+DROP TYPE IF EXISTS account_types;
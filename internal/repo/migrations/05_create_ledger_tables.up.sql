@@ -0,0 +1,39 @@
+DROP TYPE IF EXISTS account_types;CREATE TYPE account_types as enum ('wallet', 'clearing', 'revenue', 'marketing');
+DROP TYPE IF EXISTS entry_kinds;CREATE TYPE entry_kinds as enum ('top_up', 'trip_fare', 'cancellation_fee', 'refund', 'credit');
+
+CREATE TABLE IF NOT EXISTS accounts (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users (id),
+    type account_types NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS accounts_user_id_type_idx ON accounts (user_id, type) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS accounts_system_type_idx ON accounts (type) WHERE user_id IS NULL;
+
+INSERT INTO accounts (type) VALUES ('clearing'), ('revenue'), ('marketing') ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+    id SERIAL PRIMARY KEY,
+    idempotency_key VARCHAR(64) NOT NULL UNIQUE,
+    kind entry_kinds NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS postings (
+    id SERIAL PRIMARY KEY,
+    entry_id INTEGER NOT NULL REFERENCES journal_entries (id),
+    account_id INTEGER NOT NULL REFERENCES accounts (id),
+    amount BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS postings_account_id_idx ON postings (account_id);
+
+CREATE OR REPLACE FUNCTION forbid_ledger_changes() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger is append only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER journal_entries_immutable BEFORE UPDATE OR DELETE ON journal_entries FOR EACH ROW EXECUTE FUNCTION forbid_ledger_changes();
+CREATE TRIGGER postings_immutable BEFORE UPDATE OR DELETE ON postings FOR EACH ROW EXECUTE FUNCTION forbid_ledger_changes();
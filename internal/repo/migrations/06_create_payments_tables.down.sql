@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS payments;
+DROP TYPE IF EXISTS payment_states;
+DROP TABLE IF EXISTS payment_methods;
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS payment_methods (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token VARCHAR(64) NOT NULL,
+    brand VARCHAR(30) NOT NULL,
+    last4 VARCHAR(4) NOT NULL,
+    exp_month INTEGER NOT NULL,
+    exp_year INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS payment_methods_user_id_idx ON payment_methods (user_id);
+
+DROP TYPE IF EXISTS payment_states;CREATE TYPE payment_states as enum ('authorized', 'captured', 'voided', 'refunded');
+
+CREATE TABLE IF NOT EXISTS payments (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users (id),
+    order_id VARCHAR(64) NOT NULL UNIQUE,
+    payment_method_id INTEGER REFERENCES payment_methods (id) ON DELETE SET NULL,
+    authorization_id VARCHAR(64) NOT NULL UNIQUE,
+    amount BIGINT NOT NULL,
+    captured_amount BIGINT NOT NULL DEFAULT 0,
+    refunded_amount BIGINT NOT NULL DEFAULT 0,
+    status payment_states NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
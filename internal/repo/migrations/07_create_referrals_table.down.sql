@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS referrals;
+DROP TYPE IF EXISTS referral_states;
+
+DROP INDEX IF EXISTS users_referral_code_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS device_id;
+ALTER TABLE users DROP COLUMN IF EXISTS referral_code;
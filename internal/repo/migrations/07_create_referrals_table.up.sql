@@ -0,0 +1,27 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS referral_code VARCHAR(16);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS device_id VARCHAR(64) NOT NULL DEFAULT '';
+
+UPDATE users SET referral_code = upper(substr(md5(id::text || random()::text), 1, 8)) WHERE referral_code IS NULL;
+
+ALTER TABLE users ALTER COLUMN referral_code SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS users_referral_code_idx ON users (referral_code);
+
+DROP TYPE IF EXISTS referral_states;CREATE TYPE referral_states as enum ('pending', 'rewarded', 'rejected');
+
+CREATE TABLE IF NOT EXISTS referrals (
+    id SERIAL PRIMARY KEY,
+    referrer_id INTEGER NOT NULL REFERENCES users (id),
+    invitee_id INTEGER NOT NULL UNIQUE REFERENCES users (id),
+    phone_number VARCHAR(30) NOT NULL,
+    device_id VARCHAR(64) NOT NULL DEFAULT '',
+    status referral_states NOT NULL,
+    reason VARCHAR(64) NOT NULL DEFAULT '',
+    referrer_reward BIGINT NOT NULL DEFAULT 0,
+    invitee_reward BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    rewarded_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS referrals_referrer_id_idx ON referrals (referrer_id);
+CREATE INDEX IF NOT EXISTS referrals_phone_number_idx ON referrals (phone_number);
+CREATE INDEX IF NOT EXISTS referrals_device_id_idx ON referrals (device_id) WHERE device_id <> '';
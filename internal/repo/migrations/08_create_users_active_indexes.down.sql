@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS users_email_active_idx;
+DROP INDEX IF EXISTS users_phone_number_active_idx;
\ No newline at end of file
@@ -0,0 +1,23 @@
+-- Active users sharing a phone number or an email would fail the indexes.
+-- The migration doesn't choose which account keeps them: it fails with the
+-- ids of the users to resolve and changes nothing.
+DO $$
+DECLARE
+    duplicates text;
+BEGIN
+    SELECT string_agg(duplicate, '; ') INTO duplicates FROM (
+        SELECT 'phone number of users ' || string_agg(id::text, ', ' ORDER BY id) AS duplicate
+        FROM users WHERE status = 'created' GROUP BY phone_number HAVING COUNT(*) > 1
+        UNION ALL
+        SELECT 'email of users ' || string_agg(id::text, ', ' ORDER BY id)
+        FROM users WHERE status = 'created' GROUP BY email HAVING COUNT(*) > 1
+    ) AS d;
+
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'active users share a phone number or an email: %', duplicates
+            USING HINT = 'Resolve the duplicates, force version 7 and migrate again.';
+    END IF;
+END $$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_phone_number_active_idx ON users (phone_number) WHERE status = 'created';
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_active_idx ON users (email) WHERE status = 'created';
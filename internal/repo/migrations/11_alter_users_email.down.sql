@@ -0,0 +1 @@
+ALTER TABLE users ALTER COLUMN email TYPE VARCHAR(30);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE users ALTER COLUMN email TYPE VARCHAR(254);
\ No newline at end of file
@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS purged_at TIMESTAMPTZ;
+
+-- Users deleted before the column existed get the time of their last
+-- deletion event. Users deleted before the outbox existed keep NULL: they
+-- can't be restored and are purged on the next run.
+UPDATE users SET deleted_at = (
+    SELECT MAX(created_at) FROM outbox
+    WHERE outbox.type = 'user.deleted' AND outbox.aggregate_id = users.id::text
+) WHERE status = 'deleted';
+
+CREATE INDEX IF NOT EXISTS users_phone_number_deleted_idx ON users (phone_number) WHERE status = 'deleted' AND purged_at IS NULL;
+CREATE INDEX IF NOT EXISTS users_deleted_at_idx ON users (deleted_at) WHERE status = 'deleted' AND purged_at IS NULL;
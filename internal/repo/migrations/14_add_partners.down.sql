@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS webhooks_partner_id_idx;
+DROP INDEX IF EXISTS users_partner_id_idx;
+
+ALTER TABLE webhooks DROP COLUMN IF EXISTS partner_id;
+ALTER TABLE users DROP COLUMN IF EXISTS partner_id;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS webhook_deliveries_event_id_idx;
+DROP INDEX IF EXISTS outbox_aggregate_id_idx;
@@ -0,0 +1,6 @@
+-- Ids of the payment gateway events already handled, so replayed webhooks
+-- are acknowledged without being applied again.
+CREATE TABLE IF NOT EXISTS gateway_events (
+    id VARCHAR(128) PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,189 @@
+package mongo
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const (
+	archiveBatchSize = 1000
+
+	// defaultArchiveFileSize is used when the file size is not set.
+	defaultArchiveFileSize = 100000
+)
+
+// ArchiveStore is where the Archiver reads expiring entries from.
+type ArchiveStore interface {
+	GetExpiringLogs(ctx context.Context, before time.Time, after primitive.ObjectID, limit int) ([]bson.Raw, error)
+	DeleteLogs(ctx context.Context, ids []primitive.ObjectID) error
+}
+
+// Archiver exports log entries that expire before its next run to gzipped
+// NDJSON files in dir and deletes them, so the TTL index doesn't delete
+// them first. Entries are written as relaxed extended JSON, which
+// mongoimport reads back. A file holds up to fileSize entries.
+type Archiver struct {
+	store    ArchiveStore
+	dir      string
+	log      *zap.Logger
+	interval time.Duration
+	fileSize int
+}
+
+func NewArchiver(store ArchiveStore, dir string, log *zap.Logger, interval time.Duration, fileSize int) *Archiver {
+	if fileSize <= 0 {
+		fileSize = defaultArchiveFileSize
+	}
+	return &Archiver{store, dir, log, interval, fileSize}
+}
+
+// Run archives every interval until ctx is done.
+func (a *Archiver) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		n, paths, err := a.Archive(ctx, time.Now())
+		if err != nil {
+			a.log.Error("log archiver", zap.Int("archived", n), zap.Error(fmt.Errorf("archive failed: %w", err)))
+		} else if n > 0 {
+			a.log.Info("log archiver", zap.Int("archived", n), zap.Strings("files", paths))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Archive exports the entries that expire before now plus the interval and
+// returns how many were archived and the files. A new file is started every
+// fileSize entries and the entries of a file are deleted as soon as it is
+// written, so a run holds at most one file of ids whatever the backlog. No
+// file is created if nothing expires. If a delete fails the run stops, the
+// entries left are archived again by the next run.
+func (a *Archiver) Archive(ctx context.Context, now time.Time) (int, []string, error) {
+	before := now.Add(a.interval)
+
+	err := os.MkdirAll(a.dir, 0o750)
+	if err != nil {
+		return 0, nil, fmt.Errorf("mkdir all failed: %w", err)
+	}
+
+	var (
+		n     int
+		paths []string
+	)
+	for part := 1; ; part++ {
+		path := filepath.Join(a.dir, fmt.Sprintf("logs-%s-%d.ndjson.gz", now.UTC().Format("20060102T150405.000Z"), part))
+		ids, err := a.archiveFile(ctx, before, path)
+		if err != nil {
+			return n, paths, err
+		}
+		if len(ids) == 0 {
+			return n, paths, nil
+		}
+		paths = append(paths, path)
+
+		for start := 0; start < len(ids); start += archiveBatchSize {
+			end := start + archiveBatchSize
+			if end > len(ids) {
+				end = len(ids)
+			}
+
+			err = a.store.DeleteLogs(ctx, ids[start:end])
+			if err != nil {
+				return n + start, paths, fmt.Errorf("delete logs failed: %w", err)
+			}
+		}
+		n += len(ids)
+
+		if len(ids) < a.fileSize {
+			return n, paths, nil
+		}
+	}
+}
+
+// archiveFile writes up to fileSize of the oldest entries that expire before
+// before to path and returns their ids. Nothing is written if there are
+// none.
+func (a *Archiver) archiveFile(ctx context.Context, before time.Time, path string) ([]primitive.ObjectID, error) {
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open file failed: %w", err)
+	}
+	defer os.Remove(tmp)
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+
+	var (
+		ids   []primitive.ObjectID
+		after primitive.ObjectID
+	)
+	for len(ids) < a.fileSize {
+		limit := archiveBatchSize
+		if a.fileSize-len(ids) < limit {
+			limit = a.fileSize - len(ids)
+		}
+
+		logs, err := a.store.GetExpiringLogs(ctx, before, after, limit)
+		if err != nil {
+			return nil, fmt.Errorf("get expiring logs failed: %w", err)
+		}
+
+		for _, log := range logs {
+			line, err := bson.MarshalExtJSON(log, false, false)
+			if err != nil {
+				return nil, fmt.Errorf("marshal ext json failed: %w", err)
+			}
+			_, err = gz.Write(append(line, '\n'))
+			if err != nil {
+				return nil, fmt.Errorf("write failed: %w", err)
+			}
+
+			id, ok := log.Lookup("_id").ObjectIDOK()
+			if !ok {
+				return nil, fmt.Errorf("log without object id: %v", log.Lookup("_id"))
+			}
+			ids = append(ids, id)
+			after = id
+		}
+
+		if len(logs) < limit {
+			break
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	err = gz.Close()
+	if err != nil {
+		return nil, fmt.Errorf("gzip close failed: %w", err)
+	}
+	err = file.Sync()
+	if err != nil {
+		return nil, fmt.Errorf("sync failed: %w", err)
+	}
+	err = file.Close()
+	if err != nil {
+		return nil, fmt.Errorf("close failed: %w", err)
+	}
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return nil, fmt.Errorf("rename failed: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,152 @@
+package mongo_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/repo/mongo"
+	"github.com/go-playground/assert/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type archiveStore struct {
+	mu        sync.Mutex
+	logs      []bson.Raw
+	deleteErr error
+}
+
+func (s *archiveStore) add(t *testing.T, msg string, expireAt time.Time) {
+	raw, err := bson.Marshal(bson.M{"_id": primitive.NewObjectID(), "msg": msg, "expire_at": expireAt})
+	assert.Equal(t, err, nil)
+	s.logs = append(s.logs, raw)
+}
+
+func (s *archiveStore) GetExpiringLogs(ctx context.Context, before time.Time, after primitive.ObjectID, limit int) ([]bson.Raw, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var logs []bson.Raw
+	for _, log := range s.logs {
+		id := log.Lookup("_id").ObjectID()
+		if id.Hex() > after.Hex() && !log.Lookup("expire_at").Time().After(before) && len(logs) < limit {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func (s *archiveStore) DeleteLogs(ctx context.Context, ids []primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.deleteErr != nil {
+		return s.deleteErr
+	}
+	deleted := make(map[primitive.ObjectID]bool)
+	for _, id := range ids {
+		deleted[id] = true
+	}
+
+	logs := s.logs[:0]
+	for _, log := range s.logs {
+		if !deleted[log.Lookup("_id").ObjectID()] {
+			logs = append(logs, log)
+		}
+	}
+	s.logs = logs
+	return nil
+}
+
+func readArchive(t *testing.T, path string) []string {
+	file, err := os.Open(path)
+	assert.Equal(t, err, nil)
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	assert.Equal(t, err, nil)
+
+	var msgs []string
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		var doc bson.M
+		err := bson.UnmarshalExtJSON(scanner.Bytes(), false, &doc)
+		assert.Equal(t, err, nil)
+		msgs = append(msgs, fmt.Sprint(doc["msg"]))
+	}
+	assert.Equal(t, scanner.Err(), nil)
+	return msgs
+}
+
+func TestArchive(t *testing.T) {
+	now := time.Now()
+	store := &archiveStore{}
+	store.add(t, "expired", now.Add(-time.Hour))
+	store.add(t, "expiring", now.Add(30*time.Minute))
+	store.add(t, "kept", now.Add(2*time.Hour))
+
+	archiver := mongo.NewArchiver(store, t.TempDir(), zap.NewNop(), time.Hour, 0)
+
+	n, paths, err := archiver.Archive(context.Background(), now)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, n, 2)
+	assert.Equal(t, len(paths), 1)
+	assert.Equal(t, readArchive(t, paths[0]), []string{"expired", "expiring"})
+	assert.Equal(t, len(store.logs), 1)
+
+	n, paths, err = archiver.Archive(context.Background(), now)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, n, 0)
+	assert.Equal(t, len(paths), 0)
+}
+
+func TestArchiveRotation(t *testing.T) {
+	now := time.Now()
+	store := &archiveStore{}
+	for i := 0; i < 5; i++ {
+		store.add(t, fmt.Sprint(i), now.Add(-time.Hour))
+	}
+
+	archiver := mongo.NewArchiver(store, t.TempDir(), zap.NewNop(), time.Hour, 2)
+
+	n, paths, err := archiver.Archive(context.Background(), now)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, n, 5)
+	assert.Equal(t, len(paths), 3)
+	assert.Equal(t, readArchive(t, paths[0]), []string{"0", "1"})
+	assert.Equal(t, readArchive(t, paths[1]), []string{"2", "3"})
+	assert.Equal(t, readArchive(t, paths[2]), []string{"4"})
+	assert.Equal(t, len(store.logs), 0)
+}
+
+func TestArchiveDeleteFailed(t *testing.T) {
+	now := time.Now()
+	store := &archiveStore{deleteErr: fmt.Errorf("mongo is down")}
+	store.add(t, "expired", now.Add(-time.Hour))
+
+	dir := t.TempDir()
+	archiver := mongo.NewArchiver(store, dir, zap.NewNop(), time.Hour, 0)
+
+	_, paths, err := archiver.Archive(context.Background(), now)
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, len(paths), 1)
+	assert.Equal(t, readArchive(t, paths[0]), []string{"expired"})
+	assert.Equal(t, len(store.logs), 1)
+
+	store.deleteErr = nil
+	n, _, err := archiver.Archive(context.Background(), now.Add(time.Second))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, n, 1)
+	assert.Equal(t, len(store.logs), 0)
+
+	files, err := os.ReadDir(dir)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(files), 2)
+}
@@ -0,0 +1,166 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// logDocument is a stored log entry. Entries are sorted by _id, which grows
+// in insertion order.
+type logDocument struct {
+	ID      primitive.ObjectID `bson:"_id"`
+	Ts      time.Time          `bson:"ts"`
+	Level   string             `bson:"level"`
+	Caller  string             `bson:"caller"`
+	Msg     string             `bson:"msg"`
+	Method  string             `bson:"method"`
+	Url     string             `bson:"url"`
+	Uuid    string             `bson:"uuid"`
+	Error   string             `bson:"error"`
+	Time    string             `bson:"time"`
+	TraceId string             `bson:"trace_id"`
+	SpanId  string             `bson:"span_id"`
+}
+
+func (m *Mongo) logs() *mongo.Collection {
+	return m.client.Database(m.cfg.MONGO_DB_USERNAME).Collection("logs")
+}
+
+// CreateLogIndexes creates the indexes used by GetLogs and the TTL index that
+// deletes entries at expire_at. Existing indexes are left as they are.
+func (m *Mongo) CreateLogIndexes(ctx context.Context) error {
+	_, err := m.logs().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "expire_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		{Keys: bson.D{{Key: "ts", Value: -1}}},
+		{Keys: bson.D{{Key: "uuid", Value: 1}}},
+		{Keys: bson.D{{Key: "level", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "url", Value: 1}, {Key: "_id", Value: -1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("create many failed: %w", err)
+	}
+	return nil
+}
+
+// InsertLogs stores log documents in the logs collection.
+func (m *Mongo) InsertLogs(ctx context.Context, logs []interface{}) error {
+	_, err := m.logs().InsertMany(ctx, logs)
+	if err != nil {
+		return fmt.Errorf("insert many failed: %w", err)
+	}
+	return nil
+}
+
+func (m *Mongo) GetLogs(ctx context.Context, filter *model.LogFilter) ([]*model.Log, error) {
+	query := bson.M{}
+	if filter.Cursor != "" {
+		id, err := primitive.ObjectIDFromHex(filter.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("cursor: %v: %w", filter.Cursor, service.ErrBadLogCursor)
+		}
+		query["_id"] = bson.M{"$lt": id}
+	}
+
+	ts := bson.M{}
+	if !filter.From.IsZero() {
+		ts["$gte"] = filter.From.UTC()
+	}
+	if !filter.To.IsZero() {
+		ts["$lte"] = filter.To.UTC()
+	}
+	if len(ts) > 0 {
+		query["ts"] = ts
+	}
+
+	for key, value := range map[string]string{
+		"level":  filter.Level,
+		"method": filter.Method,
+		"url":    filter.Url,
+		"uuid":   filter.Uuid,
+	} {
+		if value != "" {
+			query[key] = value
+		}
+	}
+	if filter.Error != "" {
+		query["error"] = primitive.Regex{Pattern: regexp.QuoteMeta(filter.Error), Options: "i"}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(int64(filter.Limit))
+	cursor, err := m.logs().Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find failed: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	logs := make([]*model.Log, 0)
+	for cursor.Next(ctx) {
+		var doc logDocument
+		err := cursor.Decode(&doc)
+		if err != nil {
+			return nil, fmt.Errorf("decode failed: %w", err)
+		}
+
+		logs = append(logs, &model.Log{
+			ID:      doc.ID.Hex(),
+			Ts:      doc.Ts,
+			Level:   doc.Level,
+			Caller:  doc.Caller,
+			Msg:     doc.Msg,
+			Method:  doc.Method,
+			Url:     doc.Url,
+			Uuid:    doc.Uuid,
+			Error:   doc.Error,
+			Time:    doc.Time,
+			TraceId: doc.TraceId,
+			SpanId:  doc.SpanId,
+		})
+	}
+	err = cursor.Err()
+	if err != nil {
+		return nil, fmt.Errorf("cursor failed: %w", err)
+	}
+	return logs, nil
+}
+
+// GetExpiringLogs returns up to limit entries with _id greater than after
+// that expire before before, by _id.
+func (m *Mongo) GetExpiringLogs(ctx context.Context, before time.Time, after primitive.ObjectID, limit int) ([]bson.Raw, error) {
+	query := bson.M{
+		"_id":       bson.M{"$gt": after},
+		"expire_at": bson.M{"$lte": before.UTC()},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := m.logs().Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find failed: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var logs []bson.Raw
+	for cursor.Next(ctx) {
+		logs = append(logs, append(bson.Raw(nil), cursor.Current...))
+	}
+	err = cursor.Err()
+	if err != nil {
+		return nil, fmt.Errorf("cursor failed: %w", err)
+	}
+	return logs, nil
+}
+
+func (m *Mongo) DeleteLogs(ctx context.Context, ids []primitive.ObjectID) error {
+	_, err := m.logs().DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return fmt.Errorf("delete many failed: %w", err)
+	}
+	return nil
+}
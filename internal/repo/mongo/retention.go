@@ -0,0 +1,64 @@
+package mongo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Retention is how long log entries are kept. Entries of levels missing from
+// Levels are kept for Default, zero means forever.
+type Retention struct {
+	Default time.Duration
+	Levels  map[string]time.Duration
+}
+
+// ParseRetention reads retention in days. levels overrides days per level,
+// e.g. "debug=1,error=90".
+func ParseRetention(days int, levels string) (Retention, error) {
+	retention := Retention{
+		Default: time.Duration(days) * 24 * time.Hour,
+		Levels:  make(map[string]time.Duration),
+	}
+
+	for _, pair := range strings.Split(levels, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return Retention{}, fmt.Errorf("bad level retention: %v", pair)
+		}
+
+		var level zapcore.Level
+		err := level.UnmarshalText([]byte(strings.TrimSpace(name)))
+		if err != nil {
+			return Retention{}, fmt.Errorf("level: %v: %w", name, err)
+		}
+
+		days, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || days < 0 {
+			return Retention{}, fmt.Errorf("bad retention of level %v: %v", name, value)
+		}
+		retention.Levels[level.String()] = time.Duration(days) * 24 * time.Hour
+	}
+	return retention, nil
+}
+
+// expireAt returns when an entry of level written at ts expires, ok is false
+// if it is kept forever.
+func (r Retention) expireAt(level string, ts time.Time) (time.Time, bool) {
+	keep, found := r.Levels[level]
+	if !found {
+		keep = r.Default
+	}
+	if keep <= 0 {
+		return time.Time{}, false
+	}
+	return ts.Add(keep), true
+}
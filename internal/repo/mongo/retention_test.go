@@ -0,0 +1,61 @@
+package mongo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/repo/mongo"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestParseRetention(t *testing.T) {
+	test := []struct {
+		name      string
+		days      int
+		levels    string
+		retention mongo.Retention
+		err       bool
+	}{
+		{
+			name:      "default only",
+			days:      7,
+			retention: mongo.Retention{Default: 7 * 24 * time.Hour, Levels: map[string]time.Duration{}},
+		},
+		{
+			name:   "levels",
+			days:   7,
+			levels: "debug=1, ERROR=90,warn=0",
+			retention: mongo.Retention{Default: 7 * 24 * time.Hour, Levels: map[string]time.Duration{
+				"debug": 24 * time.Hour,
+				"error": 90 * 24 * time.Hour,
+				"warn":  0,
+			}},
+		},
+		{
+			name:   "unknown level",
+			levels: "verbose=1",
+			err:    true,
+		},
+		{
+			name:   "bad days",
+			levels: "info=week",
+			err:    true,
+		},
+		{
+			name:   "no days",
+			levels: "info",
+			err:    true,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			retention, err := mongo.ParseRetention(tt.days, tt.levels)
+			assert.Equal(t, err != nil, tt.err)
+			if tt.err {
+				return
+			}
+			assert.Equal(t, retention, tt.retention)
+		})
+	}
+}
@@ -0,0 +1,397 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Overflow policies of a full Sink.
+const (
+	// DropOldest drops the oldest queued entry to make room.
+	DropOldest string = "drop-oldest"
+	// Block makes Write wait until there is room.
+	Block string = "block"
+	// Spill appends the entry to SpillPath instead of queueing it.
+	Spill string = "spill"
+)
+
+const (
+	defaultBufferSize    = 10000
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+
+	insertTimeout = 5 * time.Second
+)
+
+var ErrSinkClosed = fmt.Errorf("sink closed")
+
+// LogStore is where the Sink inserts log documents.
+type LogStore interface {
+	InsertLogs(ctx context.Context, logs []interface{}) error
+}
+
+type SinkConfig struct {
+	// BufferSize is the number of entries that can wait to be inserted.
+	BufferSize    int
+	BatchSize     int
+	FlushInterval time.Duration
+	Overflow      string
+	SpillPath     string
+	// Retention sets expire_at of the stored entries.
+	Retention Retention
+}
+
+type SinkStats struct {
+	// Dropped counts entries lost to overflow or failed inserts.
+	Dropped uint64 `json:"dropped"`
+	// Spilled counts entries written to the spill file instead.
+	Spilled uint64 `json:"spilled"`
+	Queued  int    `json:"queued"`
+}
+
+// entry is a zap JSON log line.
+type entry struct {
+	Ts      json.RawMessage
+	Level   string
+	Caller  string
+	Msg     string
+	Method  string
+	Url     string
+	Uuid    string
+	Err     string `json:"error"`
+	Time    string
+	TraceId string `json:"trace_id"`
+	SpanId  string `json:"span_id"`
+}
+
+// Sink is a zapcore.WriteSyncer that queues log lines and inserts them in
+// batches in the background, so a slow store doesn't slow down requests.
+// The queue is flushed when it holds BatchSize entries and every
+// FlushInterval. A full queue is handled according to the overflow policy,
+// entries of failed inserts are spilled with the Spill policy and dropped
+// otherwise. Sync flushes the queue, Close stops the background flushes.
+type Sink struct {
+	store LogStore
+	cfg   SinkConfig
+
+	mu      sync.Mutex
+	notFull *sync.Cond
+	queue   [][]byte
+	closed  bool
+	spill   *os.File
+
+	// flushMu keeps batches in order when Sync races with the background
+	// flush.
+	flushMu sync.Mutex
+
+	wake    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+
+	dropped uint64
+	spilled uint64
+}
+
+func NewSink(store LogStore, cfg SinkConfig) (*Sink, error) {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = defaultBufferSize
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultFlushInterval
+	}
+	switch cfg.Overflow {
+	case "":
+		cfg.Overflow = DropOldest
+	case DropOldest, Block:
+	case Spill:
+		if cfg.SpillPath == "" {
+			return nil, fmt.Errorf("spill path is required for the %v policy", Spill)
+		}
+	default:
+		return nil, fmt.Errorf("unknown overflow policy: %v", cfg.Overflow)
+	}
+
+	s := &Sink{
+		store:   store,
+		cfg:     cfg,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	s.notFull = sync.NewCond(&s.mu)
+
+	go s.run()
+	return s, nil
+}
+
+func (s *Sink) Write(p []byte) (int, error) {
+	// zap reuses p after Write returns.
+	line := append([]byte(nil), p...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return 0, ErrSinkClosed
+	}
+
+	if len(s.queue) >= s.cfg.BufferSize {
+		switch s.cfg.Overflow {
+		case DropOldest:
+			s.queue[0] = nil
+			s.queue = s.queue[1:]
+			atomic.AddUint64(&s.dropped, 1)
+		case Block:
+			for len(s.queue) >= s.cfg.BufferSize && !s.closed {
+				s.flushSoon()
+				s.notFull.Wait()
+			}
+			if s.closed {
+				return 0, ErrSinkClosed
+			}
+		case Spill:
+			err := s.spillLines([][]byte{line})
+			if err != nil {
+				return 0, err
+			}
+			return len(p), nil
+		}
+	}
+
+	s.queue = append(s.queue, line)
+	if len(s.queue) >= s.cfg.BatchSize {
+		s.flushSoon()
+	}
+	return len(p), nil
+}
+
+// Sync inserts the entries queued so far.
+func (s *Sink) Sync() error {
+	return s.flush()
+}
+
+// Close stops the background flushes and inserts what is left. Writes after
+// Close fail with ErrSinkClosed.
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.notFull.Broadcast()
+	s.mu.Unlock()
+
+	close(s.done)
+	<-s.stopped
+
+	err := s.flush()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.spill != nil {
+		closeErr := s.spill.Close()
+		if closeErr != nil && err == nil {
+			err = fmt.Errorf("close spill file failed: %w", closeErr)
+		}
+	}
+	return err
+}
+
+func (s *Sink) Stats() SinkStats {
+	s.mu.Lock()
+	queued := len(s.queue)
+	s.mu.Unlock()
+
+	return SinkStats{
+		Dropped: atomic.LoadUint64(&s.dropped),
+		Spilled: atomic.LoadUint64(&s.spilled),
+		Queued:  queued,
+	}
+}
+
+func (s *Sink) run() {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+		_ = s.flush()
+	}
+}
+
+// flushSoon wakes the background flush up. mu must be held.
+func (s *Sink) flushSoon() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// flush inserts the entries queued when it was called, BatchSize at a time.
+// It returns the first insert error.
+func (s *Sink) flush() error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	pending := len(s.queue)
+	s.mu.Unlock()
+
+	var firstErr error
+	for pending > 0 {
+		s.mu.Lock()
+		n := s.cfg.BatchSize
+		if n > pending {
+			n = pending
+		}
+		if n > len(s.queue) {
+			// Entries were dropped to make room in the meantime.
+			n = len(s.queue)
+		}
+		batch := s.queue[:n:n]
+		s.queue = s.queue[n:]
+		s.notFull.Broadcast()
+		s.mu.Unlock()
+
+		if n == 0 {
+			break
+		}
+		pending -= n
+
+		err := s.insert(batch)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (s *Sink) insert(batch [][]byte) error {
+	docs := make([]interface{}, 0, len(batch))
+	for _, line := range batch {
+		docs = append(docs, document(line, s.cfg.Retention))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), insertTimeout)
+	defer cancel()
+
+	err := s.store.InsertLogs(ctx, docs)
+	if err == nil {
+		return nil
+	}
+
+	if s.cfg.Overflow == Spill {
+		s.mu.Lock()
+		spillErr := s.spillLines(batch)
+		s.mu.Unlock()
+		if spillErr == nil {
+			return fmt.Errorf("insert logs failed, spilled: %w", err)
+		}
+	} else {
+		atomic.AddUint64(&s.dropped, uint64(len(batch)))
+	}
+	return fmt.Errorf("insert logs failed: %w", err)
+}
+
+// spillLines appends lines to the spill file, which is opened on first use.
+// Lines that can't be written are dropped. mu must be held.
+func (s *Sink) spillLines(lines [][]byte) error {
+	if s.spill == nil {
+		file, err := os.OpenFile(s.cfg.SpillPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			atomic.AddUint64(&s.dropped, uint64(len(lines)))
+			return fmt.Errorf("open spill file failed: %w", err)
+		}
+		s.spill = file
+	}
+
+	for i, line := range lines {
+		if len(line) == 0 || line[len(line)-1] != '\n' {
+			line = append(line, '\n')
+		}
+		_, err := s.spill.Write(line)
+		if err != nil {
+			atomic.AddUint64(&s.dropped, uint64(len(lines)-i))
+			return fmt.Errorf("write spill file failed: %w", err)
+		}
+		atomic.AddUint64(&s.spilled, 1)
+	}
+	return nil
+}
+
+// document converts a log line to the stored document. Lines that aren't
+// valid JSON are stored as is. Entries without a readable timestamp get the
+// current time, so they can still be found by time. expire_at is set unless
+// the entry is kept forever.
+func document(line []byte, retention Retention) bson.M {
+	var (
+		e   entry
+		doc bson.M
+	)
+	err := json.Unmarshal(line, &e)
+	if err != nil {
+		doc = bson.M{
+			"ts":    time.Now().UTC(),
+			"raw":   string(line),
+			"error": fmt.Sprintf("unmarshal failed: %v", err),
+		}
+	} else {
+		doc = bson.M{
+			"ts":       timestamp(e.Ts),
+			"level":    e.Level,
+			"caller":   e.Caller,
+			"msg":      e.Msg,
+			"method":   e.Method,
+			"url":      e.Url,
+			"uuid":     e.Uuid,
+			"error":    e.Err,
+			"time":     e.Time,
+			"trace_id": e.TraceId,
+			"span_id":  e.SpanId,
+		}
+	}
+
+	expireAt, ok := retention.expireAt(e.Level, doc["ts"].(time.Time))
+	if ok {
+		doc["expire_at"] = expireAt
+	}
+	return doc
+}
+
+// timestamp reads a zap timestamp, which is a string with the ISO8601 and
+// RFC3339 encoders and epoch seconds with the default one.
+func timestamp(raw json.RawMessage) time.Time {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		for _, layout := range []string{"2006-01-02T15:04:05.000Z0700", time.RFC3339Nano} {
+			ts, err := time.Parse(layout, text)
+			if err == nil {
+				return ts.UTC()
+			}
+		}
+	}
+
+	var seconds float64
+	if json.Unmarshal(raw, &seconds) == nil {
+		return time.Unix(0, int64(seconds*float64(time.Second))).UTC()
+	}
+	return time.Now().UTC()
+}
@@ -0,0 +1,223 @@
+package mongo_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/repo/mongo"
+	"github.com/go-playground/assert/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type store struct {
+	mu       sync.Mutex
+	inserted []bson.M
+	batches  int
+	err      error
+}
+
+func (s *store) InsertLogs(ctx context.Context, logs []interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	s.batches++
+	for _, log := range logs {
+		s.inserted = append(s.inserted, log.(bson.M))
+	}
+	return nil
+}
+
+func (s *store) msgs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msgs := make([]string, 0, len(s.inserted))
+	for _, log := range s.inserted {
+		msgs = append(msgs, fmt.Sprint(log["msg"]))
+	}
+	return msgs
+}
+
+func line(msg string) []byte {
+	return []byte(`{"level":"info","ts":"2023-01-02T15:04:05.123+0300","msg":"` + msg + `","url":"/users/auth/sing-up","trace_id":"abc"}` + "\n")
+}
+
+func write(t *testing.T, sink *mongo.Sink, msgs ...string) {
+	for _, msg := range msgs {
+		_, err := sink.Write(line(msg))
+		assert.Equal(t, err, nil)
+	}
+}
+
+func TestSinkBatch(t *testing.T) {
+	s := &store{}
+	sink, err := mongo.NewSink(s, mongo.SinkConfig{BatchSize: 2, FlushInterval: time.Hour})
+	assert.Equal(t, err, nil)
+	defer sink.Close()
+
+	write(t, sink, "first", "second")
+
+	deadline := time.Now().Add(time.Second)
+	for len(s.msgs()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, s.msgs(), []string{"first", "second"})
+	assert.Equal(t, s.batches, 1)
+	assert.Equal(t, s.inserted[0]["trace_id"], "abc")
+	assert.Equal(t, s.inserted[0]["url"], "/users/auth/sing-up")
+	assert.Equal(t, s.inserted[0]["ts"], time.Date(2023, 1, 2, 12, 4, 5, 123000000, time.UTC))
+}
+
+func TestSinkSync(t *testing.T) {
+	s := &store{}
+	sink, err := mongo.NewSink(s, mongo.SinkConfig{BatchSize: 2, FlushInterval: time.Hour})
+	assert.Equal(t, err, nil)
+	defer sink.Close()
+
+	_, err = sink.Write([]byte("not json\n"))
+	assert.Equal(t, err, nil)
+
+	err = sink.Sync()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(s.inserted), 1)
+	assert.Equal(t, s.inserted[0]["raw"], "not json\n")
+	assert.Equal(t, s.inserted[0]["ts"].(time.Time).IsZero(), false)
+}
+
+func TestSinkDropOldest(t *testing.T) {
+	s := &store{}
+	sink, err := mongo.NewSink(s, mongo.SinkConfig{BufferSize: 2, FlushInterval: time.Hour})
+	assert.Equal(t, err, nil)
+	defer sink.Close()
+
+	write(t, sink, "first", "second", "third")
+	assert.Equal(t, sink.Stats().Dropped, uint64(1))
+
+	err = sink.Sync()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, s.msgs(), []string{"second", "third"})
+}
+
+func TestSinkBlock(t *testing.T) {
+	s := &store{}
+	sink, err := mongo.NewSink(s, mongo.SinkConfig{BufferSize: 1, FlushInterval: time.Hour, Overflow: mongo.Block})
+	assert.Equal(t, err, nil)
+	defer sink.Close()
+
+	// The second write waits for the background flush of the first one.
+	write(t, sink, "first", "second")
+
+	err = sink.Sync()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, s.msgs(), []string{"first", "second"})
+	assert.Equal(t, sink.Stats().Dropped, uint64(0))
+}
+
+func TestSinkSpill(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill.log")
+	s := &store{}
+	sink, err := mongo.NewSink(s, mongo.SinkConfig{BufferSize: 1, FlushInterval: time.Hour, Overflow: mongo.Spill, SpillPath: path})
+	assert.Equal(t, err, nil)
+
+	write(t, sink, "first", "second")
+
+	s.err = fmt.Errorf("mongo is down")
+	err = sink.Sync()
+	assert.NotEqual(t, err, nil)
+
+	err = sink.Close()
+	assert.Equal(t, err, nil)
+
+	data, err := os.ReadFile(path)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, strings.Count(string(data), "\n"), 2)
+	assert.Equal(t, strings.Contains(string(data), `"msg":"second"`), true)
+	assert.Equal(t, strings.Contains(string(data), `"msg":"first"`), true)
+	assert.Equal(t, sink.Stats(), mongo.SinkStats{Spilled: 2})
+}
+
+func TestSinkInsertFailed(t *testing.T) {
+	s := &store{err: fmt.Errorf("mongo is down")}
+	sink, err := mongo.NewSink(s, mongo.SinkConfig{FlushInterval: time.Hour})
+	assert.Equal(t, err, nil)
+	defer sink.Close()
+
+	write(t, sink, "first", "second")
+
+	err = sink.Sync()
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, sink.Stats().Dropped, uint64(2))
+}
+
+func TestSinkClose(t *testing.T) {
+	s := &store{}
+	sink, err := mongo.NewSink(s, mongo.SinkConfig{FlushInterval: time.Hour})
+	assert.Equal(t, err, nil)
+
+	write(t, sink, "first")
+
+	err = sink.Close()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, s.msgs(), []string{"first"})
+
+	_, err = sink.Write(line("second"))
+	assert.Equal(t, err, mongo.ErrSinkClosed)
+}
+
+func TestSinkConfig(t *testing.T) {
+	_, err := mongo.NewSink(&store{}, mongo.SinkConfig{Overflow: "ignore"})
+	assert.NotEqual(t, err, nil)
+
+	_, err = mongo.NewSink(&store{}, mongo.SinkConfig{Overflow: mongo.Spill})
+	assert.NotEqual(t, err, nil)
+}
+
+func TestSinkRetention(t *testing.T) {
+	s := &store{}
+	retention := mongo.Retention{Levels: map[string]time.Duration{"info": 24 * time.Hour}}
+	sink, err := mongo.NewSink(s, mongo.SinkConfig{FlushInterval: time.Hour, Retention: retention})
+	assert.Equal(t, err, nil)
+	defer sink.Close()
+
+	write(t, sink, "info")
+	_, err = sink.Write([]byte(`{"level":"error","msg":"error"}`))
+	assert.Equal(t, err, nil)
+
+	err = sink.Sync()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, s.inserted[0]["expire_at"], time.Date(2023, 1, 3, 12, 4, 5, 123000000, time.UTC))
+	_, ok := s.inserted[1]["expire_at"]
+	assert.Equal(t, ok, false)
+}
+
+func TestSinkZapEntry(t *testing.T) {
+	s := &store{}
+	sink, err := mongo.NewSink(s, mongo.SinkConfig{FlushInterval: time.Hour})
+	assert.Equal(t, err, nil)
+	defer sink.Close()
+
+	config := zap.NewProductionEncoderConfig()
+	config.EncodeTime = zapcore.ISO8601TimeEncoder
+	log := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(config), sink, zapcore.DebugLevel))
+	log.Error("get profile failed", zap.String("method", "GET"), zap.String("uuid", "5f1c"), zap.Error(fmt.Errorf("db is down")))
+
+	err = sink.Sync()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(s.inserted), 1)
+	assert.Equal(t, s.inserted[0]["level"], "error")
+	assert.Equal(t, s.inserted[0]["msg"], "get profile failed")
+	assert.Equal(t, s.inserted[0]["method"], "GET")
+	assert.Equal(t, s.inserted[0]["uuid"], "5f1c")
+	assert.Equal(t, s.inserted[0]["error"], "db is down")
+}
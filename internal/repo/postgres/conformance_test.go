@@ -0,0 +1,70 @@
+package postgres_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/RipperAcskt/innotaxi/config"
+	"github.com/RipperAcskt/innotaxi/internal/repo/conformance"
+	"github.com/RipperAcskt/innotaxi/internal/repo/postgres"
+	"github.com/golang-migrate/migrate/v4"
+)
+
+// newPostgres connects to the live database and truncates table. The
+// conformance tests are skipped unless POSTGRES_DB_HOST is set.
+func newPostgres(t *testing.T, table string) *postgres.Postgres {
+	cfg := &config.Config{
+		POSTGRES_DB_USERNAME: os.Getenv("POSTGRES_DB_USERNAME"),
+		POSTGRES_DB_PASSWORD: os.Getenv("POSTGRES_DB_PASSWORD"),
+		POSTGRES_DB_HOST:     os.Getenv("POSTGRES_DB_HOST"),
+		POSTGRES_DB_NAME:     os.Getenv("POSTGRES_DB_NAME"),
+		MIGRATE_PATH:         "file://../migrations",
+	}
+
+	postgres, err := postgres.New(cfg)
+	if err != nil {
+		t.Fatalf("postgres new failed: %v", err)
+	}
+	t.Cleanup(func() { postgres.Close() })
+
+	err = postgres.Migrate.Up()
+	if err != migrate.ErrNoChange && err != nil {
+		t.Fatalf("migrate up failed: %v", err)
+	}
+
+	_, err = postgres.DB.Exec("TRUNCATE " + table + " RESTART IDENTITY CASCADE")
+	if err != nil {
+		t.Fatalf("truncate failed: %v", err)
+	}
+	return postgres
+}
+
+func TestUserRepoConformance(t *testing.T) {
+	if os.Getenv("POSTGRES_DB_HOST") == "" {
+		t.Skip("POSTGRES_DB_HOST is not set")
+	}
+
+	conformance.UserRepo(t, func(t *testing.T) conformance.UserStore {
+		return newPostgres(t, "users")
+	})
+}
+
+func TestOutboxRepoConformance(t *testing.T) {
+	if os.Getenv("POSTGRES_DB_HOST") == "" {
+		t.Skip("POSTGRES_DB_HOST is not set")
+	}
+
+	conformance.OutboxRepo(t, func(t *testing.T) conformance.OutboxStore {
+		return newPostgres(t, "outbox")
+	})
+}
+
+func TestWebhookRepoConformance(t *testing.T) {
+	if os.Getenv("POSTGRES_DB_HOST") == "" {
+		t.Skip("POSTGRES_DB_HOST is not set")
+	}
+
+	conformance.WebhookRepo(t, func(t *testing.T) conformance.WebhookStore {
+		return newPostgres(t, "webhooks, users, outbox")
+	})
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+)
+
+func (p *Postgres) AddEvent(ctx context.Context, event *model.Event) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := p.conn(ctx).QueryRowContext(queryCtx, "INSERT INTO outbox (type, aggregate_id, payload) VALUES($1, $2, $3) RETURNING id, created_at", event.Type, event.AggregateID, []byte(event.Payload)).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("query row context failed: %w", err)
+	}
+	return nil
+}
+
+func (p *Postgres) GetPendingEvents(ctx context.Context, limit int) ([]*model.Event, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := p.conn(ctx).QueryContext(queryCtx, "SELECT id, type, aggregate_id, payload, created_at, attempts FROM outbox WHERE published_at IS NULL AND dead_at IS NULL ORDER BY id LIMIT $1", limit)
+	if err != nil {
+		return nil, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]*model.Event, 0)
+	for rows.Next() {
+		event := &model.Event{}
+		err := rows.Scan(&event.ID, &event.Type, &event.AggregateID, (*[]byte)(&event.Payload), &event.CreatedAt, &event.Attempts)
+		if err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows failed: %w", err)
+	}
+
+	return events, nil
+}
+
+func (p *Postgres) MarkEventPublished(ctx context.Context, id uint64) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := p.conn(ctx).ExecContext(queryCtx, "UPDATE outbox SET published_at = now() WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+	return nil
+}
+
+func (p *Postgres) MarkEventFailed(ctx context.Context, id uint64, reason string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := p.conn(ctx).ExecContext(queryCtx, "UPDATE outbox SET attempts = attempts + 1, last_error = $1 WHERE id = $2", reason, id)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+	return nil
+}
+
+func (p *Postgres) MarkEventDead(ctx context.Context, id uint64, reason string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := p.conn(ctx).ExecContext(queryCtx, "UPDATE outbox SET attempts = attempts + 1, last_error = $1, dead_at = now() WHERE id = $2", reason, id)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package postgres_test
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/repo/postgres"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestAddEvent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("sqlmock new failed: %v", err)
+	}
+
+	event := &model.Event{
+		Type:        model.EventUserDeleted,
+		AggregateID: "9",
+		Payload:     []byte(`{"user_id":"9"}`),
+	}
+	createdAt := time.Now()
+
+	mock.ExpectQuery("INSERT INTO outbox").
+		WithArgs(event.Type, event.AggregateID, []byte(event.Payload)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(4, createdAt))
+
+	postgres := &postgres.Postgres{
+		DB: db,
+	}
+
+	err = postgres.AddEvent(context.Background(), event)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, event.ID, uint64(4))
+	assert.Equal(t, event.CreatedAt, createdAt)
+	err = mock.ExpectationsWereMet()
+	assert.Equal(t, err, nil)
+}
+
+func TestGetPendingEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("sqlmock new failed: %v", err)
+	}
+
+	mock.ExpectQuery("SELECT (.+) FROM outbox WHERE published_at IS NULL AND dead_at IS NULL ORDER BY id LIMIT").
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type", "aggregate_id", "payload", "created_at", "attempts"}).
+			AddRow(4, model.EventUserDeleted, "9", []byte(`{"user_id":"9"}`), time.Now(), 2))
+
+	postgres := &postgres.Postgres{
+		DB: db,
+	}
+
+	events, err := postgres.GetPendingEvents(context.Background(), 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].ID, uint64(4))
+	assert.Equal(t, string(events[0].Payload), `{"user_id":"9"}`)
+	assert.Equal(t, events[0].Attempts, 2)
+	err = mock.ExpectationsWereMet()
+	assert.Equal(t, err, nil)
+}
@@ -0,0 +1,161 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/repo/postgres"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestGetPaymentByOrderId(t *testing.T) {
+	columns := []string{"id", "user_id", "order_id", "payment_method_id", "authorization_id", "amount", "captured_amount", "refunded_amount", "status", "created_at"}
+
+	test := []struct {
+		name string
+		rows *sqlmock.Rows
+		err  error
+	}{
+		{
+			name: "payment exists",
+			rows: sqlmock.NewRows(columns).AddRow(1, "1", "order-1", 3, "auth_1", 1000, 0, 0, model.PaymentAuthorized, time.Now()),
+			err:  nil,
+		},
+		{
+			name: "payment does not exist",
+			rows: sqlmock.NewRows(columns),
+			err:  service.ErrPaymentDoesNotExists,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("sqlmock new failed: %v", err)
+			}
+
+			mock.ExpectQuery("SELECT (.+) FROM payments WHERE order_id").WithArgs("order-1").WillReturnRows(tt.rows)
+
+			postgres := &postgres.Postgres{
+				DB: db,
+			}
+
+			_, err = postgres.GetPaymentByOrderId(context.Background(), "order-1")
+			assert.Equal(t, err, tt.err)
+			err = mock.ExpectationsWereMet()
+			assert.Equal(t, err, nil)
+		})
+	}
+}
+
+func TestUpdatePayment(t *testing.T) {
+	test := []struct {
+		name   string
+		result driver.Result
+		err    error
+	}{
+		{
+			name:   "payment updated",
+			result: sqlmock.NewResult(0, 1),
+			err:    nil,
+		},
+		{
+			name:   "payment does not exist",
+			result: sqlmock.NewResult(0, 0),
+			err:    service.ErrPaymentDoesNotExists,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("sqlmock new failed: %v", err)
+			}
+
+			payment := &model.Payment{ID: 1, CapturedAmount: 900, Status: model.PaymentCaptured}
+			mock.ExpectExec("UPDATE payments").WithArgs(payment.CapturedAmount, payment.RefundedAmount, payment.Status, payment.ID).WillReturnResult(tt.result)
+
+			postgres := &postgres.Postgres{
+				DB: db,
+			}
+
+			err = postgres.UpdatePayment(context.Background(), payment)
+			assert.Equal(t, err, tt.err)
+			err = mock.ExpectationsWereMet()
+			assert.Equal(t, err, nil)
+		})
+	}
+}
+
+func TestAddRefund(t *testing.T) {
+	columns := []string{"id", "user_id", "order_id", "payment_method_id", "authorization_id", "amount", "captured_amount", "refunded_amount", "status", "created_at"}
+
+	type mockBehavior func(mock sqlmock.Sqlmock)
+
+	test := []struct {
+		name         string
+		mockBehavior mockBehavior
+		err          error
+	}{
+		{
+			name: "refund",
+			mockBehavior: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT (.+) FROM payments WHERE order_id = (.+) FOR UPDATE").WithArgs("order-1").
+					WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "1", "order-1", 3, "auth_1", 1000, 900, 500, model.PaymentRefunded, time.Now()))
+				mock.ExpectExec("UPDATE payments SET captured_amount").WithArgs(int64(900), int64(900), model.PaymentRefunded, uint64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			err: nil,
+		},
+		{
+			name: "refund exceeds capture",
+			mockBehavior: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT (.+) FROM payments WHERE order_id = (.+) FOR UPDATE").WithArgs("order-1").
+					WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "1", "order-1", 3, "auth_1", 1000, 900, 600, model.PaymentRefunded, time.Now()))
+				mock.ExpectRollback()
+			},
+			err: service.ErrRefundExceedsCapture,
+		},
+		{
+			name: "not captured",
+			mockBehavior: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT (.+) FROM payments WHERE order_id = (.+) FOR UPDATE").WithArgs("order-1").
+					WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "1", "order-1", 3, "auth_1", 1000, 0, 0, model.PaymentAuthorized, time.Now()))
+				mock.ExpectRollback()
+			},
+			err: service.ErrPaymentWrongStatus,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("sqlmock new failed: %v", err)
+			}
+
+			tt.mockBehavior(mock)
+
+			postgres := &postgres.Postgres{
+				DB: db,
+			}
+
+			_, err = postgres.AddRefund(context.Background(), "order-1", 400)
+			assert.Equal(t, err, tt.err)
+			err = mock.ExpectationsWereMet()
+			assert.Equal(t, err, nil)
+		})
+	}
+}
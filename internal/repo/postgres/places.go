@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const uniqueViolation = "23505"
+
+// CreatePlace locks the user row first, so concurrent creates for one user
+// count each other's places and can't exceed the limit together.
+func (p *Postgres) CreatePlace(ctx context.Context, userId string, place *model.Place, limit int) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return p.WithinTransaction(queryCtx, func(ctx context.Context) error {
+		tx := p.conn(ctx)
+
+		var id uint64
+		err := tx.QueryRowContext(ctx, "SELECT id FROM users WHERE id = $1 FOR UPDATE", userId).Scan(&id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return service.ErrUserDoesNotExists
+			}
+			return fmt.Errorf("query row context failed: %w", err)
+		}
+
+		err = tx.QueryRowContext(ctx, "INSERT INTO saved_places (user_id, label, latitude, longitude, address, notes) SELECT $1::integer, $2::varchar, $3::double precision, $4::double precision, $5::varchar, $6::varchar WHERE (SELECT COUNT(*) FROM saved_places WHERE user_id = $1::integer) < $7 RETURNING id", userId, place.Label, place.Latitude, place.Longitude, place.Address, place.Notes, limit).Scan(&place.ID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return service.ErrPlacesLimitExceeded
+			}
+			if isUniqueViolation(err) {
+				return fmt.Errorf("place: %v: %w", place.Label, service.ErrPlaceAlreadyExists)
+			}
+			return fmt.Errorf("query row context failed: %w", err)
+		}
+		return nil
+	})
+}
+
+func (p *Postgres) GetPlacesByUserId(ctx context.Context, userId string) ([]*model.Place, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := p.conn(ctx).QueryContext(queryCtx, "SELECT id, label, latitude, longitude, address, notes FROM saved_places WHERE user_id = $1 ORDER BY id", userId)
+	if err != nil {
+		return nil, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	places := make([]*model.Place, 0)
+	for rows.Next() {
+		place := &model.Place{}
+		err := rows.Scan(&place.ID, &place.Label, &place.Latitude, &place.Longitude, &place.Address, &place.Notes)
+		if err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		places = append(places, place)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows failed: %w", err)
+	}
+
+	return places, nil
+}
+
+func (p *Postgres) GetPlaceById(ctx context.Context, userId, placeId string) (*model.Place, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	place := &model.Place{}
+	err := p.conn(ctx).QueryRowContext(queryCtx, "SELECT id, label, latitude, longitude, address, notes FROM saved_places WHERE id = $1 AND user_id = $2", placeId, userId).Scan(&place.ID, &place.Label, &place.Latitude, &place.Longitude, &place.Address, &place.Notes)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, service.ErrPlaceDoesNotExists
+		}
+		return nil, fmt.Errorf("query row context failed: %w", err)
+	}
+
+	return place, nil
+}
+
+func (p *Postgres) UpdatePlaceById(ctx context.Context, userId, placeId string, place *model.Place) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := p.conn(ctx).ExecContext(queryCtx, "UPDATE saved_places SET label = $1, latitude = $2, longitude = $3, address = $4, notes = $5 WHERE id = $6 AND user_id = $7", place.Label, place.Latitude, place.Longitude, place.Address, place.Notes, placeId, userId)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("place: %v: %w", place.Label, service.ErrPlaceAlreadyExists)
+		}
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return service.ErrPlaceDoesNotExists
+	}
+	return nil
+}
+
+func (p *Postgres) DeletePlaceById(ctx context.Context, userId, placeId string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := p.conn(ctx).ExecContext(queryCtx, "DELETE FROM saved_places WHERE id = $1 AND user_id = $2", placeId, userId)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return service.ErrPlaceDoesNotExists
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
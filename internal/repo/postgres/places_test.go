@@ -0,0 +1,149 @@
+package postgres_test
+
+import (
+	"context"
+	"log"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/repo/postgres"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestCreatePlace(t *testing.T) {
+	test := []struct {
+		name  string
+		place model.Place
+		rows  *sqlmock.Rows
+		err   error
+	}{
+		{
+			name: "add place",
+			place: model.Place{
+				Label:     "Home",
+				Latitude:  53.9,
+				Longitude: 27.56,
+				Address:   "Nezavisimosti 4",
+			},
+			rows: sqlmock.NewRows([]string{"id"}).AddRow(1),
+			err:  nil,
+		},
+		{
+			name: "limit exceeded",
+			place: model.Place{
+				Label:   "Work",
+				Address: "Kalinovskogo 10",
+			},
+			rows: sqlmock.NewRows([]string{"id"}),
+			err:  service.ErrPlacesLimitExceeded,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("sqlmock new failed: %v", err)
+			}
+
+			mock.ExpectBegin()
+			mock.ExpectQuery("SELECT id FROM users WHERE id = (.+) FOR UPDATE").WithArgs("1").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			mock.ExpectQuery("INSERT INTO saved_places").WithArgs("1", tt.place.Label, tt.place.Latitude, tt.place.Longitude, tt.place.Address, tt.place.Notes, 10).WillReturnRows(tt.rows)
+			if tt.err == nil {
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			postgres := &postgres.Postgres{
+				DB: db,
+			}
+
+			err = postgres.CreatePlace(context.Background(), "1", &tt.place, 10)
+			assert.Equal(t, err, tt.err)
+			err = mock.ExpectationsWereMet()
+			assert.Equal(t, err, nil)
+		})
+	}
+}
+
+func TestGetPlaceById(t *testing.T) {
+	test := []struct {
+		name string
+		rows *sqlmock.Rows
+		err  error
+	}{
+		{
+			name: "place exists",
+			rows: sqlmock.NewRows([]string{"id", "label", "latitude", "longitude", "address", "notes"}).
+				AddRow(2, "Home", 53.9, 27.56, "Nezavisimosti 4", ""),
+			err: nil,
+		},
+		{
+			name: "place does not exist",
+			rows: sqlmock.NewRows([]string{"id", "label", "latitude", "longitude", "address", "notes"}),
+			err:  service.ErrPlaceDoesNotExists,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("sqlmock new failed: %v", err)
+			}
+
+			mock.ExpectQuery("SELECT id, label, latitude, longitude, address, notes FROM saved_places").WithArgs("2", "1").WillReturnRows(tt.rows)
+
+			postgres := &postgres.Postgres{
+				DB: db,
+			}
+
+			_, err = postgres.GetPlaceById(context.Background(), "1", "2")
+			assert.Equal(t, err, tt.err)
+			err = mock.ExpectationsWereMet()
+			assert.Equal(t, err, nil)
+		})
+	}
+}
+
+func TestDeletePlaceById(t *testing.T) {
+	test := []struct {
+		name string
+		rows int64
+		err  error
+	}{
+		{
+			name: "place exists",
+			rows: 1,
+			err:  nil,
+		},
+		{
+			name: "place does not exist",
+			rows: 0,
+			err:  service.ErrPlaceDoesNotExists,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("sqlmock new failed: %v", err)
+			}
+
+			mock.ExpectExec("DELETE FROM saved_places").WithArgs("2", "1").WillReturnResult(sqlmock.NewResult(0, tt.rows))
+
+			postgres := &postgres.Postgres{
+				DB: db,
+			}
+
+			err = postgres.DeletePlaceById(context.Background(), "1", "2")
+			assert.Equal(t, err, tt.err)
+			err = mock.ExpectationsWereMet()
+			assert.Equal(t, err, nil)
+		})
+	}
+}
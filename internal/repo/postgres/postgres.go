@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
@@ -60,19 +62,24 @@ func (p *Postgres) Close() error {
 	return p.DB.Close()
 }
 
+// CreateUser relies on the unique indexes on phone number and email of
+// active users, so concurrent sign-ups with the same phone can't both
+// succeed.
 func (p *Postgres) CreateUser(ctx context.Context, user service.UserSingUp) error {
 	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	var name string
-	err := p.DB.QueryRowContext(queryCtx, "SELECT name FROM users WHERE (phone_number = $1 OR email = $2) AND status = $3", user.PhoneNumber, user.Email, model.StatusCreated).Scan(&name)
-	if err == nil {
-		return fmt.Errorf("user: %v: %w", user.Name, service.ErrUserAlreadyExists)
-
+	var err error
+	if user.Referral == nil {
+		_, err = p.conn(ctx).ExecContext(queryCtx, "INSERT INTO users (name, phone_number, email, password, raiting, status, referral_code, device_id) VALUES($1, $2, $3, $4, 0.0, $5, $6, $7)", user.Name, user.PhoneNumber, user.Email, []byte(user.Password), model.StatusCreated, user.Code, user.DeviceID)
+	} else {
+		referral := user.Referral
+		_, err = p.conn(ctx).ExecContext(queryCtx, "WITH invitee AS (INSERT INTO users (name, phone_number, email, password, raiting, status, referral_code, device_id) VALUES($1, $2, $3, $4, 0.0, $5, $6, $7) RETURNING id) INSERT INTO referrals (referrer_id, invitee_id, phone_number, device_id, status, reason) SELECT $8, id, $2, $7, $9, $10 FROM invitee", user.Name, user.PhoneNumber, user.Email, []byte(user.Password), model.StatusCreated, user.Code, user.DeviceID, referral.ReferrerID, referral.Status, referral.Reason)
 	}
-
-	_, err = p.DB.ExecContext(ctx, "INSERT INTO users (name, phone_number, email, password, raiting, status) VALUES($1, $2, $3, $4, 0.0, $5)", user.Name, user.PhoneNumber, user.Email, []byte(user.Password), model.StatusCreated)
 	if err != nil {
+		if isUserConflict(err) {
+			return service.ErrUserAlreadyExists
+		}
 		return fmt.Errorf("exec failed: %w", err)
 	}
 	return nil
@@ -82,7 +89,7 @@ func (p *Postgres) CheckUserByPhoneNumber(ctx context.Context, phone_number stri
 	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	row := p.DB.QueryRowContext(queryCtx, "SELECT id, phone_number, password FROM users WHERE phone_number = $1 AND status = $2", phone_number, model.StatusCreated)
+	row := p.conn(ctx).QueryRowContext(queryCtx, "SELECT id, phone_number, password FROM users WHERE phone_number = $1 AND status = $2", phone_number, model.StatusCreated)
 
 	var user service.UserSingIn
 
@@ -104,7 +111,7 @@ func (p *Postgres) GetUserById(ctx context.Context, id string) (*model.User, err
 	defer cancel()
 
 	user := &model.User{}
-	err := p.DB.QueryRowContext(queryCtx, "SELECT id, name, phone_number, email, raiting FROM users WHERE id = $1 AND status = $2", id, model.StatusCreated).Scan(&user.ID, &user.Name, &user.PhoneNumber, &user.Email, &user.Raiting)
+	err := p.conn(ctx).QueryRowContext(queryCtx, "SELECT id, name, phone_number, email, raiting, version FROM users WHERE id = $1 AND status = $2", id, model.StatusCreated).Scan(&user.ID, &user.Name, &user.PhoneNumber, &user.Email, &user.Raiting, &user.Version)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, service.ErrUserDoesNotExists
@@ -130,8 +137,11 @@ func (p *Postgres) UpdateUserById(ctx context.Context, id string, user *model.Us
 		transfer.Email = &user.Email
 	}
 
-	res, err := p.DB.ExecContext(queryCtx, "UPDATE users SET name = COALESCE($1, name), phone_number = COALESCE($2, phone_number), email = COALESCE($3, email) WHERE id = $4", transfer.Name, transfer.PhoneNumber, transfer.Email, id)
+	res, err := p.conn(ctx).ExecContext(queryCtx, "UPDATE users SET name = COALESCE($1, name), phone_number = COALESCE($2, phone_number), email = COALESCE($3, email), version = version + 1 WHERE id = $4 AND status = $5 AND ($6 = 0 OR version = $6)", transfer.Name, transfer.PhoneNumber, transfer.Email, id, model.StatusCreated, int64(user.Version))
 	if err != nil {
+		if isUserConflict(err) {
+			return fmt.Errorf("user: %v: %w", id, service.ErrUserAlreadyExists)
+		}
 		return fmt.Errorf("exec context failed: %w", err)
 	}
 
@@ -139,17 +149,37 @@ func (p *Postgres) UpdateUserById(ctx context.Context, id string, user *model.Us
 	if err != nil {
 		return fmt.Errorf("rows affected failed: %w", err)
 	}
+	if num == 0 && user.Version != 0 {
+		return p.versionMismatch(ctx, id)
+	}
 	if num == 0 {
 		return service.ErrUserDoesNotExists
 	}
 	return nil
 }
 
+// versionMismatch tells why an update expecting a version changed nothing:
+// the user is gone or has another version.
+func (p *Postgres) versionMismatch(ctx context.Context, id string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var exists bool
+	err := p.conn(ctx).QueryRowContext(queryCtx, "SELECT EXISTS (SELECT 1 FROM users WHERE id = $1 AND status = $2)", id, model.StatusCreated).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("query row context failed: %w", err)
+	}
+	if !exists {
+		return service.ErrUserDoesNotExists
+	}
+	return service.ErrUserModified
+}
+
 func (p *Postgres) DeleteUserById(ctx context.Context, id string) error {
 	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	res, err := p.DB.ExecContext(queryCtx, "UPDATE users SET status = $1 WHERE id = $2 AND status = $3", model.StatusDeleted, id, model.StatusCreated)
+	res, err := p.conn(ctx).ExecContext(queryCtx, "UPDATE users SET status = $1, deleted_at = now() WHERE id = $2 AND status = $3", model.StatusDeleted, id, model.StatusCreated)
 	if err != nil {
 		return fmt.Errorf("exec context failed: %w", err)
 	}
@@ -163,3 +193,11 @@ func (p *Postgres) DeleteUserById(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+// isUserConflict reports whether err violates the unique indexes that keep
+// phone numbers and emails of active users distinct.
+func isUserConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation &&
+		(pgErr.ConstraintName == "users_phone_number_active_idx" || pgErr.ConstraintName == "users_email_active_idx")
+}
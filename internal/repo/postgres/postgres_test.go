@@ -2,6 +2,7 @@ package postgres_test
 
 import (
 	"context"
+	"errors"
 	"log"
 	"testing"
 
@@ -10,13 +11,15 @@ import (
 	"github.com/RipperAcskt/innotaxi/internal/repo/postgres"
 	"github.com/RipperAcskt/innotaxi/internal/service"
 	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 func TestCreateUser(t *testing.T) {
 	test := []struct {
-		name string
-		user service.UserSingUp
-		err  error
+		name    string
+		user    service.UserSingUp
+		execErr error
+		err     error
 	}{
 		{
 			name: "add user",
@@ -25,9 +28,22 @@ func TestCreateUser(t *testing.T) {
 				PhoneNumber: "+7455456",
 				Email:       "ripper@algsdh",
 				Password:    "12345",
+				Code:        "K7QX2M9A",
 			},
 			err: nil,
 		},
+		{
+			name: "phone taken",
+			user: service.UserSingUp{
+				Name:        "Ivan",
+				PhoneNumber: "+7455456",
+				Email:       "ivan@algsdh",
+				Password:    "12345",
+				Code:        "K7QX2M9A",
+			},
+			execErr: &pgconn.PgError{Code: "23505", ConstraintName: "users_phone_number_active_idx"},
+			err:     service.ErrUserAlreadyExists,
+		},
 	}
 
 	for _, tt := range test {
@@ -37,17 +53,21 @@ func TestCreateUser(t *testing.T) {
 				log.Fatalf("sqlmock new failed: %v", err)
 			}
 
-			mock.ExpectQuery("SELECT name FROM users").WithArgs(tt.user.PhoneNumber, tt.user.Email, model.StatusCreated).WillReturnError(nil)
-			mock.ExpectExec("INSERT INTO users").WithArgs(tt.user.Name, tt.user.PhoneNumber, tt.user.Email, []byte(tt.user.Password), model.StatusCreated).WillReturnResult(sqlmock.NewResult(1, 1))
+			exec := mock.ExpectExec("INSERT INTO users").WithArgs(tt.user.Name, tt.user.PhoneNumber, tt.user.Email, []byte(tt.user.Password), model.StatusCreated, tt.user.Code, tt.user.DeviceID)
+			if tt.execErr != nil {
+				exec.WillReturnError(tt.execErr)
+			} else {
+				exec.WillReturnResult(sqlmock.NewResult(1, 1))
+			}
 
 			postgres := &postgres.Postgres{
 				DB: db,
 			}
 
 			err = postgres.CreateUser(context.Background(), tt.user)
-			assert.Equal(t, err, tt.err)
+			assert.Equal(t, errors.Is(err, tt.err), true)
 			err = mock.ExpectationsWereMet()
-			assert.Equal(t, err, tt.err)
+			assert.Equal(t, err, nil)
 		})
 	}
 }
@@ -90,10 +110,11 @@ func TestCheckUserByPhoneNumber(t *testing.T) {
 
 func TestUpdateUserById(t *testing.T) {
 	test := []struct {
-		name string
-		user model.User
-		rows int64
-		err  error
+		name   string
+		user   model.User
+		rows   int64
+		exists bool
+		err    error
 	}{
 		{
 			name: "user exists",
@@ -115,6 +136,26 @@ func TestUpdateUserById(t *testing.T) {
 			rows: 0,
 			err:  service.ErrUserDoesNotExists,
 		},
+		{
+			name: "version changed",
+			user: model.User{
+				Name:    "Ivan",
+				Version: 2,
+			},
+			rows:   0,
+			exists: true,
+			err:    service.ErrUserModified,
+		},
+		{
+			name: "user does not exist at version",
+			user: model.User{
+				Name:    "Ivan",
+				Version: 2,
+			},
+			rows:   0,
+			exists: false,
+			err:    service.ErrUserDoesNotExists,
+		},
 	}
 
 	for _, tt := range test {
@@ -124,7 +165,17 @@ func TestUpdateUserById(t *testing.T) {
 				log.Fatalf("sqlmock new failed: %v", err)
 			}
 
-			mock.ExpectExec("UPDATE users").WithArgs(tt.user.Name, tt.user.PhoneNumber, tt.user.Email, "0", model.StatusCreated).WillReturnError(nil).WillReturnResult(sqlmock.NewResult(tt.rows, tt.rows))
+			var phoneNumber, email interface{}
+			if tt.user.PhoneNumber != "" {
+				phoneNumber = tt.user.PhoneNumber
+			}
+			if tt.user.Email != "" {
+				email = tt.user.Email
+			}
+			mock.ExpectExec("UPDATE users").WithArgs(tt.user.Name, phoneNumber, email, "0", model.StatusCreated, int64(tt.user.Version)).WillReturnError(nil).WillReturnResult(sqlmock.NewResult(tt.rows, tt.rows))
+			if tt.rows == 0 && tt.user.Version != 0 {
+				mock.ExpectQuery("SELECT EXISTS").WithArgs("0", model.StatusCreated).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(tt.exists))
+			}
 
 			postgres := &postgres.Postgres{
 				DB: db,
@@ -156,9 +207,9 @@ func TestGetUserById(t *testing.T) {
 				log.Fatalf("sqlmock new failed: %v", err)
 			}
 
-			rows := sqlmock.NewRows([]string{"id", "name", "phone_number", "email", "raiting"}).
-				AddRow(1, "123", "123", "123", "123")
-			mock.ExpectQuery("SELECT id, name, phone_number, email, raiting FROM users").WithArgs("0", model.StatusCreated).WillReturnRows(rows)
+			rows := sqlmock.NewRows([]string{"id", "name", "phone_number", "email", "raiting", "version"}).
+				AddRow(1, "123", "123", "123", "123", 1)
+			mock.ExpectQuery("SELECT id, name, phone_number, email, raiting, version FROM users").WithArgs("0", model.StatusCreated).WillReturnRows(rows)
 
 			postgres := &postgres.Postgres{
 				DB: db,
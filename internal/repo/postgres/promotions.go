@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+const promotionColumns = "id, code, discount_type, discount_value, max_discount, valid_from, valid_until, usage_limit, per_user_limit, usage_count, array_to_string(taxi_classes, ','), array_to_string(zones, ','), active"
+
+func (p *Postgres) CreatePromotion(ctx context.Context, promotion *model.Promotion) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := p.conn(ctx).QueryRowContext(queryCtx, "INSERT INTO promotions (code, discount_type, discount_value, max_discount, valid_from, valid_until, usage_limit, per_user_limit, taxi_classes, zones, active) VALUES($1, $2, $3, $4, $5, $6, $7, $8, string_to_array($9, ','), string_to_array($10, ','), $11) RETURNING id",
+		promotion.Code, promotion.DiscountType, promotion.DiscountValue, promotion.MaxDiscount, promotion.ValidFrom, promotion.ValidUntil, promotion.UsageLimit, promotion.PerUserLimit, strings.Join(promotion.TaxiClasses, ","), strings.Join(promotion.Zones, ","), promotion.Active).Scan(&promotion.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("promotion: %v: %w", promotion.Code, service.ErrPromotionAlreadyExists)
+		}
+		return fmt.Errorf("query row context failed: %w", err)
+	}
+	return nil
+}
+
+func (p *Postgres) GetPromotions(ctx context.Context) ([]*model.Promotion, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := p.conn(ctx).QueryContext(queryCtx, "SELECT "+promotionColumns+" FROM promotions ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	promotions := make([]*model.Promotion, 0)
+	for rows.Next() {
+		promotion, err := scanPromotion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan promotion failed: %w", err)
+		}
+		promotions = append(promotions, promotion)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows failed: %w", err)
+	}
+
+	return promotions, nil
+}
+
+func (p *Postgres) GetPromotionByCode(ctx context.Context, code string) (*model.Promotion, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	promotion, err := scanPromotion(p.conn(ctx).QueryRowContext(queryCtx, "SELECT "+promotionColumns+" FROM promotions WHERE code = $1", code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, service.ErrPromotionDoesNotExists
+		}
+		return nil, fmt.Errorf("scan promotion failed: %w", err)
+	}
+
+	return promotion, nil
+}
+
+func (p *Postgres) DeactivatePromotionById(ctx context.Context, id string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := p.conn(ctx).ExecContext(queryCtx, "UPDATE promotions SET active = FALSE WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return service.ErrPromotionDoesNotExists
+	}
+	return nil
+}
+
+func (p *Postgres) CountRedemptions(ctx context.Context, promotionId uint64, userId string) (int, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var count int
+	err := p.conn(ctx).QueryRowContext(queryCtx, "SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id = $1 AND user_id = $2", promotionId, userId).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("query row context failed: %w", err)
+	}
+	return count, nil
+}
+
+func (p *Postgres) RedeemPromotion(ctx context.Context, redemption *model.Redemption) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return p.WithinTransaction(queryCtx, func(ctx context.Context) error {
+		tx := p.conn(ctx)
+
+		var (
+			active                               bool
+			usageLimit, perUserLimit, usageCount int
+		)
+		err := tx.QueryRowContext(ctx, "SELECT active, usage_limit, per_user_limit, usage_count FROM promotions WHERE id = $1 FOR UPDATE", redemption.PromotionID).Scan(&active, &usageLimit, &perUserLimit, &usageCount)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return service.ErrPromotionDoesNotExists
+			}
+			return fmt.Errorf("query row context failed: %w", err)
+		}
+
+		if !active {
+			return service.ErrPromotionNotApplicable
+		}
+		if usageLimit > 0 && usageCount >= usageLimit {
+			return service.ErrPromotionUsageExceeded
+		}
+
+		if perUserLimit > 0 {
+			var used int
+			err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id = $1 AND user_id = $2", redemption.PromotionID, redemption.UserID).Scan(&used)
+			if err != nil {
+				return fmt.Errorf("query row context failed: %w", err)
+			}
+			if used >= perUserLimit {
+				return service.ErrPromotionUsageExceeded
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO promotion_redemptions (promotion_id, user_id, order_id, discount) VALUES($1, $2, $3, $4)", redemption.PromotionID, redemption.UserID, redemption.OrderID, redemption.Discount)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return fmt.Errorf("order: %v: %w", redemption.OrderID, service.ErrPromotionAlreadyRedeemed)
+			}
+			return fmt.Errorf("exec context failed: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE promotions SET usage_count = usage_count + 1 WHERE id = $1", redemption.PromotionID)
+		if err != nil {
+			return fmt.Errorf("exec context failed: %w", err)
+		}
+
+		return nil
+	})
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPromotion(row scanner) (*model.Promotion, error) {
+	promotion := &model.Promotion{}
+	var taxiClasses, zones string
+
+	err := row.Scan(&promotion.ID, &promotion.Code, &promotion.DiscountType, &promotion.DiscountValue, &promotion.MaxDiscount, &promotion.ValidFrom, &promotion.ValidUntil, &promotion.UsageLimit, &promotion.PerUserLimit, &promotion.UsageCount, &taxiClasses, &zones, &promotion.Active)
+	if err != nil {
+		return nil, err
+	}
+
+	promotion.TaxiClasses = splitList(taxiClasses)
+	promotion.Zones = splitList(zones)
+	return promotion, nil
+}
+
+func splitList(list string) []string {
+	if list == "" {
+		return []string{}
+	}
+	return strings.Split(list, ",")
+}
@@ -0,0 +1,87 @@
+package postgres_test
+
+import (
+	"context"
+	"log"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/repo/postgres"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestRedeemPromotion(t *testing.T) {
+	redemption := model.Redemption{
+		PromotionID: 1,
+		UserID:      "1",
+		OrderID:     "order-1",
+		Discount:    300,
+	}
+
+	type mockBehavior func(mock sqlmock.Sqlmock)
+
+	test := []struct {
+		name         string
+		mockBehavior mockBehavior
+		err          error
+	}{
+		{
+			name: "redeem",
+			mockBehavior: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT active, usage_limit, per_user_limit, usage_count FROM promotions").WithArgs(redemption.PromotionID).
+					WillReturnRows(sqlmock.NewRows([]string{"active", "usage_limit", "per_user_limit", "usage_count"}).AddRow(true, 10, 1, 3))
+				mock.ExpectQuery("SELECT COUNT").WithArgs(redemption.PromotionID, redemption.UserID).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+				mock.ExpectExec("INSERT INTO promotion_redemptions").WithArgs(redemption.PromotionID, redemption.UserID, redemption.OrderID, redemption.Discount).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("UPDATE promotions SET usage_count").WithArgs(redemption.PromotionID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			err: nil,
+		},
+		{
+			name: "global limit exceeded",
+			mockBehavior: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT active, usage_limit, per_user_limit, usage_count FROM promotions").WithArgs(redemption.PromotionID).
+					WillReturnRows(sqlmock.NewRows([]string{"active", "usage_limit", "per_user_limit", "usage_count"}).AddRow(true, 10, 0, 10))
+				mock.ExpectRollback()
+			},
+			err: service.ErrPromotionUsageExceeded,
+		},
+		{
+			name: "deactivated",
+			mockBehavior: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT active, usage_limit, per_user_limit, usage_count FROM promotions").WithArgs(redemption.PromotionID).
+					WillReturnRows(sqlmock.NewRows([]string{"active", "usage_limit", "per_user_limit", "usage_count"}).AddRow(false, 0, 0, 0))
+				mock.ExpectRollback()
+			},
+			err: service.ErrPromotionNotApplicable,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("sqlmock new failed: %v", err)
+			}
+
+			tt.mockBehavior(mock)
+
+			postgres := &postgres.Postgres{
+				DB: db,
+			}
+
+			err = postgres.RedeemPromotion(context.Background(), &redemption)
+			assert.Equal(t, err, tt.err)
+			err = mock.ExpectationsWereMet()
+			assert.Equal(t, err, nil)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package postgres_test
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/repo/postgres"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestCreateUserWithReferral(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("sqlmock new failed: %v", err)
+	}
+
+	user := service.UserSingUp{
+		Name:        "Ivan",
+		PhoneNumber: "+375292222222",
+		Email:       "invitee@mail.com",
+		Password:    "12345",
+		DeviceID:    "device-2",
+		Code:        "K7QX2M9A",
+		Referral:    &model.Referral{ReferrerID: 7, Status: model.ReferralPending},
+	}
+
+	mock.ExpectExec("WITH invitee AS \\(INSERT INTO users (.+)\\) INSERT INTO referrals").
+		WithArgs(user.Name, user.PhoneNumber, user.Email, []byte(user.Password), model.StatusCreated, user.Code, user.DeviceID, user.Referral.ReferrerID, model.ReferralPending, "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	postgres := &postgres.Postgres{
+		DB: db,
+	}
+
+	err = postgres.CreateUser(context.Background(), user)
+	assert.Equal(t, err, nil)
+	err = mock.ExpectationsWereMet()
+	assert.Equal(t, err, nil)
+}
+
+func TestCompleteReferralById(t *testing.T) {
+	test := []struct {
+		name string
+		rows *sqlmock.Rows
+		err  error
+	}{
+		{
+			name: "referral rewarded",
+			rows: sqlmock.NewRows([]string{"rewarded_at"}).AddRow(time.Now()),
+			err:  nil,
+		},
+		{
+			name: "referral already rewarded",
+			rows: sqlmock.NewRows([]string{"rewarded_at"}),
+			err:  service.ErrReferralDoesNotExists,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("sqlmock new failed: %v", err)
+			}
+
+			referral := &model.Referral{ID: 4, ReferrerReward: 500, InviteeReward: 300, Status: model.ReferralPending}
+			mock.ExpectQuery("UPDATE referrals").WithArgs(model.ReferralRewarded, int64(500), int64(300), uint64(4), model.ReferralPending).WillReturnRows(tt.rows)
+
+			postgres := &postgres.Postgres{
+				DB: db,
+			}
+
+			err = postgres.CompleteReferralById(context.Background(), referral)
+			assert.Equal(t, err, tt.err)
+			if tt.err == nil {
+				assert.Equal(t, referral.Status, model.ReferralRewarded)
+			}
+			err = mock.ExpectationsWereMet()
+			assert.Equal(t, err, nil)
+		})
+	}
+}
+
+func TestGetReferralStatsByUserId(t *testing.T) {
+	test := []struct {
+		name string
+		rows *sqlmock.Rows
+		err  error
+	}{
+		{
+			name: "user exists",
+			rows: sqlmock.NewRows([]string{"referral_code", "invited", "pending", "rewarded", "rejected", "earned"}).AddRow("K7QX2M9A", 3, 1, 1, 1, 500),
+			err:  nil,
+		},
+		{
+			name: "user does not exist",
+			rows: sqlmock.NewRows([]string{"referral_code", "invited", "pending", "rewarded", "rejected", "earned"}),
+			err:  service.ErrUserDoesNotExists,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("sqlmock new failed: %v", err)
+			}
+
+			mock.ExpectQuery("SELECT (.+) FROM users u LEFT JOIN referrals r").WithArgs("1", model.StatusCreated).WillReturnRows(tt.rows)
+
+			postgres := &postgres.Postgres{
+				DB: db,
+			}
+
+			_, err = postgres.GetReferralStatsByUserId(context.Background(), "1")
+			assert.Equal(t, err, tt.err)
+			err = mock.ExpectationsWereMet()
+			assert.Equal(t, err, nil)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+func (p *Postgres) GetDeletedUserByPhoneNumber(ctx context.Context, phone string, since time.Time) (*service.UserSingIn, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var user service.UserSingIn
+	err := p.conn(ctx).QueryRowContext(queryCtx, "SELECT id, phone_number, password, deleted_at FROM users WHERE phone_number = $1 AND status = $2 AND purged_at IS NULL AND deleted_at >= $3 ORDER BY deleted_at DESC LIMIT 1", phone, model.StatusDeleted, since).Scan(&user.ID, &user.PhoneNumber, &user.Password, &user.DeletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, service.ErrUserDoesNotExists
+		}
+		return nil, fmt.Errorf("query row context failed: %w", err)
+	}
+
+	return &user, nil
+}
+
+// RestoreUserById relies on the unique indexes on phone number and email of
+// active users, like CreateUser.
+func (p *Postgres) RestoreUserById(ctx context.Context, id string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := p.conn(ctx).ExecContext(queryCtx, "UPDATE users SET status = $1, deleted_at = NULL WHERE id = $2 AND status = $3 AND purged_at IS NULL", model.StatusCreated, id, model.StatusDeleted)
+	if err != nil {
+		if isUserConflict(err) {
+			return fmt.Errorf("user: %v: %w", id, service.ErrUserAlreadyExists)
+		}
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return service.ErrUserDoesNotExists
+	}
+	return nil
+}
+
+func (p *Postgres) GetPurgeableUsers(ctx context.Context, before time.Time, limit int) ([]uint64, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := p.conn(ctx).QueryContext(queryCtx, "SELECT id FROM users WHERE status = $1 AND purged_at IS NULL AND (deleted_at IS NULL OR deleted_at < $2) ORDER BY deleted_at NULLS FIRST, id LIMIT $3", model.StatusDeleted, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uint64
+	for rows.Next() {
+		var id uint64
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows failed: %w", err)
+	}
+	return ids, nil
+}
+
+func (p *Postgres) PurgeUserById(ctx context.Context, id string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return p.WithinTransaction(queryCtx, func(ctx context.Context) error {
+		tx := p.conn(ctx)
+
+		res, err := tx.ExecContext(ctx, "UPDATE users SET name = '', phone_number = '', email = '', password = '', device_id = '', purged_at = now() WHERE id = $1 AND status = $2 AND purged_at IS NULL", id, model.StatusDeleted)
+		if err != nil {
+			return fmt.Errorf("exec context failed: %w", err)
+		}
+
+		num, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("rows affected failed: %w", err)
+		}
+		if num == 0 {
+			return service.ErrUserDoesNotExists
+		}
+
+		queries := []string{
+			"DELETE FROM saved_places WHERE user_id = $1",
+			"DELETE FROM payment_methods WHERE user_id = $1",
+			"UPDATE referrals SET phone_number = '', device_id = '' WHERE invitee_id = $1",
+			// Events keep their type and user id only. Deliveries go first,
+			// they are found through the events.
+			"UPDATE webhook_deliveries SET payload = jsonb_set(payload, '{payload}', jsonb_build_object('user_id', $1::text)) WHERE status = 'pending' AND event_id IN (SELECT id FROM outbox WHERE aggregate_id = $1::text)",
+			"DELETE FROM webhook_deliveries WHERE status <> 'pending' AND event_id IN (SELECT id FROM outbox WHERE aggregate_id = $1::text)",
+			"UPDATE outbox SET payload = jsonb_build_object('user_id', aggregate_id) WHERE aggregate_id = $1 AND published_at IS NULL",
+			"DELETE FROM outbox WHERE aggregate_id = $1 AND published_at IS NOT NULL",
+		}
+		for _, query := range queries {
+			_, err := tx.ExecContext(ctx, query, id)
+			if err != nil {
+				return fmt.Errorf("exec context failed: %w", err)
+			}
+		}
+		return nil
+	})
+}
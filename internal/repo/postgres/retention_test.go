@@ -0,0 +1,112 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/repo/postgres"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestGetDeletedUserByPhoneNumber(t *testing.T) {
+	since := time.Now().Add(-30 * 24 * time.Hour)
+
+	test := []struct {
+		name string
+		rows *sqlmock.Rows
+		err  error
+	}{
+		{
+			name: "deleted user",
+			rows: sqlmock.NewRows([]string{"id", "phone_number", "password", "deleted_at"}).AddRow(1, "+7455456", "hash", time.Now()),
+			err:  nil,
+		},
+		{
+			name: "user does not exist",
+			rows: sqlmock.NewRows([]string{"id", "phone_number", "password", "deleted_at"}),
+			err:  service.ErrUserDoesNotExists,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("sqlmock new failed: %v", err)
+			}
+
+			mock.ExpectQuery("SELECT id, phone_number, password, deleted_at FROM users").WithArgs("+7455456", model.StatusDeleted, since).WillReturnRows(tt.rows)
+
+			postgres := &postgres.Postgres{
+				DB: db,
+			}
+
+			_, err = postgres.GetDeletedUserByPhoneNumber(context.Background(), "+7455456", since)
+			assert.Equal(t, err, tt.err)
+			err = mock.ExpectationsWereMet()
+			assert.Equal(t, err, nil)
+		})
+	}
+}
+
+func TestPurgeUserById(t *testing.T) {
+	type mockBehavior func(mock sqlmock.Sqlmock)
+
+	test := []struct {
+		name         string
+		mockBehavior mockBehavior
+		err          error
+	}{
+		{
+			name: "deleted user",
+			mockBehavior: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET name = ''").WithArgs("1", model.StatusDeleted).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM saved_places").WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec("DELETE FROM payment_methods").WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE referrals").WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("UPDATE webhook_deliveries SET payload = jsonb_set(.+) WHERE status = 'pending'").WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM webhook_deliveries WHERE status <> 'pending'").WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectExec("UPDATE outbox SET payload = (.+) published_at IS NULL").WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("DELETE FROM outbox WHERE aggregate_id = \\$1 AND published_at IS NOT NULL").WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+			err: nil,
+		},
+		{
+			name: "user isn't deleted",
+			mockBehavior: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET name = ''").WithArgs("1", model.StatusDeleted).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			err: service.ErrUserDoesNotExists,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("sqlmock new failed: %v", err)
+			}
+
+			tt.mockBehavior(mock)
+
+			postgres := &postgres.Postgres{
+				DB: db,
+			}
+
+			err = postgres.PurgeUserById(context.Background(), "1")
+			assert.Equal(t, errors.Is(err, tt.err), true)
+			err = mock.ExpectationsWereMet()
+			assert.Equal(t, err, nil)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/RipperAcskt/innotaxi/internal/repo/postgres"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestWithinTransaction(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	test := []struct {
+		name         string
+		mockBehavior func(mock sqlmock.Sqlmock)
+		fn           func(ctx context.Context, p *postgres.Postgres) error
+		err          error
+	}{
+		{
+			name: "commit",
+			mockBehavior: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET status").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE users SET status").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			fn: func(ctx context.Context, p *postgres.Postgres) error {
+				err := p.DeleteUserById(ctx, "1")
+				if err != nil {
+					return err
+				}
+				return p.WithinTransaction(ctx, func(ctx context.Context) error {
+					return p.DeleteUserById(ctx, "2")
+				})
+			},
+			err: nil,
+		},
+		{
+			name: "rollback",
+			mockBehavior: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET status").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE users SET status").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			fn: func(ctx context.Context, p *postgres.Postgres) error {
+				err := p.DeleteUserById(ctx, "1")
+				if err != nil {
+					return err
+				}
+				return p.DeleteUserById(ctx, "2")
+			},
+			err: service.ErrUserDoesNotExists,
+		},
+		{
+			name: "retry serialization failure",
+			mockBehavior: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET status").WillReturnError(&pgconn.PgError{Code: "40001"})
+				mock.ExpectRollback()
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET status").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			fn: func(ctx context.Context, p *postgres.Postgres) error {
+				return p.DeleteUserById(ctx, "1")
+			},
+			err: nil,
+		},
+		{
+			name: "fn error",
+			mockBehavior: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+			},
+			fn: func(ctx context.Context, p *postgres.Postgres) error {
+				return errFailed
+			},
+			err: errFailed,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("sqlmock new failed: %v", err)
+			}
+
+			tt.mockBehavior(mock)
+
+			postgres := &postgres.Postgres{
+				DB: db,
+			}
+
+			err = postgres.WithinTransaction(context.Background(), func(ctx context.Context) error {
+				return tt.fn(ctx, postgres)
+			})
+			assert.Equal(t, errors.Is(err, tt.err), true)
+			err = mock.ExpectationsWereMet()
+			assert.Equal(t, err, nil)
+		})
+	}
+}
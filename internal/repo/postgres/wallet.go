@@ -0,0 +1,173 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+func (p *Postgres) PostEntry(ctx context.Context, entry *model.JournalEntry) error {
+	var sum int64
+	for _, posting := range entry.Postings {
+		sum += posting.Amount
+	}
+	if sum != 0 {
+		return fmt.Errorf("entry %v is unbalanced by %d", entry.IdempotencyKey, sum)
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return p.WithinTransaction(queryCtx, func(ctx context.Context) error {
+		tx := p.conn(ctx)
+
+		err := tx.QueryRowContext(ctx, "INSERT INTO journal_entries (idempotency_key, kind, description) VALUES($1, $2, $3) ON CONFLICT (idempotency_key) DO NOTHING RETURNING id, created_at", entry.IdempotencyKey, entry.Kind, entry.Description).Scan(&entry.ID, &entry.CreatedAt)
+		if err == sql.ErrNoRows {
+			return p.replayEntry(ctx, entry)
+		}
+		if err != nil {
+			return fmt.Errorf("query row context failed: %w", err)
+		}
+
+		for _, posting := range entry.Postings {
+			accountId, err := lockAccount(ctx, tx, posting)
+			if err != nil {
+				return fmt.Errorf("lock account failed: %w", err)
+			}
+
+			if posting.AccountType == model.AccountWallet && posting.Amount < 0 {
+				var balance int64
+				err = tx.QueryRowContext(ctx, "SELECT COALESCE(SUM(amount), 0) FROM postings WHERE account_id = $1", accountId).Scan(&balance)
+				if err != nil {
+					return fmt.Errorf("query row context failed: %w", err)
+				}
+				if balance+posting.Amount < 0 {
+					return service.ErrInsufficientFunds
+				}
+			}
+
+			_, err = tx.ExecContext(ctx, "INSERT INTO postings (entry_id, account_id, amount) VALUES($1, $2, $3)", entry.ID, accountId, posting.Amount)
+			if err != nil {
+				return fmt.Errorf("exec context failed: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// lockAccount returns the id of the posting's account. Wallet accounts are
+// created on first use and locked until the end of the transaction, so
+// concurrent charges can't overdraw them.
+func lockAccount(ctx context.Context, tx querier, posting model.Posting) (uint64, error) {
+	var id uint64
+
+	if posting.AccountType != model.AccountWallet {
+		err := tx.QueryRowContext(ctx, "SELECT id FROM accounts WHERE type = $1 AND user_id IS NULL", posting.AccountType).Scan(&id)
+		if err != nil {
+			return 0, fmt.Errorf("query row context failed: %w", err)
+		}
+		return id, nil
+	}
+
+	_, err := tx.ExecContext(ctx, "INSERT INTO accounts (user_id, type) VALUES($1, $2) ON CONFLICT DO NOTHING", posting.UserID, model.AccountWallet)
+	if err != nil {
+		return 0, fmt.Errorf("exec context failed: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, "SELECT id FROM accounts WHERE user_id = $1 AND type = $2 FOR UPDATE", posting.UserID, model.AccountWallet).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("query row context failed: %w", err)
+	}
+	return id, nil
+}
+
+// replayEntry loads the entry stored under the same idempotency key and
+// checks that it was posted with the same parameters.
+func (p *Postgres) replayEntry(ctx context.Context, entry *model.JournalEntry) error {
+	stored := &model.JournalEntry{}
+	err := p.conn(ctx).QueryRowContext(ctx, "SELECT id, kind, description, created_at FROM journal_entries WHERE idempotency_key = $1", entry.IdempotencyKey).Scan(&stored.ID, &stored.Kind, &stored.Description, &stored.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("query row context failed: %w", err)
+	}
+
+	rows, err := p.conn(ctx).QueryContext(ctx, "SELECT a.type, COALESCE(a.user_id::text, ''), p.amount FROM postings p JOIN accounts a ON a.id = p.account_id WHERE p.entry_id = $1 ORDER BY p.id", stored.ID)
+	if err != nil {
+		return fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var posting model.Posting
+		err := rows.Scan(&posting.AccountType, &posting.UserID, &posting.Amount)
+		if err != nil {
+			return fmt.Errorf("scan failed: %w", err)
+		}
+		stored.Postings = append(stored.Postings, posting)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows failed: %w", err)
+	}
+
+	if !sameEntry(entry, stored) {
+		return fmt.Errorf("key: %v: %w", entry.IdempotencyKey, service.ErrIdempotencyKeyReused)
+	}
+
+	entry.ID = stored.ID
+	entry.CreatedAt = stored.CreatedAt
+	return nil
+}
+
+func sameEntry(a, b *model.JournalEntry) bool {
+	if a.Kind != b.Kind || len(a.Postings) != len(b.Postings) {
+		return false
+	}
+	for i := range a.Postings {
+		if a.Postings[i] != b.Postings[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Postgres) GetBalance(ctx context.Context, userId string) (int64, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var balance int64
+	err := p.conn(ctx).QueryRowContext(queryCtx, "SELECT COALESCE(SUM(p.amount), 0) FROM postings p JOIN accounts a ON a.id = p.account_id WHERE a.user_id = $1 AND a.type = $2", userId, model.AccountWallet).Scan(&balance)
+	if err != nil {
+		return 0, fmt.Errorf("query row context failed: %w", err)
+	}
+	return balance, nil
+}
+
+func (p *Postgres) GetTransactions(ctx context.Context, userId string, limit, offset int) ([]*model.Transaction, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := p.conn(ctx).QueryContext(queryCtx, "SELECT e.id, e.kind, e.description, p.amount, e.created_at FROM postings p JOIN accounts a ON a.id = p.account_id JOIN journal_entries e ON e.id = p.entry_id WHERE a.user_id = $1 AND a.type = $2 ORDER BY e.id DESC LIMIT $3 OFFSET $4", userId, model.AccountWallet, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	transactions := make([]*model.Transaction, 0)
+	for rows.Next() {
+		transaction := &model.Transaction{}
+		err := rows.Scan(&transaction.ID, &transaction.Kind, &transaction.Description, &transaction.Amount, &transaction.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		transactions = append(transactions, transaction)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows failed: %w", err)
+	}
+
+	return transactions, nil
+}
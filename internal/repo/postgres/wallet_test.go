@@ -0,0 +1,119 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/repo/postgres"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestPostEntry(t *testing.T) {
+	charge := func() *model.JournalEntry {
+		return &model.JournalEntry{
+			IdempotencyKey: "order-1-fare",
+			Kind:           model.EntryTripFare,
+			Description:    "trip fare for order order-1",
+			Postings: []model.Posting{
+				{AccountType: model.AccountWallet, UserID: "1", Amount: -700},
+				{AccountType: model.AccountRevenue, Amount: 700},
+			},
+		}
+	}
+
+	type mockBehavior func(mock sqlmock.Sqlmock)
+
+	test := []struct {
+		name         string
+		entry        *model.JournalEntry
+		mockBehavior mockBehavior
+		err          error
+	}{
+		{
+			name:  "charge",
+			entry: charge(),
+			mockBehavior: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO journal_entries").WithArgs("order-1-fare", model.EntryTripFare, "trip fare for order order-1").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+				mock.ExpectExec("INSERT INTO accounts").WithArgs("1", model.AccountWallet).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT id FROM accounts WHERE user_id").WithArgs("1", model.AccountWallet).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+				mock.ExpectQuery("SELECT COALESCE").WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(1000))
+				mock.ExpectExec("INSERT INTO postings").WithArgs(1, 4, -700).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectQuery("SELECT id FROM accounts WHERE type").WithArgs(model.AccountRevenue).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				mock.ExpectExec("INSERT INTO postings").WithArgs(1, 2, 700).WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectCommit()
+			},
+			err: nil,
+		},
+		{
+			name:  "insufficient funds",
+			entry: charge(),
+			mockBehavior: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO journal_entries").WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+				mock.ExpectExec("INSERT INTO accounts").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT id FROM accounts WHERE user_id").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+				mock.ExpectQuery("SELECT COALESCE").WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(500))
+				mock.ExpectRollback()
+			},
+			err: service.ErrInsufficientFunds,
+		},
+		{
+			name:  "replayed key",
+			entry: charge(),
+			mockBehavior: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO journal_entries").WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}))
+				mock.ExpectQuery("SELECT id, kind, description, created_at FROM journal_entries").WithArgs("order-1-fare").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "kind", "description", "created_at"}).AddRow(1, model.EntryTripFare, "trip fare for order order-1", time.Now()))
+				mock.ExpectQuery("SELECT a.type").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"type", "user_id", "amount"}).AddRow(model.AccountWallet, "1", -700).AddRow(model.AccountRevenue, "", 700))
+				mock.ExpectCommit()
+			},
+			err: nil,
+		},
+		{
+			name:  "reused key",
+			entry: charge(),
+			mockBehavior: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO journal_entries").WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}))
+				mock.ExpectQuery("SELECT id, kind, description, created_at FROM journal_entries").WithArgs("order-1-fare").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "kind", "description", "created_at"}).AddRow(1, model.EntryTripFare, "trip fare for order order-1", time.Now()))
+				mock.ExpectQuery("SELECT a.type").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"type", "user_id", "amount"}).AddRow(model.AccountWallet, "1", -900).AddRow(model.AccountRevenue, "", 900))
+				mock.ExpectRollback()
+			},
+			err: service.ErrIdempotencyKeyReused,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("sqlmock new failed: %v", err)
+			}
+
+			tt.mockBehavior(mock)
+
+			postgres := &postgres.Postgres{
+				DB: db,
+			}
+
+			err = postgres.PostEntry(context.Background(), tt.entry)
+			assert.Equal(t, errors.Is(err, tt.err), true)
+			err = mock.ExpectationsWereMet()
+			assert.Equal(t, err, nil)
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+const (
+	webhookColumns  = "id, COALESCE(partner_id, 0), url, secret, array_to_string(event_types, ','), active, created_at"
+	deliveryColumns = "id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_error, response_status, created_at, delivered_at"
+)
+
+func (p *Postgres) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := p.conn(ctx).QueryRowContext(queryCtx, "INSERT INTO webhooks (partner_id, url, secret, event_types, active) VALUES($1, $2, $3, string_to_array($4, ','), $5) RETURNING id, created_at", webhook.PartnerID, webhook.URL, webhook.Secret, strings.Join(webhook.EventTypes, ","), webhook.Active).Scan(&webhook.ID, &webhook.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("query row context failed: %w", err)
+	}
+	return nil
+}
+
+func (p *Postgres) GetWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := p.conn(ctx).QueryContext(queryCtx, "SELECT "+webhookColumns+" FROM webhooks ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]*model.Webhook, 0)
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook failed: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows failed: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (p *Postgres) GetWebhookById(ctx context.Context, id string) (*model.Webhook, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	webhook, err := scanWebhook(p.conn(ctx).QueryRowContext(queryCtx, "SELECT "+webhookColumns+" FROM webhooks WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, service.ErrWebhookDoesNotExists
+		}
+		return nil, fmt.Errorf("scan webhook failed: %w", err)
+	}
+
+	return webhook, nil
+}
+
+func (p *Postgres) UpdateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := p.conn(ctx).ExecContext(queryCtx, "UPDATE webhooks SET partner_id = $1, url = $2, secret = $3, event_types = string_to_array($4, ','), active = $5 WHERE id = $6", webhook.PartnerID, webhook.URL, webhook.Secret, strings.Join(webhook.EventTypes, ","), webhook.Active, webhook.ID)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return service.ErrWebhookDoesNotExists
+	}
+	return nil
+}
+
+// DeleteWebhookById drops the webhook's deliveries as well.
+func (p *Postgres) DeleteWebhookById(ctx context.Context, id string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := p.conn(ctx).ExecContext(queryCtx, "DELETE FROM webhooks WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return service.ErrWebhookDoesNotExists
+	}
+	return nil
+}
+
+func (p *Postgres) AddPartnerEmployee(ctx context.Context, partnerId uint64, userId string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := p.conn(ctx).ExecContext(queryCtx, "UPDATE users SET partner_id = $1 WHERE id = $2 AND status = $3", partnerId, userId, model.StatusCreated)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return service.ErrUserDoesNotExists
+	}
+	return nil
+}
+
+func (p *Postgres) RemovePartnerEmployee(ctx context.Context, partnerId uint64, userId string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := p.conn(ctx).ExecContext(queryCtx, "UPDATE users SET partner_id = NULL WHERE id = $1 AND partner_id = $2", userId, partnerId)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return service.ErrEmployeeDoesNotExists
+	}
+	return nil
+}
+
+func (p *Postgres) AddWebhookDeliveries(ctx context.Context, eventId uint64, eventType, userId string, body []byte) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := p.conn(ctx).ExecContext(queryCtx, "INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload) SELECT webhooks.id, $1, $2, $4 FROM webhooks JOIN users ON users.partner_id = webhooks.partner_id WHERE users.id = $3 AND webhooks.active AND $2 = ANY(webhooks.event_types) ON CONFLICT (webhook_id, event_id) DO NOTHING", eventId, eventType, userId, body)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+	return nil
+}
+
+func (p *Postgres) GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return p.queryDeliveries(queryCtx, "SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE status = $1 AND next_attempt_at <= $2 ORDER BY id LIMIT $3", model.DeliveryPending, now, limit)
+}
+
+func (p *Postgres) UpdateWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := p.conn(ctx).ExecContext(queryCtx, "UPDATE webhook_deliveries SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, response_status = $5, delivered_at = $6 WHERE id = $7", delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastError, delivery.ResponseStatus, delivery.DeliveredAt, delivery.ID)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+	return nil
+}
+
+func (p *Postgres) GetWebhookDeliveries(ctx context.Context, webhookId, status string, limit, offset int) ([]*model.WebhookDelivery, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return p.queryDeliveries(queryCtx, "SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE webhook_id = $1 AND ($2 = '' OR status::text = $2) ORDER BY id DESC LIMIT $3 OFFSET $4", webhookId, status, limit, offset)
+}
+
+func (p *Postgres) RetryWebhookDelivery(ctx context.Context, webhookId, deliveryId string, now time.Time) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := p.conn(ctx).ExecContext(queryCtx, "UPDATE webhook_deliveries SET status = $1, attempts = 0, next_attempt_at = $2, last_error = '' WHERE id = $3 AND webhook_id = $4 AND status = $5", model.DeliveryPending, now, deliveryId, webhookId, model.DeliveryDead)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return service.ErrWebhookDeliveryDoesNotExists
+	}
+	return nil
+}
+
+func (p *Postgres) queryDeliveries(ctx context.Context, query string, args ...any) ([]*model.WebhookDelivery, error) {
+	rows, err := p.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*model.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan delivery failed: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows failed: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func scanWebhook(row scanner) (*model.Webhook, error) {
+	webhook := &model.Webhook{}
+	var eventTypes string
+
+	err := row.Scan(&webhook.ID, &webhook.PartnerID, &webhook.URL, &webhook.Secret, &eventTypes, &webhook.Active, &webhook.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.EventTypes = splitList(eventTypes)
+	return webhook, nil
+}
+
+func scanDelivery(row scanner) (*model.WebhookDelivery, error) {
+	delivery := &model.WebhookDelivery{}
+	var deliveredAt sql.NullTime
+
+	err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType, (*[]byte)(&delivery.Payload), &delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastError, &delivery.ResponseStatus, &delivery.CreatedAt, &deliveredAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return delivery, nil
+}
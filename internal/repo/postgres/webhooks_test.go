@@ -0,0 +1,76 @@
+package postgres_test
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/repo/postgres"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestAddWebhookDeliveries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("sqlmock new failed: %v", err)
+	}
+
+	body := []byte(`{"id":7}`)
+	mock.ExpectExec("INSERT INTO webhook_deliveries (.+) SELECT (.+) FROM webhooks JOIN users ON users.partner_id = webhooks.partner_id WHERE users.id = \\$3 AND webhooks.active AND (.+) ON CONFLICT \\(webhook_id, event_id\\) DO NOTHING").
+		WithArgs(uint64(7), model.EventUserDeleted, "9", body).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	postgres := &postgres.Postgres{
+		DB: db,
+	}
+
+	err = postgres.AddWebhookDeliveries(context.Background(), 7, model.EventUserDeleted, "9", body)
+	assert.Equal(t, err, nil)
+	err = mock.ExpectationsWereMet()
+	assert.Equal(t, err, nil)
+}
+
+func TestRetryWebhookDelivery(t *testing.T) {
+	test := []struct {
+		name     string
+		affected int64
+		err      error
+	}{
+		{
+			name:     "dead delivery",
+			affected: 1,
+			err:      nil,
+		},
+		{
+			name:     "delivery is not dead",
+			affected: 0,
+			err:      service.ErrWebhookDeliveryDoesNotExists,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("sqlmock new failed: %v", err)
+			}
+
+			mock.ExpectExec("UPDATE webhook_deliveries SET status = \\$1, attempts = 0").
+				WithArgs(model.DeliveryPending, sqlmock.AnyArg(), "3", "1", model.DeliveryDead).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+
+			postgres := &postgres.Postgres{
+				DB: db,
+			}
+
+			err = postgres.RetryWebhookDelivery(context.Background(), "1", "3", time.Now())
+			assert.Equal(t, err, tt.err)
+			err = mock.ExpectationsWereMet()
+			assert.Equal(t, err, nil)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package redis_test
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/RipperAcskt/innotaxi/config"
+	"github.com/RipperAcskt/innotaxi/internal/repo/conformance"
+	"github.com/RipperAcskt/innotaxi/internal/repo/redis"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+// TestTokenRepoConformance needs a live Redis and is skipped unless
+// REDIS_DB_HOST is set. Cases use their own names as tokens, so the
+// database isn't flushed.
+func TestTokenRepoConformance(t *testing.T) {
+	if os.Getenv("REDIS_DB_HOST") == "" {
+		t.Skip("REDIS_DB_HOST is not set")
+	}
+
+	db, _ := strconv.Atoi(os.Getenv("REDIS_DB_NAME"))
+	cfg := &config.Config{
+		REDIS_DB_HOST:     os.Getenv("REDIS_DB_HOST"),
+		REDIS_DB_PASSWORD: os.Getenv("REDIS_DB_PASSWORD"),
+		REDIS_DB_NAME:     db,
+	}
+
+	conformance.TokenRepo(t, func(t *testing.T) service.TokenRepo {
+		redis, err := redis.New(cfg)
+		if err != nil {
+			t.Fatalf("redis new failed: %v", err)
+		}
+		t.Cleanup(func() { redis.Close() })
+		return redis
+	})
+}
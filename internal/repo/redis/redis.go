@@ -1,11 +1,16 @@
 package redis
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/RipperAcskt/innotaxi/config"
+	"github.com/RipperAcskt/innotaxi/internal/repo/cache"
+	"github.com/RipperAcskt/innotaxi/internal/tracing"
 	"github.com/go-redis/redis"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Redis struct {
@@ -27,19 +32,80 @@ func New(cfg *config.Config) (*Redis, error) {
 	return &Redis{client, cfg}, nil
 }
 
-func (r *Redis) AddToken(token string, expired time.Duration) error {
+func (r *Redis) AddToken(ctx context.Context, token string, expired time.Duration) error {
+	span := command(ctx, "SET")
 	err := r.client.Set(token, true, expired).Err()
+	tracing.End(span, err)
 	if err != nil {
 		return fmt.Errorf("client set failed: %w", err)
 	}
 	return nil
 }
 
-func (r *Redis) GetToken(token string) bool {
+func (r *Redis) GetToken(ctx context.Context, token string) bool {
+	span := command(ctx, "GET")
 	val := r.client.Get(token).Val()
+	span.End()
 	return val == ""
 }
 
 func (r *Redis) Close() error {
 	return r.client.Close()
 }
+
+// Get, Set and Del implement cache.Store.
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	span := command(ctx, "GET")
+	data, err := r.client.Get(key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			span.End()
+			return nil, cache.ErrMiss
+		}
+		tracing.End(span, err)
+		return nil, fmt.Errorf("client get failed: %w", err)
+	}
+	span.End()
+	return data, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	span := command(ctx, "SET")
+	err := r.client.Set(key, value, ttl).Err()
+	tracing.End(span, err)
+	if err != nil {
+		return fmt.Errorf("client set failed: %w", err)
+	}
+	return nil
+}
+
+func (r *Redis) Del(ctx context.Context, key string) error {
+	span := command(ctx, "DEL")
+	err := r.client.Del(key).Err()
+	tracing.End(span, err)
+	if err != nil {
+		return fmt.Errorf("client del failed: %w", err)
+	}
+	return nil
+}
+
+// SetNX stores value unless key already exists and reports whether it did.
+func (r *Redis) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	span := command(ctx, "SETNX")
+	ok, err := r.client.SetNX(key, value, ttl).Result()
+	tracing.End(span, err)
+	if err != nil {
+		return false, fmt.Errorf("client set nx failed: %w", err)
+	}
+	return ok, nil
+}
+
+// command starts the span of a Redis command, it's a child of the span in
+// ctx.
+func command(ctx context.Context, name string) trace.Span {
+	_, span := tracing.Start(ctx, "redis "+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperation(name)),
+	)
+	return span
+}
@@ -0,0 +1,50 @@
+package sqlite_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/RipperAcskt/innotaxi/config"
+	"github.com/RipperAcskt/innotaxi/internal/repo/conformance"
+	"github.com/RipperAcskt/innotaxi/internal/repo/sqlite"
+	"github.com/golang-migrate/migrate/v4"
+)
+
+// newSqlite opens a migrated database in a temporary file that is removed
+// when the test ends.
+func newSqlite(t *testing.T) *sqlite.Sqlite {
+	cfg := &config.Config{
+		SQLITE_DB_PATH:      filepath.Join(t.TempDir(), "innotaxi.db"),
+		SQLITE_MIGRATE_PATH: "file://migrations",
+	}
+
+	sqlite, err := sqlite.New(cfg)
+	if err != nil {
+		t.Fatalf("sqlite new failed: %v", err)
+	}
+	t.Cleanup(func() { sqlite.Close() })
+
+	err = sqlite.Migrate.Up()
+	if err != migrate.ErrNoChange && err != nil {
+		t.Fatalf("migrate up failed: %v", err)
+	}
+	return sqlite
+}
+
+func TestUserRepoConformance(t *testing.T) {
+	conformance.UserRepo(t, func(t *testing.T) conformance.UserStore {
+		return newSqlite(t)
+	})
+}
+
+func TestOutboxRepoConformance(t *testing.T) {
+	conformance.OutboxRepo(t, func(t *testing.T) conformance.OutboxStore {
+		return newSqlite(t)
+	})
+}
+
+func TestWebhookRepoConformance(t *testing.T) {
+	conformance.WebhookRepo(t, func(t *testing.T) conformance.WebhookStore {
+		return newSqlite(t)
+	})
+}
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(30) NOT NULL,
+    phone_number VARCHAR(30) NOT NULL,
+    email VARCHAR(30) NOT NULL,
+    password BLOB NOT NULL,
+    raiting REAL NOT NULL,
+    status INTEGER NOT NULL
+);
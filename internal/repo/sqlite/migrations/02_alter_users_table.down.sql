@@ -0,0 +1,15 @@
+CREATE TABLE users_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(30) NOT NULL,
+    phone_number VARCHAR(30) NOT NULL,
+    email VARCHAR(30) NOT NULL,
+    password BLOB NOT NULL,
+    raiting REAL NOT NULL,
+    status INTEGER NOT NULL
+);
+
+INSERT INTO users_old (id, name, phone_number, email, password, raiting, status)
+SELECT id, name, phone_number, email, password, raiting, status FROM users;
+
+DROP TABLE users;
+ALTER TABLE users_old RENAME TO users;
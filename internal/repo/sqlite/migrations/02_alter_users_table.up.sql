@@ -0,0 +1,17 @@
+-- SQLite can't alter column types, so the table is rebuilt. The states enum
+-- is emulated with a CHECK constraint.
+CREATE TABLE users_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(30) NOT NULL,
+    phone_number VARCHAR(30) NOT NULL,
+    email VARCHAR(30) NOT NULL,
+    password BLOB NOT NULL,
+    raiting INTEGER NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('created', 'deleted'))
+);
+
+INSERT INTO users_new (id, name, phone_number, email, password, raiting, status)
+SELECT id, name, phone_number, email, password, CAST(raiting AS INTEGER), CAST(status AS TEXT) FROM users;
+
+DROP TABLE users;
+ALTER TABLE users_new RENAME TO users;
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS accounts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER REFERENCES users (id),
+    type TEXT NOT NULL CHECK (type IN ('wallet', 'clearing', 'revenue', 'marketing'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS accounts_user_id_type_idx ON accounts (user_id, type) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS accounts_system_type_idx ON accounts (type) WHERE user_id IS NULL;
+
+INSERT INTO accounts (type) VALUES ('clearing'), ('revenue'), ('marketing') ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    idempotency_key VARCHAR(64) NOT NULL UNIQUE,
+    kind TEXT NOT NULL CHECK (kind IN ('top_up', 'trip_fare', 'cancellation_fee', 'refund', 'credit')),
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS postings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    entry_id INTEGER NOT NULL REFERENCES journal_entries (id),
+    account_id INTEGER NOT NULL REFERENCES accounts (id),
+    amount INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS postings_account_id_idx ON postings (account_id);
+
+CREATE TRIGGER IF NOT EXISTS journal_entries_no_update BEFORE UPDATE ON journal_entries BEGIN SELECT RAISE(ABORT, 'ledger is append only'); END;
+CREATE TRIGGER IF NOT EXISTS journal_entries_no_delete BEFORE DELETE ON journal_entries BEGIN SELECT RAISE(ABORT, 'ledger is append only'); END;
+CREATE TRIGGER IF NOT EXISTS postings_no_update BEFORE UPDATE ON postings BEGIN SELECT RAISE(ABORT, 'ledger is append only'); END;
+CREATE TRIGGER IF NOT EXISTS postings_no_delete BEFORE DELETE ON postings BEGIN SELECT RAISE(ABORT, 'ledger is append only'); END;
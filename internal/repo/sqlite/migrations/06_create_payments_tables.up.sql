@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS payment_methods (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token VARCHAR(64) NOT NULL,
+    brand VARCHAR(30) NOT NULL,
+    last4 VARCHAR(4) NOT NULL,
+    exp_month INTEGER NOT NULL,
+    exp_year INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS payment_methods_user_id_idx ON payment_methods (user_id);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users (id),
+    order_id VARCHAR(64) NOT NULL UNIQUE,
+    payment_method_id INTEGER REFERENCES payment_methods (id) ON DELETE SET NULL,
+    authorization_id VARCHAR(64) NOT NULL UNIQUE,
+    amount INTEGER NOT NULL,
+    captured_amount INTEGER NOT NULL DEFAULT 0,
+    refunded_amount INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL CHECK (status IN ('authorized', 'captured', 'voided', 'refunded')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS referrals;
+
+DROP INDEX IF EXISTS users_referral_code_idx;
+ALTER TABLE users DROP COLUMN device_id;
+ALTER TABLE users DROP COLUMN referral_code;
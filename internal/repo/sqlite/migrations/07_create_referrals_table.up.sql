@@ -0,0 +1,24 @@
+ALTER TABLE users ADD COLUMN referral_code VARCHAR(16);
+ALTER TABLE users ADD COLUMN device_id VARCHAR(64) NOT NULL DEFAULT '';
+
+UPDATE users SET referral_code = upper(hex(randomblob(4))) WHERE referral_code IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_referral_code_idx ON users (referral_code);
+
+CREATE TABLE IF NOT EXISTS referrals (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    referrer_id INTEGER NOT NULL REFERENCES users (id),
+    invitee_id INTEGER NOT NULL UNIQUE REFERENCES users (id),
+    phone_number VARCHAR(30) NOT NULL,
+    device_id VARCHAR(64) NOT NULL DEFAULT '',
+    status TEXT NOT NULL CHECK (status IN ('pending', 'rewarded', 'rejected')),
+    reason VARCHAR(64) NOT NULL DEFAULT '',
+    referrer_reward INTEGER NOT NULL DEFAULT 0,
+    invitee_reward INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    rewarded_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS referrals_referrer_id_idx ON referrals (referrer_id);
+CREATE INDEX IF NOT EXISTS referrals_phone_number_idx ON referrals (phone_number);
+CREATE INDEX IF NOT EXISTS referrals_device_id_idx ON referrals (device_id) WHERE device_id <> '';
@@ -0,0 +1,20 @@
+-- Active users sharing a phone number or an email would fail the indexes.
+-- The migration doesn't choose which account keeps them: it fails and
+-- changes nothing. SQLite can't put the duplicates in the error, the README
+-- has the queries that list them.
+CREATE TEMP TABLE users_duplicates (value TEXT);
+CREATE TEMP TRIGGER users_duplicates_abort BEFORE INSERT ON users_duplicates
+BEGIN
+    SELECT RAISE(ABORT, 'active users share a phone number or an email');
+END;
+
+INSERT INTO users_duplicates
+SELECT phone_number FROM users WHERE status = 'created' GROUP BY phone_number HAVING COUNT(*) > 1
+UNION ALL
+SELECT email FROM users WHERE status = 'created' GROUP BY email HAVING COUNT(*) > 1;
+
+DROP TRIGGER users_duplicates_abort;
+DROP TABLE users_duplicates;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_phone_number_active_idx ON users (phone_number) WHERE status = 'created';
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_active_idx ON users (email) WHERE status = 'created';
@@ -0,0 +1,7 @@
+ALTER TABLE users ADD COLUMN partner_id INTEGER;
+ALTER TABLE webhooks ADD COLUMN partner_id INTEGER;
+
+-- Webhooks created before partners existed get no deliveries until an
+-- admin assigns them a partner, instead of the events of every user.
+CREATE INDEX IF NOT EXISTS users_partner_id_idx ON users (partner_id) WHERE partner_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS webhooks_partner_id_idx ON webhooks (partner_id) WHERE active;
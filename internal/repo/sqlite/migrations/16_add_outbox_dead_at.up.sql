@@ -0,0 +1,5 @@
+-- Events the relay gave up on stay in the outbox, out of the pending ones.
+ALTER TABLE outbox ADD COLUMN dead_at TIMESTAMP;
+
+DROP INDEX IF EXISTS outbox_pending_idx;
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (id) WHERE published_at IS NULL AND dead_at IS NULL;
@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+)
+
+func (s *Sqlite) AddEvent(ctx context.Context, event *model.Event) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := s.conn(ctx).QueryRowContext(queryCtx, "INSERT INTO outbox (type, aggregate_id, payload) VALUES(?, ?, ?) RETURNING id, created_at", event.Type, event.AggregateID, string(event.Payload)).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("query row context failed: %w", err)
+	}
+	return nil
+}
+
+func (s *Sqlite) GetPendingEvents(ctx context.Context, limit int) ([]*model.Event, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := s.conn(ctx).QueryContext(queryCtx, "SELECT id, type, aggregate_id, payload, created_at, attempts FROM outbox WHERE published_at IS NULL AND dead_at IS NULL ORDER BY id LIMIT ?", limit)
+	if err != nil {
+		return nil, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]*model.Event, 0)
+	for rows.Next() {
+		event := &model.Event{}
+		err := rows.Scan(&event.ID, &event.Type, &event.AggregateID, (*[]byte)(&event.Payload), &event.CreatedAt, &event.Attempts)
+		if err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows failed: %w", err)
+	}
+
+	return events, nil
+}
+
+func (s *Sqlite) MarkEventPublished(ctx context.Context, id uint64) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.conn(ctx).ExecContext(queryCtx, "UPDATE outbox SET published_at = CURRENT_TIMESTAMP WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+	return nil
+}
+
+func (s *Sqlite) MarkEventFailed(ctx context.Context, id uint64, reason string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.conn(ctx).ExecContext(queryCtx, "UPDATE outbox SET attempts = attempts + 1, last_error = ? WHERE id = ?", reason, id)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+	return nil
+}
+
+func (s *Sqlite) MarkEventDead(ctx context.Context, id uint64, reason string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.conn(ctx).ExecContext(queryCtx, "UPDATE outbox SET attempts = attempts + 1, last_error = ?, dead_at = CURRENT_TIMESTAMP WHERE id = ?", reason, id)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+	return nil
+}
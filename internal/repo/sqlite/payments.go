@@ -0,0 +1,218 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+const paymentColumns = "id, CAST(user_id AS TEXT), order_id, COALESCE(payment_method_id, 0), authorization_id, amount, captured_amount, refunded_amount, status, created_at"
+
+func (s *Sqlite) CreatePaymentMethod(ctx context.Context, userId string, method *model.PaymentMethod) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := s.conn(ctx).QueryRowContext(queryCtx, "INSERT INTO payment_methods (user_id, token, brand, last4, exp_month, exp_year) VALUES(?, ?, ?, ?, ?, ?) RETURNING id", userId, method.Token, method.Brand, method.Last4, method.ExpMonth, method.ExpYear).Scan(&method.ID)
+	if err != nil {
+		return fmt.Errorf("query row context failed: %w", err)
+	}
+	return nil
+}
+
+func (s *Sqlite) GetPaymentMethodsByUserId(ctx context.Context, userId string) ([]*model.PaymentMethod, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := s.conn(ctx).QueryContext(queryCtx, "SELECT id, token, brand, last4, exp_month, exp_year FROM payment_methods WHERE user_id = ? ORDER BY id", userId)
+	if err != nil {
+		return nil, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	methods := make([]*model.PaymentMethod, 0)
+	for rows.Next() {
+		method := &model.PaymentMethod{}
+		err := rows.Scan(&method.ID, &method.Token, &method.Brand, &method.Last4, &method.ExpMonth, &method.ExpYear)
+		if err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		methods = append(methods, method)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows failed: %w", err)
+	}
+
+	return methods, nil
+}
+
+func (s *Sqlite) GetPaymentMethodById(ctx context.Context, userId, methodId string) (*model.PaymentMethod, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	method := &model.PaymentMethod{}
+	err := s.conn(ctx).QueryRowContext(queryCtx, "SELECT id, token, brand, last4, exp_month, exp_year FROM payment_methods WHERE id = ? AND user_id = ?", methodId, userId).Scan(&method.ID, &method.Token, &method.Brand, &method.Last4, &method.ExpMonth, &method.ExpYear)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, service.ErrPaymentMethodDoesNotExists
+		}
+		return nil, fmt.Errorf("query row context failed: %w", err)
+	}
+
+	return method, nil
+}
+
+func (s *Sqlite) DeletePaymentMethodById(ctx context.Context, userId, methodId string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := s.conn(ctx).ExecContext(queryCtx, "DELETE FROM payment_methods WHERE id = ? AND user_id = ?", methodId, userId)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return service.ErrPaymentMethodDoesNotExists
+	}
+	return nil
+}
+
+func (s *Sqlite) CreatePayment(ctx context.Context, payment *model.Payment) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var methodId any
+	if payment.PaymentMethodID != 0 {
+		methodId = payment.PaymentMethodID
+	}
+
+	err := s.conn(ctx).QueryRowContext(queryCtx, "INSERT INTO payments (user_id, order_id, payment_method_id, authorization_id, amount, status, created_at) VALUES(?, ?, ?, ?, ?, ?, ?) RETURNING id, created_at", payment.UserID, payment.OrderID, methodId, payment.AuthorizationID, payment.Amount, payment.Status, time.Now().UTC()).Scan(&payment.ID, &payment.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("order: %v: %w", payment.OrderID, service.ErrPaymentAlreadyExists)
+		}
+		return fmt.Errorf("query row context failed: %w", err)
+	}
+	return nil
+}
+
+func (s *Sqlite) GetPaymentByOrderId(ctx context.Context, orderId string) (*model.Payment, error) {
+	return s.getPayment(ctx, "order_id", orderId)
+}
+
+func (s *Sqlite) GetPaymentByAuthorizationId(ctx context.Context, authorizationId string) (*model.Payment, error) {
+	return s.getPayment(ctx, "authorization_id", authorizationId)
+}
+
+func (s *Sqlite) getPayment(ctx context.Context, column, value string) (*model.Payment, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	payment := &model.Payment{}
+	err := s.conn(ctx).QueryRowContext(queryCtx, "SELECT "+paymentColumns+" FROM payments WHERE "+column+" = ?", value).Scan(&payment.ID, &payment.UserID, &payment.OrderID, &payment.PaymentMethodID, &payment.AuthorizationID, &payment.Amount, &payment.CapturedAmount, &payment.RefundedAmount, &payment.Status, &payment.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, service.ErrPaymentDoesNotExists
+		}
+		return nil, fmt.Errorf("query row context failed: %w", err)
+	}
+
+	return payment, nil
+}
+
+func (s *Sqlite) UpdatePayment(ctx context.Context, payment *model.Payment) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := s.conn(ctx).ExecContext(queryCtx, "UPDATE payments SET captured_amount = ?, refunded_amount = ?, status = ? WHERE id = ?", payment.CapturedAmount, payment.RefundedAmount, payment.Status, payment.ID)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return service.ErrPaymentDoesNotExists
+	}
+	return nil
+}
+
+// AddCapture doesn't need row locks, the single connection already
+// serializes the transaction with other writers.
+func (s *Sqlite) AddCapture(ctx context.Context, orderId string, amount int64) (*model.Payment, error) {
+	return s.changePayment(ctx, orderId, func(payment *model.Payment) error {
+		return service.ApplyCapture(payment, amount)
+	})
+}
+
+// AddRefund doesn't need row locks, the single connection already
+// serializes the transaction with other writers.
+func (s *Sqlite) AddRefund(ctx context.Context, orderId string, amount int64) (*model.Payment, error) {
+	return s.changePayment(ctx, orderId, func(payment *model.Payment) error {
+		return service.ApplyRefund(payment, amount)
+	})
+}
+
+func (s *Sqlite) AddGatewayEvent(ctx context.Context, id string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := s.conn(ctx).ExecContext(queryCtx, "INSERT INTO gateway_events (id) VALUES(?) ON CONFLICT (id) DO NOTHING", id)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return service.ErrGatewayEventHandled
+	}
+	return nil
+}
+
+// changePayment applies change to the payment of the order and stores its
+// amounts and status in one transaction.
+func (s *Sqlite) changePayment(ctx context.Context, orderId string, change func(payment *model.Payment) error) (*model.Payment, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var payment *model.Payment
+	err := s.WithinTransaction(queryCtx, func(ctx context.Context) error {
+		tx := s.conn(ctx)
+
+		payment = &model.Payment{}
+		err := tx.QueryRowContext(ctx, "SELECT "+paymentColumns+" FROM payments WHERE order_id = ?", orderId).Scan(&payment.ID, &payment.UserID, &payment.OrderID, &payment.PaymentMethodID, &payment.AuthorizationID, &payment.Amount, &payment.CapturedAmount, &payment.RefundedAmount, &payment.Status, &payment.CreatedAt)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return service.ErrPaymentDoesNotExists
+			}
+			return fmt.Errorf("query row context failed: %w", err)
+		}
+
+		err = change(payment)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE payments SET captured_amount = ?, refunded_amount = ?, status = ? WHERE id = ?", payment.CapturedAmount, payment.RefundedAmount, payment.Status, payment.ID)
+		if err != nil {
+			return fmt.Errorf("exec context failed: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
@@ -0,0 +1,113 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+// CreatePlace counts and inserts in one statement, the single connection
+// serializes it with other writers.
+func (s *Sqlite) CreatePlace(ctx context.Context, userId string, place *model.Place, limit int) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := s.conn(ctx).QueryRowContext(queryCtx, "INSERT INTO saved_places (user_id, label, latitude, longitude, address, notes) SELECT ?, ?, ?, ?, ?, ? WHERE (SELECT COUNT(*) FROM saved_places WHERE user_id = ?) < ? RETURNING id", userId, place.Label, place.Latitude, place.Longitude, place.Address, place.Notes, userId, limit).Scan(&place.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return service.ErrPlacesLimitExceeded
+		}
+		if isUniqueViolation(err) {
+			return fmt.Errorf("place: %v: %w", place.Label, service.ErrPlaceAlreadyExists)
+		}
+		return fmt.Errorf("query row context failed: %w", err)
+	}
+	return nil
+}
+
+func (s *Sqlite) GetPlacesByUserId(ctx context.Context, userId string) ([]*model.Place, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := s.conn(ctx).QueryContext(queryCtx, "SELECT id, label, latitude, longitude, address, notes FROM saved_places WHERE user_id = ? ORDER BY id", userId)
+	if err != nil {
+		return nil, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	places := make([]*model.Place, 0)
+	for rows.Next() {
+		place := &model.Place{}
+		err := rows.Scan(&place.ID, &place.Label, &place.Latitude, &place.Longitude, &place.Address, &place.Notes)
+		if err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		places = append(places, place)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows failed: %w", err)
+	}
+
+	return places, nil
+}
+
+func (s *Sqlite) GetPlaceById(ctx context.Context, userId, placeId string) (*model.Place, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	place := &model.Place{}
+	err := s.conn(ctx).QueryRowContext(queryCtx, "SELECT id, label, latitude, longitude, address, notes FROM saved_places WHERE id = ? AND user_id = ?", placeId, userId).Scan(&place.ID, &place.Label, &place.Latitude, &place.Longitude, &place.Address, &place.Notes)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, service.ErrPlaceDoesNotExists
+		}
+		return nil, fmt.Errorf("query row context failed: %w", err)
+	}
+
+	return place, nil
+}
+
+func (s *Sqlite) UpdatePlaceById(ctx context.Context, userId, placeId string, place *model.Place) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := s.conn(ctx).ExecContext(queryCtx, "UPDATE saved_places SET label = ?, latitude = ?, longitude = ?, address = ?, notes = ? WHERE id = ? AND user_id = ?", place.Label, place.Latitude, place.Longitude, place.Address, place.Notes, placeId, userId)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("place: %v: %w", place.Label, service.ErrPlaceAlreadyExists)
+		}
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return service.ErrPlaceDoesNotExists
+	}
+	return nil
+}
+
+func (s *Sqlite) DeletePlaceById(ctx context.Context, userId, placeId string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := s.conn(ctx).ExecContext(queryCtx, "DELETE FROM saved_places WHERE id = ? AND user_id = ?", placeId, userId)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return service.ErrPlaceDoesNotExists
+	}
+	return nil
+}
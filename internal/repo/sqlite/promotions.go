@@ -0,0 +1,181 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+const promotionColumns = "id, code, discount_type, discount_value, max_discount, valid_from, valid_until, usage_limit, per_user_limit, usage_count, taxi_classes, zones, active"
+
+func (s *Sqlite) CreatePromotion(ctx context.Context, promotion *model.Promotion) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := s.conn(ctx).QueryRowContext(queryCtx, "INSERT INTO promotions (code, discount_type, discount_value, max_discount, valid_from, valid_until, usage_limit, per_user_limit, taxi_classes, zones, active) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id",
+		promotion.Code, promotion.DiscountType, promotion.DiscountValue, promotion.MaxDiscount, promotion.ValidFrom.UTC(), promotion.ValidUntil.UTC(), promotion.UsageLimit, promotion.PerUserLimit, strings.Join(promotion.TaxiClasses, ","), strings.Join(promotion.Zones, ","), promotion.Active).Scan(&promotion.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("promotion: %v: %w", promotion.Code, service.ErrPromotionAlreadyExists)
+		}
+		return fmt.Errorf("query row context failed: %w", err)
+	}
+	return nil
+}
+
+func (s *Sqlite) GetPromotions(ctx context.Context) ([]*model.Promotion, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := s.conn(ctx).QueryContext(queryCtx, "SELECT "+promotionColumns+" FROM promotions ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	promotions := make([]*model.Promotion, 0)
+	for rows.Next() {
+		promotion, err := scanPromotion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan promotion failed: %w", err)
+		}
+		promotions = append(promotions, promotion)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows failed: %w", err)
+	}
+
+	return promotions, nil
+}
+
+func (s *Sqlite) GetPromotionByCode(ctx context.Context, code string) (*model.Promotion, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	promotion, err := scanPromotion(s.conn(ctx).QueryRowContext(queryCtx, "SELECT "+promotionColumns+" FROM promotions WHERE code = ?", code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, service.ErrPromotionDoesNotExists
+		}
+		return nil, fmt.Errorf("scan promotion failed: %w", err)
+	}
+
+	return promotion, nil
+}
+
+func (s *Sqlite) DeactivatePromotionById(ctx context.Context, id string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := s.conn(ctx).ExecContext(queryCtx, "UPDATE promotions SET active = FALSE WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return service.ErrPromotionDoesNotExists
+	}
+	return nil
+}
+
+func (s *Sqlite) CountRedemptions(ctx context.Context, promotionId uint64, userId string) (int, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var count int
+	err := s.conn(ctx).QueryRowContext(queryCtx, "SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id = ? AND user_id = ?", promotionId, userId).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("query row context failed: %w", err)
+	}
+	return count, nil
+}
+
+// RedeemPromotion doesn't need row locks, the single connection already
+// serializes the transaction with other writers.
+func (s *Sqlite) RedeemPromotion(ctx context.Context, redemption *model.Redemption) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.WithinTransaction(queryCtx, func(ctx context.Context) error {
+		tx := s.conn(ctx)
+
+		var (
+			active                               bool
+			usageLimit, perUserLimit, usageCount int
+		)
+		err := tx.QueryRowContext(ctx, "SELECT active, usage_limit, per_user_limit, usage_count FROM promotions WHERE id = ?", redemption.PromotionID).Scan(&active, &usageLimit, &perUserLimit, &usageCount)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return service.ErrPromotionDoesNotExists
+			}
+			return fmt.Errorf("query row context failed: %w", err)
+		}
+
+		if !active {
+			return service.ErrPromotionNotApplicable
+		}
+		if usageLimit > 0 && usageCount >= usageLimit {
+			return service.ErrPromotionUsageExceeded
+		}
+
+		if perUserLimit > 0 {
+			var used int
+			err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id = ? AND user_id = ?", redemption.PromotionID, redemption.UserID).Scan(&used)
+			if err != nil {
+				return fmt.Errorf("query row context failed: %w", err)
+			}
+			if used >= perUserLimit {
+				return service.ErrPromotionUsageExceeded
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO promotion_redemptions (promotion_id, user_id, order_id, discount) VALUES(?, ?, ?, ?)", redemption.PromotionID, redemption.UserID, redemption.OrderID, redemption.Discount)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return fmt.Errorf("order: %v: %w", redemption.OrderID, service.ErrPromotionAlreadyRedeemed)
+			}
+			return fmt.Errorf("exec context failed: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE promotions SET usage_count = usage_count + 1 WHERE id = ?", redemption.PromotionID)
+		if err != nil {
+			return fmt.Errorf("exec context failed: %w", err)
+		}
+
+		return nil
+	})
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPromotion(row scanner) (*model.Promotion, error) {
+	promotion := &model.Promotion{}
+	var taxiClasses, zones string
+
+	err := row.Scan(&promotion.ID, &promotion.Code, &promotion.DiscountType, &promotion.DiscountValue, &promotion.MaxDiscount, &promotion.ValidFrom, &promotion.ValidUntil, &promotion.UsageLimit, &promotion.PerUserLimit, &promotion.UsageCount, &taxiClasses, &zones, &promotion.Active)
+	if err != nil {
+		return nil, err
+	}
+
+	promotion.TaxiClasses = splitList(taxiClasses)
+	promotion.Zones = splitList(zones)
+	return promotion, nil
+}
+
+func splitList(list string) []string {
+	if list == "" {
+		return []string{}
+	}
+	return strings.Split(list, ",")
+}
@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+func (s *Sqlite) GetUserByReferralCode(ctx context.Context, code string) (*model.User, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	user := &model.User{}
+	err := s.conn(ctx).QueryRowContext(queryCtx, "SELECT id, name, phone_number, email, device_id FROM users WHERE referral_code = ? AND status = ?", code, model.StatusCreated).Scan(&user.ID, &user.Name, &user.PhoneNumber, &user.Email, &user.DeviceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, service.ErrReferralCodeDoesNotExists
+		}
+		return nil, fmt.Errorf("query row context failed: %w", err)
+	}
+
+	return user, nil
+}
+
+func (s *Sqlite) CountReferralsByPhoneNumber(ctx context.Context, phone string) (int, error) {
+	return s.countReferrals(ctx, "phone_number", phone)
+}
+
+func (s *Sqlite) CountReferralsByDeviceId(ctx context.Context, deviceId string) (int, error) {
+	return s.countReferrals(ctx, "device_id", deviceId)
+}
+
+func (s *Sqlite) countReferrals(ctx context.Context, column, value string) (int, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var num int
+	err := s.conn(ctx).QueryRowContext(queryCtx, "SELECT COUNT(*) FROM referrals WHERE "+column+" = ?", value).Scan(&num)
+	if err != nil {
+		return 0, fmt.Errorf("query row context failed: %w", err)
+	}
+	return num, nil
+}
+
+func (s *Sqlite) GetPendingReferralByInviteeId(ctx context.Context, inviteeId string) (*model.Referral, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	referral := &model.Referral{}
+	err := s.conn(ctx).QueryRowContext(queryCtx, "SELECT id, referrer_id, invitee_id, status, created_at FROM referrals WHERE invitee_id = ? AND status = ?", inviteeId, model.ReferralPending).Scan(&referral.ID, &referral.ReferrerID, &referral.InviteeID, &referral.Status, &referral.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, service.ErrReferralDoesNotExists
+		}
+		return nil, fmt.Errorf("query row context failed: %w", err)
+	}
+
+	return referral, nil
+}
+
+func (s *Sqlite) CompleteReferralById(ctx context.Context, referral *model.Referral) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var rewardedAt time.Time
+	err := s.conn(ctx).QueryRowContext(queryCtx, "UPDATE referrals SET status = ?, referrer_reward = ?, invitee_reward = ?, rewarded_at = ? WHERE id = ? AND status = ? RETURNING rewarded_at", model.ReferralRewarded, referral.ReferrerReward, referral.InviteeReward, time.Now().UTC(), referral.ID, model.ReferralPending).Scan(&rewardedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return service.ErrReferralDoesNotExists
+		}
+		return fmt.Errorf("query row context failed: %w", err)
+	}
+
+	referral.Status = model.ReferralRewarded
+	referral.RewardedAt = &rewardedAt
+	return nil
+}
+
+func (s *Sqlite) GetReferralStatsByUserId(ctx context.Context, userId string) (*model.ReferralStats, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	stats := &model.ReferralStats{}
+	err := s.conn(ctx).QueryRowContext(queryCtx, "SELECT u.referral_code, COUNT(r.id), COUNT(r.id) FILTER (WHERE r.status = 'pending'), COUNT(r.id) FILTER (WHERE r.status = 'rewarded'), COUNT(r.id) FILTER (WHERE r.status = 'rejected'), COALESCE(SUM(r.referrer_reward), 0) FROM users u LEFT JOIN referrals r ON r.referrer_id = u.id WHERE u.id = ? AND u.status = ? GROUP BY u.referral_code", userId, model.StatusCreated).Scan(&stats.Code, &stats.Invited, &stats.Pending, &stats.Rewarded, &stats.Rejected, &stats.Earned)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, service.ErrUserDoesNotExists
+		}
+		return nil, fmt.Errorf("query row context failed: %w", err)
+	}
+
+	return stats, nil
+}
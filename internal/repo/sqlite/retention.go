@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+func (s *Sqlite) GetDeletedUserByPhoneNumber(ctx context.Context, phone string, since time.Time) (*service.UserSingIn, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var (
+		user     service.UserSingIn
+		password []byte
+	)
+	err := s.conn(ctx).QueryRowContext(queryCtx, "SELECT id, phone_number, password, deleted_at FROM users WHERE phone_number = ? AND status = ? AND purged_at IS NULL AND deleted_at >= ? ORDER BY deleted_at DESC LIMIT 1", phone, model.StatusDeleted, since.UTC()).Scan(&user.ID, &user.PhoneNumber, &password, &user.DeletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, service.ErrUserDoesNotExists
+		}
+		return nil, fmt.Errorf("query row context failed: %w", err)
+	}
+
+	user.Password = string(password)
+	return &user, nil
+}
+
+func (s *Sqlite) RestoreUserById(ctx context.Context, id string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := s.conn(ctx).ExecContext(queryCtx, "UPDATE users SET status = ?, deleted_at = NULL WHERE id = ? AND status = ? AND purged_at IS NULL", model.StatusCreated, id, model.StatusDeleted)
+	if err != nil {
+		if isUserConflict(err) {
+			return fmt.Errorf("user: %v: %w", id, service.ErrUserAlreadyExists)
+		}
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return service.ErrUserDoesNotExists
+	}
+	return nil
+}
+
+func (s *Sqlite) GetPurgeableUsers(ctx context.Context, before time.Time, limit int) ([]uint64, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := s.conn(ctx).QueryContext(queryCtx, "SELECT id FROM users WHERE status = ? AND purged_at IS NULL AND (deleted_at IS NULL OR deleted_at < ?) ORDER BY deleted_at NULLS FIRST, id LIMIT ?", model.StatusDeleted, before.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uint64
+	for rows.Next() {
+		var id uint64
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows failed: %w", err)
+	}
+	return ids, nil
+}
+
+func (s *Sqlite) PurgeUserById(ctx context.Context, id string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.WithinTransaction(queryCtx, func(ctx context.Context) error {
+		tx := s.conn(ctx)
+
+		res, err := tx.ExecContext(ctx, "UPDATE users SET name = '', phone_number = '', email = '', password = X'', device_id = '', purged_at = ? WHERE id = ? AND status = ? AND purged_at IS NULL", time.Now().UTC(), id, model.StatusDeleted)
+		if err != nil {
+			return fmt.Errorf("exec context failed: %w", err)
+		}
+
+		num, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("rows affected failed: %w", err)
+		}
+		if num == 0 {
+			return service.ErrUserDoesNotExists
+		}
+
+		queries := []string{
+			"DELETE FROM saved_places WHERE user_id = ?",
+			"DELETE FROM payment_methods WHERE user_id = ?",
+			"UPDATE referrals SET phone_number = '', device_id = '' WHERE invitee_id = ?",
+			// Events keep their type and user id only. Deliveries go first,
+			// they are found through the events.
+			"UPDATE webhook_deliveries SET payload = json_set(payload, '$.payload', json_object('user_id', CAST(?1 AS TEXT))) WHERE status = 'pending' AND event_id IN (SELECT id FROM outbox WHERE aggregate_id = ?1)",
+			"DELETE FROM webhook_deliveries WHERE status <> 'pending' AND event_id IN (SELECT id FROM outbox WHERE aggregate_id = ?)",
+			"UPDATE outbox SET payload = json_object('user_id', aggregate_id) WHERE aggregate_id = ? AND published_at IS NULL",
+			"DELETE FROM outbox WHERE aggregate_id = ? AND published_at IS NOT NULL",
+		}
+		for _, query := range queries {
+			_, err := tx.ExecContext(ctx, query, id)
+			if err != nil {
+				return fmt.Errorf("exec context failed: %w", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,216 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/config"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	sqlitedriver "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Sqlite implements service.Repo on top of a single SQLite file. SQLite
+// allows one writer at a time, so the pool holds a single connection and
+// every statement, transactions included, is serialized.
+type Sqlite struct {
+	DB      *sql.DB
+	Migrate *migrate.Migrate
+	cfg     *config.Config
+}
+
+type transferUser struct {
+	Name        *string
+	PhoneNumber *string
+	Email       *string
+}
+
+func New(cfg *config.Config) (*Sqlite, error) {
+	DB, err := sql.Open("sqlite", cfg.SQLITE_DB_PATH)
+	if err != nil {
+		return nil, fmt.Errorf("open failed: %w", err)
+	}
+	DB.SetMaxOpenConns(1)
+
+	_, err = DB.Exec("PRAGMA foreign_keys = ON")
+	if err != nil {
+		return nil, fmt.Errorf("exec failed: %w", err)
+	}
+
+	driver, err := sqlite.WithInstance(DB, &sqlite.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("with instance failed: %w", err)
+	}
+
+	m, err := migrate.NewWithDatabaseInstance(cfg.SQLITE_MIGRATE_PATH, "sqlite", driver)
+	if err != nil {
+		return nil, fmt.Errorf("new with database instance failed: %w", err)
+	}
+
+	return &Sqlite{
+		DB,
+		m,
+		cfg,
+	}, nil
+}
+
+func (s *Sqlite) Close() error {
+	return s.DB.Close()
+}
+
+// CreateUser relies on the unique indexes on phone number and email of
+// active users to refuse duplicates.
+func (s *Sqlite) CreateUser(ctx context.Context, user service.UserSingUp) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.WithinTransaction(queryCtx, func(ctx context.Context) error {
+		tx := s.conn(ctx)
+
+		var id uint64
+		err := tx.QueryRowContext(ctx, "INSERT INTO users (name, phone_number, email, password, raiting, status, referral_code, device_id) VALUES(?, ?, ?, ?, 0, ?, ?, ?) RETURNING id", user.Name, user.PhoneNumber, user.Email, []byte(user.Password), model.StatusCreated, user.Code, user.DeviceID).Scan(&id)
+		if err != nil {
+			if isUserConflict(err) {
+				return service.ErrUserAlreadyExists
+			}
+			return fmt.Errorf("query row context failed: %w", err)
+		}
+
+		if user.Referral != nil {
+			_, err = tx.ExecContext(ctx, "INSERT INTO referrals (referrer_id, invitee_id, phone_number, device_id, status, reason) VALUES(?, ?, ?, ?, ?, ?)", user.Referral.ReferrerID, id, user.PhoneNumber, user.DeviceID, user.Referral.Status, user.Referral.Reason)
+			if err != nil {
+				return fmt.Errorf("exec context failed: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Sqlite) CheckUserByPhoneNumber(ctx context.Context, phone_number string) (*service.UserSingIn, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var (
+		user     service.UserSingIn
+		password []byte
+	)
+	err := s.conn(ctx).QueryRowContext(queryCtx, "SELECT id, phone_number, password FROM users WHERE phone_number = ? AND status = ?", phone_number, model.StatusCreated).Scan(&user.ID, &user.PhoneNumber, &password)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, service.ErrUserDoesNotExists
+		}
+		return nil, fmt.Errorf("scan failed: %w", err)
+	}
+
+	user.Password = string(password)
+	return &user, nil
+}
+
+func (s *Sqlite) GetUserById(ctx context.Context, id string) (*model.User, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	user := &model.User{}
+	err := s.conn(ctx).QueryRowContext(queryCtx, "SELECT id, name, phone_number, email, raiting, version FROM users WHERE id = ? AND status = ?", id, model.StatusCreated).Scan(&user.ID, &user.Name, &user.PhoneNumber, &user.Email, &user.Raiting, &user.Version)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, service.ErrUserDoesNotExists
+		}
+		return nil, fmt.Errorf("query row context failed: %w", err)
+	}
+
+	return user, nil
+}
+
+func (s *Sqlite) UpdateUserById(ctx context.Context, id string, user *model.User) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var transfer transferUser
+	if user.Name != "" {
+		transfer.Name = &user.Name
+	}
+	if user.PhoneNumber != "" {
+		transfer.PhoneNumber = &user.PhoneNumber
+	}
+	if user.Email != "" {
+		transfer.Email = &user.Email
+	}
+
+	res, err := s.conn(ctx).ExecContext(queryCtx, "UPDATE users SET name = COALESCE(?, name), phone_number = COALESCE(?, phone_number), email = COALESCE(?, email), version = version + 1 WHERE id = ? AND status = ? AND (? = 0 OR version = ?)", transfer.Name, transfer.PhoneNumber, transfer.Email, id, model.StatusCreated, int64(user.Version), int64(user.Version))
+	if err != nil {
+		if isUserConflict(err) {
+			return fmt.Errorf("user: %v: %w", id, service.ErrUserAlreadyExists)
+		}
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 && user.Version != 0 {
+		return s.versionMismatch(ctx, id)
+	}
+	if num == 0 {
+		return service.ErrUserDoesNotExists
+	}
+	return nil
+}
+
+// versionMismatch tells why an update expecting a version changed nothing:
+// the user is gone or has another version.
+func (s *Sqlite) versionMismatch(ctx context.Context, id string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var exists bool
+	err := s.conn(ctx).QueryRowContext(queryCtx, "SELECT EXISTS (SELECT 1 FROM users WHERE id = ? AND status = ?)", id, model.StatusCreated).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("query row context failed: %w", err)
+	}
+	if !exists {
+		return service.ErrUserDoesNotExists
+	}
+	return service.ErrUserModified
+}
+
+func (s *Sqlite) DeleteUserById(ctx context.Context, id string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := s.conn(ctx).ExecContext(queryCtx, "UPDATE users SET status = ?, deleted_at = ? WHERE id = ? AND status = ?", model.StatusDeleted, time.Now().UTC(), id, model.StatusCreated)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return service.ErrUserDoesNotExists
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlitedriver.Error
+	return errors.As(err, &sqliteErr) && (sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY)
+}
+
+// isUserConflict reports whether err violates the unique indexes that keep
+// phone numbers and emails of active users distinct. SQLite reports the
+// indexed columns instead of the index name.
+func isUserConflict(err error) bool {
+	return isUniqueViolation(err) && (strings.Contains(err.Error(), "users.phone_number") || strings.Contains(err.Error(), "users.email"))
+}
@@ -0,0 +1,365 @@
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/config"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/repo/sqlite"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/go-playground/assert/v2"
+)
+
+func createUser(t *testing.T, repo interface {
+	CreateUser(context.Context, service.UserSingUp) error
+}, user service.UserSingUp) {
+	err := repo.CreateUser(context.Background(), user)
+	if err != nil {
+		t.Fatalf("create user failed: %v", err)
+	}
+}
+
+func TestPlaces(t *testing.T) {
+	repo := newSqlite(t)
+	ctx := context.Background()
+	createUser(t, repo, service.UserSingUp{Name: "Ivan", PhoneNumber: "+375298830001", Email: "ivan@gmail.com", Password: "12345678", Code: "AAAAAAAA"})
+
+	place := &model.Place{Label: "home", Latitude: 53.9, Longitude: 27.56, Address: "Nezavisimosti 1"}
+	err := repo.CreatePlace(ctx, "1", place, 1)
+	assert.Equal(t, err, nil)
+
+	err = repo.CreatePlace(ctx, "1", &model.Place{Label: "work", Address: "Pobediteley 2"}, 1)
+	assert.Equal(t, errors.Is(err, service.ErrPlacesLimitExceeded), true)
+
+	err = repo.CreatePlace(ctx, "1", &model.Place{Label: "home", Address: "Pobediteley 2"}, 2)
+	assert.Equal(t, errors.Is(err, service.ErrPlaceAlreadyExists), true)
+
+	err = repo.UpdatePlaceById(ctx, "1", "1", &model.Place{Label: "home", Address: place.Address, Notes: "second entrance"})
+	assert.Equal(t, err, nil)
+
+	got, err := repo.GetPlaceById(ctx, "1", "1")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, got.Address, place.Address)
+	assert.Equal(t, got.Notes, "second entrance")
+
+	err = repo.DeletePlaceById(ctx, "1", "1")
+	assert.Equal(t, err, nil)
+
+	places, err := repo.GetPlacesByUserId(ctx, "1")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(places), 0)
+}
+
+func TestPromotions(t *testing.T) {
+	repo := newSqlite(t)
+	ctx := context.Background()
+	createUser(t, repo, service.UserSingUp{Name: "Ivan", PhoneNumber: "+375298830001", Email: "ivan@gmail.com", Password: "12345678", Code: "AAAAAAAA"})
+	createUser(t, repo, service.UserSingUp{Name: "Petr", PhoneNumber: "+375298830002", Email: "petr@gmail.com", Password: "12345678", Code: "BBBBBBBB"})
+
+	promotion := &model.Promotion{
+		Code:          "SPRING",
+		DiscountType:  model.DiscountPercent,
+		DiscountValue: 10,
+		ValidFrom:     time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC),
+		ValidUntil:    time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+		UsageLimit:    1,
+		TaxiClasses:   []string{"economy", "comfort"},
+		Active:        true,
+	}
+	err := repo.CreatePromotion(ctx, promotion)
+	assert.Equal(t, err, nil)
+
+	got, err := repo.GetPromotionByCode(ctx, "SPRING")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, got.TaxiClasses, []string{"economy", "comfort"})
+	assert.Equal(t, len(got.Zones), 0)
+	assert.Equal(t, got.ValidUntil.Equal(promotion.ValidUntil), true)
+	assert.Equal(t, got.Active, true)
+
+	err = repo.RedeemPromotion(ctx, &model.Redemption{PromotionID: got.ID, UserID: "1", OrderID: "order-1", Discount: 100})
+	assert.Equal(t, err, nil)
+
+	err = repo.RedeemPromotion(ctx, &model.Redemption{PromotionID: got.ID, UserID: "2", OrderID: "order-2", Discount: 100})
+	assert.Equal(t, errors.Is(err, service.ErrPromotionUsageExceeded), true)
+
+	num, err := repo.CountRedemptions(ctx, got.ID, "1")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, num, 1)
+}
+
+func TestWallet(t *testing.T) {
+	repo := newSqlite(t)
+	ctx := context.Background()
+	createUser(t, repo, service.UserSingUp{Name: "Ivan", PhoneNumber: "+375298830001", Email: "ivan@gmail.com", Password: "12345678", Code: "AAAAAAAA"})
+
+	topUp := func() *model.JournalEntry {
+		return &model.JournalEntry{
+			IdempotencyKey: "top-up-1",
+			Kind:           model.EntryTopUp,
+			Description:    "wallet top up",
+			Postings: []model.Posting{
+				{AccountType: model.AccountClearing, Amount: -1000},
+				{AccountType: model.AccountWallet, UserID: "1", Amount: 1000},
+			},
+		}
+	}
+
+	first := topUp()
+	err := repo.PostEntry(ctx, first)
+	assert.Equal(t, err, nil)
+
+	replay := topUp()
+	err = repo.PostEntry(ctx, replay)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, replay.ID, first.ID)
+
+	reused := topUp()
+	reused.Postings[0].Amount, reused.Postings[1].Amount = -500, 500
+	err = repo.PostEntry(ctx, reused)
+	assert.Equal(t, errors.Is(err, service.ErrIdempotencyKeyReused), true)
+
+	err = repo.PostEntry(ctx, &model.JournalEntry{
+		IdempotencyKey: "fare-1",
+		Kind:           model.EntryTripFare,
+		Postings: []model.Posting{
+			{AccountType: model.AccountWallet, UserID: "1", Amount: -1500},
+			{AccountType: model.AccountRevenue, Amount: 1500},
+		},
+	})
+	assert.Equal(t, errors.Is(err, service.ErrInsufficientFunds), true)
+
+	balance, err := repo.GetBalance(ctx, "1")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, balance, int64(1000))
+
+	transactions, err := repo.GetTransactions(ctx, "1", 10, 0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(transactions), 1)
+	assert.Equal(t, transactions[0].Amount, int64(1000))
+}
+
+func TestReferrals(t *testing.T) {
+	repo := newSqlite(t)
+	ctx := context.Background()
+	createUser(t, repo, service.UserSingUp{Name: "Ivan", PhoneNumber: "+375298830001", Email: "ivan@gmail.com", Password: "12345678", Code: "AAAAAAAA"})
+
+	referrer, err := repo.GetUserByReferralCode(ctx, "AAAAAAAA")
+	assert.Equal(t, err, nil)
+
+	createUser(t, repo, service.UserSingUp{
+		Name:        "Petr",
+		PhoneNumber: "+375298830002",
+		Email:       "petr@gmail.com",
+		Password:    "12345678",
+		Code:        "BBBBBBBB",
+		DeviceID:    "device-2",
+		Referral:    &model.Referral{ReferrerID: referrer.ID, Status: model.ReferralPending},
+	})
+
+	num, err := repo.CountReferralsByDeviceId(ctx, "device-2")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, num, 1)
+
+	referral, err := repo.GetPendingReferralByInviteeId(ctx, "2")
+	assert.Equal(t, err, nil)
+
+	referral.ReferrerReward, referral.InviteeReward = 500, 300
+	err = repo.CompleteReferralById(ctx, referral)
+	assert.Equal(t, err, nil)
+	assert.NotEqual(t, referral.RewardedAt, nil)
+
+	err = repo.CompleteReferralById(ctx, referral)
+	assert.Equal(t, errors.Is(err, service.ErrReferralDoesNotExists), true)
+
+	stats, err := repo.GetReferralStatsByUserId(ctx, "1")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, stats, &model.ReferralStats{Code: "AAAAAAAA", Invited: 1, Rewarded: 1, Earned: 500})
+}
+
+func TestRefunds(t *testing.T) {
+	repo := newSqlite(t)
+	ctx := context.Background()
+	createUser(t, repo, service.UserSingUp{Name: "Ivan", PhoneNumber: "+375298830001", Email: "ivan@gmail.com", Password: "12345678", Code: "AAAAAAAA"})
+
+	payment := &model.Payment{UserID: "1", OrderID: "order-1", AuthorizationID: "auth-1", Amount: 1000, Status: model.PaymentAuthorized}
+	err := repo.CreatePayment(ctx, payment)
+	assert.Equal(t, err, nil)
+
+	_, err = repo.AddRefund(ctx, "order-1", 100)
+	assert.Equal(t, err, service.ErrPaymentWrongStatus)
+
+	_, err = repo.AddCapture(ctx, "order-1", 900)
+	assert.Equal(t, err, nil)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		refunded int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := repo.AddRefund(ctx, "order-1", 200)
+			if err == nil {
+				mu.Lock()
+				refunded++
+				mu.Unlock()
+				return
+			}
+			assert.Equal(t, err, service.ErrRefundExceedsCapture)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, refunded, 4)
+
+	got, err := repo.AddRefund(ctx, "order-1", -800)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, got.RefundedAmount, int64(0))
+	assert.Equal(t, got.Status, model.PaymentCaptured)
+
+	_, err = repo.AddRefund(ctx, "order-2", 100)
+	assert.Equal(t, err, service.ErrPaymentDoesNotExists)
+}
+
+func TestCaptures(t *testing.T) {
+	repo := newSqlite(t)
+	ctx := context.Background()
+	createUser(t, repo, service.UserSingUp{Name: "Ivan", PhoneNumber: "+375298830001", Email: "ivan@gmail.com", Password: "12345678", Code: "AAAAAAAA"})
+
+	err := repo.CreatePayment(ctx, &model.Payment{UserID: "1", OrderID: "order-1", AuthorizationID: "auth-1", Amount: 1000, Status: model.PaymentAuthorized})
+	assert.Equal(t, err, nil)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		captured int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := repo.AddCapture(ctx, "order-1", 600)
+			if err == nil {
+				mu.Lock()
+				captured++
+				mu.Unlock()
+				return
+			}
+			assert.Equal(t, err, service.ErrPaymentWrongStatus)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, captured, 1)
+
+	got, err := repo.AddCapture(ctx, "order-1", -600)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, got.CapturedAmount, int64(0))
+	assert.Equal(t, got.Status, model.PaymentAuthorized)
+
+	_, err = repo.AddCapture(ctx, "order-1", 1100)
+	assert.Equal(t, err, service.ErrCaptureExceedsAuthorized)
+}
+
+func TestMigrateDuplicateUsers(t *testing.T) {
+	repo, err := sqlite.New(&config.Config{
+		SQLITE_DB_PATH:      filepath.Join(t.TempDir(), "innotaxi.db"),
+		SQLITE_MIGRATE_PATH: "file://migrations",
+	})
+	if err != nil {
+		t.Fatalf("sqlite new failed: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+
+	err = repo.Migrate.Migrate(7)
+	assert.Equal(t, err, nil)
+
+	// 2 shares the phone number of 1, 3 the email of 2 and 4 the email of 1.
+	users := [][2]string{
+		{"+375298830001", "ivan@gmail.com"},
+		{"+375298830001", "petr@gmail.com"},
+		{"+375298830003", "petr@gmail.com"},
+		{"+375298830004", "ivan@gmail.com"},
+		{"+375298830005", "oleg@gmail.com"},
+	}
+	for _, user := range users {
+		_, err = repo.DB.Exec("INSERT INTO users (name, phone_number, email, password, raiting, status) VALUES('Ivan', ?, ?, '', 0, 'created')", user[0], user[1])
+		assert.Equal(t, err, nil)
+	}
+
+	err = repo.Migrate.Up()
+	if err == nil || !strings.Contains(err.Error(), "active users share a phone number or an email") {
+		t.Fatalf("migrate up: got %v, want duplicates error", err)
+	}
+
+	var active int
+	err = repo.DB.QueryRow("SELECT COUNT(*) FROM users WHERE status = 'created'").Scan(&active)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, active, 5)
+
+	_, err = repo.DB.Exec("DELETE FROM users WHERE id IN (2, 4)")
+	assert.Equal(t, err, nil)
+	err = repo.Migrate.Force(7)
+	assert.Equal(t, err, nil)
+
+	err = repo.Migrate.Up()
+	assert.Equal(t, err, nil)
+}
+
+func TestMigrateDeletedAt(t *testing.T) {
+	repo, err := sqlite.New(&config.Config{
+		SQLITE_DB_PATH:      filepath.Join(t.TempDir(), "innotaxi.db"),
+		SQLITE_MIGRATE_PATH: "file://migrations",
+	})
+	if err != nil {
+		t.Fatalf("sqlite new failed: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	ctx := context.Background()
+
+	err = repo.Migrate.Migrate(12)
+	assert.Equal(t, err, nil)
+
+	// 1 was deleted with an event, 2 before the outbox existed.
+	for _, phone := range []string{"+375298830001", "+375298830002"} {
+		_, err = repo.DB.Exec("INSERT INTO users (name, phone_number, email, password, raiting, status) VALUES('Ivan', ?, ?, '', 0, 'deleted')", phone, phone+"@gmail.com")
+		assert.Equal(t, err, nil)
+	}
+	_, err = repo.DB.Exec("INSERT INTO outbox (type, aggregate_id, payload, created_at) VALUES('user.deleted', '1', '{}', ?)", time.Now().UTC().Add(-time.Minute).Format("2006-01-02 15:04:05"))
+	assert.Equal(t, err, nil)
+
+	err = repo.Migrate.Up()
+	assert.Equal(t, err, nil)
+
+	_, err = repo.GetDeletedUserByPhoneNumber(ctx, "+375298830001", time.Now().Add(-time.Hour))
+	assert.Equal(t, err, nil)
+	_, err = repo.GetDeletedUserByPhoneNumber(ctx, "+375298830002", time.Now().Add(-time.Hour))
+	assert.Equal(t, err, service.ErrUserDoesNotExists)
+
+	ids, err := repo.GetPurgeableUsers(ctx, time.Now().Add(-time.Hour), 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, ids, []uint64{2})
+
+	ids, err = repo.GetPurgeableUsers(ctx, time.Now(), 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, ids, []uint64{2, 1})
+}
+
+func TestGatewayEvents(t *testing.T) {
+	repo := newSqlite(t)
+
+	err := repo.AddGatewayEvent(context.Background(), "evt_1")
+	assert.Equal(t, err, nil)
+	err = repo.AddGatewayEvent(context.Background(), "evt_1")
+	assert.Equal(t, err, service.ErrGatewayEventHandled)
+	err = repo.AddGatewayEvent(context.Background(), "evt_2")
+	assert.Equal(t, err, nil)
+}
@@ -0,0 +1,172 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+func (s *Sqlite) PostEntry(ctx context.Context, entry *model.JournalEntry) error {
+	var sum int64
+	for _, posting := range entry.Postings {
+		sum += posting.Amount
+	}
+	if sum != 0 {
+		return fmt.Errorf("entry %v is unbalanced by %d", entry.IdempotencyKey, sum)
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.WithinTransaction(queryCtx, func(ctx context.Context) error {
+		tx := s.conn(ctx)
+
+		err := tx.QueryRowContext(ctx, "INSERT INTO journal_entries (idempotency_key, kind, description, created_at) VALUES(?, ?, ?, ?) ON CONFLICT (idempotency_key) DO NOTHING RETURNING id, created_at", entry.IdempotencyKey, entry.Kind, entry.Description, time.Now().UTC()).Scan(&entry.ID, &entry.CreatedAt)
+		if err == sql.ErrNoRows {
+			return replayEntry(ctx, tx, entry)
+		}
+		if err != nil {
+			return fmt.Errorf("query row context failed: %w", err)
+		}
+
+		for _, posting := range entry.Postings {
+			accountId, err := account(ctx, tx, posting)
+			if err != nil {
+				return fmt.Errorf("account failed: %w", err)
+			}
+
+			if posting.AccountType == model.AccountWallet && posting.Amount < 0 {
+				var balance int64
+				err = tx.QueryRowContext(ctx, "SELECT COALESCE(SUM(amount), 0) FROM postings WHERE account_id = ?", accountId).Scan(&balance)
+				if err != nil {
+					return fmt.Errorf("query row context failed: %w", err)
+				}
+				if balance+posting.Amount < 0 {
+					return service.ErrInsufficientFunds
+				}
+			}
+
+			_, err = tx.ExecContext(ctx, "INSERT INTO postings (entry_id, account_id, amount) VALUES(?, ?, ?)", entry.ID, accountId, posting.Amount)
+			if err != nil {
+				return fmt.Errorf("exec context failed: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// account returns the id of the posting's account, wallet accounts are
+// created on first use.
+func account(ctx context.Context, tx querier, posting model.Posting) (uint64, error) {
+	var id uint64
+
+	if posting.AccountType != model.AccountWallet {
+		err := tx.QueryRowContext(ctx, "SELECT id FROM accounts WHERE type = ? AND user_id IS NULL", posting.AccountType).Scan(&id)
+		if err != nil {
+			return 0, fmt.Errorf("query row context failed: %w", err)
+		}
+		return id, nil
+	}
+
+	_, err := tx.ExecContext(ctx, "INSERT INTO accounts (user_id, type) VALUES(?, ?) ON CONFLICT DO NOTHING", posting.UserID, model.AccountWallet)
+	if err != nil {
+		return 0, fmt.Errorf("exec context failed: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, "SELECT id FROM accounts WHERE user_id = ? AND type = ?", posting.UserID, model.AccountWallet).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("query row context failed: %w", err)
+	}
+	return id, nil
+}
+
+// replayEntry loads the entry stored under the same idempotency key and
+// checks that it was posted with the same parameters.
+func replayEntry(ctx context.Context, tx querier, entry *model.JournalEntry) error {
+	stored := &model.JournalEntry{}
+	err := tx.QueryRowContext(ctx, "SELECT id, kind, description, created_at FROM journal_entries WHERE idempotency_key = ?", entry.IdempotencyKey).Scan(&stored.ID, &stored.Kind, &stored.Description, &stored.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("query row context failed: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT a.type, COALESCE(CAST(a.user_id AS TEXT), ''), p.amount FROM postings p JOIN accounts a ON a.id = p.account_id WHERE p.entry_id = ? ORDER BY p.id", stored.ID)
+	if err != nil {
+		return fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var posting model.Posting
+		err := rows.Scan(&posting.AccountType, &posting.UserID, &posting.Amount)
+		if err != nil {
+			return fmt.Errorf("scan failed: %w", err)
+		}
+		stored.Postings = append(stored.Postings, posting)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows failed: %w", err)
+	}
+
+	if !sameEntry(entry, stored) {
+		return fmt.Errorf("key: %v: %w", entry.IdempotencyKey, service.ErrIdempotencyKeyReused)
+	}
+
+	entry.ID = stored.ID
+	entry.CreatedAt = stored.CreatedAt
+	return nil
+}
+
+func sameEntry(a, b *model.JournalEntry) bool {
+	if a.Kind != b.Kind || len(a.Postings) != len(b.Postings) {
+		return false
+	}
+	for i := range a.Postings {
+		if a.Postings[i] != b.Postings[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Sqlite) GetBalance(ctx context.Context, userId string) (int64, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var balance int64
+	err := s.conn(ctx).QueryRowContext(queryCtx, "SELECT COALESCE(SUM(p.amount), 0) FROM postings p JOIN accounts a ON a.id = p.account_id WHERE a.user_id = ? AND a.type = ?", userId, model.AccountWallet).Scan(&balance)
+	if err != nil {
+		return 0, fmt.Errorf("query row context failed: %w", err)
+	}
+	return balance, nil
+}
+
+func (s *Sqlite) GetTransactions(ctx context.Context, userId string, limit, offset int) ([]*model.Transaction, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := s.conn(ctx).QueryContext(queryCtx, "SELECT e.id, e.kind, e.description, p.amount, e.created_at FROM postings p JOIN accounts a ON a.id = p.account_id JOIN journal_entries e ON e.id = p.entry_id WHERE a.user_id = ? AND a.type = ? ORDER BY e.id DESC LIMIT ? OFFSET ?", userId, model.AccountWallet, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	transactions := make([]*model.Transaction, 0)
+	for rows.Next() {
+		transaction := &model.Transaction{}
+		err := rows.Scan(&transaction.ID, &transaction.Kind, &transaction.Description, &transaction.Amount, &transaction.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		transactions = append(transactions, transaction)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows failed: %w", err)
+	}
+
+	return transactions, nil
+}
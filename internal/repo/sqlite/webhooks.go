@@ -0,0 +1,261 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+const (
+	webhookColumns  = "id, COALESCE(partner_id, 0), url, secret, event_types, active, created_at"
+	deliveryColumns = "id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_error, response_status, created_at, delivered_at"
+)
+
+func (s *Sqlite) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := s.conn(ctx).QueryRowContext(queryCtx, "INSERT INTO webhooks (partner_id, url, secret, event_types, active, created_at) VALUES(?, ?, ?, ?, ?, ?) RETURNING id, created_at", int64(webhook.PartnerID), webhook.URL, webhook.Secret, strings.Join(webhook.EventTypes, ","), webhook.Active, time.Now().UTC()).Scan(&webhook.ID, &webhook.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("query row context failed: %w", err)
+	}
+	return nil
+}
+
+func (s *Sqlite) GetWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := s.conn(ctx).QueryContext(queryCtx, "SELECT "+webhookColumns+" FROM webhooks ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]*model.Webhook, 0)
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook failed: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows failed: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (s *Sqlite) GetWebhookById(ctx context.Context, id string) (*model.Webhook, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	webhook, err := scanWebhook(s.conn(ctx).QueryRowContext(queryCtx, "SELECT "+webhookColumns+" FROM webhooks WHERE id = ?", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, service.ErrWebhookDoesNotExists
+		}
+		return nil, fmt.Errorf("scan webhook failed: %w", err)
+	}
+
+	return webhook, nil
+}
+
+func (s *Sqlite) UpdateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := s.conn(ctx).ExecContext(queryCtx, "UPDATE webhooks SET partner_id = ?, url = ?, secret = ?, event_types = ?, active = ? WHERE id = ?", int64(webhook.PartnerID), webhook.URL, webhook.Secret, strings.Join(webhook.EventTypes, ","), webhook.Active, webhook.ID)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return service.ErrWebhookDoesNotExists
+	}
+	return nil
+}
+
+// DeleteWebhookById drops the webhook's deliveries as well.
+func (s *Sqlite) DeleteWebhookById(ctx context.Context, id string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := s.conn(ctx).ExecContext(queryCtx, "DELETE FROM webhooks WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return service.ErrWebhookDoesNotExists
+	}
+	return nil
+}
+
+func (s *Sqlite) AddPartnerEmployee(ctx context.Context, partnerId uint64, userId string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := s.conn(ctx).ExecContext(queryCtx, "UPDATE users SET partner_id = ? WHERE id = ? AND status = ?", int64(partnerId), userId, model.StatusCreated)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return service.ErrUserDoesNotExists
+	}
+	return nil
+}
+
+func (s *Sqlite) RemovePartnerEmployee(ctx context.Context, partnerId uint64, userId string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := s.conn(ctx).ExecContext(queryCtx, "UPDATE users SET partner_id = NULL WHERE id = ? AND partner_id = ?", userId, int64(partnerId))
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return service.ErrEmployeeDoesNotExists
+	}
+	return nil
+}
+
+func (s *Sqlite) AddWebhookDeliveries(ctx context.Context, eventId uint64, eventType, userId string, body []byte) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+
+	_, err := s.conn(ctx).ExecContext(queryCtx, "INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload, next_attempt_at, created_at) SELECT webhooks.id, ?, ?, ?, ?, ? FROM webhooks JOIN users ON users.partner_id = webhooks.partner_id WHERE users.id = ? AND webhooks.active AND instr(',' || webhooks.event_types || ',', ',' || ? || ',') > 0 ON CONFLICT (webhook_id, event_id) DO NOTHING", eventId, eventType, string(body), now, now, userId, eventType)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+	return nil
+}
+
+func (s *Sqlite) GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.queryDeliveries(queryCtx, "SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE status = ? AND next_attempt_at <= ? ORDER BY id LIMIT ?", model.DeliveryPending, now.UTC(), limit)
+}
+
+func (s *Sqlite) UpdateWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.conn(ctx).ExecContext(queryCtx, "UPDATE webhook_deliveries SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?, response_status = ?, delivered_at = ? WHERE id = ?", delivery.Status, delivery.Attempts, delivery.NextAttemptAt.UTC(), delivery.LastError, delivery.ResponseStatus, utcTime(delivery.DeliveredAt), delivery.ID)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+	return nil
+}
+
+func (s *Sqlite) GetWebhookDeliveries(ctx context.Context, webhookId, status string, limit, offset int) ([]*model.WebhookDelivery, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.queryDeliveries(queryCtx, "SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE webhook_id = ? AND (? = '' OR status = ?) ORDER BY id DESC LIMIT ? OFFSET ?", webhookId, status, status, limit, offset)
+}
+
+func (s *Sqlite) RetryWebhookDelivery(ctx context.Context, webhookId, deliveryId string, now time.Time) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := s.conn(ctx).ExecContext(queryCtx, "UPDATE webhook_deliveries SET status = ?, attempts = 0, next_attempt_at = ?, last_error = '' WHERE id = ? AND webhook_id = ? AND status = ?", model.DeliveryPending, now.UTC(), deliveryId, webhookId, model.DeliveryDead)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return service.ErrWebhookDeliveryDoesNotExists
+	}
+	return nil
+}
+
+func (s *Sqlite) queryDeliveries(ctx context.Context, query string, args ...any) ([]*model.WebhookDelivery, error) {
+	rows, err := s.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*model.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan delivery failed: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows failed: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func scanWebhook(row scanner) (*model.Webhook, error) {
+	webhook := &model.Webhook{}
+	var eventTypes string
+
+	err := row.Scan(&webhook.ID, &webhook.PartnerID, &webhook.URL, &webhook.Secret, &eventTypes, &webhook.Active, &webhook.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.EventTypes = splitList(eventTypes)
+	return webhook, nil
+}
+
+func scanDelivery(row scanner) (*model.WebhookDelivery, error) {
+	delivery := &model.WebhookDelivery{}
+	var deliveredAt sql.NullTime
+
+	err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType, (*[]byte)(&delivery.Payload), &delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastError, &delivery.ResponseStatus, &delivery.CreatedAt, &deliveredAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return delivery, nil
+}
+
+// utcTime stores times in one zone, SQLite compares them as text.
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
@@ -0,0 +1,72 @@
+// Package requestid carries the id of the request being served in a
+// context.Context and passes it on to outgoing calls.
+package requestid
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// Header is the HTTP header of the id.
+const Header = "X-Request-ID"
+
+const maxLength = 128
+
+type key struct{}
+
+// New generates an id.
+func New() string {
+	return uuid.New().String()
+}
+
+// Valid reports whether an id sent by a client can be used as is: it is
+// not empty, not longer than 128 bytes and made of printable ASCII without
+// spaces, so it is safe in headers and logs.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, key{}, id)
+}
+
+// From returns the id in ctx or an empty string.
+func From(ctx context.Context) string {
+	id, _ := ctx.Value(key{}).(string)
+	return id
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+// NewTransport returns a RoundTripper that sets the Header of requests whose
+// context carries an id. A nil base means http.DefaultTransport.
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return transport{base}
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := From(req.Context())
+	if id == "" || req.Header.Get(Header) != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the request.
+	req = req.Clone(req.Context())
+	req.Header.Set(Header, id)
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,38 @@
+package requestid_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RipperAcskt/innotaxi/internal/requestid"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestValid(t *testing.T) {
+	assert.Equal(t, requestid.Valid(requestid.New()), true)
+	assert.Equal(t, requestid.Valid("req-42"), true)
+	assert.Equal(t, requestid.Valid(""), false)
+	assert.Equal(t, requestid.Valid("bad id"), false)
+	assert.Equal(t, requestid.Valid("bad\nid"), false)
+	assert.Equal(t, requestid.Valid(strings.Repeat("a", 129)), false)
+}
+
+func TestTransport(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(requestid.Header)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: requestid.NewTransport(nil)}
+	req, _ := http.NewRequestWithContext(requestid.With(context.Background(), "req-42"), http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.Equal(t, err, nil)
+	resp.Body.Close()
+
+	assert.Equal(t, got, "req-42")
+	assert.Equal(t, req.Header.Get(requestid.Header), "")
+}
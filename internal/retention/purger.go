@@ -0,0 +1,67 @@
+// Package retention runs the purge of deleted users whose restore period
+// is over.
+package retention
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Users purges deleted users, it is implemented by
+// service.RetentionService.
+type Users interface {
+	PurgeUsers(ctx context.Context, now time.Time, limit int) (int, error)
+}
+
+// Purger purges users in batches. A user that fails to purge is retried on
+// the next run.
+type Purger struct {
+	users    Users
+	log      *zap.Logger
+	interval time.Duration
+	batch    int
+}
+
+func NewPurger(users Users, log *zap.Logger, interval time.Duration, batch int) *Purger {
+	return &Purger{users, log, interval, batch}
+}
+
+// Run purges users every interval until ctx is done.
+func (p *Purger) Run(ctx context.Context) {
+	delay := p.interval
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		n, err := p.Flush(ctx, time.Now())
+		switch {
+		case err != nil:
+			p.log.Error("user purger", zap.Error(err))
+			delay = p.interval
+		case n == p.batch:
+			// There may be more users to purge, don't wait.
+			delay = 0
+		default:
+			delay = p.interval
+		}
+	}
+}
+
+// Flush purges one batch of users whose restore period was over at now and
+// returns how many were purged.
+func (p *Purger) Flush(ctx context.Context, now time.Time) (int, error) {
+	n, err := p.users.PurgeUsers(ctx, now, p.batch)
+	if err != nil {
+		return n, fmt.Errorf("purge users failed: %w", err)
+	}
+	if n > 0 {
+		p.log.Info("user purger", zap.Int("purged", n))
+	}
+	return n, nil
+}
@@ -3,63 +3,128 @@ package service
 import (
 	"context"
 	"crypto/sha1"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/RipperAcskt/innotaxi/config"
+	"github.com/RipperAcskt/innotaxi/internal/apperror"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/tracing"
 )
 
 var (
-	ErrUserAlreadyExists = fmt.Errorf("user already exists")
-	ErrUserDoesNotExists = fmt.Errorf("user does not exists")
-	ErrIncorrectPassword = fmt.Errorf("incorrect password")
+	ErrUserAlreadyExists = apperror.New(apperror.Conflict, "user_already_exists", "user already exists")
+	ErrUserDoesNotExists = apperror.New(apperror.NotFound, "user_does_not_exist", "user does not exists")
+	ErrIncorrectPassword = apperror.New(apperror.PermissionDenied, "incorrect_password", "incorrect password")
+	ErrUserModified      = apperror.New(apperror.PreconditionFailed, "user_modified", "user was modified")
 )
 
 type UserSingUp struct {
-	Name        string `json:"name" binding:"required"`
-	PhoneNumber string `json:"phone_number" binding:"required"`
-	Email       string `json:"email" binding:"required"`
-	Password    string `json:"password" binding:"required"`
+	Name         string `json:"name" binding:"required,person_name"`
+	PhoneNumber  string `json:"phone_number" binding:"required,phone"`
+	Email        string `json:"email" binding:"required,rfc_email"`
+	Password     string `json:"password" binding:"required"`
+	ReferralCode string `json:"referral_code" binding:"max=16"`
+	DeviceID     string `json:"device_id" binding:"max=64"`
+
+	// Code is the user's own referral code, Referral is set if the user
+	// signed up with someone else's code.
+	Code     string          `json:"-"`
+	Referral *model.Referral `json:"-"`
 }
 
 type UserSingIn struct {
 	ID          uint64 `json:"-"`
 	PhoneNumber string `json:"phone_number" binding:"required"`
 	Password    string `json:"password" binding:"required"`
+	// Restore confirms restoring the account if it is deleted.
+	Restore bool `json:"restore"`
+
+	// DeletedAt is set for deleted users.
+	DeletedAt time.Time `json:"-"`
 }
 
 type AuthRepo interface {
+	// CreateUser stores the user and user.Referral, if any, atomically.
 	CreateUser(ctx context.Context, user UserSingUp) error
 	CheckUserByPhoneNumber(ctx context.Context, phone string) (*UserSingIn, error)
+	GetUserByReferralCode(ctx context.Context, code string) (*model.User, error)
+	CountReferralsByPhoneNumber(ctx context.Context, phone string) (int, error)
+	CountReferralsByDeviceId(ctx context.Context, deviceId string) (int, error)
+	// GetDeletedUserByPhoneNumber returns the user with the phone number
+	// deleted last, if it was deleted after since and isn't purged. Users
+	// deleted at an unknown time can't be restored.
+	GetDeletedUserByPhoneNumber(ctx context.Context, phone string, since time.Time) (*UserSingIn, error)
+	// RestoreUserById makes a deleted user active again. It returns
+	// ErrUserAlreadyExists if an active user took the phone number or the
+	// email in the meantime.
+	RestoreUserById(ctx context.Context, id string) error
 }
 
 type TokenRepo interface {
-	AddToken(token string, expired time.Duration) error
-	GetToken(token string) bool
+	AddToken(ctx context.Context, token string, expired time.Duration) error
+	GetToken(ctx context.Context, token string) bool
 }
 type AuthService struct {
 	AuthRepo
 	TokenRepo
-	salt string
-	cfg  *config.Config
+	outbox OutboxRepo
+	tx     Transactor
+	salt   string
+	cfg    *config.Config
 }
 
-func NewAuthSevice(postgres AuthRepo, redis TokenRepo, salt string, cfg *config.Config) *AuthService {
-	return &AuthService{postgres, redis, salt, cfg}
+func NewAuthSevice(postgres AuthRepo, redis TokenRepo, outbox OutboxRepo, tx Transactor, salt string, cfg *config.Config) *AuthService {
+	return &AuthService{postgres, redis, outbox, tx, salt, cfg}
 }
 
 func (s *AuthService) SingUp(ctx context.Context, user UserSingUp) error {
+	ctx, span := tracing.Start(ctx, "AuthService.SingUp")
+	defer span.End()
+
 	var err error
 	user.Password, err = s.GenerateHash(user.Password)
 	if err != nil {
 		return fmt.Errorf("generate hash failed: %w", err)
 	}
 
-	err = s.CreateUser(ctx, user)
+	user.Code, err = generateReferralCode()
 	if err != nil {
-		return err
+		return fmt.Errorf("generate referral code failed: %w", err)
 	}
-	return nil
+
+	// The referral checks and the insert run in one transaction, so two
+	// sign-ups from the same device can't both pass the checks.
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if user.ReferralCode != "" {
+			user.Referral, err = s.refer(ctx, user)
+			if err != nil {
+				return fmt.Errorf("refer failed: %w", err)
+			}
+		}
+
+		err := s.CreateUser(ctx, user)
+		if err != nil {
+			return err
+		}
+
+		// CreateUser doesn't return the id, the phone number is unique
+		// among active users and the lookup sees the uncommitted insert.
+		created, err := s.CheckUserByPhoneNumber(ctx, user.PhoneNumber)
+		if err != nil {
+			return fmt.Errorf("check user by phone number failed: %w", err)
+		}
+
+		id := strconv.FormatUint(created.ID, 10)
+		return addEvent(ctx, s.outbox, model.EventUserSignedUp, id, model.UserSignedUp{
+			UserID:      id,
+			Name:        user.Name,
+			PhoneNumber: user.PhoneNumber,
+			Email:       user.Email,
+		})
+	})
 }
 
 func (s *AuthService) GenerateHash(password string) (string, error) {
@@ -71,24 +136,76 @@ func (s *AuthService) GenerateHash(password string) (string, error) {
 	return string(hash.Sum([]byte(s.salt))), nil
 }
 
+// SingIn signs in an active user. A deleted user whose restore period isn't
+// over gets ErrUserDeleted, or is restored and signed in if user.Restore is
+// set.
 func (s *AuthService) SingIn(ctx context.Context, user UserSingIn) (*Token, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.SingIn")
+	defer span.End()
+
 	userDB, err := s.CheckUserByPhoneNumber(ctx, user.PhoneNumber)
+	if errors.Is(err, ErrUserDoesNotExists) {
+		return s.restore(ctx, user)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("check user by phone number failed: %w", err)
 	}
 
+	err = s.checkPassword(userDB, user.Password)
+	if err != nil {
+		return nil, err
+	}
+	return s.newToken(userDB.ID)
+}
+
+func (s *AuthService) restore(ctx context.Context, user UserSingIn) (*Token, error) {
+	period := RestorePeriod(s.cfg)
+	deleted, err := s.GetDeletedUserByPhoneNumber(ctx, user.PhoneNumber, time.Now().Add(-period))
+	if err != nil {
+		return nil, fmt.Errorf("get deleted user by phone number failed: %w", err)
+	}
+
+	err = s.checkPassword(deleted, user.Password)
+	if err != nil {
+		return nil, err
+	}
+	if !user.Restore {
+		return nil, ErrUserDeleted.WithDetail("sign in with restore set to true until %s to restore it", deleted.DeletedAt.Add(period).UTC().Format(time.RFC3339))
+	}
+
+	id := strconv.FormatUint(deleted.ID, 10)
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.RestoreUserById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return addEvent(ctx, s.outbox, model.EventUserRestored, id, model.UserRestored{
+			UserID: id,
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("restore user failed: %w", err)
+	}
+	return s.newToken(deleted.ID)
+}
+
+func (s *AuthService) checkPassword(user *UserSingIn, password string) error {
 	hash := sha1.New()
-	_, err = hash.Write([]byte(user.Password))
+	_, err := hash.Write([]byte(password))
 	if err != nil {
-		return nil, fmt.Errorf("write failed: %w", err)
+		return fmt.Errorf("write failed: %w", err)
 	}
 
-	if userDB.Password != string(hash.Sum([]byte(s.salt))) {
-		return nil, ErrIncorrectPassword
+	if user.Password != string(hash.Sum([]byte(s.salt))) {
+		return ErrIncorrectPassword
 	}
+	return nil
+}
 
+func (s *AuthService) newToken(id uint64) (*Token, error) {
 	params := TokenParams{
-		ID:                userDB.ID,
+		ID:                id,
 		Type:              User,
 		HS256_SECRET:      s.cfg.HS256_SECRET,
 		ACCESS_TOKEN_EXP:  s.cfg.ACCESS_TOKEN_EXP,
@@ -103,10 +220,25 @@ func (s *AuthService) SingIn(ctx context.Context, user UserSingIn) (*Token, erro
 	return token, nil
 }
 
-func (s *AuthService) Logout(userId string, token string, expired time.Duration) error {
-	return s.AddToken(token, expired)
+func (s *AuthService) Logout(ctx context.Context, userId string, token string, expired time.Duration) error {
+	ctx, span := tracing.Start(ctx, "AuthService.Logout")
+	defer span.End()
+
+	return s.AddToken(ctx, token, expired)
 }
 
-func (s *AuthService) CheckToken(userId string) bool {
-	return s.GetToken(userId)
+func (s *AuthService) CheckToken(ctx context.Context, userId string) bool {
+	ctx, span := tracing.Start(ctx, "AuthService.CheckToken")
+	defer span.End()
+
+	return s.GetToken(ctx, userId)
+}
+
+// CheckUser reports whether the tokens of the user aren't revoked. They are
+// revoked when the user is purged.
+func (s *AuthService) CheckUser(ctx context.Context, userId string) bool {
+	ctx, span := tracing.Start(ctx, "AuthService.CheckUser")
+	defer span.End()
+
+	return s.GetToken(ctx, revokedUserKey(userId))
 }
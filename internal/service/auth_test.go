@@ -2,22 +2,54 @@ package service_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/RipperAcskt/innotaxi/config"
+	"github.com/RipperAcskt/innotaxi/internal/model"
 	"github.com/RipperAcskt/innotaxi/internal/service"
 	"github.com/RipperAcskt/innotaxi/internal/service/mocks"
 	"github.com/go-playground/assert/v2"
 	"github.com/golang/mock/gomock"
 )
 
+// signUpMatcher matches a sign up ignoring the generated referral code.
+type signUpMatcher struct {
+	user service.UserSingUp
+}
+
+func (m signUpMatcher) Matches(x interface{}) bool {
+	user, ok := x.(service.UserSingUp)
+	if !ok || len(user.Code) != 8 {
+		return false
+	}
+	user.Code = m.user.Code
+	return reflect.DeepEqual(user, m.user)
+}
+
+func (m signUpMatcher) String() string {
+	return fmt.Sprintf("is sign up %v", m.user)
+}
+
+// newTransactor returns a Transactor that runs fn with the ctx it was given.
+func newTransactor(ctrl *gomock.Controller) *mocks.MockTransactor {
+	tx := mocks.NewMockTransactor(ctrl)
+	tx.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		}).AnyTimes()
+	return tx
+}
+
 func TestSingUp(t *testing.T) {
-	type mockBehavior func(s *mocks.MockAuthRepo, user service.UserSingUp)
+	type mockBehavior func(s *mocks.MockAuthRepo, o *mocks.MockOutboxRepo, user service.UserSingUp)
 	type fileds struct {
-		authRepo  *mocks.MockAuthRepo
-		tokenRepo *mocks.MockTokenRepo
+		authRepo   *mocks.MockAuthRepo
+		tokenRepo  *mocks.MockTokenRepo
+		outboxRepo *mocks.MockOutboxRepo
 	}
 	test := []struct {
 		name         string
@@ -33,11 +65,31 @@ func TestSingUp(t *testing.T) {
 				Email:       "ripper@algsdh",
 				Password:    "12345",
 			},
-			mockBehavior: func(s *mocks.MockAuthRepo, user service.UserSingUp) {
-				s.EXPECT().CreateUser(context.Background(), user).Return(nil)
+			mockBehavior: func(s *mocks.MockAuthRepo, o *mocks.MockOutboxRepo, user service.UserSingUp) {
+				s.EXPECT().CreateUser(gomock.Any(), signUpMatcher{user}).Return(nil)
+				s.EXPECT().CheckUserByPhoneNumber(gomock.Any(), user.PhoneNumber).Return(&service.UserSingIn{ID: 9}, nil)
+				o.EXPECT().AddEvent(gomock.Any(), eventMatcher{model.EventUserSignedUp, "9", model.UserSignedUp{
+					UserID:      "9",
+					Name:        user.Name,
+					PhoneNumber: user.PhoneNumber,
+					Email:       user.Email,
+				}}).Return(nil)
 			},
 			err: nil,
 		},
+		{
+			name: "user already exists",
+			user: service.UserSingUp{
+				Name:        "Ivan",
+				PhoneNumber: "+7455456",
+				Email:       "ripper@algsdh",
+				Password:    "12345",
+			},
+			mockBehavior: func(s *mocks.MockAuthRepo, o *mocks.MockOutboxRepo, user service.UserSingUp) {
+				s.EXPECT().CreateUser(gomock.Any(), signUpMatcher{user}).Return(service.ErrUserAlreadyExists)
+			},
+			err: service.ErrUserAlreadyExists,
+		},
 	}
 
 	for _, tt := range test {
@@ -46,31 +98,39 @@ func TestSingUp(t *testing.T) {
 			defer ctrl.Finish()
 
 			f := fileds{
-				authRepo:  mocks.NewMockAuthRepo(ctrl),
-				tokenRepo: mocks.NewMockTokenRepo(ctrl),
+				authRepo:   mocks.NewMockAuthRepo(ctrl),
+				tokenRepo:  mocks.NewMockTokenRepo(ctrl),
+				outboxRepo: mocks.NewMockOutboxRepo(ctrl),
 			}
 
 			service := service.Service{
-				AuthService: service.NewAuthSevice(f.authRepo, f.tokenRepo, "124jkhsdaf3425", &config.Config{}),
+				AuthService: service.NewAuthSevice(f.authRepo, f.tokenRepo, f.outboxRepo, newTransactor(ctrl), "124jkhsdaf3425", &config.Config{}),
 			}
 
 			tmpPass := tt.user.Password
 			tt.user.Password, _ = service.GenerateHash(tt.user.Password)
-			tt.mockBehavior(f.authRepo, tt.user)
+			tt.mockBehavior(f.authRepo, f.outboxRepo, tt.user)
 
 			tt.user.Password = tmpPass
 			err := service.SingUp(context.Background(), tt.user)
-			assert.IsEqual(err, tt.err)
+			assert.Equal(t, errors.Is(err, tt.err), true)
 		})
 	}
 }
 
 func TestSingIn(t *testing.T) {
-	type mockBehavior func(s *mocks.MockAuthRepo, phone_number string)
+	type mockBehavior func(s *mocks.MockAuthRepo, o *mocks.MockOutboxRepo, phone_number string)
 	type fileds struct {
-		authRepo  *mocks.MockAuthRepo
-		tokenRepo *mocks.MockTokenRepo
+		authRepo   *mocks.MockAuthRepo
+		tokenRepo  *mocks.MockTokenRepo
+		outboxRepo *mocks.MockOutboxRepo
+	}
+
+	password := string([]byte{49, 50, 52, 106, 107, 104, 115, 100, 97, 102, 51, 52, 50, 53, 218, 75, 146, 55, 186, 204, 205, 241, 156, 7, 96, 202, 183, 174, 196, 168, 53, 144, 16, 176})
+	deleted := func() *service.UserSingIn {
+		return &service.UserSingIn{ID: 9, PhoneNumber: "2", Password: password, DeletedAt: time.Now().Add(-time.Hour)}
 	}
+
 	test := []struct {
 		name         string
 		user         service.UserSingIn
@@ -84,11 +144,11 @@ func TestSingIn(t *testing.T) {
 				PhoneNumber: "2",
 				Password:    "2",
 			},
-			mockBehavior: func(s *mocks.MockAuthRepo, phone_number string) {
-				s.EXPECT().CheckUserByPhoneNumber(context.Background(), phone_number).Return(&service.UserSingIn{
+			mockBehavior: func(s *mocks.MockAuthRepo, o *mocks.MockOutboxRepo, phone_number string) {
+				s.EXPECT().CheckUserByPhoneNumber(gomock.Any(), phone_number).Return(&service.UserSingIn{
 					ID:          9,
 					PhoneNumber: "2",
-					Password:    string([]byte{49, 50, 52, 106, 107, 104, 115, 100, 97, 102, 51, 52, 50, 53, 218, 75, 146, 55, 186, 204, 205, 241, 156, 7, 96, 202, 183, 174, 196, 168, 53, 144, 16, 176}),
+					Password:    password,
 				}, nil)
 			},
 			token: "",
@@ -100,11 +160,84 @@ func TestSingIn(t *testing.T) {
 				PhoneNumber: "+7455456",
 				Password:    "123456",
 			},
-			mockBehavior: func(s *mocks.MockAuthRepo, phone_number string) {
-				s.EXPECT().CheckUserByPhoneNumber(context.Background(), phone_number).Return(&service.UserSingIn{}, nil)
+			mockBehavior: func(s *mocks.MockAuthRepo, o *mocks.MockOutboxRepo, phone_number string) {
+				s.EXPECT().CheckUserByPhoneNumber(gomock.Any(), phone_number).Return(&service.UserSingIn{}, nil)
+			},
+			token: "",
+			err:   service.ErrIncorrectPassword,
+		},
+		{
+			name: "user does not exist",
+			user: service.UserSingIn{
+				PhoneNumber: "2",
+				Password:    "2",
+			},
+			mockBehavior: func(s *mocks.MockAuthRepo, o *mocks.MockOutboxRepo, phone_number string) {
+				s.EXPECT().CheckUserByPhoneNumber(gomock.Any(), phone_number).Return(nil, service.ErrUserDoesNotExists)
+				s.EXPECT().GetDeletedUserByPhoneNumber(gomock.Any(), phone_number, gomock.Any()).Return(nil, service.ErrUserDoesNotExists)
 			},
 			token: "",
-			err:   fmt.Errorf("incorrect password"),
+			err:   service.ErrUserDoesNotExists,
+		},
+		{
+			name: "deleted user",
+			user: service.UserSingIn{
+				PhoneNumber: "2",
+				Password:    "2",
+			},
+			mockBehavior: func(s *mocks.MockAuthRepo, o *mocks.MockOutboxRepo, phone_number string) {
+				s.EXPECT().CheckUserByPhoneNumber(gomock.Any(), phone_number).Return(nil, service.ErrUserDoesNotExists)
+				s.EXPECT().GetDeletedUserByPhoneNumber(gomock.Any(), phone_number, gomock.Any()).Return(deleted(), nil)
+			},
+			token: "",
+			err:   service.ErrUserDeleted,
+		},
+		{
+			name: "deleted user incorrect password",
+			user: service.UserSingIn{
+				PhoneNumber: "2",
+				Password:    "3",
+				Restore:     true,
+			},
+			mockBehavior: func(s *mocks.MockAuthRepo, o *mocks.MockOutboxRepo, phone_number string) {
+				s.EXPECT().CheckUserByPhoneNumber(gomock.Any(), phone_number).Return(nil, service.ErrUserDoesNotExists)
+				s.EXPECT().GetDeletedUserByPhoneNumber(gomock.Any(), phone_number, gomock.Any()).Return(deleted(), nil)
+			},
+			token: "",
+			err:   service.ErrIncorrectPassword,
+		},
+		{
+			name: "restore",
+			user: service.UserSingIn{
+				PhoneNumber: "2",
+				Password:    "2",
+				Restore:     true,
+			},
+			mockBehavior: func(s *mocks.MockAuthRepo, o *mocks.MockOutboxRepo, phone_number string) {
+				s.EXPECT().CheckUserByPhoneNumber(gomock.Any(), phone_number).Return(nil, service.ErrUserDoesNotExists)
+				s.EXPECT().GetDeletedUserByPhoneNumber(gomock.Any(), phone_number, gomock.Any()).Return(deleted(), nil)
+				s.EXPECT().RestoreUserById(gomock.Any(), "9").Return(nil)
+				o.EXPECT().AddEvent(gomock.Any(), eventMatcher{model.EventUserRestored, "9", model.UserRestored{
+					UserID: "9",
+				}}).Return(nil)
+			},
+			token: "",
+			err:   nil,
+		},
+		{
+			name: "restore taken phone number",
+			user: service.UserSingIn{
+				PhoneNumber: "2",
+				Password:    "2",
+				Restore:     true,
+			},
+			mockBehavior: func(s *mocks.MockAuthRepo, o *mocks.MockOutboxRepo, phone_number string) {
+				s.EXPECT().CheckUserByPhoneNumber(gomock.Any(), phone_number).Return(nil, service.ErrUserDoesNotExists)
+				s.EXPECT().GetDeletedUserByPhoneNumber(gomock.Any(), phone_number, gomock.Any()).Return(deleted(), nil)
+				s.EXPECT().RestoreUserById(gomock.Any(), "9").Return(service.ErrUserAlreadyExists)
+			},
+			token: "",
+			err:   service.ErrUserAlreadyExists,
 		},
 	}
 
@@ -114,12 +247,13 @@ func TestSingIn(t *testing.T) {
 			defer ctrl.Finish()
 
 			f := fileds{
-				authRepo:  mocks.NewMockAuthRepo(ctrl),
-				tokenRepo: mocks.NewMockTokenRepo(ctrl),
+				authRepo:   mocks.NewMockAuthRepo(ctrl),
+				tokenRepo:  mocks.NewMockTokenRepo(ctrl),
+				outboxRepo: mocks.NewMockOutboxRepo(ctrl),
 			}
-			authService := service.NewAuthSevice(f.authRepo, f.tokenRepo, "124jkhsdaf3425", &config.Config{})
+			authService := service.NewAuthSevice(f.authRepo, f.tokenRepo, f.outboxRepo, newTransactor(ctrl), "124jkhsdaf3425", &config.Config{})
 
-			tt.mockBehavior(f.authRepo, tt.user.PhoneNumber)
+			tt.mockBehavior(f.authRepo, f.outboxRepo, tt.user.PhoneNumber)
 
 			service := service.Service{
 				AuthService: authService,
@@ -127,7 +261,7 @@ func TestSingIn(t *testing.T) {
 
 			token, err := service.SingIn(context.Background(), tt.user)
 			assert.NotEqual(t, token, tt.token)
-			assert.Equal(t, err, tt.err)
+			assert.Equal(t, errors.Is(err, tt.err), true)
 		})
 	}
 
@@ -161,7 +295,7 @@ func TestGenerateHash(t *testing.T) {
 				authRepo:  mocks.NewMockAuthRepo(ctrl),
 				tokenRepo: mocks.NewMockTokenRepo(ctrl),
 			}
-			authService := service.NewAuthSevice(f.authRepo, f.tokenRepo, "124jkhsdaf3425", &config.Config{})
+			authService := service.NewAuthSevice(f.authRepo, f.tokenRepo, mocks.NewMockOutboxRepo(ctrl), newTransactor(ctrl), "124jkhsdaf3425", &config.Config{})
 
 			service := service.Service{
 				AuthService: authService,
@@ -188,7 +322,7 @@ func TestLogout(t *testing.T) {
 		{
 			name: "logout",
 			mockBehavior: func(s *mocks.MockTokenRepo) {
-				s.EXPECT().AddToken("", time.Duration(123)).Return(nil)
+				s.EXPECT().AddToken(gomock.Any(), "", time.Duration(123)).Return(nil)
 			},
 			err: nil,
 		},
@@ -203,14 +337,14 @@ func TestLogout(t *testing.T) {
 				authRepo:  mocks.NewMockAuthRepo(ctrl),
 				tokenRepo: mocks.NewMockTokenRepo(ctrl),
 			}
-			authService := service.NewAuthSevice(f.authRepo, f.tokenRepo, "124jkhsdaf3425", &config.Config{})
+			authService := service.NewAuthSevice(f.authRepo, f.tokenRepo, mocks.NewMockOutboxRepo(ctrl), newTransactor(ctrl), "124jkhsdaf3425", &config.Config{})
 
 			service := service.Service{
 				AuthService: authService,
 			}
 
 			tt.mockBehavior(f.tokenRepo)
-			err := service.Logout("", "", time.Duration(123))
+			err := service.Logout(context.Background(), "", "", time.Duration(123))
 			assert.Equal(t, err, tt.err)
 		})
 	}
@@ -230,7 +364,7 @@ func TestCheckToken(t *testing.T) {
 		{
 			name: "check token",
 			mockBehavior: func(s *mocks.MockTokenRepo) {
-				s.EXPECT().GetToken("0").Return(false)
+				s.EXPECT().GetToken(gomock.Any(), "0").Return(false)
 			},
 			exist: false,
 		},
@@ -245,14 +379,14 @@ func TestCheckToken(t *testing.T) {
 				authRepo:  mocks.NewMockAuthRepo(ctrl),
 				tokenRepo: mocks.NewMockTokenRepo(ctrl),
 			}
-			authService := service.NewAuthSevice(f.authRepo, f.tokenRepo, "124jkhsdaf3425", &config.Config{})
+			authService := service.NewAuthSevice(f.authRepo, f.tokenRepo, mocks.NewMockOutboxRepo(ctrl), newTransactor(ctrl), "124jkhsdaf3425", &config.Config{})
 
 			service := service.Service{
 				AuthService: authService,
 			}
 
 			tt.mockBehavior(f.tokenRepo)
-			err := service.CheckToken("0")
+			err := service.CheckToken(context.Background(), "0")
 			assert.Equal(t, err, tt.exist)
 		})
 	}
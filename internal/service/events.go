@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+)
+
+// OutboxRepo stores domain events next to the changes they describe.
+// AddEvent must be called with the ctx of the transaction that makes the
+// change, so the event is stored if and only if the change is committed.
+type OutboxRepo interface {
+	AddEvent(ctx context.Context, event *model.Event) error
+	// GetPendingEvents returns up to limit unpublished events, oldest first.
+	GetPendingEvents(ctx context.Context, limit int) ([]*model.Event, error)
+	MarkEventPublished(ctx context.Context, id uint64) error
+	// MarkEventFailed counts a failed delivery attempt and keeps the
+	// event pending.
+	MarkEventFailed(ctx context.Context, id uint64, reason string) error
+	// MarkEventDead counts the last failed delivery attempt and takes the
+	// event out of the pending ones. The event is kept in the outbox.
+	MarkEventDead(ctx context.Context, id uint64, reason string) error
+}
+
+func addEvent(ctx context.Context, outbox OutboxRepo, eventType, userId string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal failed: %w", err)
+	}
+
+	err = outbox.AddEvent(ctx, &model.Event{
+		Type:        eventType,
+		AggregateID: userId,
+		Payload:     data,
+	})
+	if err != nil {
+		return fmt.Errorf("add event failed: %w", err)
+	}
+	return nil
+}
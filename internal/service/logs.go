@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/RipperAcskt/innotaxi/internal/apperror"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/tracing"
+)
+
+const (
+	defaultLogsLimit = 50
+	maxLogsLimit     = 500
+)
+
+var (
+	ErrLogsUnavailable = apperror.New(apperror.NotImplemented, "logs_unavailable", "logs are not stored")
+	ErrUnknownLogLevel = apperror.New(apperror.Invalid, "unknown_log_level", "unknown log level")
+	ErrBadLogRange     = apperror.New(apperror.Invalid, "bad_log_range", "from is after to")
+	ErrBadLogCursor    = apperror.New(apperror.Invalid, "bad_log_cursor", "bad cursor")
+)
+
+// logLevels are the levels zap writes.
+var logLevels = map[string]bool{
+	"debug":  true,
+	"info":   true,
+	"warn":   true,
+	"error":  true,
+	"dpanic": true,
+	"panic":  true,
+	"fatal":  true,
+}
+
+type LogRepo interface {
+	// GetLogs returns up to filter.Limit logs matching filter, newest first,
+	// starting after filter.Cursor. A cursor the repo didn't return is
+	// ErrBadLogCursor.
+	GetLogs(ctx context.Context, filter *model.LogFilter) ([]*model.Log, error)
+}
+
+// LogService reads the stored logs. Logs are stored only with Mongo, so the
+// repo is nil with the other storages.
+type LogService struct {
+	LogRepo
+}
+
+func NewLogService(mongo LogRepo) *LogService {
+	return &LogService{mongo}
+}
+
+func (s *LogService) SearchLogs(ctx context.Context, filter model.LogFilter) (*model.LogPage, error) {
+	ctx, span := tracing.Start(ctx, "LogService.SearchLogs")
+	defer span.End()
+
+	if s.LogRepo == nil {
+		return nil, ErrLogsUnavailable
+	}
+	if filter.Level != "" && !logLevels[filter.Level] {
+		return nil, fmt.Errorf("level: %v: %w", filter.Level, ErrUnknownLogLevel)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.From.After(filter.To) {
+		return nil, ErrBadLogRange
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultLogsLimit
+	}
+	if filter.Limit > maxLogsLimit {
+		filter.Limit = maxLogsLimit
+	}
+
+	limit := filter.Limit
+	// One more log tells whether there is a next page.
+	filter.Limit++
+	logs, err := s.GetLogs(ctx, &filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &model.LogPage{Logs: logs}
+	if len(logs) > limit {
+		page.Logs = logs[:limit]
+		page.NextCursor = logs[limit-1].ID
+	}
+	return page, nil
+}
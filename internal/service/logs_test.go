@@ -0,0 +1,125 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/RipperAcskt/innotaxi/internal/service/mocks"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func logs(n int) []*model.Log {
+	logs := make([]*model.Log, 0, n)
+	for i := n; i > 0; i-- {
+		logs = append(logs, &model.Log{ID: string(rune('a' + i)), Level: "error"})
+	}
+	return logs
+}
+
+func TestSearchLogs(t *testing.T) {
+	type mockBehavior func(s *mocks.MockLogRepo)
+
+	test := []struct {
+		name         string
+		filter       model.LogFilter
+		mockBehavior mockBehavior
+		count        int
+		nextCursor   string
+		err          error
+	}{
+		{
+			name:   "last page",
+			filter: model.LogFilter{Level: "error", Limit: 2},
+			mockBehavior: func(s *mocks.MockLogRepo) {
+				s.EXPECT().GetLogs(gomock.Any(), &model.LogFilter{Level: "error", Limit: 3}).Return(logs(2), nil)
+			},
+			count: 2,
+			err:   nil,
+		},
+		{
+			name:   "next page",
+			filter: model.LogFilter{Limit: 2},
+			mockBehavior: func(s *mocks.MockLogRepo) {
+				s.EXPECT().GetLogs(gomock.Any(), &model.LogFilter{Limit: 3}).Return(logs(3), nil)
+			},
+			count:      2,
+			nextCursor: "c",
+			err:        nil,
+		},
+		{
+			name:   "default limit",
+			filter: model.LogFilter{},
+			mockBehavior: func(s *mocks.MockLogRepo) {
+				s.EXPECT().GetLogs(gomock.Any(), &model.LogFilter{Limit: 51}).Return(logs(0), nil)
+			},
+			count: 0,
+			err:   nil,
+		},
+		{
+			name:   "max limit",
+			filter: model.LogFilter{Limit: 10000},
+			mockBehavior: func(s *mocks.MockLogRepo) {
+				s.EXPECT().GetLogs(gomock.Any(), &model.LogFilter{Limit: 501}).Return(logs(0), nil)
+			},
+			count: 0,
+			err:   nil,
+		},
+		{
+			name:         "unknown level",
+			filter:       model.LogFilter{Level: "verbose"},
+			mockBehavior: func(s *mocks.MockLogRepo) {},
+			err:          service.ErrUnknownLogLevel,
+		},
+		{
+			name:         "bad range",
+			filter:       model.LogFilter{From: time.Now(), To: time.Now().Add(-time.Hour)},
+			mockBehavior: func(s *mocks.MockLogRepo) {},
+			err:          service.ErrBadLogRange,
+		},
+		{
+			name:   "bad cursor",
+			filter: model.LogFilter{Cursor: "bad"},
+			mockBehavior: func(s *mocks.MockLogRepo) {
+				s.EXPECT().GetLogs(gomock.Any(), gomock.Any()).Return(nil, service.ErrBadLogCursor)
+			},
+			err: service.ErrBadLogCursor,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			logRepo := mocks.NewMockLogRepo(ctrl)
+			tt.mockBehavior(logRepo)
+
+			s := service.Service{
+				LogService: service.NewLogService(logRepo),
+			}
+
+			page, err := s.SearchLogs(context.Background(), tt.filter)
+			assert.Equal(t, errors.Is(err, tt.err), true)
+			if tt.err != nil {
+				return
+			}
+
+			assert.Equal(t, len(page.Logs), tt.count)
+			assert.Equal(t, page.NextCursor, tt.nextCursor)
+		})
+	}
+}
+
+func TestSearchLogsUnavailable(t *testing.T) {
+	s := service.Service{
+		LogService: service.NewLogService(nil),
+	}
+
+	_, err := s.SearchLogs(context.Background(), model.LogFilter{})
+	assert.Equal(t, err, service.ErrLogsUnavailable)
+}
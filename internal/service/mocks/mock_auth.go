@@ -7,7 +7,9 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
+	model "github.com/RipperAcskt/innotaxi/internal/model"
 	service "github.com/RipperAcskt/innotaxi/internal/service"
 	gomock "github.com/golang/mock/gomock"
 )
@@ -50,6 +52,36 @@ func (mr *MockAuthRepoMockRecorder) CheckUserByPhoneNumber(arg0, arg1 interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckUserByPhoneNumber", reflect.TypeOf((*MockAuthRepo)(nil).CheckUserByPhoneNumber), arg0, arg1)
 }
 
+// CountReferralsByDeviceId mocks base method.
+func (m *MockAuthRepo) CountReferralsByDeviceId(arg0 context.Context, arg1 string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountReferralsByDeviceId", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountReferralsByDeviceId indicates an expected call of CountReferralsByDeviceId.
+func (mr *MockAuthRepoMockRecorder) CountReferralsByDeviceId(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountReferralsByDeviceId", reflect.TypeOf((*MockAuthRepo)(nil).CountReferralsByDeviceId), arg0, arg1)
+}
+
+// CountReferralsByPhoneNumber mocks base method.
+func (m *MockAuthRepo) CountReferralsByPhoneNumber(arg0 context.Context, arg1 string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountReferralsByPhoneNumber", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountReferralsByPhoneNumber indicates an expected call of CountReferralsByPhoneNumber.
+func (mr *MockAuthRepoMockRecorder) CountReferralsByPhoneNumber(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountReferralsByPhoneNumber", reflect.TypeOf((*MockAuthRepo)(nil).CountReferralsByPhoneNumber), arg0, arg1)
+}
+
 // CreateUser mocks base method.
 func (m *MockAuthRepo) CreateUser(arg0 context.Context, arg1 service.UserSingUp) error {
 	m.ctrl.T.Helper()
@@ -63,3 +95,47 @@ func (mr *MockAuthRepoMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAuthRepo)(nil).CreateUser), arg0, arg1)
 }
+
+// GetDeletedUserByPhoneNumber mocks base method.
+func (m *MockAuthRepo) GetDeletedUserByPhoneNumber(arg0 context.Context, arg1 string, arg2 time.Time) (*service.UserSingIn, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedUserByPhoneNumber", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*service.UserSingIn)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedUserByPhoneNumber indicates an expected call of GetDeletedUserByPhoneNumber.
+func (mr *MockAuthRepoMockRecorder) GetDeletedUserByPhoneNumber(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedUserByPhoneNumber", reflect.TypeOf((*MockAuthRepo)(nil).GetDeletedUserByPhoneNumber), arg0, arg1, arg2)
+}
+
+// GetUserByReferralCode mocks base method.
+func (m *MockAuthRepo) GetUserByReferralCode(arg0 context.Context, arg1 string) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByReferralCode", arg0, arg1)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByReferralCode indicates an expected call of GetUserByReferralCode.
+func (mr *MockAuthRepoMockRecorder) GetUserByReferralCode(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByReferralCode", reflect.TypeOf((*MockAuthRepo)(nil).GetUserByReferralCode), arg0, arg1)
+}
+
+// RestoreUserById mocks base method.
+func (m *MockAuthRepo) RestoreUserById(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUserById", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreUserById indicates an expected call of RestoreUserById.
+func (mr *MockAuthRepoMockRecorder) RestoreUserById(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUserById", reflect.TypeOf((*MockAuthRepo)(nil).RestoreUserById), arg0, arg1)
+}
@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/RipperAcskt/innotaxi/internal/service (interfaces: LogRepo)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/RipperAcskt/innotaxi/internal/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockLogRepo is a mock of LogRepo interface.
+type MockLogRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockLogRepoMockRecorder
+}
+
+// MockLogRepoMockRecorder is the mock recorder for MockLogRepo.
+type MockLogRepoMockRecorder struct {
+	mock *MockLogRepo
+}
+
+// NewMockLogRepo creates a new mock instance.
+func NewMockLogRepo(ctrl *gomock.Controller) *MockLogRepo {
+	mock := &MockLogRepo{ctrl: ctrl}
+	mock.recorder = &MockLogRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLogRepo) EXPECT() *MockLogRepoMockRecorder {
+	return m.recorder
+}
+
+// GetLogs mocks base method.
+func (m *MockLogRepo) GetLogs(arg0 context.Context, arg1 *model.LogFilter) ([]*model.Log, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLogs", arg0, arg1)
+	ret0, _ := ret[0].([]*model.Log)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLogs indicates an expected call of GetLogs.
+func (mr *MockLogRepoMockRecorder) GetLogs(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLogs", reflect.TypeOf((*MockLogRepo)(nil).GetLogs), arg0, arg1)
+}
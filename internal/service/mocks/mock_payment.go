@@ -0,0 +1,196 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/RipperAcskt/innotaxi/internal/service (interfaces: PaymentRepo)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/RipperAcskt/innotaxi/internal/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPaymentRepo is a mock of PaymentRepo interface.
+type MockPaymentRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentRepoMockRecorder
+}
+
+// MockPaymentRepoMockRecorder is the mock recorder for MockPaymentRepo.
+type MockPaymentRepoMockRecorder struct {
+	mock *MockPaymentRepo
+}
+
+// NewMockPaymentRepo creates a new mock instance.
+func NewMockPaymentRepo(ctrl *gomock.Controller) *MockPaymentRepo {
+	mock := &MockPaymentRepo{ctrl: ctrl}
+	mock.recorder = &MockPaymentRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentRepo) EXPECT() *MockPaymentRepoMockRecorder {
+	return m.recorder
+}
+
+// AddCapture mocks base method.
+func (m *MockPaymentRepo) AddCapture(arg0 context.Context, arg1 string, arg2 int64) (*model.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCapture", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddCapture indicates an expected call of AddCapture.
+func (mr *MockPaymentRepoMockRecorder) AddCapture(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCapture", reflect.TypeOf((*MockPaymentRepo)(nil).AddCapture), arg0, arg1, arg2)
+}
+
+// AddGatewayEvent mocks base method.
+func (m *MockPaymentRepo) AddGatewayEvent(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddGatewayEvent", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddGatewayEvent indicates an expected call of AddGatewayEvent.
+func (mr *MockPaymentRepoMockRecorder) AddGatewayEvent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddGatewayEvent", reflect.TypeOf((*MockPaymentRepo)(nil).AddGatewayEvent), arg0, arg1)
+}
+
+// AddRefund mocks base method.
+func (m *MockPaymentRepo) AddRefund(arg0 context.Context, arg1 string, arg2 int64) (*model.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRefund", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddRefund indicates an expected call of AddRefund.
+func (mr *MockPaymentRepoMockRecorder) AddRefund(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRefund", reflect.TypeOf((*MockPaymentRepo)(nil).AddRefund), arg0, arg1, arg2)
+}
+
+// CreatePayment mocks base method.
+func (m *MockPaymentRepo) CreatePayment(arg0 context.Context, arg1 *model.Payment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePayment", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePayment indicates an expected call of CreatePayment.
+func (mr *MockPaymentRepoMockRecorder) CreatePayment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePayment", reflect.TypeOf((*MockPaymentRepo)(nil).CreatePayment), arg0, arg1)
+}
+
+// CreatePaymentMethod mocks base method.
+func (m *MockPaymentRepo) CreatePaymentMethod(arg0 context.Context, arg1 string, arg2 *model.PaymentMethod) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePaymentMethod", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePaymentMethod indicates an expected call of CreatePaymentMethod.
+func (mr *MockPaymentRepoMockRecorder) CreatePaymentMethod(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePaymentMethod", reflect.TypeOf((*MockPaymentRepo)(nil).CreatePaymentMethod), arg0, arg1, arg2)
+}
+
+// DeletePaymentMethodById mocks base method.
+func (m *MockPaymentRepo) DeletePaymentMethodById(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePaymentMethodById", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePaymentMethodById indicates an expected call of DeletePaymentMethodById.
+func (mr *MockPaymentRepoMockRecorder) DeletePaymentMethodById(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePaymentMethodById", reflect.TypeOf((*MockPaymentRepo)(nil).DeletePaymentMethodById), arg0, arg1, arg2)
+}
+
+// GetPaymentByAuthorizationId mocks base method.
+func (m *MockPaymentRepo) GetPaymentByAuthorizationId(arg0 context.Context, arg1 string) (*model.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentByAuthorizationId", arg0, arg1)
+	ret0, _ := ret[0].(*model.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentByAuthorizationId indicates an expected call of GetPaymentByAuthorizationId.
+func (mr *MockPaymentRepoMockRecorder) GetPaymentByAuthorizationId(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentByAuthorizationId", reflect.TypeOf((*MockPaymentRepo)(nil).GetPaymentByAuthorizationId), arg0, arg1)
+}
+
+// GetPaymentByOrderId mocks base method.
+func (m *MockPaymentRepo) GetPaymentByOrderId(arg0 context.Context, arg1 string) (*model.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentByOrderId", arg0, arg1)
+	ret0, _ := ret[0].(*model.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentByOrderId indicates an expected call of GetPaymentByOrderId.
+func (mr *MockPaymentRepoMockRecorder) GetPaymentByOrderId(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentByOrderId", reflect.TypeOf((*MockPaymentRepo)(nil).GetPaymentByOrderId), arg0, arg1)
+}
+
+// GetPaymentMethodById mocks base method.
+func (m *MockPaymentRepo) GetPaymentMethodById(arg0 context.Context, arg1, arg2 string) (*model.PaymentMethod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentMethodById", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.PaymentMethod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentMethodById indicates an expected call of GetPaymentMethodById.
+func (mr *MockPaymentRepoMockRecorder) GetPaymentMethodById(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentMethodById", reflect.TypeOf((*MockPaymentRepo)(nil).GetPaymentMethodById), arg0, arg1, arg2)
+}
+
+// GetPaymentMethodsByUserId mocks base method.
+func (m *MockPaymentRepo) GetPaymentMethodsByUserId(arg0 context.Context, arg1 string) ([]*model.PaymentMethod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentMethodsByUserId", arg0, arg1)
+	ret0, _ := ret[0].([]*model.PaymentMethod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentMethodsByUserId indicates an expected call of GetPaymentMethodsByUserId.
+func (mr *MockPaymentRepoMockRecorder) GetPaymentMethodsByUserId(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentMethodsByUserId", reflect.TypeOf((*MockPaymentRepo)(nil).GetPaymentMethodsByUserId), arg0, arg1)
+}
+
+// UpdatePayment mocks base method.
+func (m *MockPaymentRepo) UpdatePayment(arg0 context.Context, arg1 *model.Payment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePayment", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePayment indicates an expected call of UpdatePayment.
+func (mr *MockPaymentRepoMockRecorder) UpdatePayment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePayment", reflect.TypeOf((*MockPaymentRepo)(nil).UpdatePayment), arg0, arg1)
+}
@@ -0,0 +1,108 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/RipperAcskt/innotaxi/internal/service (interfaces: PlaceRepo)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/RipperAcskt/innotaxi/internal/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPlaceRepo is a mock of PlaceRepo interface.
+type MockPlaceRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockPlaceRepoMockRecorder
+}
+
+// MockPlaceRepoMockRecorder is the mock recorder for MockPlaceRepo.
+type MockPlaceRepoMockRecorder struct {
+	mock *MockPlaceRepo
+}
+
+// NewMockPlaceRepo creates a new mock instance.
+func NewMockPlaceRepo(ctrl *gomock.Controller) *MockPlaceRepo {
+	mock := &MockPlaceRepo{ctrl: ctrl}
+	mock.recorder = &MockPlaceRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPlaceRepo) EXPECT() *MockPlaceRepoMockRecorder {
+	return m.recorder
+}
+
+// CreatePlace mocks base method.
+func (m *MockPlaceRepo) CreatePlace(arg0 context.Context, arg1 string, arg2 *model.Place, arg3 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePlace", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePlace indicates an expected call of CreatePlace.
+func (mr *MockPlaceRepoMockRecorder) CreatePlace(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePlace", reflect.TypeOf((*MockPlaceRepo)(nil).CreatePlace), arg0, arg1, arg2, arg3)
+}
+
+// DeletePlaceById mocks base method.
+func (m *MockPlaceRepo) DeletePlaceById(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePlaceById", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePlaceById indicates an expected call of DeletePlaceById.
+func (mr *MockPlaceRepoMockRecorder) DeletePlaceById(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePlaceById", reflect.TypeOf((*MockPlaceRepo)(nil).DeletePlaceById), arg0, arg1, arg2)
+}
+
+// GetPlaceById mocks base method.
+func (m *MockPlaceRepo) GetPlaceById(arg0 context.Context, arg1, arg2 string) (*model.Place, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPlaceById", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.Place)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPlaceById indicates an expected call of GetPlaceById.
+func (mr *MockPlaceRepoMockRecorder) GetPlaceById(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPlaceById", reflect.TypeOf((*MockPlaceRepo)(nil).GetPlaceById), arg0, arg1, arg2)
+}
+
+// GetPlacesByUserId mocks base method.
+func (m *MockPlaceRepo) GetPlacesByUserId(arg0 context.Context, arg1 string) ([]*model.Place, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPlacesByUserId", arg0, arg1)
+	ret0, _ := ret[0].([]*model.Place)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPlacesByUserId indicates an expected call of GetPlacesByUserId.
+func (mr *MockPlaceRepoMockRecorder) GetPlacesByUserId(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPlacesByUserId", reflect.TypeOf((*MockPlaceRepo)(nil).GetPlacesByUserId), arg0, arg1)
+}
+
+// UpdatePlaceById mocks base method.
+func (m *MockPlaceRepo) UpdatePlaceById(arg0 context.Context, arg1, arg2 string, arg3 *model.Place) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePlaceById", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePlaceById indicates an expected call of UpdatePlaceById.
+func (mr *MockPlaceRepoMockRecorder) UpdatePlaceById(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePlaceById", reflect.TypeOf((*MockPlaceRepo)(nil).UpdatePlaceById), arg0, arg1, arg2, arg3)
+}
@@ -0,0 +1,123 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/RipperAcskt/innotaxi/internal/service (interfaces: PromotionRepo)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/RipperAcskt/innotaxi/internal/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPromotionRepo is a mock of PromotionRepo interface.
+type MockPromotionRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockPromotionRepoMockRecorder
+}
+
+// MockPromotionRepoMockRecorder is the mock recorder for MockPromotionRepo.
+type MockPromotionRepoMockRecorder struct {
+	mock *MockPromotionRepo
+}
+
+// NewMockPromotionRepo creates a new mock instance.
+func NewMockPromotionRepo(ctrl *gomock.Controller) *MockPromotionRepo {
+	mock := &MockPromotionRepo{ctrl: ctrl}
+	mock.recorder = &MockPromotionRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPromotionRepo) EXPECT() *MockPromotionRepoMockRecorder {
+	return m.recorder
+}
+
+// CountRedemptions mocks base method.
+func (m *MockPromotionRepo) CountRedemptions(arg0 context.Context, arg1 uint64, arg2 string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountRedemptions", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountRedemptions indicates an expected call of CountRedemptions.
+func (mr *MockPromotionRepoMockRecorder) CountRedemptions(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountRedemptions", reflect.TypeOf((*MockPromotionRepo)(nil).CountRedemptions), arg0, arg1, arg2)
+}
+
+// CreatePromotion mocks base method.
+func (m *MockPromotionRepo) CreatePromotion(arg0 context.Context, arg1 *model.Promotion) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePromotion", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePromotion indicates an expected call of CreatePromotion.
+func (mr *MockPromotionRepoMockRecorder) CreatePromotion(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePromotion", reflect.TypeOf((*MockPromotionRepo)(nil).CreatePromotion), arg0, arg1)
+}
+
+// DeactivatePromotionById mocks base method.
+func (m *MockPromotionRepo) DeactivatePromotionById(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeactivatePromotionById", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeactivatePromotionById indicates an expected call of DeactivatePromotionById.
+func (mr *MockPromotionRepoMockRecorder) DeactivatePromotionById(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeactivatePromotionById", reflect.TypeOf((*MockPromotionRepo)(nil).DeactivatePromotionById), arg0, arg1)
+}
+
+// GetPromotionByCode mocks base method.
+func (m *MockPromotionRepo) GetPromotionByCode(arg0 context.Context, arg1 string) (*model.Promotion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPromotionByCode", arg0, arg1)
+	ret0, _ := ret[0].(*model.Promotion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPromotionByCode indicates an expected call of GetPromotionByCode.
+func (mr *MockPromotionRepoMockRecorder) GetPromotionByCode(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPromotionByCode", reflect.TypeOf((*MockPromotionRepo)(nil).GetPromotionByCode), arg0, arg1)
+}
+
+// GetPromotions mocks base method.
+func (m *MockPromotionRepo) GetPromotions(arg0 context.Context) ([]*model.Promotion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPromotions", arg0)
+	ret0, _ := ret[0].([]*model.Promotion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPromotions indicates an expected call of GetPromotions.
+func (mr *MockPromotionRepoMockRecorder) GetPromotions(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPromotions", reflect.TypeOf((*MockPromotionRepo)(nil).GetPromotions), arg0)
+}
+
+// RedeemPromotion mocks base method.
+func (m *MockPromotionRepo) RedeemPromotion(arg0 context.Context, arg1 *model.Redemption) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedeemPromotion", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RedeemPromotion indicates an expected call of RedeemPromotion.
+func (mr *MockPromotionRepoMockRecorder) RedeemPromotion(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeemPromotion", reflect.TypeOf((*MockPromotionRepo)(nil).RedeemPromotion), arg0, arg1)
+}
@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/RipperAcskt/innotaxi/internal/service (interfaces: ReferralRepo)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/RipperAcskt/innotaxi/internal/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockReferralRepo is a mock of ReferralRepo interface.
+type MockReferralRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockReferralRepoMockRecorder
+}
+
+// MockReferralRepoMockRecorder is the mock recorder for MockReferralRepo.
+type MockReferralRepoMockRecorder struct {
+	mock *MockReferralRepo
+}
+
+// NewMockReferralRepo creates a new mock instance.
+func NewMockReferralRepo(ctrl *gomock.Controller) *MockReferralRepo {
+	mock := &MockReferralRepo{ctrl: ctrl}
+	mock.recorder = &MockReferralRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReferralRepo) EXPECT() *MockReferralRepoMockRecorder {
+	return m.recorder
+}
+
+// CompleteReferralById mocks base method.
+func (m *MockReferralRepo) CompleteReferralById(arg0 context.Context, arg1 *model.Referral) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteReferralById", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteReferralById indicates an expected call of CompleteReferralById.
+func (mr *MockReferralRepoMockRecorder) CompleteReferralById(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteReferralById", reflect.TypeOf((*MockReferralRepo)(nil).CompleteReferralById), arg0, arg1)
+}
+
+// GetPendingReferralByInviteeId mocks base method.
+func (m *MockReferralRepo) GetPendingReferralByInviteeId(arg0 context.Context, arg1 string) (*model.Referral, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingReferralByInviteeId", arg0, arg1)
+	ret0, _ := ret[0].(*model.Referral)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingReferralByInviteeId indicates an expected call of GetPendingReferralByInviteeId.
+func (mr *MockReferralRepoMockRecorder) GetPendingReferralByInviteeId(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingReferralByInviteeId", reflect.TypeOf((*MockReferralRepo)(nil).GetPendingReferralByInviteeId), arg0, arg1)
+}
+
+// GetReferralStatsByUserId mocks base method.
+func (m *MockReferralRepo) GetReferralStatsByUserId(arg0 context.Context, arg1 string) (*model.ReferralStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReferralStatsByUserId", arg0, arg1)
+	ret0, _ := ret[0].(*model.ReferralStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReferralStatsByUserId indicates an expected call of GetReferralStatsByUserId.
+func (mr *MockReferralRepoMockRecorder) GetReferralStatsByUserId(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReferralStatsByUserId", reflect.TypeOf((*MockReferralRepo)(nil).GetReferralStatsByUserId), arg0, arg1)
+}
@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 	time "time"
 
@@ -35,29 +36,29 @@ func (m *MockTokenRepo) EXPECT() *MockTokenRepoMockRecorder {
 }
 
 // AddToken mocks base method.
-func (m *MockTokenRepo) AddToken(arg0 string, arg1 time.Duration) error {
+func (m *MockTokenRepo) AddToken(arg0 context.Context, arg1 string, arg2 time.Duration) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddToken", arg0, arg1)
+	ret := m.ctrl.Call(m, "AddToken", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // AddToken indicates an expected call of AddToken.
-func (mr *MockTokenRepoMockRecorder) AddToken(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockTokenRepoMockRecorder) AddToken(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddToken", reflect.TypeOf((*MockTokenRepo)(nil).AddToken), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddToken", reflect.TypeOf((*MockTokenRepo)(nil).AddToken), arg0, arg1, arg2)
 }
 
 // GetToken mocks base method.
-func (m *MockTokenRepo) GetToken(arg0 string) bool {
+func (m *MockTokenRepo) GetToken(arg0 context.Context, arg1 string) bool {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetToken", arg0)
+	ret := m.ctrl.Call(m, "GetToken", arg0, arg1)
 	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
 // GetToken indicates an expected call of GetToken.
-func (mr *MockTokenRepoMockRecorder) GetToken(arg0 interface{}) *gomock.Call {
+func (mr *MockTokenRepoMockRecorder) GetToken(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetToken", reflect.TypeOf((*MockTokenRepo)(nil).GetToken), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetToken", reflect.TypeOf((*MockTokenRepo)(nil).GetToken), arg0, arg1)
 }
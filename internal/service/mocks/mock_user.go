@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	model "github.com/RipperAcskt/innotaxi/internal/model"
 	gomock "github.com/golang/mock/gomock"
@@ -49,6 +50,21 @@ func (mr *MockUserRepoMockRecorder) DeleteUserById(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserById", reflect.TypeOf((*MockUserRepo)(nil).DeleteUserById), arg0, arg1)
 }
 
+// GetPurgeableUsers mocks base method.
+func (m *MockUserRepo) GetPurgeableUsers(arg0 context.Context, arg1 time.Time, arg2 int) ([]uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPurgeableUsers", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPurgeableUsers indicates an expected call of GetPurgeableUsers.
+func (mr *MockUserRepoMockRecorder) GetPurgeableUsers(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPurgeableUsers", reflect.TypeOf((*MockUserRepo)(nil).GetPurgeableUsers), arg0, arg1, arg2)
+}
+
 // GetUserById mocks base method.
 func (m *MockUserRepo) GetUserById(arg0 context.Context, arg1 string) (*model.User, error) {
 	m.ctrl.T.Helper()
@@ -64,6 +80,20 @@ func (mr *MockUserRepoMockRecorder) GetUserById(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockUserRepo)(nil).GetUserById), arg0, arg1)
 }
 
+// PurgeUserById mocks base method.
+func (m *MockUserRepo) PurgeUserById(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeUserById", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeUserById indicates an expected call of PurgeUserById.
+func (mr *MockUserRepoMockRecorder) PurgeUserById(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeUserById", reflect.TypeOf((*MockUserRepo)(nil).PurgeUserById), arg0, arg1)
+}
+
 // UpdateUserById mocks base method.
 func (m *MockUserRepo) UpdateUserById(arg0 context.Context, arg1 string, arg2 *model.User) error {
 	m.ctrl.T.Helper()
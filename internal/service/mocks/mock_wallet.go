@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/RipperAcskt/innotaxi/internal/service (interfaces: WalletRepo)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/RipperAcskt/innotaxi/internal/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockWalletRepo is a mock of WalletRepo interface.
+type MockWalletRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockWalletRepoMockRecorder
+}
+
+// MockWalletRepoMockRecorder is the mock recorder for MockWalletRepo.
+type MockWalletRepoMockRecorder struct {
+	mock *MockWalletRepo
+}
+
+// NewMockWalletRepo creates a new mock instance.
+func NewMockWalletRepo(ctrl *gomock.Controller) *MockWalletRepo {
+	mock := &MockWalletRepo{ctrl: ctrl}
+	mock.recorder = &MockWalletRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWalletRepo) EXPECT() *MockWalletRepoMockRecorder {
+	return m.recorder
+}
+
+// GetBalance mocks base method.
+func (m *MockWalletRepo) GetBalance(arg0 context.Context, arg1 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalance", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalance indicates an expected call of GetBalance.
+func (mr *MockWalletRepoMockRecorder) GetBalance(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalance", reflect.TypeOf((*MockWalletRepo)(nil).GetBalance), arg0, arg1)
+}
+
+// GetTransactions mocks base method.
+func (m *MockWalletRepo) GetTransactions(arg0 context.Context, arg1 string, arg2, arg3 int) ([]*model.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransactions", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*model.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransactions indicates an expected call of GetTransactions.
+func (mr *MockWalletRepoMockRecorder) GetTransactions(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactions", reflect.TypeOf((*MockWalletRepo)(nil).GetTransactions), arg0, arg1, arg2, arg3)
+}
+
+// PostEntry mocks base method.
+func (m *MockWalletRepo) PostEntry(arg0 context.Context, arg1 *model.JournalEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostEntry", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PostEntry indicates an expected call of PostEntry.
+func (mr *MockWalletRepoMockRecorder) PostEntry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostEntry", reflect.TypeOf((*MockWalletRepo)(nil).PostEntry), arg0, arg1)
+}
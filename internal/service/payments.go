@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/RipperAcskt/innotaxi/internal/apperror"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/payments"
+	"github.com/RipperAcskt/innotaxi/internal/tracing"
+)
+
+var (
+	ErrPaymentMethodDoesNotExists = apperror.New(apperror.NotFound, "payment_method_does_not_exist", "payment method does not exists")
+	ErrPaymentDoesNotExists       = apperror.New(apperror.NotFound, "payment_does_not_exist", "payment does not exists")
+	ErrPaymentAlreadyExists       = apperror.New(apperror.Conflict, "payment_already_exists", "payment for order already exists")
+	ErrPaymentWrongStatus         = apperror.New(apperror.Conflict, "payment_wrong_status", "payment has wrong status")
+	ErrRefundExceedsCapture       = apperror.New(apperror.FailedPrecondition, "refund_exceeds_capture", "refund exceeds captured amount")
+	ErrCaptureExceedsAuthorized   = apperror.New(apperror.FailedPrecondition, "capture_exceeds_authorized", "capture exceeds authorized amount")
+	ErrGatewayEventHandled        = apperror.New(apperror.Conflict, "gateway_event_handled", "gateway event already handled")
+)
+
+type PreAuthorize struct {
+	OrderID         string `json:"order_id" binding:"required,max=64"`
+	PaymentMethodID uint64 `json:"payment_method_id" binding:"required"`
+	Amount          int64  `json:"amount" binding:"required,gt=0"`
+}
+
+type PaymentAmount struct {
+	Amount int64 `json:"amount" binding:"required,gt=0"`
+}
+
+type PaymentRepo interface {
+	CreatePaymentMethod(ctx context.Context, userId string, method *model.PaymentMethod) error
+	GetPaymentMethodsByUserId(ctx context.Context, userId string) ([]*model.PaymentMethod, error)
+	GetPaymentMethodById(ctx context.Context, userId, methodId string) (*model.PaymentMethod, error)
+	DeletePaymentMethodById(ctx context.Context, userId, methodId string) error
+	CreatePayment(ctx context.Context, payment *model.Payment) error
+	GetPaymentByOrderId(ctx context.Context, orderId string) (*model.Payment, error)
+	GetPaymentByAuthorizationId(ctx context.Context, authorizationId string) (*model.Payment, error)
+	UpdatePayment(ctx context.Context, payment *model.Payment) error
+	// AddCapture applies ApplyCapture to the payment of the order and returns
+	// the updated payment. The check and the update are atomic.
+	AddCapture(ctx context.Context, orderId string, amount int64) (*model.Payment, error)
+	// AddRefund applies ApplyRefund to the payment of the order and returns
+	// the updated payment. The check and the update are atomic.
+	AddRefund(ctx context.Context, orderId string, amount int64) (*model.Payment, error)
+	// AddGatewayEvent records that the gateway event with the id is
+	// handled. It returns ErrGatewayEventHandled if it already was.
+	AddGatewayEvent(ctx context.Context, id string) error
+}
+
+// ApplyCapture adds amount to the captured amount of an authorized payment,
+// which becomes captured. The captured amount can't exceed the authorized
+// one. A negative amount takes a capture back, the payment is authorized
+// again once nothing is captured.
+func ApplyCapture(payment *model.Payment, amount int64) error {
+	if amount > 0 && payment.Status != model.PaymentAuthorized || amount < 0 && payment.Status != model.PaymentCaptured {
+		return ErrPaymentWrongStatus
+	}
+	if payment.CapturedAmount+amount > payment.Amount || payment.CapturedAmount+amount < 0 {
+		return ErrCaptureExceedsAuthorized
+	}
+
+	payment.CapturedAmount += amount
+	payment.Status = model.PaymentCaptured
+	if payment.CapturedAmount == 0 {
+		payment.Status = model.PaymentAuthorized
+	}
+	return nil
+}
+
+// ApplyRefund adds amount to the refunded amount of a captured or refunded
+// payment. The refunded amount can't exceed the captured one. A negative
+// amount takes a refund back, the payment is captured again once nothing is
+// refunded.
+func ApplyRefund(payment *model.Payment, amount int64) error {
+	if payment.Status != model.PaymentCaptured && payment.Status != model.PaymentRefunded {
+		return ErrPaymentWrongStatus
+	}
+	if payment.RefundedAmount+amount > payment.CapturedAmount || payment.RefundedAmount+amount < 0 {
+		return ErrRefundExceedsCapture
+	}
+
+	payment.RefundedAmount += amount
+	payment.Status = model.PaymentRefunded
+	if payment.RefundedAmount == 0 {
+		payment.Status = model.PaymentCaptured
+	}
+	return nil
+}
+
+type PaymentService struct {
+	PaymentRepo
+	tx      Transactor
+	gateway payments.PaymentGateway
+}
+
+func NewPaymentService(postgres PaymentRepo, tx Transactor, gateway payments.PaymentGateway) *PaymentService {
+	return &PaymentService{postgres, tx, gateway}
+}
+
+// AddPaymentMethod tokenizes the card at the gateway and stores the token
+// only, the card number never reaches the database.
+func (s *PaymentService) AddPaymentMethod(ctx context.Context, userId string, card payments.Card) (*model.PaymentMethod, error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.AddPaymentMethod")
+	defer span.End()
+
+	token, err := s.gateway.TokenizeCard(ctx, card)
+	if err != nil {
+		return nil, fmt.Errorf("tokenize card failed: %w", err)
+	}
+
+	method := &model.PaymentMethod{
+		Token:    token.Token,
+		Brand:    token.Brand,
+		Last4:    token.Last4,
+		ExpMonth: token.ExpMonth,
+		ExpYear:  token.ExpYear,
+	}
+	err = s.CreatePaymentMethod(ctx, userId, method)
+	if err != nil {
+		return nil, fmt.Errorf("create payment method failed: %w", err)
+	}
+	return method, nil
+}
+
+func (s *PaymentService) GetPaymentMethods(ctx context.Context, userId string) ([]*model.PaymentMethod, error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.GetPaymentMethods")
+	defer span.End()
+
+	return s.GetPaymentMethodsByUserId(ctx, userId)
+}
+
+func (s *PaymentService) DeletePaymentMethod(ctx context.Context, userId, methodId string) error {
+	ctx, span := tracing.Start(ctx, "PaymentService.DeletePaymentMethod")
+	defer span.End()
+
+	return s.DeletePaymentMethodById(ctx, userId, methodId)
+}
+
+// PreAuthorizePayment holds the estimated fare on the user's card when an
+// order is created. The order id is used as the gateway idempotency key, so
+// retries return the existing payment.
+func (s *PaymentService) PreAuthorizePayment(ctx context.Context, userId string, pre PreAuthorize) (*model.Payment, error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.PreAuthorizePayment")
+	defer span.End()
+
+	existing, err := s.GetPaymentByOrderId(ctx, pre.OrderID)
+	if err == nil {
+		if existing.UserID != userId {
+			return nil, ErrPaymentAlreadyExists
+		}
+		return existing, nil
+	}
+	if !errors.Is(err, ErrPaymentDoesNotExists) {
+		return nil, fmt.Errorf("get payment by order id failed: %w", err)
+	}
+
+	method, err := s.GetPaymentMethodById(ctx, userId, strconv.FormatUint(pre.PaymentMethodID, 10))
+	if err != nil {
+		return nil, fmt.Errorf("get payment method by id failed: %w", err)
+	}
+
+	auth, err := s.gateway.Authorize(ctx, method.Token, pre.Amount, pre.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("authorize failed: %w", err)
+	}
+
+	payment := &model.Payment{
+		UserID:          userId,
+		OrderID:         pre.OrderID,
+		PaymentMethodID: method.ID,
+		AuthorizationID: auth.ID,
+		Amount:          auth.Amount,
+		Status:          model.PaymentAuthorized,
+	}
+	err = s.CreatePayment(ctx, payment)
+	if err != nil {
+		return nil, fmt.Errorf("create payment failed: %w", err)
+	}
+	return payment, nil
+}
+
+// CapturePayment charges the final fare when the order is completed. The
+// amount can't exceed the pre-authorized one. The capture is reserved before
+// it reaches the gateway, so of concurrent captures only one gets there. It
+// is released only if the gateway rejected it, on any other error the
+// gateway may have captured.
+func (s *PaymentService) CapturePayment(ctx context.Context, orderId string, amount int64) (*model.Payment, error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.CapturePayment")
+	defer span.End()
+
+	payment, err := s.GetPaymentByOrderId(ctx, orderId)
+	if err != nil {
+		return nil, fmt.Errorf("get payment by order id failed: %w", err)
+	}
+	if payment.Status == model.PaymentCaptured && payment.CapturedAmount == amount {
+		return payment, nil
+	}
+
+	payment, err = s.AddCapture(ctx, orderId, amount)
+	if err != nil {
+		return nil, fmt.Errorf("add capture failed: %w", err)
+	}
+
+	err = s.gateway.Capture(ctx, payment.AuthorizationID, amount)
+	if err != nil {
+		if !gatewayRejected(err) {
+			return nil, fmt.Errorf("capture failed, capture is kept reserved: %w", err)
+		}
+
+		_, releaseErr := s.AddCapture(ctx, orderId, -amount)
+		if releaseErr != nil {
+			return nil, fmt.Errorf("capture failed: %w, release capture failed: %v", err, releaseErr)
+		}
+		return nil, fmt.Errorf("capture failed: %w", err)
+	}
+	return payment, nil
+}
+
+// VoidPayment releases the hold when the order is cancelled.
+func (s *PaymentService) VoidPayment(ctx context.Context, orderId string) (*model.Payment, error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.VoidPayment")
+	defer span.End()
+
+	payment, err := s.GetPaymentByOrderId(ctx, orderId)
+	if err != nil {
+		return nil, fmt.Errorf("get payment by order id failed: %w", err)
+	}
+	if payment.Status == model.PaymentVoided {
+		return payment, nil
+	}
+	if payment.Status != model.PaymentAuthorized {
+		return nil, ErrPaymentWrongStatus
+	}
+
+	err = s.gateway.Void(ctx, payment.AuthorizationID)
+	if err != nil {
+		return nil, fmt.Errorf("void failed: %w", err)
+	}
+
+	payment.Status = model.PaymentVoided
+	err = s.UpdatePayment(ctx, payment)
+	if err != nil {
+		return nil, fmt.Errorf("update payment failed: %w", err)
+	}
+	return payment, nil
+}
+
+// RefundPayment reserves the refund before it reaches the gateway, so
+// concurrent refunds of one payment are checked against each other. The
+// gateway key is the refunded amount the refund starts at and its amount,
+// no two reservations share it. The reservation is released only if the
+// gateway rejected the refund. On any other error the gateway may have
+// refunded, so the reservation is kept and the payment stays refunded
+// until it is checked at the gateway.
+func (s *PaymentService) RefundPayment(ctx context.Context, orderId string, amount int64) (*model.Payment, error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.RefundPayment")
+	defer span.End()
+
+	payment, err := s.AddRefund(ctx, orderId, amount)
+	if err != nil {
+		return nil, fmt.Errorf("add refund failed: %w", err)
+	}
+
+	key := fmt.Sprintf("%s:refund:%d:%d", orderId, payment.RefundedAmount-amount, amount)
+	err = s.gateway.Refund(ctx, payment.AuthorizationID, amount, key)
+	if err != nil {
+		if !gatewayRejected(err) {
+			return nil, fmt.Errorf("refund failed, refund is kept reserved: %w", err)
+		}
+
+		_, releaseErr := s.AddRefund(ctx, orderId, -amount)
+		if releaseErr != nil {
+			return nil, fmt.Errorf("refund failed: %w, release refund failed: %v", err, releaseErr)
+		}
+		return nil, fmt.Errorf("refund failed: %w", err)
+	}
+	return payment, nil
+}
+
+// gatewayRejected reports whether the gateway answered a call with a
+// rejection, so it surely didn't change the payment.
+func gatewayRejected(err error) bool {
+	return errors.Is(err, payments.ErrInvalidAmount) || errors.Is(err, payments.ErrAuthorizationNotFound)
+}
+
+// HandlePaymentWebhook reconciles a payment with a gateway event. Payment
+// state is normally changed by the calls above, events only catch up on
+// captures and voids that reached the gateway but weren't stored. Every
+// event is handled once: replays of an event id are acknowledged without
+// changing the payment again.
+func (s *PaymentService) HandlePaymentWebhook(ctx context.Context, payload []byte, signature string) error {
+	ctx, span := tracing.Start(ctx, "PaymentService.HandlePaymentWebhook")
+	defer span.End()
+
+	event, err := s.gateway.ParseWebhook(payload, signature)
+	if err != nil {
+		return fmt.Errorf("parse webhook failed: %w", err)
+	}
+
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.AddGatewayEvent(ctx, event.ID)
+		if err != nil {
+			if errors.Is(err, ErrGatewayEventHandled) {
+				return nil
+			}
+			return fmt.Errorf("add gateway event failed: %w", err)
+		}
+		return s.handleGatewayEvent(ctx, event)
+	})
+}
+
+func (s *PaymentService) handleGatewayEvent(ctx context.Context, event *payments.Event) error {
+	payment, err := s.GetPaymentByAuthorizationId(ctx, event.AuthorizationID)
+	if err != nil {
+		return fmt.Errorf("get payment by authorization id failed: %w", err)
+	}
+	if payment.Status != model.PaymentAuthorized {
+		return nil
+	}
+
+	switch event.Type {
+	case payments.EventCaptured:
+		_, err = s.AddCapture(ctx, payment.OrderID, event.Amount)
+		if err != nil && !errors.Is(err, ErrPaymentWrongStatus) {
+			return fmt.Errorf("add capture failed: %w", err)
+		}
+		return nil
+	case payments.EventVoided:
+		payment.Status = model.PaymentVoided
+	default:
+		return nil
+	}
+
+	err = s.UpdatePayment(ctx, payment)
+	if err != nil {
+		return fmt.Errorf("update payment failed: %w", err)
+	}
+	return nil
+}
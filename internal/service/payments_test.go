@@ -0,0 +1,191 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/payments"
+	"github.com/RipperAcskt/innotaxi/internal/payments/fake"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/RipperAcskt/innotaxi/internal/service/mocks"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func TestAddPaymentMethod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	paymentRepo := mocks.NewMockPaymentRepo(ctrl)
+	paymentRepo.EXPECT().CreatePaymentMethod(gomock.Any(), "1", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, userId string, method *model.PaymentMethod) error {
+			assert.NotEqual(t, method.Token, "")
+			assert.Equal(t, method.Last4, "4242")
+			return nil
+		})
+
+	service := service.Service{
+		PaymentService: service.NewPaymentService(paymentRepo, newTransactor(ctrl), fake.New(0, "secret", "")),
+	}
+
+	_, err := service.AddPaymentMethod(context.Background(), "1", payments.Card{
+		Number:   "4242424242424242",
+		ExpMonth: 12,
+		ExpYear:  time.Now().Year() + 1,
+		CVC:      "123",
+		Holder:   "IVAN IVANOV",
+	})
+	assert.Equal(t, err, nil)
+}
+
+func TestPreAuthorizeAndCapture(t *testing.T) {
+	test := []struct {
+		name    string
+		number  string
+		authErr error
+	}{
+		{
+			name:    "authorize and capture",
+			number:  "4242424242424242",
+			authErr: nil,
+		},
+		{
+			name:    "declined",
+			number:  fake.DeclinedCard,
+			authErr: payments.ErrCardDeclined,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			gateway := fake.New(0, "secret", "")
+			token, err := gateway.TokenizeCard(context.Background(), payments.Card{Number: tt.number, ExpMonth: 12, ExpYear: time.Now().Year() + 1})
+			assert.Equal(t, err, nil)
+
+			var stored *model.Payment
+			paymentRepo := mocks.NewMockPaymentRepo(ctrl)
+			paymentRepo.EXPECT().GetPaymentByOrderId(gomock.Any(), "order-1").DoAndReturn(
+				func(ctx context.Context, orderId string) (*model.Payment, error) {
+					if stored == nil {
+						return nil, service.ErrPaymentDoesNotExists
+					}
+					return stored, nil
+				}).AnyTimes()
+			paymentRepo.EXPECT().GetPaymentMethodById(gomock.Any(), "1", "3").Return(&model.PaymentMethod{ID: 3, Token: token.Token}, nil)
+
+			s := service.Service{
+				PaymentService: service.NewPaymentService(paymentRepo, newTransactor(ctrl), gateway),
+			}
+
+			if tt.authErr != nil {
+				_, err = s.PreAuthorizePayment(context.Background(), "1", service.PreAuthorize{OrderID: "order-1", PaymentMethodID: 3, Amount: 1000})
+				assert.Equal(t, errors.Is(err, tt.authErr), true)
+				return
+			}
+
+			paymentRepo.EXPECT().CreatePayment(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, payment *model.Payment) error {
+					stored = payment
+					return nil
+				})
+			paymentRepo.EXPECT().AddCapture(gomock.Any(), "order-1", int64(900)).DoAndReturn(
+				func(ctx context.Context, orderId string, amount int64) (*model.Payment, error) {
+					captured := *stored
+					err := service.ApplyCapture(&captured, amount)
+					if err != nil {
+						return nil, err
+					}
+					stored = &captured
+					return stored, nil
+				})
+
+			payment, err := s.PreAuthorizePayment(context.Background(), "1", service.PreAuthorize{OrderID: "order-1", PaymentMethodID: 3, Amount: 1000})
+			assert.Equal(t, err, nil)
+			assert.Equal(t, payment.Status, model.PaymentAuthorized)
+
+			payment, err = s.CapturePayment(context.Background(), "order-1", 900)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, payment.Status, model.PaymentCaptured)
+			assert.Equal(t, payment.CapturedAmount, int64(900))
+
+			_, err = s.VoidPayment(context.Background(), "order-1")
+			assert.Equal(t, err, service.ErrPaymentWrongStatus)
+		})
+	}
+}
+
+func TestRefundPayment(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	gateway := fake.New(0, "secret", "")
+	token, err := gateway.TokenizeCard(context.Background(), payments.Card{Number: "4242424242424242", ExpMonth: 12, ExpYear: time.Now().Year() + 1})
+	assert.Equal(t, err, nil)
+	auth, err := gateway.Authorize(context.Background(), token.Token, 1000, "order-1")
+	assert.Equal(t, err, nil)
+	err = gateway.Capture(context.Background(), auth.ID, 900)
+	assert.Equal(t, err, nil)
+
+	paymentRepo := mocks.NewMockPaymentRepo(ctrl)
+	s := service.Service{
+		PaymentService: service.NewPaymentService(paymentRepo, newTransactor(ctrl), gateway),
+	}
+
+	paymentRepo.EXPECT().AddRefund(gomock.Any(), "order-1", int64(600)).Return(&model.Payment{
+		OrderID:         "order-1",
+		AuthorizationID: auth.ID,
+		CapturedAmount:  900,
+		RefundedAmount:  600,
+		Status:          model.PaymentRefunded,
+	}, nil)
+	payment, err := s.RefundPayment(context.Background(), "order-1", 600)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, payment.RefundedAmount, int64(600))
+
+	// The gateway refuses the refund, the reserved amount is taken back.
+	paymentRepo.EXPECT().AddRefund(gomock.Any(), "order-1", int64(600)).Return(&model.Payment{
+		OrderID:         "order-1",
+		AuthorizationID: auth.ID,
+		CapturedAmount:  900,
+		RefundedAmount:  1200,
+		Status:          model.PaymentRefunded,
+	}, nil)
+	paymentRepo.EXPECT().AddRefund(gomock.Any(), "order-1", int64(-600)).Return(&model.Payment{}, nil)
+	_, err = s.RefundPayment(context.Background(), "order-1", 600)
+	assert.Equal(t, errors.Is(err, payments.ErrInvalidAmount), true)
+
+	paymentRepo.EXPECT().AddRefund(gomock.Any(), "order-1", int64(600)).Return(nil, service.ErrRefundExceedsCapture)
+	_, err = s.RefundPayment(context.Background(), "order-1", 600)
+	assert.Equal(t, errors.Is(err, service.ErrRefundExceedsCapture), true)
+}
+
+func TestRefundPaymentTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	gateway := fake.New(50*time.Millisecond, "secret", "")
+	paymentRepo := mocks.NewMockPaymentRepo(ctrl)
+	s := service.Service{
+		PaymentService: service.NewPaymentService(paymentRepo, newTransactor(ctrl), gateway),
+	}
+
+	// The gateway may have refunded, the reservation isn't released.
+	paymentRepo.EXPECT().AddRefund(gomock.Any(), "order-1", int64(600)).Return(&model.Payment{
+		OrderID:         "order-1",
+		AuthorizationID: "auth_1",
+		CapturedAmount:  900,
+		RefundedAmount:  600,
+		Status:          model.PaymentRefunded,
+	}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err := s.RefundPayment(ctx, "order-1", 600)
+	assert.Equal(t, errors.Is(err, context.DeadlineExceeded), true)
+}
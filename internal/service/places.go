@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+
+	"github.com/RipperAcskt/innotaxi/config"
+	"github.com/RipperAcskt/innotaxi/internal/apperror"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/tracing"
+)
+
+const defaultPlacesLimit = 10
+
+var (
+	ErrPlaceAlreadyExists  = apperror.New(apperror.Conflict, "place_already_exists", "place already exists")
+	ErrPlaceDoesNotExists  = apperror.New(apperror.NotFound, "place_does_not_exist", "place does not exists")
+	ErrPlacesLimitExceeded = apperror.New(apperror.FailedPrecondition, "places_limit_exceeded", "places limit exceeded")
+)
+
+type PlaceRepo interface {
+	CreatePlace(ctx context.Context, userId string, place *model.Place, limit int) error
+	GetPlacesByUserId(ctx context.Context, userId string) ([]*model.Place, error)
+	GetPlaceById(ctx context.Context, userId, placeId string) (*model.Place, error)
+	UpdatePlaceById(ctx context.Context, userId, placeId string, place *model.Place) error
+	DeletePlaceById(ctx context.Context, userId, placeId string) error
+}
+
+type PlaceService struct {
+	PlaceRepo
+	cfg *config.Config
+}
+
+func NewPlaceService(postgres PlaceRepo, cfg *config.Config) *PlaceService {
+	return &PlaceService{postgres, cfg}
+}
+
+func (s *PlaceService) AddPlace(ctx context.Context, userId string, place *model.Place) error {
+	ctx, span := tracing.Start(ctx, "PlaceService.AddPlace")
+	defer span.End()
+
+	limit := s.cfg.SAVED_PLACES_LIMIT
+	if limit <= 0 {
+		limit = defaultPlacesLimit
+	}
+
+	return s.CreatePlace(ctx, userId, place, limit)
+}
+
+func (s *PlaceService) GetPlaces(ctx context.Context, userId string) ([]*model.Place, error) {
+	ctx, span := tracing.Start(ctx, "PlaceService.GetPlaces")
+	defer span.End()
+
+	return s.GetPlacesByUserId(ctx, userId)
+}
+
+// GetPlace is used by other services to resolve a saved place id to its
+// coordinates, e.g. when an order is created from "Home" or "Work".
+func (s *PlaceService) GetPlace(ctx context.Context, userId, placeId string) (*model.Place, error) {
+	ctx, span := tracing.Start(ctx, "PlaceService.GetPlace")
+	defer span.End()
+
+	return s.GetPlaceById(ctx, userId, placeId)
+}
+
+func (s *PlaceService) UpdatePlace(ctx context.Context, userId, placeId string, place *model.Place) error {
+	ctx, span := tracing.Start(ctx, "PlaceService.UpdatePlace")
+	defer span.End()
+
+	return s.UpdatePlaceById(ctx, userId, placeId, place)
+}
+
+func (s *PlaceService) DeletePlace(ctx context.Context, userId, placeId string) error {
+	ctx, span := tracing.Start(ctx, "PlaceService.DeletePlace")
+	defer span.End()
+
+	return s.DeletePlaceById(ctx, userId, placeId)
+}
@@ -0,0 +1,159 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RipperAcskt/innotaxi/config"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/RipperAcskt/innotaxi/internal/service/mocks"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func TestAddPlace(t *testing.T) {
+	type mockBehavior func(s *mocks.MockPlaceRepo, place *model.Place, limit int)
+
+	test := []struct {
+		name         string
+		place        model.Place
+		cfgLimit     int
+		limit        int
+		mockBehavior mockBehavior
+		err          error
+	}{
+		{
+			name: "configured limit",
+			place: model.Place{
+				Label:   "Home",
+				Address: "Nezavisimosti 4",
+			},
+			cfgLimit: 3,
+			limit:    3,
+			mockBehavior: func(s *mocks.MockPlaceRepo, place *model.Place, limit int) {
+				s.EXPECT().CreatePlace(gomock.Any(), "1", place, limit).Return(nil)
+			},
+			err: nil,
+		},
+		{
+			name: "default limit",
+			place: model.Place{
+				Label:   "Work",
+				Address: "Kalinovskogo 10",
+			},
+			cfgLimit: 0,
+			limit:    10,
+			mockBehavior: func(s *mocks.MockPlaceRepo, place *model.Place, limit int) {
+				s.EXPECT().CreatePlace(gomock.Any(), "1", place, limit).Return(service.ErrPlacesLimitExceeded)
+			},
+			err: service.ErrPlacesLimitExceeded,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			placeRepo := mocks.NewMockPlaceRepo(ctrl)
+			placeService := service.NewPlaceService(placeRepo, &config.Config{SAVED_PLACES_LIMIT: tt.cfgLimit})
+
+			tt.mockBehavior(placeRepo, &tt.place, tt.limit)
+
+			service := service.Service{
+				PlaceService: placeService,
+			}
+
+			err := service.AddPlace(context.Background(), "1", &tt.place)
+			assert.Equal(t, err, tt.err)
+		})
+	}
+}
+
+func TestGetPlaces(t *testing.T) {
+	type mockBehavior func(s *mocks.MockPlaceRepo)
+
+	test := []struct {
+		name         string
+		mockBehavior mockBehavior
+		places       int
+		err          error
+	}{
+		{
+			name: "get places",
+			mockBehavior: func(s *mocks.MockPlaceRepo) {
+				s.EXPECT().GetPlacesByUserId(gomock.Any(), "1").Return([]*model.Place{
+					{ID: 1, Label: "Home"},
+					{ID: 2, Label: "Work"},
+				}, nil)
+			},
+			places: 2,
+			err:    nil,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			placeRepo := mocks.NewMockPlaceRepo(ctrl)
+			placeService := service.NewPlaceService(placeRepo, &config.Config{})
+
+			tt.mockBehavior(placeRepo)
+
+			service := service.Service{
+				PlaceService: placeService,
+			}
+
+			places, err := service.GetPlaces(context.Background(), "1")
+			assert.Equal(t, err, tt.err)
+			assert.Equal(t, len(places), tt.places)
+		})
+	}
+}
+
+func TestDeletePlace(t *testing.T) {
+	type mockBehavior func(s *mocks.MockPlaceRepo)
+
+	test := []struct {
+		name         string
+		mockBehavior mockBehavior
+		err          error
+	}{
+		{
+			name: "delete place",
+			mockBehavior: func(s *mocks.MockPlaceRepo) {
+				s.EXPECT().DeletePlaceById(gomock.Any(), "1", "2").Return(nil)
+			},
+			err: nil,
+		},
+		{
+			name: "place does not exist",
+			mockBehavior: func(s *mocks.MockPlaceRepo) {
+				s.EXPECT().DeletePlaceById(gomock.Any(), "1", "2").Return(service.ErrPlaceDoesNotExists)
+			},
+			err: service.ErrPlaceDoesNotExists,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			placeRepo := mocks.NewMockPlaceRepo(ctrl)
+			placeService := service.NewPlaceService(placeRepo, &config.Config{})
+
+			tt.mockBehavior(placeRepo)
+
+			service := service.Service{
+				PlaceService: placeService,
+			}
+
+			err := service.DeletePlace(context.Background(), "1", "2")
+			assert.Equal(t, err, tt.err)
+		})
+	}
+}
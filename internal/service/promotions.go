@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/apperror"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/tracing"
+)
+
+var (
+	ErrPromotionAlreadyExists   = apperror.New(apperror.Conflict, "promotion_already_exists", "promotion already exists")
+	ErrPromotionDoesNotExists   = apperror.New(apperror.NotFound, "promotion_does_not_exist", "promotion does not exists")
+	ErrPromotionNotApplicable   = apperror.New(apperror.FailedPrecondition, "promotion_not_applicable", "promotion is not applicable")
+	ErrPromotionUsageExceeded   = apperror.New(apperror.FailedPrecondition, "promotion_usage_exceeded", "promotion usage limit exceeded")
+	ErrPromotionAlreadyRedeemed = apperror.New(apperror.Conflict, "promotion_already_redeemed", "promotion already redeemed for order")
+	ErrPromotionBadPercent      = apperror.New(apperror.Invalid, "promotion_bad_percent", "percent discount exceeds 100")
+)
+
+type PromotionApply struct {
+	Code      string `json:"code" binding:"required"`
+	Fare      int64  `json:"fare" binding:"required,gt=0"`
+	TaxiClass string `json:"taxi_class"`
+	Zone      string `json:"zone"`
+}
+
+// PromotionRedeem is the code a rider entered for an order. The fare and the
+// rider are taken from the order's payment.
+type PromotionRedeem struct {
+	Code      string `json:"code" binding:"required"`
+	TaxiClass string `json:"taxi_class"`
+	Zone      string `json:"zone"`
+}
+
+type Discount struct {
+	Code     string `json:"code"`
+	Fare     int64  `json:"fare"`
+	Discount int64  `json:"discount"`
+	Total    int64  `json:"total"`
+}
+
+type PromotionRepo interface {
+	CreatePromotion(ctx context.Context, promotion *model.Promotion) error
+	GetPromotions(ctx context.Context) ([]*model.Promotion, error)
+	GetPromotionByCode(ctx context.Context, code string) (*model.Promotion, error)
+	DeactivatePromotionById(ctx context.Context, id string) error
+	CountRedemptions(ctx context.Context, promotionId uint64, userId string) (int, error)
+	RedeemPromotion(ctx context.Context, redemption *model.Redemption) error
+}
+
+type PromotionService struct {
+	PromotionRepo
+	payments PaymentRepo
+}
+
+func NewPromotionService(postgres PromotionRepo, payments PaymentRepo) *PromotionService {
+	return &PromotionService{postgres, payments}
+}
+
+// AddPromotion creates an active promotion. A percent discount can't exceed
+// 100.
+func (s *PromotionService) AddPromotion(ctx context.Context, promotion *model.Promotion) error {
+	ctx, span := tracing.Start(ctx, "PromotionService.AddPromotion")
+	defer span.End()
+
+	if promotion.DiscountType == model.DiscountPercent && promotion.DiscountValue > 100 {
+		return ErrPromotionBadPercent
+	}
+
+	promotion.Code = strings.ToUpper(promotion.Code)
+	promotion.Active = true
+	return s.CreatePromotion(ctx, promotion)
+}
+
+func (s *PromotionService) GetAllPromotions(ctx context.Context) ([]*model.Promotion, error) {
+	ctx, span := tracing.Start(ctx, "PromotionService.GetAllPromotions")
+	defer span.End()
+
+	return s.GetPromotions(ctx)
+}
+
+func (s *PromotionService) DeactivatePromotion(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "PromotionService.DeactivatePromotion")
+	defer span.End()
+
+	return s.DeactivatePromotionById(ctx, id)
+}
+
+// ApplyPromotion calculates the discount a code gives for a fare estimate
+// without redeeming it.
+func (s *PromotionService) ApplyPromotion(ctx context.Context, userId string, apply PromotionApply) (*Discount, error) {
+	ctx, span := tracing.Start(ctx, "PromotionService.ApplyPromotion")
+	defer span.End()
+
+	_, discount, err := s.apply(ctx, userId, apply)
+	return discount, err
+}
+
+// Redeem is called at order completion, once the final fare is captured.
+// The fare and the rider are the captured payment's, so a code can't be
+// redeemed for an order that doesn't exist. Usage limits are re-checked by
+// the repository under a row lock, so concurrent redemptions can't exceed
+// them.
+func (s *PromotionService) Redeem(ctx context.Context, orderId string, redeem PromotionRedeem) (*Discount, error) {
+	ctx, span := tracing.Start(ctx, "PromotionService.Redeem")
+	defer span.End()
+
+	payment, err := s.payments.GetPaymentByOrderId(ctx, orderId)
+	if err != nil {
+		return nil, fmt.Errorf("get payment by order id failed: %w", err)
+	}
+	if payment.Status != model.PaymentCaptured {
+		return nil, ErrPaymentWrongStatus
+	}
+
+	promotion, discount, err := s.apply(ctx, payment.UserID, PromotionApply{
+		Code:      redeem.Code,
+		Fare:      payment.CapturedAmount,
+		TaxiClass: redeem.TaxiClass,
+		Zone:      redeem.Zone,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.RedeemPromotion(ctx, &model.Redemption{
+		PromotionID: promotion.ID,
+		UserID:      payment.UserID,
+		OrderID:     orderId,
+		Discount:    discount.Discount,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("redeem promotion failed: %w", err)
+	}
+
+	return discount, nil
+}
+
+func (s *PromotionService) apply(ctx context.Context, userId string, apply PromotionApply) (*model.Promotion, *Discount, error) {
+	ctx, span := tracing.Start(ctx, "PromotionService.apply")
+	defer span.End()
+
+	promotion, err := s.GetPromotionByCode(ctx, strings.ToUpper(apply.Code))
+	if err != nil {
+		return nil, nil, fmt.Errorf("get promotion by code failed: %w", err)
+	}
+
+	err = checkPromotion(promotion, apply, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if promotion.PerUserLimit > 0 {
+		used, err := s.CountRedemptions(ctx, promotion.ID, userId)
+		if err != nil {
+			return nil, nil, fmt.Errorf("count redemptions failed: %w", err)
+		}
+		if used >= promotion.PerUserLimit {
+			return nil, nil, ErrPromotionUsageExceeded
+		}
+	}
+
+	discount := CalculateDiscount(promotion, apply.Fare)
+	return promotion, &Discount{
+		Code:     promotion.Code,
+		Fare:     apply.Fare,
+		Discount: discount,
+		Total:    apply.Fare - discount,
+	}, nil
+}
+
+func checkPromotion(promotion *model.Promotion, apply PromotionApply, now time.Time) error {
+	if !promotion.Active || now.Before(promotion.ValidFrom) || now.After(promotion.ValidUntil) {
+		return ErrPromotionNotApplicable
+	}
+	if promotion.UsageLimit > 0 && promotion.UsageCount >= promotion.UsageLimit {
+		return ErrPromotionUsageExceeded
+	}
+	if len(promotion.TaxiClasses) > 0 && !contains(promotion.TaxiClasses, apply.TaxiClass) {
+		return ErrPromotionNotApplicable
+	}
+	if len(promotion.Zones) > 0 && !contains(promotion.Zones, apply.Zone) {
+		return ErrPromotionNotApplicable
+	}
+	return nil
+}
+
+func CalculateDiscount(promotion *model.Promotion, fare int64) int64 {
+	var discount int64
+	switch promotion.DiscountType {
+	case model.DiscountPercent:
+		discount = fare * promotion.DiscountValue / 100
+	case model.DiscountFixed:
+		discount = promotion.DiscountValue
+	}
+
+	if promotion.MaxDiscount > 0 && discount > promotion.MaxDiscount {
+		discount = promotion.MaxDiscount
+	}
+	if discount > fare {
+		discount = fare
+	}
+	return discount
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,269 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/RipperAcskt/innotaxi/internal/service/mocks"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func TestCalculateDiscount(t *testing.T) {
+	test := []struct {
+		name      string
+		promotion model.Promotion
+		fare      int64
+		discount  int64
+	}{
+		{
+			name:      "percent",
+			promotion: model.Promotion{DiscountType: model.DiscountPercent, DiscountValue: 15},
+			fare:      1000,
+			discount:  150,
+		},
+		{
+			name:      "percent with cap",
+			promotion: model.Promotion{DiscountType: model.DiscountPercent, DiscountValue: 50, MaxDiscount: 300},
+			fare:      1000,
+			discount:  300,
+		},
+		{
+			name:      "fixed",
+			promotion: model.Promotion{DiscountType: model.DiscountFixed, DiscountValue: 200},
+			fare:      1000,
+			discount:  200,
+		},
+		{
+			name:      "fixed greater than fare",
+			promotion: model.Promotion{DiscountType: model.DiscountFixed, DiscountValue: 2000},
+			fare:      1000,
+			discount:  1000,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			discount := service.CalculateDiscount(&tt.promotion, tt.fare)
+			assert.Equal(t, discount, tt.discount)
+		})
+	}
+}
+
+func TestAddPromotion(t *testing.T) {
+	test := []struct {
+		name      string
+		promotion model.Promotion
+		err       error
+	}{
+		{
+			name:      "percent",
+			promotion: model.Promotion{Code: "spring", DiscountType: model.DiscountPercent, DiscountValue: 100},
+			err:       nil,
+		},
+		{
+			name:      "percent over 100",
+			promotion: model.Promotion{Code: "spring", DiscountType: model.DiscountPercent, DiscountValue: 150},
+			err:       service.ErrPromotionBadPercent,
+		},
+		{
+			name:      "fixed over 100",
+			promotion: model.Promotion{Code: "spring", DiscountType: model.DiscountFixed, DiscountValue: 150},
+			err:       nil,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			promotionRepo := mocks.NewMockPromotionRepo(ctrl)
+			if tt.err == nil {
+				promotionRepo.EXPECT().CreatePromotion(gomock.Any(), gomock.Any()).Return(nil)
+			}
+
+			s := service.Service{
+				PromotionService: service.NewPromotionService(promotionRepo, nil),
+			}
+
+			err := s.AddPromotion(context.Background(), &tt.promotion)
+			assert.Equal(t, err, tt.err)
+		})
+	}
+}
+
+func TestApplyPromotion(t *testing.T) {
+	type mockBehavior func(s *mocks.MockPromotionRepo)
+
+	active := func() *model.Promotion {
+		return &model.Promotion{
+			ID:            1,
+			Code:          "SPRING",
+			DiscountType:  model.DiscountPercent,
+			DiscountValue: 10,
+			ValidFrom:     time.Now().Add(-time.Hour),
+			ValidUntil:    time.Now().Add(time.Hour),
+			PerUserLimit:  1,
+			TaxiClasses:   []string{"economy"},
+			Active:        true,
+		}
+	}
+
+	test := []struct {
+		name         string
+		apply        service.PromotionApply
+		mockBehavior mockBehavior
+		discount     *service.Discount
+		err          error
+	}{
+		{
+			name:  "apply code",
+			apply: service.PromotionApply{Code: "spring", Fare: 1000, TaxiClass: "economy"},
+			mockBehavior: func(s *mocks.MockPromotionRepo) {
+				s.EXPECT().GetPromotionByCode(gomock.Any(), "SPRING").Return(active(), nil)
+				s.EXPECT().CountRedemptions(gomock.Any(), uint64(1), "1").Return(0, nil)
+			},
+			discount: &service.Discount{Code: "SPRING", Fare: 1000, Discount: 100, Total: 900},
+			err:      nil,
+		},
+		{
+			name:  "not eligible taxi class",
+			apply: service.PromotionApply{Code: "SPRING", Fare: 1000, TaxiClass: "business"},
+			mockBehavior: func(s *mocks.MockPromotionRepo) {
+				s.EXPECT().GetPromotionByCode(gomock.Any(), "SPRING").Return(active(), nil)
+			},
+			discount: nil,
+			err:      service.ErrPromotionNotApplicable,
+		},
+		{
+			name:  "expired",
+			apply: service.PromotionApply{Code: "SPRING", Fare: 1000, TaxiClass: "economy"},
+			mockBehavior: func(s *mocks.MockPromotionRepo) {
+				promotion := active()
+				promotion.ValidUntil = time.Now().Add(-time.Minute)
+				s.EXPECT().GetPromotionByCode(gomock.Any(), "SPRING").Return(promotion, nil)
+			},
+			discount: nil,
+			err:      service.ErrPromotionNotApplicable,
+		},
+		{
+			name:  "per user limit",
+			apply: service.PromotionApply{Code: "SPRING", Fare: 1000, TaxiClass: "economy"},
+			mockBehavior: func(s *mocks.MockPromotionRepo) {
+				s.EXPECT().GetPromotionByCode(gomock.Any(), "SPRING").Return(active(), nil)
+				s.EXPECT().CountRedemptions(gomock.Any(), uint64(1), "1").Return(1, nil)
+			},
+			discount: nil,
+			err:      service.ErrPromotionUsageExceeded,
+		},
+		{
+			name:  "unknown code",
+			apply: service.PromotionApply{Code: "WINTER", Fare: 1000},
+			mockBehavior: func(s *mocks.MockPromotionRepo) {
+				s.EXPECT().GetPromotionByCode(gomock.Any(), "WINTER").Return(nil, service.ErrPromotionDoesNotExists)
+			},
+			discount: nil,
+			err:      fmt.Errorf("get promotion by code failed: %w", service.ErrPromotionDoesNotExists),
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			promotionRepo := mocks.NewMockPromotionRepo(ctrl)
+			tt.mockBehavior(promotionRepo)
+
+			service := service.Service{
+				PromotionService: service.NewPromotionService(promotionRepo, nil),
+			}
+
+			discount, err := service.ApplyPromotion(context.Background(), "1", tt.apply)
+			assert.Equal(t, err, tt.err)
+			assert.Equal(t, discount, tt.discount)
+		})
+	}
+}
+
+func TestRedeem(t *testing.T) {
+	type mockBehavior func(s *mocks.MockPromotionRepo, p *mocks.MockPaymentRepo)
+
+	promotion := &model.Promotion{
+		ID:            1,
+		Code:          "FIRST",
+		DiscountType:  model.DiscountPercent,
+		DiscountValue: 10,
+		ValidFrom:     time.Now().Add(-time.Hour),
+		ValidUntil:    time.Now().Add(time.Hour),
+		Active:        true,
+	}
+	captured := &model.Payment{UserID: "1", OrderID: "order-1", Amount: 1200, CapturedAmount: 1000, Status: model.PaymentCaptured}
+
+	test := []struct {
+		name         string
+		mockBehavior mockBehavior
+		err          error
+	}{
+		{
+			name: "redeem code",
+			mockBehavior: func(s *mocks.MockPromotionRepo, p *mocks.MockPaymentRepo) {
+				p.EXPECT().GetPaymentByOrderId(gomock.Any(), "order-1").Return(captured, nil)
+				s.EXPECT().GetPromotionByCode(gomock.Any(), "FIRST").Return(promotion, nil)
+				s.EXPECT().RedeemPromotion(gomock.Any(), &model.Redemption{
+					PromotionID: 1,
+					UserID:      "1",
+					OrderID:     "order-1",
+					Discount:    100,
+				}).Return(nil)
+			},
+			err: nil,
+		},
+		{
+			name: "order does not exist",
+			mockBehavior: func(s *mocks.MockPromotionRepo, p *mocks.MockPaymentRepo) {
+				p.EXPECT().GetPaymentByOrderId(gomock.Any(), "order-1").Return(nil, service.ErrPaymentDoesNotExists)
+			},
+			err: fmt.Errorf("get payment by order id failed: %w", service.ErrPaymentDoesNotExists),
+		},
+		{
+			name: "fare not captured",
+			mockBehavior: func(s *mocks.MockPromotionRepo, p *mocks.MockPaymentRepo) {
+				p.EXPECT().GetPaymentByOrderId(gomock.Any(), "order-1").Return(&model.Payment{UserID: "1", OrderID: "order-1", Amount: 1200, Status: model.PaymentAuthorized}, nil)
+			},
+			err: service.ErrPaymentWrongStatus,
+		},
+		{
+			name: "already redeemed",
+			mockBehavior: func(s *mocks.MockPromotionRepo, p *mocks.MockPaymentRepo) {
+				p.EXPECT().GetPaymentByOrderId(gomock.Any(), "order-1").Return(captured, nil)
+				s.EXPECT().GetPromotionByCode(gomock.Any(), "FIRST").Return(promotion, nil)
+				s.EXPECT().RedeemPromotion(gomock.Any(), gomock.Any()).Return(service.ErrPromotionAlreadyRedeemed)
+			},
+			err: fmt.Errorf("redeem promotion failed: %w", service.ErrPromotionAlreadyRedeemed),
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			promotionRepo := mocks.NewMockPromotionRepo(ctrl)
+			paymentRepo := mocks.NewMockPaymentRepo(ctrl)
+			tt.mockBehavior(promotionRepo, paymentRepo)
+
+			s := service.Service{
+				PromotionService: service.NewPromotionService(promotionRepo, paymentRepo),
+			}
+
+			_, err := s.Redeem(context.Background(), "order-1", service.PromotionRedeem{Code: "first"})
+			assert.Equal(t, err, tt.err)
+		})
+	}
+}
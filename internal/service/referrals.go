@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/RipperAcskt/innotaxi/config"
+	"github.com/RipperAcskt/innotaxi/internal/apperror"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/tracing"
+)
+
+const (
+	referralCodeLength    = 8
+	referralCodeAlphabet  = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	defaultReferrerReward = 500
+	defaultInviteeReward  = 300
+)
+
+var (
+	ErrReferralCodeDoesNotExists = apperror.New(apperror.NotFound, "referral_code_does_not_exist", "referral code does not exists")
+	ErrReferralDoesNotExists     = apperror.New(apperror.NotFound, "referral_does_not_exist", "referral does not exists")
+)
+
+type ReferralRepo interface {
+	GetPendingReferralByInviteeId(ctx context.Context, inviteeId string) (*model.Referral, error)
+	// CompleteReferralById marks a pending referral as rewarded and returns
+	// ErrReferralDoesNotExists if it isn't pending anymore.
+	CompleteReferralById(ctx context.Context, referral *model.Referral) error
+	GetReferralStatsByUserId(ctx context.Context, userId string) (*model.ReferralStats, error)
+}
+
+type ReferralService struct {
+	ReferralRepo
+	tx     Transactor
+	wallet *WalletService
+	cfg    *config.Config
+}
+
+func NewReferralService(postgres ReferralRepo, tx Transactor, wallet *WalletService, cfg *config.Config) *ReferralService {
+	return &ReferralService{postgres, tx, wallet, cfg}
+}
+
+func (s *ReferralService) GetReferralStats(ctx context.Context, userId string) (*model.ReferralStats, error) {
+	ctx, span := tracing.Start(ctx, "ReferralService.GetReferralStats")
+	defer span.End()
+
+	return s.GetReferralStatsByUserId(ctx, userId)
+}
+
+// CompleteFirstTrip rewards both sides of the invitee's pending referral. It
+// is meant to be called on every completed trip, so having nothing to reward
+// is not an error and nil is returned. Both credits and the referral update
+// are committed together, and the credits are idempotent, so concurrent
+// calls don't pay twice.
+func (s *ReferralService) CompleteFirstTrip(ctx context.Context, inviteeId string) (*model.Referral, error) {
+	ctx, span := tracing.Start(ctx, "ReferralService.CompleteFirstTrip")
+	defer span.End()
+
+	var completed *model.Referral
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		referral, err := s.GetPendingReferralByInviteeId(ctx, inviteeId)
+		if err != nil {
+			if err == ErrReferralDoesNotExists {
+				return nil
+			}
+			return fmt.Errorf("get pending referral by invitee id failed: %w", err)
+		}
+
+		referral.ReferrerReward = s.cfg.REFERRAL_REFERRER_REWARD
+		if referral.ReferrerReward <= 0 {
+			referral.ReferrerReward = defaultReferrerReward
+		}
+		referral.InviteeReward = s.cfg.REFERRAL_INVITEE_REWARD
+		if referral.InviteeReward <= 0 {
+			referral.InviteeReward = defaultInviteeReward
+		}
+
+		err = s.credit(ctx, fmt.Sprintf("referral-%d-referrer", referral.ID), referral.ReferrerID, referral.ReferrerReward)
+		if err != nil {
+			return fmt.Errorf("credit referrer failed: %w", err)
+		}
+		err = s.credit(ctx, fmt.Sprintf("referral-%d-invitee", referral.ID), referral.InviteeID, referral.InviteeReward)
+		if err != nil {
+			return fmt.Errorf("credit invitee failed: %w", err)
+		}
+
+		err = s.CompleteReferralById(ctx, referral)
+		if err != nil {
+			if err == ErrReferralDoesNotExists {
+				return nil
+			}
+			return fmt.Errorf("complete referral by id failed: %w", err)
+		}
+
+		completed = referral
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return completed, nil
+}
+
+func (s *ReferralService) credit(ctx context.Context, key string, userId uint64, amount int64) error {
+	ctx, span := tracing.Start(ctx, "ReferralService.credit")
+	defer span.End()
+
+	_, err := s.wallet.post(ctx, model.EntryCredit, "referral reward", key,
+		model.Posting{AccountType: model.AccountMarketing, Amount: -amount},
+		model.Posting{AccountType: model.AccountWallet, UserID: strconv.FormatUint(userId, 10), Amount: amount},
+	)
+	return err
+}
+
+// refer resolves the referral code the user signed up with. Suspicious
+// sign-ups aren't refused, the referral is stored as rejected instead so
+// that abusers can't probe the checks.
+func (s *AuthService) refer(ctx context.Context, user UserSingUp) (*model.Referral, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.refer")
+	defer span.End()
+
+	referrer, err := s.GetUserByReferralCode(ctx, strings.ToUpper(strings.TrimSpace(user.ReferralCode)))
+	if err != nil {
+		return nil, fmt.Errorf("get user by referral code failed: %w", err)
+	}
+
+	referral := &model.Referral{
+		ReferrerID:  referrer.ID,
+		PhoneNumber: user.PhoneNumber,
+		DeviceID:    user.DeviceID,
+		Status:      model.ReferralPending,
+	}
+
+	if referrer.PhoneNumber == user.PhoneNumber || referrer.Email == user.Email {
+		referral.Status, referral.Reason = model.ReferralRejected, model.ReasonSelfReferral
+		return referral, nil
+	}
+
+	if user.DeviceID != "" {
+		if referrer.DeviceID == user.DeviceID {
+			referral.Status, referral.Reason = model.ReferralRejected, model.ReasonSameDevice
+			return referral, nil
+		}
+
+		num, err := s.CountReferralsByDeviceId(ctx, user.DeviceID)
+		if err != nil {
+			return nil, fmt.Errorf("count referrals by device id failed: %w", err)
+		}
+		if num > 0 {
+			referral.Status, referral.Reason = model.ReferralRejected, model.ReasonSameDevice
+			return referral, nil
+		}
+	}
+
+	num, err := s.CountReferralsByPhoneNumber(ctx, user.PhoneNumber)
+	if err != nil {
+		return nil, fmt.Errorf("count referrals by phone number failed: %w", err)
+	}
+	if num > 0 {
+		referral.Status, referral.Reason = model.ReferralRejected, model.ReasonPhoneReferred
+	}
+
+	return referral, nil
+}
+
+func generateReferralCode() (string, error) {
+	code := make([]byte, referralCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(referralCodeAlphabet))))
+		if err != nil {
+			return "", fmt.Errorf("rand int failed: %w", err)
+		}
+		code[i] = referralCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
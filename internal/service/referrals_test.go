@@ -0,0 +1,174 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/RipperAcskt/innotaxi/config"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/RipperAcskt/innotaxi/internal/service/mocks"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func TestSingUpWithReferral(t *testing.T) {
+	type mockBehavior func(s *mocks.MockAuthRepo)
+
+	referrer := &model.User{ID: 7, PhoneNumber: "+375291111111", Email: "referrer@mail.com", DeviceID: "device-1"}
+
+	test := []struct {
+		name         string
+		user         service.UserSingUp
+		mockBehavior mockBehavior
+		status       string
+		reason       string
+		err          error
+	}{
+		{
+			name: "pending referral",
+			user: service.UserSingUp{PhoneNumber: "+375292222222", Email: "invitee@mail.com", ReferralCode: " k7qx2m9a", DeviceID: "device-2"},
+			mockBehavior: func(s *mocks.MockAuthRepo) {
+				s.EXPECT().GetUserByReferralCode(gomock.Any(), "K7QX2M9A").Return(referrer, nil)
+				s.EXPECT().CountReferralsByDeviceId(gomock.Any(), "device-2").Return(0, nil)
+				s.EXPECT().CountReferralsByPhoneNumber(gomock.Any(), "+375292222222").Return(0, nil)
+			},
+			status: model.ReferralPending,
+			err:    nil,
+		},
+		{
+			name: "self referral",
+			user: service.UserSingUp{PhoneNumber: "+375293333333", Email: "referrer@mail.com", ReferralCode: "K7QX2M9A"},
+			mockBehavior: func(s *mocks.MockAuthRepo) {
+				s.EXPECT().GetUserByReferralCode(gomock.Any(), "K7QX2M9A").Return(referrer, nil)
+			},
+			status: model.ReferralRejected,
+			reason: model.ReasonSelfReferral,
+			err:    nil,
+		},
+		{
+			name: "referrer's device",
+			user: service.UserSingUp{PhoneNumber: "+375292222222", Email: "invitee@mail.com", ReferralCode: "K7QX2M9A", DeviceID: "device-1"},
+			mockBehavior: func(s *mocks.MockAuthRepo) {
+				s.EXPECT().GetUserByReferralCode(gomock.Any(), "K7QX2M9A").Return(referrer, nil)
+			},
+			status: model.ReferralRejected,
+			reason: model.ReasonSameDevice,
+			err:    nil,
+		},
+		{
+			name: "phone already referred",
+			user: service.UserSingUp{PhoneNumber: "+375292222222", Email: "invitee@mail.com", ReferralCode: "K7QX2M9A"},
+			mockBehavior: func(s *mocks.MockAuthRepo) {
+				s.EXPECT().GetUserByReferralCode(gomock.Any(), "K7QX2M9A").Return(referrer, nil)
+				s.EXPECT().CountReferralsByPhoneNumber(gomock.Any(), "+375292222222").Return(1, nil)
+			},
+			status: model.ReferralRejected,
+			reason: model.ReasonPhoneReferred,
+			err:    nil,
+		},
+		{
+			name: "unknown code",
+			user: service.UserSingUp{PhoneNumber: "+375292222222", Email: "invitee@mail.com", ReferralCode: "AAAAAAAA"},
+			mockBehavior: func(s *mocks.MockAuthRepo) {
+				s.EXPECT().GetUserByReferralCode(gomock.Any(), "AAAAAAAA").Return(nil, service.ErrReferralCodeDoesNotExists)
+			},
+			err: service.ErrReferralCodeDoesNotExists,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			authRepo := mocks.NewMockAuthRepo(ctrl)
+			tt.mockBehavior(authRepo)
+
+			outboxRepo := mocks.NewMockOutboxRepo(ctrl)
+
+			var created service.UserSingUp
+			if tt.err == nil {
+				authRepo.EXPECT().CreateUser(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, user service.UserSingUp) error {
+						created = user
+						return nil
+					})
+				authRepo.EXPECT().CheckUserByPhoneNumber(gomock.Any(), tt.user.PhoneNumber).Return(&service.UserSingIn{ID: 9}, nil)
+				outboxRepo.EXPECT().AddEvent(gomock.Any(), gomock.Any()).Return(nil)
+			}
+
+			s := service.Service{
+				AuthService: service.NewAuthSevice(authRepo, mocks.NewMockTokenRepo(ctrl), outboxRepo, newTransactor(ctrl), "124jkhsdaf3425", &config.Config{}),
+			}
+
+			err := s.SingUp(context.Background(), tt.user)
+			assert.Equal(t, errors.Is(err, tt.err), true)
+			if tt.err != nil {
+				return
+			}
+
+			assert.Equal(t, created.Referral.ReferrerID, referrer.ID)
+			assert.Equal(t, created.Referral.Status, tt.status)
+			assert.Equal(t, created.Referral.Reason, tt.reason)
+		})
+	}
+}
+
+func TestCompleteFirstTrip(t *testing.T) {
+	test := []struct {
+		name     string
+		referral *model.Referral
+		getErr   error
+		rewarded bool
+	}{
+		{
+			name:     "pending referral",
+			referral: &model.Referral{ID: 4, ReferrerID: 7, InviteeID: 9, Status: model.ReferralPending},
+			rewarded: true,
+		},
+		{
+			name:     "nothing to reward",
+			getErr:   service.ErrReferralDoesNotExists,
+			rewarded: false,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			referralRepo := mocks.NewMockReferralRepo(ctrl)
+			walletRepo := mocks.NewMockWalletRepo(ctrl)
+
+			referralRepo.EXPECT().GetPendingReferralByInviteeId(gomock.Any(), "9").Return(tt.referral, tt.getErr)
+			if tt.rewarded {
+				walletRepo.EXPECT().PostEntry(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, entry *model.JournalEntry) error {
+						assert.Equal(t, entry.IdempotencyKey, "referral-4-referrer")
+						assert.Equal(t, entry.Postings[1].UserID, "7")
+						assert.Equal(t, entry.Postings[1].Amount, int64(500))
+						return nil
+					})
+				walletRepo.EXPECT().PostEntry(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, entry *model.JournalEntry) error {
+						assert.Equal(t, entry.IdempotencyKey, "referral-4-invitee")
+						assert.Equal(t, entry.Postings[1].UserID, "9")
+						assert.Equal(t, entry.Postings[1].Amount, int64(250))
+						return nil
+					})
+				referralRepo.EXPECT().CompleteReferralById(gomock.Any(), tt.referral).Return(nil)
+			}
+
+			s := service.Service{
+				ReferralService: service.NewReferralService(referralRepo, newTransactor(ctrl), service.NewWalletService(walletRepo), &config.Config{REFERRAL_INVITEE_REWARD: 250}),
+			}
+
+			referral, err := s.CompleteFirstTrip(context.Background(), "9")
+			assert.Equal(t, err, nil)
+			assert.Equal(t, referral != nil, tt.rewarded)
+		})
+	}
+}
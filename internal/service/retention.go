@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/config"
+	"github.com/RipperAcskt/innotaxi/internal/apperror"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/tracing"
+)
+
+var ErrUserDeleted = apperror.New(apperror.FailedPrecondition, "user_deleted", "user is deleted")
+
+// defaultRestoreDays is the restore period when USER_RESTORE_DAYS isn't set.
+const defaultRestoreDays = 30
+
+// RestorePeriod is how long deleted users can restore their accounts by
+// signing in. After it they are purged.
+func RestorePeriod(cfg *config.Config) time.Duration {
+	days := cfg.USER_RESTORE_DAYS
+	if days <= 0 {
+		days = defaultRestoreDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// revokedUserKey is the TokenRepo key that revokes every token of a user.
+// User ids aren't reused, so the key never revokes someone else's tokens.
+func revokedUserKey(userId string) string {
+	return "revoked_user:" + userId
+}
+
+// RetentionService purges users whose restore period is over.
+type RetentionService struct {
+	users  UserRepo
+	tokens TokenRepo
+	outbox OutboxRepo
+	tx     Transactor
+	cfg    *config.Config
+}
+
+func NewRetentionService(users UserRepo, tokens TokenRepo, outbox OutboxRepo, tx Transactor, cfg *config.Config) *RetentionService {
+	return &RetentionService{users, tokens, outbox, tx, cfg}
+}
+
+// PurgeUsers purges up to limit users whose restore period was over at now,
+// oldest deletions first, and returns how many were purged.
+func (s *RetentionService) PurgeUsers(ctx context.Context, now time.Time, limit int) (int, error) {
+	ctx, span := tracing.Start(ctx, "RetentionService.PurgeUsers")
+	defer span.End()
+
+	ids, err := s.users.GetPurgeableUsers(ctx, now.Add(-RestorePeriod(s.cfg)), limit)
+	if err != nil {
+		return 0, fmt.Errorf("get purgeable users failed: %w", err)
+	}
+
+	for i, id := range ids {
+		err := s.purge(ctx, strconv.FormatUint(id, 10))
+		if err != nil {
+			return i, fmt.Errorf("user: %v: purge failed: %w", id, err)
+		}
+	}
+	return len(ids), nil
+}
+
+// purge revokes the tokens of the user before erasing it, so a failure in
+// between leaves a user that is purged again on the next run, never one
+// that is erased but can still use its tokens.
+func (s *RetentionService) purge(ctx context.Context, id string) error {
+	exp := time.Duration(s.cfg.REFRESH_TOKEN_EXP) * time.Hour * 24
+	err := s.tokens.AddToken(ctx, revokedUserKey(id), exp)
+	if err != nil {
+		return fmt.Errorf("add token failed: %w", err)
+	}
+
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.users.PurgeUserById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return addEvent(ctx, s.outbox, model.EventUserPurged, id, model.UserPurged{
+			UserID: id,
+		})
+	})
+}
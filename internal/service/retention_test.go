@@ -0,0 +1,104 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/config"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/RipperAcskt/innotaxi/internal/service/mocks"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func TestPurgeUsers(t *testing.T) {
+	type mockBehavior func(u *mocks.MockUserRepo, tr *mocks.MockTokenRepo, o *mocks.MockOutboxRepo)
+
+	now := time.Date(2023, 3, 31, 12, 0, 0, 0, time.UTC)
+	before := now.Add(-2 * 24 * time.Hour)
+	errDB := fmt.Errorf("db is down")
+
+	test := []struct {
+		name         string
+		mockBehavior mockBehavior
+		n            int
+		err          error
+	}{
+		{
+			name: "purge users",
+			mockBehavior: func(u *mocks.MockUserRepo, tr *mocks.MockTokenRepo, o *mocks.MockOutboxRepo) {
+				u.EXPECT().GetPurgeableUsers(gomock.Any(), before, 10).Return([]uint64{4, 9}, nil)
+				for _, id := range []string{"4", "9"} {
+					tr.EXPECT().AddToken(gomock.Any(), "revoked_user:"+id, 7*24*time.Hour).Return(nil)
+					u.EXPECT().PurgeUserById(gomock.Any(), id).Return(nil)
+					o.EXPECT().AddEvent(gomock.Any(), eventMatcher{model.EventUserPurged, id, model.UserPurged{
+						UserID: id,
+					}}).Return(nil)
+				}
+			},
+			n:   2,
+			err: nil,
+		},
+		{
+			name: "nothing to purge",
+			mockBehavior: func(u *mocks.MockUserRepo, tr *mocks.MockTokenRepo, o *mocks.MockOutboxRepo) {
+				u.EXPECT().GetPurgeableUsers(gomock.Any(), before, 10).Return(nil, nil)
+			},
+			n:   0,
+			err: nil,
+		},
+		{
+			name: "tokens are not revoked",
+			mockBehavior: func(u *mocks.MockUserRepo, tr *mocks.MockTokenRepo, o *mocks.MockOutboxRepo) {
+				u.EXPECT().GetPurgeableUsers(gomock.Any(), before, 10).Return([]uint64{4, 9}, nil)
+				tr.EXPECT().AddToken(gomock.Any(), "revoked_user:4", 7*24*time.Hour).Return(errDB)
+			},
+			n:   0,
+			err: errDB,
+		},
+		{
+			name: "purge fails",
+			mockBehavior: func(u *mocks.MockUserRepo, tr *mocks.MockTokenRepo, o *mocks.MockOutboxRepo) {
+				u.EXPECT().GetPurgeableUsers(gomock.Any(), before, 10).Return([]uint64{4, 9}, nil)
+				tr.EXPECT().AddToken(gomock.Any(), "revoked_user:4", 7*24*time.Hour).Return(nil)
+				u.EXPECT().PurgeUserById(gomock.Any(), "4").Return(nil)
+				o.EXPECT().AddEvent(gomock.Any(), gomock.Any()).Return(nil)
+				tr.EXPECT().AddToken(gomock.Any(), "revoked_user:9", 7*24*time.Hour).Return(nil)
+				u.EXPECT().PurgeUserById(gomock.Any(), "9").Return(errDB)
+			},
+			n:   1,
+			err: errDB,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo := mocks.NewMockUserRepo(ctrl)
+			tokenRepo := mocks.NewMockTokenRepo(ctrl)
+			outboxRepo := mocks.NewMockOutboxRepo(ctrl)
+			cfg := &config.Config{
+				USER_RESTORE_DAYS: 2,
+				REFRESH_TOKEN_EXP: 7,
+			}
+			retentionService := service.NewRetentionService(userRepo, tokenRepo, outboxRepo, newTransactor(ctrl), cfg)
+
+			tt.mockBehavior(userRepo, tokenRepo, outboxRepo)
+
+			n, err := retentionService.PurgeUsers(context.Background(), now, 10)
+			assert.Equal(t, n, tt.n)
+			assert.Equal(t, errors.Is(err, tt.err), true)
+		})
+	}
+}
+
+func TestRestorePeriod(t *testing.T) {
+	assert.Equal(t, service.RestorePeriod(&config.Config{}), 30*24*time.Hour)
+	assert.Equal(t, service.RestorePeriod(&config.Config{USER_RESTORE_DAYS: 7}), 7*24*time.Hour)
+}
@@ -2,50 +2,217 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/RipperAcskt/innotaxi/config"
 	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/payments"
+	"github.com/RipperAcskt/innotaxi/internal/tracing"
 )
 
 //go:generate mockgen -destination=mocks/mock_auth.go -package=mocks github.com/RipperAcskt/innotaxi/internal/service AuthRepo
 //go:generate mockgen -destination=mocks/mock_token.go -package=mocks github.com/RipperAcskt/innotaxi/internal/service TokenRepo
 //go:generate mockgen -destination=mocks/mock_user.go -package=mocks github.com/RipperAcskt/innotaxi/internal/service UserRepo
+//go:generate mockgen -destination=mocks/mock_place.go -package=mocks github.com/RipperAcskt/innotaxi/internal/service PlaceRepo
+//go:generate mockgen -destination=mocks/mock_promotion.go -package=mocks github.com/RipperAcskt/innotaxi/internal/service PromotionRepo
+//go:generate mockgen -destination=mocks/mock_wallet.go -package=mocks github.com/RipperAcskt/innotaxi/internal/service WalletRepo
+//go:generate mockgen -destination=mocks/mock_payment.go -package=mocks github.com/RipperAcskt/innotaxi/internal/service PaymentRepo
+//go:generate mockgen -destination=mocks/mock_referral.go -package=mocks github.com/RipperAcskt/innotaxi/internal/service ReferralRepo
+//go:generate mockgen -destination=mocks/mock_transactor.go -package=mocks github.com/RipperAcskt/innotaxi/internal/service Transactor
+//go:generate mockgen -destination=mocks/mock_outbox.go -package=mocks github.com/RipperAcskt/innotaxi/internal/service OutboxRepo
+//go:generate mockgen -destination=mocks/mock_webhook.go -package=mocks github.com/RipperAcskt/innotaxi/internal/service WebhookRepo
+//go:generate mockgen -destination=mocks/mock_log.go -package=mocks github.com/RipperAcskt/innotaxi/internal/service LogRepo
 type Service struct {
 	*AuthService
 	*UserService
+	*PlaceService
+	*PromotionService
+	*WalletService
+	*PaymentService
+	*ReferralService
+	*WebhookService
+	*LogService
+	*RetentionService
 }
 type Repo interface {
+	Transactor
 	AuthRepo
 	UserRepo
+	PlaceRepo
+	PromotionRepo
+	WalletRepo
+	PaymentRepo
+	ReferralRepo
+	OutboxRepo
+	WebhookRepo
+}
+
+// Transactor runs fn as a unit of work: repository calls made with the ctx
+// passed to fn share one transaction, which is committed if fn returns nil
+// and rolled back otherwise. Nested calls join the outer transaction.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
 type UserRepo interface {
 	GetUserById(ctx context.Context, id string) (*model.User, error)
+	// UpdateUserById sets the non-empty fields of user and increments the
+	// version. If user.Version isn't 0 the update only happens at that
+	// version, at any other it returns ErrUserModified.
 	UpdateUserById(ctx context.Context, id string, user *model.User) error
+	// DeleteUserById marks the user deleted. Its data is kept until it is
+	// purged.
 	DeleteUserById(ctx context.Context, id string) error
+	// GetPurgeableUsers returns the ids of up to limit users deleted before
+	// before and not purged yet, oldest deletions first. Users deleted at an
+	// unknown time, before deletions were timestamped, come first.
+	GetPurgeableUsers(ctx context.Context, before time.Time, limit int) ([]uint64, error)
+	// PurgeUserById erases the personal data of a deleted user: its name,
+	// phone number, email, password and device id, its saved places and
+	// payment methods, and its phone number and device id in referrals.
+	// Its published events and finished webhook deliveries are deleted,
+	// pending ones keep only the user id in their payload. Its id stays,
+	// so ledger entries, payments and referral stats it is part of stay
+	// intact.
+	PurgeUserById(ctx context.Context, id string) error
 }
 type UserService struct {
 	UserRepo
+	outbox OutboxRepo
+	tx     Transactor
 }
 
-func New(postgres Repo, redis TokenRepo, salt string, cfg *config.Config) *Service {
+func New(postgres Repo, redis TokenRepo, logs LogRepo, gateway payments.PaymentGateway, salt string, cfg *config.Config) *Service {
+	wallet := NewWalletService(postgres)
 	return &Service{
-		AuthService: NewAuthSevice(postgres, redis, salt, cfg),
-		UserService: NewUserService(postgres),
+		AuthService:      NewAuthSevice(postgres, redis, postgres, postgres, salt, cfg),
+		UserService:      NewUserService(postgres, postgres, postgres),
+		PlaceService:     NewPlaceService(postgres, cfg),
+		PromotionService: NewPromotionService(postgres, postgres),
+		WalletService:    wallet,
+		PaymentService:   NewPaymentService(postgres, postgres, gateway),
+		ReferralService:  NewReferralService(postgres, postgres, wallet, cfg),
+		WebhookService:   NewWebhookService(postgres),
+		LogService:       NewLogService(logs),
+		RetentionService: NewRetentionService(postgres, redis, postgres, postgres, cfg),
 	}
 }
 
-func NewUserService(postgres UserRepo) *UserService {
-	return &UserService{postgres}
+func NewUserService(postgres UserRepo, outbox OutboxRepo, tx Transactor) *UserService {
+	return &UserService{postgres, outbox, tx}
 }
 
 func (user *UserService) GetProfile(ctx context.Context, id string) (*model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetProfile")
+	defer span.End()
+
 	return user.GetUserById(ctx, id)
 }
 
-func (user *UserService) UpdateProfile(ctx context.Context, id string, userUpdate *model.User) error {
-	return user.UpdateUserById(ctx, id, userUpdate)
+// UserUpdate replaces every field of the profile users can change.
+type UserUpdate struct {
+	Name        string `json:"name" binding:"required,person_name"`
+	PhoneNumber string `json:"phone_number" binding:"required,phone"`
+	Email       string `json:"email" binding:"required,rfc_email"`
+}
+
+// UserPatch changes the fields that are set and keeps the others. Its
+// fields are the fields of the profile users can change.
+type UserPatch struct {
+	Name        *string `json:"name" binding:"omitempty,person_name"`
+	PhoneNumber *string `json:"phone_number" binding:"omitempty,phone"`
+	Email       *string `json:"email" binding:"omitempty,rfc_email"`
+}
+
+func (patch UserPatch) apply(user *model.User) {
+	if patch.Name != nil {
+		user.Name = *patch.Name
+	}
+	if patch.PhoneNumber != nil {
+		user.PhoneNumber = *patch.PhoneNumber
+	}
+	if patch.Email != nil {
+		user.Email = *patch.Email
+	}
+}
+
+// UpdateProfile replaces the profile at version and returns the updated
+// user. Version 0 matches any version.
+func (user *UserService) UpdateProfile(ctx context.Context, id string, version uint64, update UserUpdate) (*model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateProfile")
+	defer span.End()
+
+	return user.PatchProfile(ctx, id, version, UserPatch{
+		Name:        &update.Name,
+		PhoneNumber: &update.PhoneNumber,
+		Email:       &update.Email,
+	})
+}
+
+// PatchProfile changes the fields set in patch at version and returns the
+// updated user. Version 0 matches any version, any other one that isn't
+// current fails with ErrUserModified. It publishes user.phone_changed if the
+// phone number changed.
+func (user *UserService) PatchProfile(ctx context.Context, id string, version uint64, patch UserPatch) (*model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.PatchProfile")
+	defer span.End()
+
+	var updated *model.User
+	err := user.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		current, err := user.GetUserById(ctx, id)
+		if err != nil {
+			return err
+		}
+		if version != 0 && version != current.Version {
+			return ErrUserModified
+		}
+
+		next := *current
+		patch.apply(&next)
+		if next == *current {
+			updated = current
+			return nil
+		}
+
+		// The update is made at the version read, so a concurrent update
+		// that got in between fails it instead of being overwritten.
+		err = user.UpdateUserById(ctx, id, &model.User{
+			Name:        next.Name,
+			PhoneNumber: next.PhoneNumber,
+			Email:       next.Email,
+			Version:     current.Version,
+		})
+		if err != nil {
+			return err
+		}
+		next.Version++
+		updated = &next
+
+		if next.PhoneNumber == current.PhoneNumber {
+			return nil
+		}
+		return addEvent(ctx, user.outbox, model.EventUserPhoneChanged, id, model.UserPhoneChanged{
+			UserID:      id,
+			PhoneNumber: next.PhoneNumber,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
 }
 
 func (user *UserService) DeleteUser(ctx context.Context, id string) error {
-	return user.DeleteUserById(ctx, id)
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser")
+	defer span.End()
+
+	return user.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := user.DeleteUserById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return addEvent(ctx, user.outbox, model.EventUserDeleted, id, model.UserDeleted{
+			UserID: id,
+		})
+	})
 }
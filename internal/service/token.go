@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/RipperAcskt/innotaxi/config"
+	"github.com/RipperAcskt/innotaxi/internal/apperror"
 	"github.com/golang-jwt/jwt"
 )
 
@@ -14,8 +15,8 @@ const (
 )
 
 var (
-	ErrTokenExpired = fmt.Errorf("token expired")
-	ErrUnknownType  = fmt.Errorf("unknown type")
+	ErrTokenExpired = apperror.New(apperror.Unauthenticated, "token_expired", "token expired")
+	ErrUnknownType  = apperror.New(apperror.Invalid, "unknown_token_type", "unknown type")
 )
 
 type Token struct {
@@ -1,7 +1,10 @@
 package service_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
 	"testing"
 
 	"github.com/RipperAcskt/innotaxi/internal/model"
@@ -11,6 +14,26 @@ import (
 	"github.com/golang/mock/gomock"
 )
 
+// eventMatcher matches an outbox event by type, user and payload.
+type eventMatcher struct {
+	eventType string
+	userId    string
+	payload   interface{}
+}
+
+func (m eventMatcher) Matches(x interface{}) bool {
+	event, ok := x.(*model.Event)
+	if !ok {
+		return false
+	}
+	payload, err := json.Marshal(m.payload)
+	return err == nil && event.Type == m.eventType && event.AggregateID == m.userId && bytes.Equal(event.Payload, payload)
+}
+
+func (m eventMatcher) String() string {
+	return fmt.Sprintf("is %v event of user %v with %v", m.eventType, m.userId, m.payload)
+}
+
 func TestGetProfile(t *testing.T) {
 	type mockBehavior func(s *mocks.MockUserRepo)
 	test := []struct {
@@ -21,7 +44,7 @@ func TestGetProfile(t *testing.T) {
 		{
 			name: "get user",
 			mockBehavior: func(s *mocks.MockUserRepo) {
-				s.EXPECT().GetUserById(context.Background(), "").Return(&model.User{
+				s.EXPECT().GetUserById(gomock.Any(), "").Return(&model.User{
 					Name:        "2",
 					PhoneNumber: "2",
 					Email:       "2",
@@ -38,7 +61,7 @@ func TestGetProfile(t *testing.T) {
 			defer ctrl.Finish()
 
 			userRepo := mocks.NewMockUserRepo(ctrl)
-			userService := service.NewUserService(userRepo)
+			userService := service.NewUserService(userRepo, mocks.NewMockOutboxRepo(ctrl), newTransactor(ctrl))
 
 			tt.mockBehavior(userRepo)
 
@@ -53,25 +76,150 @@ func TestGetProfile(t *testing.T) {
 }
 
 func TestUpdateProfile(t *testing.T) {
-	type mockBehavior func(s *mocks.MockUserRepo, user model.User)
+	type mockBehavior func(s *mocks.MockUserRepo, o *mocks.MockOutboxRepo)
+
+	current := model.User{
+		Name:        "Ivan",
+		PhoneNumber: "+7455456",
+		Email:       "ripper@algsdh",
+		Raiting:     4.5,
+		Version:     2,
+	}
 
 	test := []struct {
 		name         string
-		user         model.User
+		version      uint64
+		update       service.UserUpdate
 		mockBehavior mockBehavior
+		user         *model.User
 		err          error
 	}{
 		{
-			name: "update user",
-			user: model.User{
+			name:    "update user",
+			version: 2,
+			update: service.UserUpdate{
+				Name:        "Ivan",
 				PhoneNumber: "+77777778",
 				Email:       "ripper@mail.ru",
 			},
-			mockBehavior: func(s *mocks.MockUserRepo, user model.User) {
-				s.EXPECT().UpdateUserById(context.Background(), "", &user).Return(nil)
+			mockBehavior: func(s *mocks.MockUserRepo, o *mocks.MockOutboxRepo) {
+				user := current
+				s.EXPECT().GetUserById(gomock.Any(), "9").Return(&user, nil)
+				s.EXPECT().UpdateUserById(gomock.Any(), "9", &model.User{
+					Name:        "Ivan",
+					PhoneNumber: "+77777778",
+					Email:       "ripper@mail.ru",
+					Version:     2,
+				}).Return(nil)
+				o.EXPECT().AddEvent(gomock.Any(), eventMatcher{model.EventUserPhoneChanged, "9", model.UserPhoneChanged{
+					UserID:      "9",
+					PhoneNumber: "+77777778",
+				}}).Return(nil)
+			},
+			user: &model.User{
+				Name:        "Ivan",
+				PhoneNumber: "+77777778",
+				Email:       "ripper@mail.ru",
+				Raiting:     4.5,
+				Version:     3,
 			},
 			err: nil,
 		},
+		{
+			name: "phone number not changed",
+			update: service.UserUpdate{
+				Name:        "Ivan",
+				PhoneNumber: "+7455456",
+				Email:       "ripper@mail.ru",
+			},
+			mockBehavior: func(s *mocks.MockUserRepo, o *mocks.MockOutboxRepo) {
+				user := current
+				s.EXPECT().GetUserById(gomock.Any(), "9").Return(&user, nil)
+				s.EXPECT().UpdateUserById(gomock.Any(), "9", &model.User{
+					Name:        "Ivan",
+					PhoneNumber: "+7455456",
+					Email:       "ripper@mail.ru",
+					Version:     2,
+				}).Return(nil)
+			},
+			user: &model.User{
+				Name:        "Ivan",
+				PhoneNumber: "+7455456",
+				Email:       "ripper@mail.ru",
+				Raiting:     4.5,
+				Version:     3,
+			},
+			err: nil,
+		},
+		{
+			name: "nothing changed",
+			update: service.UserUpdate{
+				Name:        "Ivan",
+				PhoneNumber: "+7455456",
+				Email:       "ripper@algsdh",
+			},
+			mockBehavior: func(s *mocks.MockUserRepo, o *mocks.MockOutboxRepo) {
+				user := current
+				s.EXPECT().GetUserById(gomock.Any(), "9").Return(&user, nil)
+			},
+			user: &current,
+			err:  nil,
+		},
+		{
+			name: "phone number taken",
+			update: service.UserUpdate{
+				Name:        "Ivan",
+				PhoneNumber: "+77777778",
+				Email:       "ripper@algsdh",
+			},
+			mockBehavior: func(s *mocks.MockUserRepo, o *mocks.MockOutboxRepo) {
+				user := current
+				s.EXPECT().GetUserById(gomock.Any(), "9").Return(&user, nil)
+				s.EXPECT().UpdateUserById(gomock.Any(), "9", gomock.Any()).Return(service.ErrUserAlreadyExists)
+			},
+			err: service.ErrUserAlreadyExists,
+		},
+		{
+			name:    "version changed",
+			version: 1,
+			update: service.UserUpdate{
+				Name:        "Petr",
+				PhoneNumber: "+7455456",
+				Email:       "ripper@algsdh",
+			},
+			mockBehavior: func(s *mocks.MockUserRepo, o *mocks.MockOutboxRepo) {
+				user := current
+				s.EXPECT().GetUserById(gomock.Any(), "9").Return(&user, nil)
+			},
+			err: service.ErrUserModified,
+		},
+		{
+			name:    "concurrent update",
+			version: 2,
+			update: service.UserUpdate{
+				Name:        "Petr",
+				PhoneNumber: "+7455456",
+				Email:       "ripper@algsdh",
+			},
+			mockBehavior: func(s *mocks.MockUserRepo, o *mocks.MockOutboxRepo) {
+				user := current
+				s.EXPECT().GetUserById(gomock.Any(), "9").Return(&user, nil)
+				s.EXPECT().UpdateUserById(gomock.Any(), "9", gomock.Any()).Return(service.ErrUserModified)
+			},
+			err: service.ErrUserModified,
+		},
+		{
+			name: "user does not exist",
+			update: service.UserUpdate{
+				Name:        "Ivan",
+				PhoneNumber: "+77777778",
+				Email:       "ripper@algsdh",
+			},
+			mockBehavior: func(s *mocks.MockUserRepo, o *mocks.MockOutboxRepo) {
+				s.EXPECT().GetUserById(gomock.Any(), "9").Return(nil, service.ErrUserDoesNotExists)
+			},
+			err: service.ErrUserDoesNotExists,
+		},
 	}
 
 	for _, tt := range test {
@@ -80,22 +228,55 @@ func TestUpdateProfile(t *testing.T) {
 			defer ctrl.Finish()
 
 			userRepo := mocks.NewMockUserRepo(ctrl)
-			userService := service.NewUserService(userRepo)
+			outboxRepo := mocks.NewMockOutboxRepo(ctrl)
+			userService := service.NewUserService(userRepo, outboxRepo, newTransactor(ctrl))
 
-			tt.mockBehavior(userRepo, tt.user)
+			tt.mockBehavior(userRepo, outboxRepo)
 
 			service := service.Service{
 				UserService: userService,
 			}
 
-			err := service.UpdateProfile(context.Background(), "", &tt.user)
+			user, err := service.UpdateProfile(context.Background(), "9", tt.version, tt.update)
 			assert.Equal(t, err, tt.err)
+			assert.Equal(t, user, tt.user)
 		})
 	}
 }
 
+func TestPatchProfile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepo(ctrl)
+	userService := service.NewUserService(userRepo, mocks.NewMockOutboxRepo(ctrl), newTransactor(ctrl))
+
+	userRepo.EXPECT().GetUserById(gomock.Any(), "9").Return(&model.User{
+		Name:        "Ivan",
+		PhoneNumber: "+7455456",
+		Email:       "ripper@algsdh",
+		Version:     1,
+	}, nil)
+	userRepo.EXPECT().UpdateUserById(gomock.Any(), "9", &model.User{
+		Name:        "Petr",
+		PhoneNumber: "+7455456",
+		Email:       "ripper@algsdh",
+		Version:     1,
+	}).Return(nil)
+
+	name := "Petr"
+	user, err := userService.PatchProfile(context.Background(), "9", 0, service.UserPatch{Name: &name})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, user, &model.User{
+		Name:        "Petr",
+		PhoneNumber: "+7455456",
+		Email:       "ripper@algsdh",
+		Version:     2,
+	})
+}
+
 func TestDeleteProfile(t *testing.T) {
-	type mockBehavior func(s *mocks.MockUserRepo)
+	type mockBehavior func(s *mocks.MockUserRepo, o *mocks.MockOutboxRepo)
 
 	test := []struct {
 		name         string
@@ -104,11 +285,21 @@ func TestDeleteProfile(t *testing.T) {
 	}{
 		{
 			name: "delete user",
-			mockBehavior: func(s *mocks.MockUserRepo) {
-				s.EXPECT().DeleteUserById(context.Background(), "").Return(nil)
+			mockBehavior: func(s *mocks.MockUserRepo, o *mocks.MockOutboxRepo) {
+				s.EXPECT().DeleteUserById(gomock.Any(), "9").Return(nil)
+				o.EXPECT().AddEvent(gomock.Any(), eventMatcher{model.EventUserDeleted, "9", model.UserDeleted{
+					UserID: "9",
+				}}).Return(nil)
 			},
 			err: nil,
 		},
+		{
+			name: "user does not exist",
+			mockBehavior: func(s *mocks.MockUserRepo, o *mocks.MockOutboxRepo) {
+				s.EXPECT().DeleteUserById(gomock.Any(), "9").Return(service.ErrUserDoesNotExists)
+			},
+			err: service.ErrUserDoesNotExists,
+		},
 	}
 
 	for _, tt := range test {
@@ -117,15 +308,16 @@ func TestDeleteProfile(t *testing.T) {
 			defer ctrl.Finish()
 
 			userRepo := mocks.NewMockUserRepo(ctrl)
-			userService := service.NewUserService(userRepo)
+			outboxRepo := mocks.NewMockOutboxRepo(ctrl)
+			userService := service.NewUserService(userRepo, outboxRepo, newTransactor(ctrl))
 
-			tt.mockBehavior(userRepo)
+			tt.mockBehavior(userRepo, outboxRepo)
 
 			service := service.Service{
 				UserService: userService,
 			}
 
-			err := service.DeleteUser(context.Background(), "")
+			err := service.DeleteUser(context.Background(), "9")
 			assert.Equal(t, err, tt.err)
 		})
 	}
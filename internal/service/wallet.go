@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/RipperAcskt/innotaxi/internal/apperror"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/tracing"
+)
+
+const (
+	defaultTransactionsLimit = 20
+	maxTransactionsLimit     = 100
+)
+
+var (
+	ErrInsufficientFunds    = apperror.New(apperror.FailedPrecondition, "insufficient_funds", "insufficient funds")
+	ErrIdempotencyKeyReused = apperror.New(apperror.Conflict, "wallet_idempotency_key_reused", "idempotency key reused with different parameters")
+	ErrUnknownEntryKind     = apperror.New(apperror.Invalid, "unknown_entry_kind", "unknown entry kind")
+)
+
+type WalletOperation struct {
+	IdempotencyKey string `json:"idempotency_key" binding:"required,max=64"`
+	Amount         int64  `json:"amount" binding:"required,gt=0"`
+	OrderID        string `json:"order_id" binding:"max=64"`
+}
+
+type WalletCharge struct {
+	WalletOperation
+	Kind string `json:"kind" binding:"required,oneof=trip_fare cancellation_fee"`
+}
+
+type WalletRepo interface {
+	// PostEntry appends the entry to the ledger. Posting an entry with an
+	// already used idempotency key returns the stored entry instead, or
+	// ErrIdempotencyKeyReused if the stored entry differs.
+	PostEntry(ctx context.Context, entry *model.JournalEntry) error
+	GetBalance(ctx context.Context, userId string) (int64, error)
+	GetTransactions(ctx context.Context, userId string, limit, offset int) ([]*model.Transaction, error)
+}
+
+type WalletService struct {
+	WalletRepo
+}
+
+func NewWalletService(postgres WalletRepo) *WalletService {
+	return &WalletService{postgres}
+}
+
+func (s *WalletService) GetWallet(ctx context.Context, userId string, limit, offset int) (*model.Wallet, error) {
+	ctx, span := tracing.Start(ctx, "WalletService.GetWallet")
+	defer span.End()
+
+	if limit <= 0 {
+		limit = defaultTransactionsLimit
+	}
+	if limit > maxTransactionsLimit {
+		limit = maxTransactionsLimit
+	}
+
+	balance, err := s.GetBalance(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("get balance failed: %w", err)
+	}
+
+	transactions, err := s.GetTransactions(ctx, userId, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("get transactions failed: %w", err)
+	}
+
+	return &model.Wallet{
+		Balance:      balance,
+		Transactions: transactions,
+	}, nil
+}
+
+func (s *WalletService) TopUp(ctx context.Context, userId string, op WalletOperation) (*model.JournalEntry, error) {
+	ctx, span := tracing.Start(ctx, "WalletService.TopUp")
+	defer span.End()
+
+	return s.post(ctx, model.EntryTopUp, "wallet top up", op.IdempotencyKey,
+		model.Posting{AccountType: model.AccountClearing, Amount: -op.Amount},
+		model.Posting{AccountType: model.AccountWallet, UserID: userId, Amount: op.Amount},
+	)
+}
+
+// Charge debits a trip fare or a cancellation fee from the wallet. The
+// wallet balance can't go below zero.
+func (s *WalletService) Charge(ctx context.Context, userId string, charge WalletCharge) (*model.JournalEntry, error) {
+	ctx, span := tracing.Start(ctx, "WalletService.Charge")
+	defer span.End()
+
+	var description string
+	switch charge.Kind {
+	case model.EntryTripFare:
+		description = fmt.Sprintf("trip fare for order %s", charge.OrderID)
+	case model.EntryCancellationFee:
+		description = fmt.Sprintf("cancellation fee for order %s", charge.OrderID)
+	default:
+		return nil, ErrUnknownEntryKind
+	}
+
+	return s.post(ctx, charge.Kind, description, charge.IdempotencyKey,
+		model.Posting{AccountType: model.AccountWallet, UserID: userId, Amount: -charge.Amount},
+		model.Posting{AccountType: model.AccountRevenue, Amount: charge.Amount},
+	)
+}
+
+func (s *WalletService) Refund(ctx context.Context, userId string, op WalletOperation) (*model.JournalEntry, error) {
+	ctx, span := tracing.Start(ctx, "WalletService.Refund")
+	defer span.End()
+
+	return s.post(ctx, model.EntryRefund, fmt.Sprintf("refund for order %s", op.OrderID), op.IdempotencyKey,
+		model.Posting{AccountType: model.AccountRevenue, Amount: -op.Amount},
+		model.Posting{AccountType: model.AccountWallet, UserID: userId, Amount: op.Amount},
+	)
+}
+
+func (s *WalletService) post(ctx context.Context, kind, description, key string, postings ...model.Posting) (*model.JournalEntry, error) {
+	ctx, span := tracing.Start(ctx, "WalletService.post")
+	defer span.End()
+
+	entry := &model.JournalEntry{
+		IdempotencyKey: key,
+		Kind:           kind,
+		Description:    description,
+		Postings:       postings,
+	}
+
+	err := s.PostEntry(ctx, entry)
+	if err != nil {
+		return nil, fmt.Errorf("post entry failed: %w", err)
+	}
+	return entry, nil
+}
@@ -0,0 +1,184 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/RipperAcskt/innotaxi/internal/service/mocks"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func TestTopUp(t *testing.T) {
+	type mockBehavior func(s *mocks.MockWalletRepo)
+
+	op := service.WalletOperation{IdempotencyKey: "key-1", Amount: 500}
+
+	test := []struct {
+		name         string
+		mockBehavior mockBehavior
+		err          error
+	}{
+		{
+			name: "top up",
+			mockBehavior: func(s *mocks.MockWalletRepo) {
+				s.EXPECT().PostEntry(gomock.Any(), &model.JournalEntry{
+					IdempotencyKey: "key-1",
+					Kind:           model.EntryTopUp,
+					Description:    "wallet top up",
+					Postings: []model.Posting{
+						{AccountType: model.AccountClearing, Amount: -500},
+						{AccountType: model.AccountWallet, UserID: "1", Amount: 500},
+					},
+				}).Return(nil)
+			},
+			err: nil,
+		},
+		{
+			name: "reused key",
+			mockBehavior: func(s *mocks.MockWalletRepo) {
+				s.EXPECT().PostEntry(gomock.Any(), gomock.Any()).Return(service.ErrIdempotencyKeyReused)
+			},
+			err: fmt.Errorf("post entry failed: %w", service.ErrIdempotencyKeyReused),
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			walletRepo := mocks.NewMockWalletRepo(ctrl)
+			tt.mockBehavior(walletRepo)
+
+			service := service.Service{
+				WalletService: service.NewWalletService(walletRepo),
+			}
+
+			_, err := service.TopUp(context.Background(), "1", op)
+			assert.Equal(t, err, tt.err)
+		})
+	}
+}
+
+func TestCharge(t *testing.T) {
+	type mockBehavior func(s *mocks.MockWalletRepo)
+
+	test := []struct {
+		name         string
+		charge       service.WalletCharge
+		mockBehavior mockBehavior
+		err          error
+	}{
+		{
+			name: "trip fare",
+			charge: service.WalletCharge{
+				WalletOperation: service.WalletOperation{IdempotencyKey: "order-1-fare", Amount: 700, OrderID: "order-1"},
+				Kind:            model.EntryTripFare,
+			},
+			mockBehavior: func(s *mocks.MockWalletRepo) {
+				s.EXPECT().PostEntry(gomock.Any(), &model.JournalEntry{
+					IdempotencyKey: "order-1-fare",
+					Kind:           model.EntryTripFare,
+					Description:    "trip fare for order order-1",
+					Postings: []model.Posting{
+						{AccountType: model.AccountWallet, UserID: "1", Amount: -700},
+						{AccountType: model.AccountRevenue, Amount: 700},
+					},
+				}).Return(nil)
+			},
+			err: nil,
+		},
+		{
+			name: "insufficient funds",
+			charge: service.WalletCharge{
+				WalletOperation: service.WalletOperation{IdempotencyKey: "order-2-fee", Amount: 300, OrderID: "order-2"},
+				Kind:            model.EntryCancellationFee,
+			},
+			mockBehavior: func(s *mocks.MockWalletRepo) {
+				s.EXPECT().PostEntry(gomock.Any(), gomock.Any()).Return(service.ErrInsufficientFunds)
+			},
+			err: fmt.Errorf("post entry failed: %w", service.ErrInsufficientFunds),
+		},
+		{
+			name: "unknown kind",
+			charge: service.WalletCharge{
+				WalletOperation: service.WalletOperation{IdempotencyKey: "order-3", Amount: 300},
+				Kind:            model.EntryTopUp,
+			},
+			mockBehavior: func(s *mocks.MockWalletRepo) {},
+			err:          service.ErrUnknownEntryKind,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			walletRepo := mocks.NewMockWalletRepo(ctrl)
+			tt.mockBehavior(walletRepo)
+
+			service := service.Service{
+				WalletService: service.NewWalletService(walletRepo),
+			}
+
+			_, err := service.Charge(context.Background(), "1", tt.charge)
+			assert.Equal(t, err, tt.err)
+		})
+	}
+}
+
+func TestGetWallet(t *testing.T) {
+	type mockBehavior func(s *mocks.MockWalletRepo)
+
+	test := []struct {
+		name         string
+		limit        int
+		mockBehavior mockBehavior
+		balance      int64
+		err          error
+	}{
+		{
+			name:  "default limit",
+			limit: 0,
+			mockBehavior: func(s *mocks.MockWalletRepo) {
+				s.EXPECT().GetBalance(gomock.Any(), "1").Return(int64(1200), nil)
+				s.EXPECT().GetTransactions(gomock.Any(), "1", 20, 0).Return([]*model.Transaction{}, nil)
+			},
+			balance: 1200,
+			err:     nil,
+		},
+		{
+			name:  "max limit",
+			limit: 1000,
+			mockBehavior: func(s *mocks.MockWalletRepo) {
+				s.EXPECT().GetBalance(gomock.Any(), "1").Return(int64(0), nil)
+				s.EXPECT().GetTransactions(gomock.Any(), "1", 100, 0).Return([]*model.Transaction{}, nil)
+			},
+			balance: 0,
+			err:     nil,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			walletRepo := mocks.NewMockWalletRepo(ctrl)
+			tt.mockBehavior(walletRepo)
+
+			service := service.Service{
+				WalletService: service.NewWalletService(walletRepo),
+			}
+
+			wallet, err := service.GetWallet(context.Background(), "1", tt.limit, 0)
+			assert.Equal(t, err, tt.err)
+			assert.Equal(t, wallet.Balance, tt.balance)
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/apperror"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/tracing"
+)
+
+const (
+	defaultDeliveriesLimit = 20
+	maxDeliveriesLimit     = 100
+
+	webhookSecretLength = 32
+)
+
+var (
+	ErrWebhookDoesNotExists         = apperror.New(apperror.NotFound, "webhook_does_not_exist", "webhook does not exists")
+	ErrWebhookDeliveryDoesNotExists = apperror.New(apperror.NotFound, "webhook_delivery_does_not_exist", "dead webhook delivery does not exists")
+	ErrUnknownEventType             = apperror.New(apperror.Invalid, "unknown_event_type", "unknown event type")
+	ErrUnknownDeliveryStatus        = apperror.New(apperror.Invalid, "unknown_delivery_status", "unknown delivery status")
+	ErrEmployeeDoesNotExists        = apperror.New(apperror.NotFound, "employee_does_not_exist", "user is not an employee of the partner")
+)
+
+// webhookEventTypes are the events partners can subscribe to.
+var webhookEventTypes = map[string]bool{
+	model.EventUserSignedUp:     true,
+	model.EventUserPhoneChanged: true,
+	model.EventUserDeleted:      true,
+	model.EventUserRestored:     true,
+	model.EventUserPurged:       true,
+}
+
+type WebhookRepo interface {
+	CreateWebhook(ctx context.Context, webhook *model.Webhook) error
+	GetWebhooks(ctx context.Context) ([]*model.Webhook, error)
+	GetWebhookById(ctx context.Context, id string) (*model.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhook *model.Webhook) error
+	DeleteWebhookById(ctx context.Context, id string) error
+
+	// AddPartnerEmployee makes the user an employee of the partner, moving
+	// it from its previous partner if any.
+	AddPartnerEmployee(ctx context.Context, partnerId uint64, userId string) error
+	// RemovePartnerEmployee returns ErrEmployeeDoesNotExists if the user
+	// isn't an employee of the partner.
+	RemovePartnerEmployee(ctx context.Context, partnerId uint64, userId string) error
+
+	// AddWebhookDeliveries queues body for every active webhook of the
+	// partner of userId subscribed to eventType. Nothing is queued for
+	// users without a partner. Events already queued for a webhook are
+	// skipped.
+	AddWebhookDeliveries(ctx context.Context, eventId uint64, eventType, userId string, body []byte) error
+	// GetDueWebhookDeliveries returns up to limit pending deliveries whose
+	// next attempt is not after now, oldest first.
+	GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+	// GetWebhookDeliveries returns the webhook's deliveries newest first,
+	// empty status means any.
+	GetWebhookDeliveries(ctx context.Context, webhookId, status string, limit, offset int) ([]*model.WebhookDelivery, error)
+	// RetryWebhookDelivery moves a dead delivery back to the queue.
+	RetryWebhookDelivery(ctx context.Context, webhookId, deliveryId string, now time.Time) error
+}
+
+type WebhookService struct {
+	WebhookRepo
+}
+
+func NewWebhookService(postgres WebhookRepo) *WebhookService {
+	return &WebhookService{postgres}
+}
+
+// AddWebhook generates a secret unless the admin provided one. The secret is
+// returned only here.
+func (s *WebhookService) AddWebhook(ctx context.Context, webhook *model.Webhook) error {
+	ctx, span := tracing.Start(ctx, "WebhookService.AddWebhook")
+	defer span.End()
+
+	err := checkEventTypes(webhook.EventTypes)
+	if err != nil {
+		return err
+	}
+
+	if webhook.Secret == "" {
+		webhook.Secret, err = generateWebhookSecret()
+		if err != nil {
+			return fmt.Errorf("generate webhook secret failed: %w", err)
+		}
+	}
+	webhook.Active = true
+	return s.CreateWebhook(ctx, webhook)
+}
+
+func (s *WebhookService) GetAllWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.GetAllWebhooks")
+	defer span.End()
+
+	webhooks, err := s.GetWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, webhook := range webhooks {
+		webhook.Secret = ""
+	}
+	return webhooks, nil
+}
+
+func (s *WebhookService) GetWebhook(ctx context.Context, id string) (*model.Webhook, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.GetWebhook")
+	defer span.End()
+
+	webhook, err := s.GetWebhookById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+// EditWebhook replaces the partner, URL, event types and active flag. The secret is
+// only replaced if a new one is given.
+func (s *WebhookService) EditWebhook(ctx context.Context, id string, webhook *model.Webhook) error {
+	ctx, span := tracing.Start(ctx, "WebhookService.EditWebhook")
+	defer span.End()
+
+	err := checkEventTypes(webhook.EventTypes)
+	if err != nil {
+		return err
+	}
+
+	stored, err := s.GetWebhookById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	stored.PartnerID = webhook.PartnerID
+	stored.URL = webhook.URL
+	stored.EventTypes = webhook.EventTypes
+	stored.Active = webhook.Active
+	if webhook.Secret != "" {
+		stored.Secret = webhook.Secret
+	}
+	return s.UpdateWebhook(ctx, stored)
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "WebhookService.DeleteWebhook")
+	defer span.End()
+
+	return s.DeleteWebhookById(ctx, id)
+}
+
+// AddEmployee subscribes the partner's webhooks to the events of the user.
+func (s *WebhookService) AddEmployee(ctx context.Context, partnerId uint64, userId string) error {
+	ctx, span := tracing.Start(ctx, "WebhookService.AddEmployee")
+	defer span.End()
+
+	return s.AddPartnerEmployee(ctx, partnerId, userId)
+}
+
+func (s *WebhookService) RemoveEmployee(ctx context.Context, partnerId uint64, userId string) error {
+	ctx, span := tracing.Start(ctx, "WebhookService.RemoveEmployee")
+	defer span.End()
+
+	return s.RemovePartnerEmployee(ctx, partnerId, userId)
+}
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, webhookId, status string, limit, offset int) ([]*model.WebhookDelivery, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.GetDeliveries")
+	defer span.End()
+
+	if status != "" && status != model.DeliveryPending && status != model.DeliveryDelivered && status != model.DeliveryDead {
+		return nil, fmt.Errorf("status: %v: %w", status, ErrUnknownDeliveryStatus)
+	}
+	if limit <= 0 {
+		limit = defaultDeliveriesLimit
+	}
+	if limit > maxDeliveriesLimit {
+		limit = maxDeliveriesLimit
+	}
+
+	_, err := s.GetWebhookById(ctx, webhookId)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetWebhookDeliveries(ctx, webhookId, status, limit, offset)
+}
+
+func (s *WebhookService) RetryDelivery(ctx context.Context, webhookId, deliveryId string) error {
+	ctx, span := tracing.Start(ctx, "WebhookService.RetryDelivery")
+	defer span.End()
+
+	return s.RetryWebhookDelivery(ctx, webhookId, deliveryId, time.Now())
+}
+
+func checkEventTypes(eventTypes []string) error {
+	for _, eventType := range eventTypes {
+		if !webhookEventTypes[eventType] {
+			return fmt.Errorf("event type: %v: %w", eventType, ErrUnknownEventType)
+		}
+	}
+	return nil
+}
+
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, webhookSecretLength)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", fmt.Errorf("read failed: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
@@ -0,0 +1,176 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/RipperAcskt/innotaxi/internal/service/mocks"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func TestAddWebhook(t *testing.T) {
+	type mockBehavior func(s *mocks.MockWebhookRepo)
+
+	test := []struct {
+		name         string
+		webhook      model.Webhook
+		mockBehavior mockBehavior
+		secret       string
+		err          error
+	}{
+		{
+			name:    "generated secret",
+			webhook: model.Webhook{URL: "https://partner.example/hook", EventTypes: []string{model.EventUserDeleted}},
+			mockBehavior: func(s *mocks.MockWebhookRepo) {
+				s.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			err: nil,
+		},
+		{
+			name:    "given secret",
+			webhook: model.Webhook{URL: "https://partner.example/hook", Secret: "s3cret", EventTypes: []string{model.EventUserDeleted}},
+			mockBehavior: func(s *mocks.MockWebhookRepo) {
+				s.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			secret: "s3cret",
+			err:    nil,
+		},
+		{
+			name:         "unknown event type",
+			webhook:      model.Webhook{URL: "https://partner.example/hook", EventTypes: []string{"trip.finished"}},
+			mockBehavior: func(s *mocks.MockWebhookRepo) {},
+			err:          service.ErrUnknownEventType,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			webhookRepo := mocks.NewMockWebhookRepo(ctrl)
+			tt.mockBehavior(webhookRepo)
+
+			s := service.Service{
+				WebhookService: service.NewWebhookService(webhookRepo),
+			}
+
+			err := s.AddWebhook(context.Background(), &tt.webhook)
+			assert.Equal(t, errors.Is(err, tt.err), true)
+			if tt.err != nil {
+				return
+			}
+
+			assert.Equal(t, tt.webhook.Active, true)
+			if tt.secret != "" {
+				assert.Equal(t, tt.webhook.Secret, tt.secret)
+			} else {
+				assert.Equal(t, len(tt.webhook.Secret), 64)
+			}
+		})
+	}
+}
+
+func TestGetAllWebhooks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	webhookRepo := mocks.NewMockWebhookRepo(ctrl)
+	webhookRepo.EXPECT().GetWebhooks(gomock.Any()).Return([]*model.Webhook{
+		{ID: 1, URL: "https://partner.example/hook", Secret: "s3cret", EventTypes: []string{model.EventUserDeleted}},
+	}, nil)
+
+	s := service.Service{
+		WebhookService: service.NewWebhookService(webhookRepo),
+	}
+
+	webhooks, err := s.GetAllWebhooks(context.Background())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(webhooks), 1)
+	assert.Equal(t, webhooks[0].Secret, "")
+}
+
+func TestEditWebhook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	webhookRepo := mocks.NewMockWebhookRepo(ctrl)
+	webhookRepo.EXPECT().GetWebhookById(gomock.Any(), "1").Return(&model.Webhook{
+		ID: 1, PartnerID: 3, URL: "https://partner.example/hook", Secret: "s3cret", EventTypes: []string{model.EventUserDeleted}, Active: true,
+	}, nil)
+	webhookRepo.EXPECT().UpdateWebhook(gomock.Any(), &model.Webhook{
+		ID: 1, PartnerID: 4, URL: "https://partner.example/v2", Secret: "s3cret", EventTypes: []string{model.EventUserSignedUp}, Active: false,
+	}).Return(nil)
+
+	s := service.Service{
+		WebhookService: service.NewWebhookService(webhookRepo),
+	}
+
+	err := s.EditWebhook(context.Background(), "1", &model.Webhook{PartnerID: 4, URL: "https://partner.example/v2", EventTypes: []string{model.EventUserSignedUp}})
+	assert.Equal(t, err, nil)
+}
+
+func TestGetDeliveries(t *testing.T) {
+	type mockBehavior func(s *mocks.MockWebhookRepo)
+
+	test := []struct {
+		name         string
+		status       string
+		limit        int
+		mockBehavior mockBehavior
+		err          error
+	}{
+		{
+			name:   "default limit",
+			status: model.DeliveryDead,
+			mockBehavior: func(s *mocks.MockWebhookRepo) {
+				s.EXPECT().GetWebhookById(gomock.Any(), "1").Return(&model.Webhook{ID: 1}, nil)
+				s.EXPECT().GetWebhookDeliveries(gomock.Any(), "1", model.DeliveryDead, 20, 0).Return([]*model.WebhookDelivery{}, nil)
+			},
+			err: nil,
+		},
+		{
+			name:  "limit capped",
+			limit: 1000,
+			mockBehavior: func(s *mocks.MockWebhookRepo) {
+				s.EXPECT().GetWebhookById(gomock.Any(), "1").Return(&model.Webhook{ID: 1}, nil)
+				s.EXPECT().GetWebhookDeliveries(gomock.Any(), "1", "", 100, 0).Return([]*model.WebhookDelivery{}, nil)
+			},
+			err: nil,
+		},
+		{
+			name:         "unknown status",
+			status:       "lost",
+			mockBehavior: func(s *mocks.MockWebhookRepo) {},
+			err:          service.ErrUnknownDeliveryStatus,
+		},
+		{
+			name: "unknown webhook",
+			mockBehavior: func(s *mocks.MockWebhookRepo) {
+				s.EXPECT().GetWebhookById(gomock.Any(), "1").Return(nil, service.ErrWebhookDoesNotExists)
+			},
+			err: service.ErrWebhookDoesNotExists,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			webhookRepo := mocks.NewMockWebhookRepo(ctrl)
+			tt.mockBehavior(webhookRepo)
+
+			s := service.Service{
+				WebhookService: service.NewWebhookService(webhookRepo),
+			}
+
+			_, err := s.GetDeliveries(context.Background(), "1", tt.status, tt.limit, 0)
+			assert.Equal(t, errors.Is(err, tt.err), true)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Package tracing sets up OpenTelemetry tracing and holds the helpers used
+// to create spans and propagate trace context.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/RipperAcskt/innotaxi/config"
+	"github.com/RipperAcskt/innotaxi/internal/requestid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/RipperAcskt/innotaxi"
+	defaultServiceName  = "innotaxi-user"
+)
+
+// New installs the global tracer provider and the W3C trace context
+// propagator. TRACING_EXPORTER selects where spans go: "stdout" prints them,
+// "otlp" sends them to OTLP_ENDPOINT over gRPC and empty disables tracing,
+// trace context is still propagated then. The returned func flushes the
+// spans that are left and must be called on exit.
+func New(ctx context.Context, cfg *config.Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	switch cfg.TRACING_EXPORTER {
+	case "":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		stdout, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("stdout new failed: %w", err)
+		}
+		exporter = stdout
+	case "otlp":
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.OTLP_ENDPOINT)}
+		if cfg.OTLP_INSECURE {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+
+		otlp, err := otlptracegrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("otlp new failed: %w", err)
+		}
+		exporter = otlp
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter: %v", cfg.TRACING_EXPORTER)
+	}
+
+	name := cfg.TRACING_SERVICE_NAME
+	if name == "" {
+		name = defaultServiceName
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(name))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Start starts a span with the global tracer provider. Spans of a request
+// get its id as the request.id attribute.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if id := requestid.From(ctx); id != "" {
+		opts = append(opts, trace.WithAttributes(attribute.String("request.id", id)))
+	}
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// End records err, if any, and ends the span.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,57 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RipperAcskt/innotaxi/internal/tracing"
+	"github.com/go-playground/assert/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func setUp(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		_ = provider.Shutdown(context.Background())
+	})
+	return recorder
+}
+
+func TestTransport(t *testing.T) {
+	recorder := setUp(t)
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	ctx, parent := tracing.Start(context.Background(), "parent")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, nil)
+	client := &http.Client{Transport: tracing.NewTransport(nil)}
+	resp, err := client.Do(req)
+	assert.Equal(t, err, nil)
+	resp.Body.Close()
+	parent.End()
+
+	assert.Equal(t, resp.StatusCode, http.StatusAccepted)
+	assert.Equal(t, req.Header.Get("traceparent"), "")
+
+	spans := recorder.Ended()
+	assert.Equal(t, len(spans), 2)
+	clientSpan := spans[0]
+	assert.Equal(t, clientSpan.Name(), "HTTP POST")
+	assert.Equal(t, clientSpan.SpanKind(), trace.SpanKindClient)
+	assert.Equal(t, clientSpan.Parent().SpanID(), parent.SpanContext().SpanID())
+	assert.Equal(t, traceparent, "00-"+clientSpan.SpanContext().TraceID().String()+"-"+clientSpan.SpanContext().SpanID().String()+"-01")
+}
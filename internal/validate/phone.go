@@ -0,0 +1,93 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	minPhoneDigits = 7
+	maxPhoneDigits = 15
+)
+
+var (
+	ErrBadPhone      = fmt.Errorf("not a phone number")
+	ErrUnknownRegion = fmt.Errorf("unknown region")
+)
+
+// region is how national numbers of a country are dialed: the trunk prefix
+// is dropped and the country code is put in front.
+type region struct {
+	code  string
+	trunk string
+}
+
+// regions are the ISO 3166 codes PHONE_REGION may be set to.
+var regions = map[string]region{
+	"BY": {"375", "80"},
+	"DE": {"49", "0"},
+	"FR": {"33", "0"},
+	"GB": {"44", "0"},
+	"KZ": {"7", "8"},
+	"LT": {"370", "8"},
+	"LV": {"371", ""},
+	"PL": {"48", ""},
+	"RU": {"7", "8"},
+	"UA": {"380", "0"},
+	"US": {"1", "1"},
+}
+
+// KnownRegion reports whether national numbers of code can be normalized.
+// The empty region accepts international numbers only.
+func KnownRegion(code string) bool {
+	_, ok := regions[strings.ToUpper(code)]
+	return ok || code == ""
+}
+
+// Phone normalizes raw to E.164, e.g. "+375291234567". Spaces, hyphens,
+// periods and parentheses are dropped, "00" is read as "+", and numbers
+// without either are read as national numbers of regionCode.
+func Phone(raw, regionCode string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(raw))
+
+	international := true
+	switch {
+	case strings.HasPrefix(digits, "+"):
+		digits = digits[1:]
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	default:
+		international = false
+	}
+
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return "", ErrBadPhone
+	}
+
+	if !international {
+		if regionCode == "" {
+			return "", fmt.Errorf("%w: national number without a region", ErrBadPhone)
+		}
+		r, ok := regions[strings.ToUpper(regionCode)]
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrUnknownRegion, regionCode)
+		}
+		digits = r.code + strings.TrimPrefix(digits, r.trunk)
+	}
+
+	switch {
+	case digits[0] == '0':
+		return "", fmt.Errorf("%w: country code starts with 0", ErrBadPhone)
+	case len(digits) < minPhoneDigits:
+		return "", fmt.Errorf("%w: too short", ErrBadPhone)
+	case len(digits) > maxPhoneDigits:
+		return "", fmt.Errorf("%w: too long", ErrBadPhone)
+	}
+	return "+" + digits, nil
+}
@@ -0,0 +1,157 @@
+// Package validate holds the rules for user input that the database can't
+// be trusted to check: phone numbers, emails and names. The rules are
+// registered as validator tags, so binding checks them like built-in tags.
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	// Tags of the rules.
+	PhoneTag = "phone"
+	EmailTag = "rfc_email"
+	NameTag  = "person_name"
+
+	// MaxNameLength and MaxEmailLength are the widths of the users columns.
+	MaxNameLength  = 30
+	MaxEmailLength = 254
+
+	maxLocalPartLength = 64
+	maxLabelLength     = 63
+)
+
+// Email reports whether s is a bare RFC 5322 address, without a display
+// name or comments, whose domain is a host name.
+func Email(s string) bool {
+	if len(s) > MaxEmailLength {
+		return false
+	}
+
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return false
+	}
+
+	at := strings.LastIndexByte(s, '@')
+	if at > maxLocalPartLength {
+		return false
+	}
+	for _, label := range strings.Split(s[at+1:], ".") {
+		if !hostLabel(label) {
+			return false
+		}
+	}
+	return true
+}
+
+func hostLabel(label string) bool {
+	if label == "" || len(label) > maxLabelLength || label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+	for _, r := range label {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// Name reports whether s is a person's name: letters of any script, spaces
+// between words, hyphens, apostrophes and periods, starting with a letter.
+func Name(s string) bool {
+	if s == "" || utf8.RuneCountInString(s) > MaxNameLength {
+		return false
+	}
+
+	first, _ := utf8.DecodeRuneInString(s)
+	last, _ := utf8.DecodeLastRuneInString(s)
+	if !unicode.IsLetter(first) || last == ' ' || strings.Contains(s, "  ") {
+		return false
+	}
+
+	for _, r := range s {
+		if !(unicode.IsLetter(r) || unicode.Is(unicode.Mn, r) || r == ' ' || r == '-' || r == '\'' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// Register adds the rules to v. National phone numbers are read as numbers
+// of region. Errors of v report fields by their JSON names.
+func Register(v *validator.Validate, region string) error {
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	rules := map[string]func(string) bool{
+		PhoneTag: func(s string) bool {
+			_, err := Phone(s, region)
+			return err == nil
+		},
+		EmailTag: Email,
+		NameTag:  Name,
+	}
+	for tag, rule := range rules {
+		rule := rule
+		err := v.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+			return rule(fl.Field().String())
+		})
+		if err != nil {
+			return fmt.Errorf("register validation %s failed: %w", tag, err)
+		}
+	}
+	return nil
+}
+
+// Fields describes the fields err rejects by their JSON names, or returns
+// nil if err isn't a validation error.
+func Fields(err error) map[string]string {
+	errs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return nil
+	}
+
+	fields := make(map[string]string, len(errs))
+	for _, fieldErr := range errs {
+		fields[fieldErr.Field()] = message(fieldErr)
+	}
+	return fields
+}
+
+func message(err validator.FieldError) string {
+	switch err.Tag() {
+	case "required":
+		return "is required"
+	case "max":
+		if err.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters", err.Param())
+		}
+		return fmt.Sprintf("must be at most %s", err.Param())
+	case "min":
+		if err.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", err.Param())
+		}
+		return fmt.Sprintf("must be at least %s", err.Param())
+	case PhoneTag:
+		return "must be a phone number, e.g. +375291234567"
+	case EmailTag:
+		return "must be an email address"
+	case NameTag:
+		return fmt.Sprintf("must be at most %d letters, spaces, hyphens, apostrophes or periods, starting with a letter", MaxNameLength)
+	default:
+		return fmt.Sprintf("failed the %s rule", err.Tag())
+	}
+}
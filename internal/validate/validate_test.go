@@ -0,0 +1,103 @@
+package validate_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/RipperAcskt/innotaxi/internal/validate"
+	"github.com/go-playground/assert/v2"
+	"github.com/go-playground/validator/v10"
+)
+
+func TestPhone(t *testing.T) {
+	test := []struct {
+		name   string
+		raw    string
+		region string
+		phone  string
+		err    error
+	}{
+		{name: "e164", raw: "+375291234567", phone: "+375291234567"},
+		{name: "formatted", raw: " +375 (29) 123-45-67 ", phone: "+375291234567"},
+		{name: "00 prefix", raw: "00375291234567", phone: "+375291234567"},
+		{name: "national", raw: "8 029 123-45-67", region: "BY", phone: "+375291234567"},
+		{name: "national lower case region", raw: "(202) 555-0143", region: "us", phone: "+12025550143"},
+		{name: "national without region", raw: "80291234567", err: validate.ErrBadPhone},
+		{name: "unknown region", raw: "0291234567", region: "XX", err: validate.ErrUnknownRegion},
+		{name: "letters", raw: "+37529CALLME", err: validate.ErrBadPhone},
+		{name: "country code 0", raw: "+0291234567", err: validate.ErrBadPhone},
+		{name: "too short", raw: "+37529", err: validate.ErrBadPhone},
+		{name: "too long", raw: "+3752912345678901", err: validate.ErrBadPhone},
+		{name: "empty", raw: "", err: validate.ErrBadPhone},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			phone, err := validate.Phone(tt.raw, tt.region)
+			assert.Equal(t, errors.Is(err, tt.err), true)
+			assert.Equal(t, phone, tt.phone)
+		})
+	}
+}
+
+func TestEmail(t *testing.T) {
+	valid := []string{"ripper@mail.ru", "first.last+tag@example.co.uk", "ripper@algsdh", "o'brien@example.com"}
+	for _, email := range valid {
+		assert.Equal(t, validate.Email(email), true)
+	}
+
+	invalid := []string{
+		"",
+		"ripper",
+		"ripper@",
+		"@mail.ru",
+		"Ripper <ripper@mail.ru>",
+		"ripper@mail..ru",
+		"ripper@-mail.ru",
+		"ripper@[127.0.0.1]",
+		strings.Repeat("a", 65) + "@mail.ru",
+		"ripper@" + strings.Repeat("a", 250) + ".ru",
+	}
+	for _, email := range invalid {
+		assert.Equal(t, validate.Email(email), false)
+	}
+}
+
+func TestName(t *testing.T) {
+	valid := []string{"Ivan", "Anna-Maria", "D'Artagnan", "J. R. R. Tolkien", "Łukasz", "Иван Петров"}
+	for _, name := range valid {
+		assert.Equal(t, validate.Name(name), true)
+	}
+
+	invalid := []string{"", " Ivan", "Ivan ", "Ivan  Petrov", "-Ivan", "Ivan1", "Ivan<script>", strings.Repeat("a", 31)}
+	for _, name := range invalid {
+		assert.Equal(t, validate.Name(name), false)
+	}
+}
+
+func TestFields(t *testing.T) {
+	v := validator.New()
+	err := validate.Register(v, "BY")
+	assert.Equal(t, err, nil)
+
+	type user struct {
+		Name        string `json:"name" validate:"required,person_name"`
+		PhoneNumber string `json:"phone_number" validate:"required,phone"`
+		Email       string `json:"email,omitempty" validate:"omitempty,rfc_email"`
+		Code        string `json:"code" validate:"max=4"`
+	}
+
+	err = v.Struct(user{Name: "Ivan", PhoneNumber: "80291234567"})
+	assert.Equal(t, err, nil)
+
+	err = v.Struct(user{PhoneNumber: "12", Email: "ripper", Code: "ABCDE"})
+	assert.Equal(t, validate.Fields(err), map[string]string{
+		"name":         "is required",
+		"phone_number": "must be a phone number, e.g. +375291234567",
+		"email":        "must be an email address",
+		"code":         "must be at most 4 characters",
+	})
+
+	assert.Equal(t, validate.Fields(errors.New("EOF")) == nil, true)
+}
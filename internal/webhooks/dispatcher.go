@@ -0,0 +1,150 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"go.uber.org/zap"
+)
+
+// maxRetryDelay caps the back-off between attempts of one delivery.
+const maxRetryDelay = time.Hour
+
+// Dispatcher sends due deliveries. A delivery succeeds on any 2xx answer.
+// Otherwise it is retried after backoff, doubled on every attempt, until
+// maxAttempts is reached and the delivery is dead.
+type Dispatcher struct {
+	repo        service.WebhookRepo
+	client      *http.Client
+	log         *zap.Logger
+	interval    time.Duration
+	batch       int
+	maxAttempts int
+	backoff     time.Duration
+}
+
+func NewDispatcher(repo service.WebhookRepo, client *http.Client, log *zap.Logger, interval time.Duration, batch, maxAttempts int, backoff time.Duration) *Dispatcher {
+	return &Dispatcher{repo, client, log, interval, batch, maxAttempts, backoff}
+}
+
+// Run flushes due deliveries every interval until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.interval):
+		}
+
+		_, err := d.Flush(ctx, time.Now())
+		if err != nil {
+			d.log.Error("webhook dispatcher", zap.Error(fmt.Errorf("flush failed: %w", err)))
+		}
+	}
+}
+
+// Flush attempts one batch of deliveries due at now and returns how many
+// were attempted. Retries are scheduled from now, but every attempt is
+// signed and recorded at the time it is made. Retries are scheduled from now, but every attempt is
+// signed and recorded at the time it is made.
+func (d *Dispatcher) Flush(ctx context.Context, now time.Time) (int, error) {
+	deliveries, err := d.repo.GetDueWebhookDeliveries(ctx, now, d.batch)
+	if err != nil {
+		return 0, fmt.Errorf("get due webhook deliveries failed: %w", err)
+	}
+
+	webhooks := make(map[uint64]*model.Webhook)
+	for _, delivery := range deliveries {
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			webhook, err = d.repo.GetWebhookById(ctx, strconv.FormatUint(delivery.WebhookID, 10))
+			if err != nil {
+				return 0, fmt.Errorf("get webhook by id failed: %w", err)
+			}
+			webhooks[delivery.WebhookID] = webhook
+		}
+
+		d.attempt(ctx, webhook, delivery, now)
+
+		err := d.repo.UpdateWebhookDelivery(ctx, delivery)
+		if err != nil {
+			return 0, fmt.Errorf("update webhook delivery failed: %w", err)
+		}
+	}
+	return len(deliveries), nil
+}
+
+// attempt sends the delivery and records the outcome in it.
+func (d *Dispatcher) attempt(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery, now time.Time) {
+	delivery.Attempts++
+
+	if !webhook.Active {
+		delivery.Status = model.DeliveryDead
+		delivery.LastError = "webhook is inactive"
+		return
+	}
+
+	status, err := d.send(ctx, webhook, delivery)
+	delivery.ResponseStatus = status
+	if err == nil {
+		deliveredAt := time.Now()
+		delivery.Status = model.DeliveryDelivered
+		delivery.LastError = ""
+		delivery.DeliveredAt = &deliveredAt
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= d.maxAttempts {
+		delivery.Status = model.DeliveryDead
+		return
+	}
+	delivery.NextAttemptAt = now.Add(d.retryDelay(delivery.Attempts))
+}
+
+func (d *Dispatcher) send(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("new request failed: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, timestamp, delivery.Payload))
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(delivery.ID, 10))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("do failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Drain the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status: %v", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryDelay is backoff doubled for every failed attempt after the first.
+func (d *Dispatcher) retryDelay(attempts int) time.Duration {
+	delay := d.backoff
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,279 @@
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/repo/memory"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/RipperAcskt/innotaxi/internal/webhooks"
+	"github.com/go-playground/assert/v2"
+	"go.uber.org/zap"
+)
+
+// receiver is a partner endpoint that checks signatures and answers with
+// status.
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	secret   string
+	received []model.Event
+	invalid  int
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	body, _ := io.ReadAll(req.Body)
+	err := webhooks.Verify(r.secret, req.Header.Get(webhooks.TimestampHeader), body, req.Header.Get(webhooks.SignatureHeader), time.Now(), 5*time.Minute)
+	if err != nil {
+		r.invalid++
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var event model.Event
+	_ = json.Unmarshal(body, &event)
+	r.received = append(r.received, event)
+	w.WriteHeader(r.status)
+}
+
+func (r *receiver) setStatus(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+// subscribe creates a local receiver and subscribes it to user.deleted of
+// the partner's employees.
+func subscribe(t *testing.T, repo *memory.Memory, status int, partnerId uint64) (*receiver, *model.Webhook) {
+	t.Helper()
+
+	recv := &receiver{status: status, secret: "secret"}
+	server := httptest.NewServer(recv)
+	t.Cleanup(server.Close)
+
+	webhook := &model.Webhook{PartnerID: partnerId, URL: server.URL, Secret: "secret", EventTypes: []string{model.EventUserDeleted}, Active: true}
+	err := repo.CreateWebhook(context.Background(), webhook)
+	if err != nil {
+		t.Fatalf("create webhook failed: %v", err)
+	}
+	return recv, webhook
+}
+
+// addEmployee signs up a user and makes it an employee of the partner.
+func addEmployee(t *testing.T, repo *memory.Memory, phoneNumber string, partnerId uint64) string {
+	t.Helper()
+
+	err := repo.CreateUser(context.Background(), service.UserSingUp{Name: "Ivan", PhoneNumber: phoneNumber, Email: phoneNumber + "@algsdh", Password: "hash", Code: phoneNumber})
+	if err != nil {
+		t.Fatalf("create user failed: %v", err)
+	}
+	user, err := repo.CheckUserByPhoneNumber(context.Background(), phoneNumber)
+	if err != nil {
+		t.Fatalf("check user by phone number failed: %v", err)
+	}
+
+	id := strconv.FormatUint(user.ID, 10)
+	err = repo.AddPartnerEmployee(context.Background(), partnerId, id)
+	if err != nil {
+		t.Fatalf("add partner employee failed: %v", err)
+	}
+	return id
+}
+
+// setUp subscribes a local receiver to user.deleted and publishes one event
+// about an employee of its partner.
+func setUp(t *testing.T, status int) (*memory.Memory, *receiver, *model.Webhook) {
+	t.Helper()
+
+	repo := memory.New()
+	recv, webhook := subscribe(t, repo, status, 3)
+	id := addEmployee(t, repo, "+7455456", 3)
+
+	err := webhooks.NewPublisher(repo).Publish(context.Background(), &model.Event{ID: 7, Type: model.EventUserDeleted, AggregateID: id, Payload: []byte(`{"user_id":"` + id + `"}`)})
+	if err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+	return repo, recv, webhook
+}
+
+func newDispatcher(repo *memory.Memory) *webhooks.Dispatcher {
+	return webhooks.NewDispatcher(repo, http.DefaultClient, zap.NewNop(), time.Second, 10, 3, time.Minute)
+}
+
+func getDeliveries(t *testing.T, repo *memory.Memory) []*model.WebhookDelivery {
+	t.Helper()
+
+	deliveries, err := repo.GetWebhookDeliveries(context.Background(), "1", "", 10, 0)
+	if err != nil {
+		t.Fatalf("get webhook deliveries failed: %v", err)
+	}
+	return deliveries
+}
+
+func TestDelivered(t *testing.T) {
+	repo, recv, _ := setUp(t, http.StatusOK)
+
+	n, err := newDispatcher(repo).Flush(context.Background(), time.Now())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, n, 1)
+
+	assert.Equal(t, recv.invalid, 0)
+	assert.Equal(t, len(recv.received), 1)
+	assert.Equal(t, recv.received[0].ID, uint64(7))
+	assert.Equal(t, recv.received[0].AggregateID, "1")
+
+	deliveries := getDeliveries(t, repo)
+	assert.Equal(t, len(deliveries), 1)
+	assert.Equal(t, deliveries[0].Status, model.DeliveryDelivered)
+	assert.Equal(t, deliveries[0].Attempts, 1)
+	assert.Equal(t, deliveries[0].ResponseStatus, http.StatusOK)
+	assert.NotEqual(t, deliveries[0].DeliveredAt, nil)
+}
+
+func TestSignedAtAttempt(t *testing.T) {
+	repo, recv, _ := setUp(t, http.StatusOK)
+
+	// Deliveries are selected an hour ahead, the attempt is still signed and
+	// recorded at the time it is made.
+	now := time.Now().Add(time.Hour)
+	n, err := newDispatcher(repo).Flush(context.Background(), now)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, n, 1)
+
+	assert.Equal(t, recv.invalid, 0)
+	assert.Equal(t, len(recv.received), 1)
+
+	deliveries := getDeliveries(t, repo)
+	assert.Equal(t, deliveries[0].Status, model.DeliveryDelivered)
+	assert.Equal(t, deliveries[0].DeliveredAt.Before(now), true)
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	repo, recv, _ := setUp(t, http.StatusInternalServerError)
+	dispatcher := newDispatcher(repo)
+	now := time.Now()
+
+	n, err := dispatcher.Flush(context.Background(), now)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, n, 1)
+
+	deliveries := getDeliveries(t, repo)
+	assert.Equal(t, deliveries[0].Status, model.DeliveryPending)
+	assert.Equal(t, deliveries[0].ResponseStatus, http.StatusInternalServerError)
+	assert.Equal(t, deliveries[0].NextAttemptAt.Equal(now.Add(time.Minute)), true)
+
+	// Not due yet.
+	n, err = dispatcher.Flush(context.Background(), now.Add(30*time.Second))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, n, 0)
+
+	now = now.Add(time.Minute)
+	_, err = dispatcher.Flush(context.Background(), now)
+	assert.Equal(t, err, nil)
+
+	deliveries = getDeliveries(t, repo)
+	assert.Equal(t, deliveries[0].Attempts, 2)
+	assert.Equal(t, deliveries[0].NextAttemptAt.Equal(now.Add(2*time.Minute)), true)
+
+	recv.setStatus(http.StatusNoContent)
+	_, err = dispatcher.Flush(context.Background(), now.Add(2*time.Minute))
+	assert.Equal(t, err, nil)
+
+	deliveries = getDeliveries(t, repo)
+	assert.Equal(t, deliveries[0].Status, model.DeliveryDelivered)
+	assert.Equal(t, deliveries[0].Attempts, 3)
+	assert.Equal(t, len(recv.received), 3)
+}
+
+func TestDeadLetter(t *testing.T) {
+	repo, recv, _ := setUp(t, http.StatusBadGateway)
+	dispatcher := newDispatcher(repo)
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		_, err := dispatcher.Flush(context.Background(), now)
+		assert.Equal(t, err, nil)
+		now = now.Add(time.Hour)
+	}
+
+	deliveries := getDeliveries(t, repo)
+	assert.Equal(t, deliveries[0].Status, model.DeliveryDead)
+	assert.Equal(t, deliveries[0].Attempts, 3)
+
+	n, err := dispatcher.Flush(context.Background(), now)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, n, 0)
+
+	recv.setStatus(http.StatusOK)
+	err = repo.RetryWebhookDelivery(context.Background(), "1", "1", now)
+	assert.Equal(t, err, nil)
+
+	n, err = dispatcher.Flush(context.Background(), now)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, n, 1)
+	assert.Equal(t, getDeliveries(t, repo)[0].Status, model.DeliveryDelivered)
+}
+
+func TestInactiveWebhook(t *testing.T) {
+	repo, recv, webhook := setUp(t, http.StatusOK)
+
+	webhook.Active = false
+	err := repo.UpdateWebhook(context.Background(), webhook)
+	assert.Equal(t, err, nil)
+
+	_, err = newDispatcher(repo).Flush(context.Background(), time.Now())
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, len(recv.received), 0)
+	assert.Equal(t, getDeliveries(t, repo)[0].Status, model.DeliveryDead)
+}
+
+func TestPublisher(t *testing.T) {
+	repo, _, _ := setUp(t, http.StatusOK)
+	publisher := webhooks.NewPublisher(repo)
+
+	// A redelivered event and an event nobody subscribed to queue nothing.
+	err := publisher.Publish(context.Background(), &model.Event{ID: 7, Type: model.EventUserDeleted, AggregateID: "1"})
+	assert.Equal(t, err, nil)
+	err = publisher.Publish(context.Background(), &model.Event{ID: 8, Type: model.EventUserSignedUp, AggregateID: "1"})
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, len(getDeliveries(t, repo)), 1)
+}
+
+func TestPartnerIsolation(t *testing.T) {
+	repo := memory.New()
+	recvA, _ := subscribe(t, repo, http.StatusOK, 3)
+	recvB, _ := subscribe(t, repo, http.StatusOK, 4)
+	employeeA := addEmployee(t, repo, "+7455456", 3)
+	employeeB := addEmployee(t, repo, "+7455457", 4)
+
+	publisher := webhooks.NewPublisher(repo)
+	for i, id := range []string{employeeA, employeeA, employeeB} {
+		err := publisher.Publish(context.Background(), &model.Event{ID: uint64(i + 1), Type: model.EventUserDeleted, AggregateID: id})
+		assert.Equal(t, err, nil)
+	}
+
+	n, err := newDispatcher(repo).Flush(context.Background(), time.Now())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, n, 3)
+
+	// Partner B gets no events about partner A's employees.
+	assert.Equal(t, len(recvA.received), 2)
+	for _, event := range recvA.received {
+		assert.Equal(t, event.AggregateID, employeeA)
+	}
+	assert.Equal(t, len(recvB.received), 1)
+	assert.Equal(t, recvB.received[0].AggregateID, employeeB)
+}
@@ -0,0 +1,34 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+)
+
+// Publisher is an events.Publisher that queues the event for every
+// subscribed webhook of the partner of the user the event is about.
+// Publishing the same event again queues nothing new.
+type Publisher struct {
+	repo service.WebhookRepo
+}
+
+func NewPublisher(repo service.WebhookRepo) *Publisher {
+	return &Publisher{repo}
+}
+
+func (p *Publisher) Publish(ctx context.Context, event *model.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal failed: %w", err)
+	}
+
+	err = p.repo.AddWebhookDeliveries(ctx, event.ID, event.Type, event.AggregateID, body)
+	if err != nil {
+		return fmt.Errorf("add webhook deliveries failed: %w", err)
+	}
+	return nil
+}
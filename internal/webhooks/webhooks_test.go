@@ -0,0 +1,73 @@
+package webhooks_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/RipperAcskt/innotaxi/internal/webhooks"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"id":1}`)
+	signature := webhooks.Sign("secret", now.Unix(), body)
+
+	test := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      []byte
+		now       time.Time
+		err       error
+	}{
+		{
+			name:      "valid",
+			secret:    "secret",
+			timestamp: strconv.FormatInt(now.Unix(), 10),
+			body:      body,
+			now:       now.Add(time.Minute),
+			err:       nil,
+		},
+		{
+			name:      "wrong secret",
+			secret:    "other",
+			timestamp: strconv.FormatInt(now.Unix(), 10),
+			body:      body,
+			now:       now,
+			err:       webhooks.ErrInvalidSignature,
+		},
+		{
+			name:      "tampered body",
+			secret:    "secret",
+			timestamp: strconv.FormatInt(now.Unix(), 10),
+			body:      []byte(`{"id":2}`),
+			now:       now,
+			err:       webhooks.ErrInvalidSignature,
+		},
+		{
+			name:      "tampered timestamp",
+			secret:    "secret",
+			timestamp: strconv.FormatInt(now.Unix()+1, 10),
+			body:      body,
+			now:       now,
+			err:       webhooks.ErrInvalidSignature,
+		},
+		{
+			name:      "replayed",
+			secret:    "secret",
+			timestamp: strconv.FormatInt(now.Unix(), 10),
+			body:      body,
+			now:       now.Add(10 * time.Minute),
+			err:       webhooks.ErrTimestampExpired,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			err := webhooks.Verify(tt.secret, tt.timestamp, tt.body, signature, tt.now, 5*time.Minute)
+			assert.Equal(t, err, tt.err)
+		})
+	}
+}
@@ -13,9 +13,13 @@ import (
 
 	"github.com/RipperAcskt/innotaxi/config"
 	"github.com/RipperAcskt/innotaxi/internal/handler"
+	"github.com/RipperAcskt/innotaxi/internal/metrics"
+	"github.com/RipperAcskt/innotaxi/internal/payments/fake"
+	"github.com/RipperAcskt/innotaxi/internal/repo/memory"
 	"github.com/RipperAcskt/innotaxi/internal/repo/mongo"
 	"github.com/RipperAcskt/innotaxi/internal/repo/postgres"
 	"github.com/RipperAcskt/innotaxi/internal/repo/redis"
+	"github.com/RipperAcskt/innotaxi/internal/repo/sqlite"
 	"github.com/RipperAcskt/innotaxi/internal/service"
 	"github.com/go-playground/assert/v2"
 
@@ -25,8 +29,18 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
-func SetUpRouter() *gin.Engine {
+// memoryRepo, memoryTokens and memoryStore are shared by all tests, like
+// the databases are, because tests depend on the users created by previous
+// ones.
+var (
+	memoryRepo   = memory.New()
+	memoryTokens = memory.NewTokens()
+	memoryStore  = memory.NewStore()
+)
+
+func SetUpRouter(h *handler.Handler) *gin.Engine {
 	router := gin.Default()
+	router.Use(h.Errors(), h.Log())
 	return router
 }
 
@@ -36,40 +50,72 @@ func InitHandler() (*handler.Handler, error) {
 		return nil, fmt.Errorf("config new failed: %w", err)
 	}
 
-	postgres, err := postgres.New(cfg)
-	if err != nil {
-		return nil, fmt.Errorf("postgres new failed: %w", err)
-	}
-
-	err = postgres.Migrate.Up()
-	if err != migrate.ErrNoChange && err != nil {
-		return nil, fmt.Errorf("migrate up failed: %w", err)
+	config := zap.NewProductionEncoderConfig()
+	config.EncodeTime = zapcore.ISO8601TimeEncoder
+	defaultLogLevel := zapcore.DebugLevel
+	cores := []zapcore.Core{
+		zapcore.NewCore(zapcore.NewConsoleEncoder(config), zapcore.AddSync(os.Stdout), defaultLogLevel),
 	}
 
-	redis, err := redis.New(cfg)
-	if err != nil {
-		return nil, fmt.Errorf("redis new failed: %w", err)
+	var (
+		repo        service.Repo
+		tokens      service.TokenRepo
+		logs        service.LogRepo
+		idempotency handler.IdempotencyStore
+	)
+	switch cfg.STORAGE {
+	case "", "postgres":
+		postgres, err := postgres.New(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("postgres new failed: %w", err)
+		}
+
+		err = postgres.Migrate.Up()
+		if err != migrate.ErrNoChange && err != nil {
+			return nil, fmt.Errorf("migrate up failed: %w", err)
+		}
+
+		redis, err := redis.New(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("redis new failed: %w", err)
+		}
+
+		mongoDB, err := mongo.New(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("mongo new failed: %w", err)
+		}
+
+		sink, err := mongo.NewSink(mongoDB, mongo.SinkConfig{})
+		if err != nil {
+			return nil, fmt.Errorf("sink new failed: %w", err)
+		}
+
+		cores = append(cores, zapcore.NewCore(zapcore.NewJSONEncoder(config), sink, defaultLogLevel))
+		repo, tokens, logs, idempotency = postgres, redis, mongoDB, redis
+	case "sqlite":
+		sqlite, err := sqlite.New(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite new failed: %w", err)
+		}
+
+		err = sqlite.Migrate.Up()
+		if err != migrate.ErrNoChange && err != nil {
+			return nil, fmt.Errorf("migrate up failed: %w", err)
+		}
+
+		repo, tokens, idempotency = sqlite, memory.NewTokens(), memoryStore
+	case "memory":
+		repo, tokens, idempotency = memoryRepo, memoryTokens, memoryStore
+	default:
+		return nil, fmt.Errorf("unknown storage: %v", cfg.STORAGE)
 	}
 
-	mongo, err := mongo.New(cfg)
-	if err != nil {
-		return nil, fmt.Errorf("mongo new failed: %w", err)
-	}
+	log := zap.New(zapcore.NewTee(cores...), zap.AddCaller())
 
-	config := zap.NewProductionEncoderConfig()
-	config.EncodeTime = zapcore.ISO8601TimeEncoder
-	fileEncoder := zapcore.NewJSONEncoder(config)
-	consoleEncoder := zapcore.NewConsoleEncoder(config)
-	writer := zapcore.AddSync(mongo)
-	defaultLogLevel := zapcore.DebugLevel
-	core := zapcore.NewTee(
-		zapcore.NewCore(fileEncoder, writer, defaultLogLevel),
-		zapcore.NewCore(consoleEncoder, zapcore.AddSync(os.Stdout), defaultLogLevel),
-	)
-	log := zap.New(core, zap.AddCaller())
+	gateway := fake.New(0, cfg.PAYMENTS_WEBHOOK_SECRET, "")
 
-	service := service.New(postgres, redis, cfg.SALT, cfg)
-	return handler.New(service, cfg, log), nil
+	service := service.New(repo, tokens, logs, gateway, cfg.SALT, cfg)
+	return handler.New(service, cfg, log, idempotency, metrics.New()), nil
 }
 
 func TestSingUp(t *testing.T) {
@@ -93,7 +139,7 @@ func TestSingUp(t *testing.T) {
 		{
 			name: "existed user",
 			body: `{"name": "Ivan", "phone_number": "+7455456", "email": "ripper@algsdh", "password": "12345"}`,
-			code: http.StatusBadRequest,
+			code: http.StatusConflict,
 			err:  service.ErrUserAlreadyExists,
 		},
 		{
@@ -106,7 +152,7 @@ func TestSingUp(t *testing.T) {
 
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			r := SetUpRouter()
+			r := SetUpRouter(h)
 			r.POST("/users/auth/sing-up", h.SingUp)
 
 			req, _ := http.NewRequest("POST", "/users/auth/sing-up", bytes.NewBufferString(tt.body))
@@ -153,7 +199,7 @@ func TestSingIn(t *testing.T) {
 
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			r := SetUpRouter()
+			r := SetUpRouter(h)
 			r.POST("/users/auth/sing-up", h.SingIn)
 
 			req, _ := http.NewRequest("POST", "/users/auth/sing-up", bytes.NewBufferString(tt.body))
@@ -209,7 +255,7 @@ func TestRefresh(t *testing.T) {
 
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			r := SetUpRouter()
+			r := SetUpRouter(h)
 			r.GET("/users/auth/refresh", h.Refresh)
 
 			req, _ := http.NewRequest("GET", "/users/auth/refresh", nil)
@@ -239,7 +285,7 @@ func TestLogout(t *testing.T) {
 		{
 			name: "correct access token",
 			getAccess: func() string {
-				r := SetUpRouter()
+				r := SetUpRouter(h)
 				r.POST("/users/auth/sing-in", h.SingIn)
 
 				req, _ := http.NewRequest("POST", "/users/auth/sing-in", bytes.NewBufferString(`{"phone_number": "+7455456", "password": "12345"}`))
@@ -269,7 +315,7 @@ func TestLogout(t *testing.T) {
 
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			r := SetUpRouter()
+			r := SetUpRouter(h)
 			r.GET("/users/auth/logout/:id", h.VerifyToken(), h.Logout)
 
 			if tt.getAccess != nil {
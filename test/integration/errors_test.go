@@ -0,0 +1,69 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RipperAcskt/innotaxi/internal/apperror"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestErrorBody(t *testing.T) {
+	h, _ := initObservedHandler(t)
+	r := h.InitRouters()
+
+	test := []struct {
+		name string
+		body string
+		code int
+		err  map[string]string
+	}{
+		{
+			name: "new user",
+			body: `{"name": "Private Name", "phone_number": "+7455460", "email": "private@example.com", "password": "12345"}`,
+			code: http.StatusCreated,
+		},
+		{
+			name: "existed user",
+			body: `{"name": "Private Name", "phone_number": "+7455460", "email": "private@example.com", "password": "12345"}`,
+			code: http.StatusConflict,
+			err:  map[string]string{"code": "user_already_exists", "title": "user already exists"},
+		},
+		{
+			name: "bad body",
+			body: `{"name": 1}`,
+			code: http.StatusBadRequest,
+			err:  map[string]string{"code": "invalid_request"},
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/users/auth/sing-up", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.code, w.Code)
+			if tt.err == nil {
+				return
+			}
+
+			assert.Equal(t, w.Header().Get("Content-Type"), apperror.ContentType)
+			assert.Equal(t, strings.Contains(w.Body.String(), "Private Name"), false)
+			var body apperror.Problem
+			err := json.Unmarshal(w.Body.Bytes(), &body)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, body.Status, tt.code)
+			assert.Equal(t, body.Code, tt.err["code"])
+			assert.Equal(t, body.Type, "urn:innotaxi:error:"+tt.err["code"])
+			assert.Equal(t, body.Instance, "/users/auth/sing-up")
+			if title, ok := tt.err["title"]; ok {
+				assert.Equal(t, body.Title, title)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package handler_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/RipperAcskt/innotaxi/config"
+	"github.com/RipperAcskt/innotaxi/internal/handler"
+	"github.com/RipperAcskt/innotaxi/internal/metrics"
+	"github.com/RipperAcskt/innotaxi/internal/payments/fake"
+	"github.com/RipperAcskt/innotaxi/internal/repo/memory"
+	"github.com/RipperAcskt/innotaxi/internal/requestid"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func initObservedHandler(t *testing.T) (*handler.Handler, *observer.ObservedLogs) {
+	cfg, err := config.New()
+	if err != nil {
+		t.Fatalf("config new failed: %v", err)
+	}
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	service := service.New(memory.New(), memory.NewTokens(), nil, fake.New(0, cfg.PAYMENTS_WEBHOOK_SECRET, ""), cfg.SALT, cfg)
+	return handler.New(service, cfg, zap.New(core), memory.NewStore(), metrics.New()), logs
+}
+
+func TestRequestID(t *testing.T) {
+	h, _ := initObservedHandler(t)
+	r := h.InitRouters()
+
+	test := []struct {
+		name      string
+		requestID string
+		generated bool
+	}{
+		{
+			name:      "given id",
+			requestID: "req-42",
+		},
+		{
+			name:      "no id",
+			generated: true,
+		},
+		{
+			name:      "invalid id",
+			requestID: "bad id",
+			generated: true,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/users/profile/1", nil)
+			if tt.requestID != "" {
+				req.Header.Set(requestid.Header, tt.requestID)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			id := w.Header().Get(requestid.Header)
+			if tt.generated {
+				assert.Equal(t, requestid.Valid(id), true)
+				assert.NotEqual(t, id, tt.requestID)
+				return
+			}
+			assert.Equal(t, id, tt.requestID)
+		})
+	}
+}
+
+func TestRequestLoggerConcurrency(t *testing.T) {
+	h, logs := initObservedHandler(t)
+	r := h.InitRouters()
+
+	const requests = 50
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/users/profile/%d", i), nil)
+			req.Header.Set(requestid.Header, fmt.Sprintf("req-%d", i))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+		}(i)
+	}
+	wg.Wait()
+
+	entries := logs.FilterMessage("request").All()
+	assert.Equal(t, len(entries), requests)
+
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		var ids, urls []string
+		for _, field := range entry.Context {
+			switch field.Key {
+			case "uuid":
+				ids = append(ids, field.String)
+			case "url":
+				urls = append(urls, field.String)
+			}
+		}
+
+		assert.Equal(t, len(ids), 1)
+		assert.Equal(t, len(urls), 1)
+		var i int
+		_, err := fmt.Sscanf(ids[0], "req-%d", &i)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, urls[0], fmt.Sprintf("/users/profile/%d", i))
+		seen[ids[0]] = true
+	}
+	assert.Equal(t, len(seen), requests)
+}
+
+func TestErrorLogOutsideLoggedGroups(t *testing.T) {
+	h, logs := initObservedHandler(t)
+	r := h.InitRouters()
+	r.GET("/health", func(c *gin.Context) {
+		_ = c.Error(errors.New("ping failed"))
+	})
+
+	req, _ := http.NewRequest("GET", "/health", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, w.Code, http.StatusInternalServerError)
+	entries := logs.FilterMessage("/health").All()
+	assert.Equal(t, len(entries), 1)
+	assert.Equal(t, entries[0].Level, zapcore.ErrorLevel)
+	assert.Equal(t, entries[0].ContextMap()["error"], "ping failed")
+	assert.Equal(t, entries[0].ContextMap()["url"], "/health")
+}
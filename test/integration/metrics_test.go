@@ -0,0 +1,30 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestMetrics(t *testing.T) {
+	h, err := InitHandler()
+	if err != nil {
+		t.Errorf("init handler failed: %v", err)
+	}
+	r := h.InitRouters()
+
+	req, _ := http.NewRequest("GET", "/users/profile/1", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req, _ = http.NewRequest("GET", "/metrics", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, true, strings.Contains(w.Body.String(), `innotaxi_http_requests_total{method="GET",route="/users/profile/:id",status="401"} 1`))
+}
@@ -0,0 +1,62 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RipperAcskt/innotaxi/config"
+	"github.com/RipperAcskt/innotaxi/internal/handler"
+	"github.com/RipperAcskt/innotaxi/internal/metrics"
+	"github.com/RipperAcskt/innotaxi/internal/model"
+	"github.com/RipperAcskt/innotaxi/internal/payments"
+	"github.com/RipperAcskt/innotaxi/internal/payments/fake"
+	"github.com/RipperAcskt/innotaxi/internal/repo/memory"
+	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/go-playground/assert/v2"
+	"go.uber.org/zap"
+)
+
+func TestReplayedPaymentWebhook(t *testing.T) {
+	cfg, err := config.New()
+	if err != nil {
+		t.Fatalf("config new failed: %v", err)
+	}
+
+	repo := memory.New()
+	gateway := fake.New(0, cfg.PAYMENTS_WEBHOOK_SECRET, "")
+	s := service.New(repo, memory.NewTokens(), nil, gateway, cfg.SALT, cfg)
+	r := handler.New(s, cfg, zap.NewNop(), memory.NewStore(), metrics.New()).InitRouters()
+
+	ctx := context.Background()
+	err = repo.CreatePayment(ctx, &model.Payment{UserID: "1", OrderID: "order-1", AuthorizationID: "auth-1", Amount: 1000, Status: model.PaymentAuthorized})
+	assert.Equal(t, err, nil)
+
+	payload := []byte(`{"id": "evt_1", "type": "payment.captured", "authorization_id": "auth-1", "amount": 900}`)
+	send := func() int {
+		req, _ := http.NewRequest("POST", "/payments/webhook", bytes.NewReader(payload))
+		req.Header.Set(payments.SignatureHeader, gateway.Sign(payload))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, send(), http.StatusOK)
+	payment, err := repo.GetPaymentByOrderId(ctx, "order-1")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, payment.Status, model.PaymentCaptured)
+	assert.Equal(t, payment.CapturedAmount, int64(900))
+
+	// The capture is released, e.g. the gateway rejected a later capture
+	// call. A replay of the old event must not capture the payment again.
+	_, err = repo.AddCapture(ctx, "order-1", -900)
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, send(), http.StatusOK)
+	payment, err = repo.GetPaymentByOrderId(ctx, "order-1")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, payment.Status, model.PaymentAuthorized)
+	assert.Equal(t, payment.CapturedAmount, int64(0))
+}
@@ -2,11 +2,15 @@ package handler_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/RipperAcskt/innotaxi/internal/apperror"
+	"github.com/RipperAcskt/innotaxi/internal/handler"
 	"github.com/RipperAcskt/innotaxi/internal/service"
+	"github.com/gin-gonic/gin"
 	"github.com/go-playground/assert/v2"
 )
 
@@ -27,7 +31,7 @@ func TestGetProfile(t *testing.T) {
 			name: "user does not exist",
 			id:   "2",
 			user: "",
-			code: http.StatusBadRequest,
+			code: http.StatusNotFound,
 			err:  service.ErrUserDoesNotExists,
 		},
 		{
@@ -41,7 +45,7 @@ func TestGetProfile(t *testing.T) {
 
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			r := SetUpRouter()
+			r := SetUpRouter(h)
 			r.GET("/users/profile/:id", h.GetProfile)
 
 			req, _ := http.NewRequest("GET", "/users/profile/"+tt.id, nil)
@@ -65,34 +69,55 @@ func TestUpdateProfile(t *testing.T) {
 	}
 
 	test := []struct {
-		name string
-		id   string
-		body string
-		code int
-		err  error
+		name    string
+		id      string
+		ifMatch string
+		body    string
+		code    int
+		err     error
 	}{
 		{
-			name: "user does not exist",
-			id:   "2",
-			body: "",
-			code: http.StatusBadRequest,
-			err:  service.ErrUserDoesNotExists,
+			name:    "user does not exist",
+			id:      "2",
+			ifMatch: "*",
+			body:    "",
+			code:    http.StatusBadRequest,
+			err:     service.ErrUserDoesNotExists,
 		},
 		{
-			name: "existed user",
+			name:    "missing fields",
+			id:      "1",
+			ifMatch: "*",
+			body:    `{"phone_number": "+77777778","email": "ripper@mail.ru"}`,
+			code:    http.StatusBadRequest,
+			err:     nil,
+		},
+		{
+			name: "without If-Match",
 			id:   "1",
-			body: `{"phone_number": "+77777778","email": "ripper@mail.ru"}`,
-			code: http.StatusOK,
+			body: `{"name": "Ivan", "phone_number": "+77777778","email": "ripper@mail.ru"}`,
+			code: http.StatusPreconditionRequired,
 			err:  nil,
 		},
+		{
+			name:    "existed user",
+			id:      "1",
+			ifMatch: "*",
+			body:    `{"name": "Ivan", "phone_number": "+77777778","email": "ripper@mail.ru"}`,
+			code:    http.StatusOK,
+			err:     nil,
+		},
 	}
 
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			r := SetUpRouter()
+			r := SetUpRouter(h)
 			r.PUT("/users/profile/:id", h.UpdateProfile)
 
 			req, _ := http.NewRequest("PUT", "/users/profile/"+tt.id, bytes.NewBufferString(tt.body))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
 			w := httptest.NewRecorder()
 			r.ServeHTTP(w, req)
 
@@ -103,6 +128,336 @@ func TestUpdateProfile(t *testing.T) {
 	}
 }
 
+func TestPatchProfile(t *testing.T) {
+	h, _ := initObservedHandler(t)
+	r := SetUpRouter(h)
+	r.POST("/users/auth/sing-up", h.SingUp)
+	r.PATCH("/users/profile/:id", h.PatchProfile)
+
+	req, _ := http.NewRequest("POST", "/users/auth/sing-up", bytes.NewBufferString(`{"name": "Ivan", "phone_number": "+7455456", "email": "ripper@algsdh", "password": "12345"}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, w.Code, http.StatusCreated)
+
+	test := []struct {
+		name        string
+		contentType string
+		body        string
+		code        int
+		user        string
+		fields      map[string]interface{}
+	}{
+		{
+			name:        "json",
+			contentType: "application/json",
+			body:        `{"name": "Petr"}`,
+			code:        http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "immutable and removed fields",
+			contentType: "application/merge-patch+json",
+			body:        `{"raiting": 5, "email": null}`,
+			code:        http.StatusBadRequest,
+			fields: map[string]interface{}{
+				"raiting": "can't be changed",
+				"email":   "can't be removed",
+			},
+		},
+		{
+			name:        "invalid value",
+			contentType: "application/merge-patch+json",
+			body:        `{"name": ""}`,
+			code:        http.StatusBadRequest,
+			fields: map[string]interface{}{
+				"name": "must be at most 30 letters, spaces, hyphens, apostrophes or periods, starting with a letter",
+			},
+		},
+		{
+			name:        "not an object",
+			contentType: "application/merge-patch+json",
+			body:        `null`,
+			code:        http.StatusBadRequest,
+		},
+		{
+			name:        "name",
+			contentType: "application/merge-patch+json",
+			body:        `{"name": "Petr"}`,
+			code:        http.StatusOK,
+			user:        `{"name":"Petr","phone_number":"+7455456","email":"ripper@algsdh","raiting":0}`,
+		},
+		{
+			name:        "phone number",
+			contentType: "application/merge-patch+json; charset=utf-8",
+			body:        `{"phone_number": "+7 455 457"}`,
+			code:        http.StatusOK,
+			user:        `{"name":"Petr","phone_number":"+7455457","email":"ripper@algsdh","raiting":0}`,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("PATCH", "/users/profile/1", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req.Header.Set("If-Match", "*")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, tt.code)
+			if tt.user != "" {
+				assert.Equal(t, w.Body.String(), tt.user)
+			}
+			if tt.fields != nil {
+				var problem apperror.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &problem)
+				assert.Equal(t, err, nil)
+				assert.Equal(t, problem.Details["fields"], tt.fields)
+			}
+		})
+	}
+}
+
+func TestProfileETag(t *testing.T) {
+	h, _ := initObservedHandler(t)
+	r := SetUpRouter(h)
+	r.POST("/users/auth/sing-up", h.SingUp)
+	r.GET("/users/profile/:id", h.GetProfile)
+	r.PUT("/users/profile/:id", h.UpdateProfile)
+	r.PATCH("/users/profile/:id", h.PatchProfile)
+
+	req, _ := http.NewRequest("POST", "/users/auth/sing-up", bytes.NewBufferString(`{"name": "Ivan", "phone_number": "+7455456", "email": "ripper@algsdh", "password": "12345"}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, w.Code, http.StatusCreated)
+
+	test := []struct {
+		name    string
+		method  string
+		header  map[string]string
+		body    string
+		code    int
+		etag    string
+		errCode string
+	}{
+		{
+			name:   "get",
+			method: "GET",
+			code:   http.StatusOK,
+			etag:   `"1"`,
+		},
+		{
+			name:   "not modified",
+			method: "GET",
+			header: map[string]string{"If-None-Match": `"1"`},
+			code:   http.StatusNotModified,
+			etag:   `"1"`,
+		},
+		{
+			name:   "not modified weak",
+			method: "GET",
+			header: map[string]string{"If-None-Match": `"7", W/"1"`},
+			code:   http.StatusNotModified,
+			etag:   `"1"`,
+		},
+		{
+			name:   "modified",
+			method: "GET",
+			header: map[string]string{"If-None-Match": `"7"`},
+			code:   http.StatusOK,
+			etag:   `"1"`,
+		},
+		{
+			name:    "patch without If-Match",
+			method:  "PATCH",
+			header:  map[string]string{"Content-Type": "application/merge-patch+json"},
+			body:    `{"name": "Petr"}`,
+			code:    http.StatusPreconditionRequired,
+			errCode: "if_match_required",
+		},
+		{
+			name:   "patch",
+			method: "PATCH",
+			header: map[string]string{"Content-Type": "application/merge-patch+json", "If-Match": `"1"`},
+			body:   `{"name": "Petr"}`,
+			code:   http.StatusOK,
+			etag:   `"2"`,
+		},
+		{
+			name:    "patch stale",
+			method:  "PATCH",
+			header:  map[string]string{"Content-Type": "application/merge-patch+json", "If-Match": `"1"`},
+			body:    `{"name": "Oleg"}`,
+			code:    http.StatusPreconditionFailed,
+			errCode: "user_modified",
+		},
+		{
+			name:    "put weak",
+			method:  "PUT",
+			header:  map[string]string{"If-Match": `W/"2"`},
+			body:    `{"name": "Oleg", "phone_number": "+7455456", "email": "ripper@algsdh"}`,
+			code:    http.StatusPreconditionFailed,
+			errCode: "user_modified",
+		},
+		{
+			name:    "put several",
+			method:  "PUT",
+			header:  map[string]string{"If-Match": `"1", "2"`},
+			body:    `{"name": "Oleg", "phone_number": "+7455456", "email": "ripper@algsdh"}`,
+			code:    http.StatusBadRequest,
+			errCode: "invalid_request",
+		},
+		{
+			name:   "put",
+			method: "PUT",
+			header: map[string]string{"If-Match": `"2"`},
+			body:   `{"name": "Oleg", "phone_number": "+7455456", "email": "ripper@algsdh"}`,
+			code:   http.StatusOK,
+			etag:   `"3"`,
+		},
+		{
+			name:   "previous version modified",
+			method: "GET",
+			header: map[string]string{"If-None-Match": `"2"`},
+			code:   http.StatusOK,
+			etag:   `"3"`,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, "/users/profile/1", bytes.NewBufferString(tt.body))
+			for name, value := range tt.header {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, tt.code)
+			assert.Equal(t, w.Header().Get("ETag"), tt.etag)
+			if tt.code == http.StatusNotModified {
+				assert.Equal(t, w.Body.Len(), 0)
+			}
+			if tt.errCode != "" {
+				var problem apperror.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &problem)
+				assert.Equal(t, err, nil)
+				assert.Equal(t, problem.Code, tt.errCode)
+			}
+		})
+	}
+}
+
+func TestRestoreUser(t *testing.T) {
+	h, _ := initObservedHandler(t)
+	r := SetUpRouter(h)
+	r.POST("/users/auth/sing-up", h.SingUp)
+	r.POST("/users/auth/sing-in", h.SingIn)
+	r.GET("/users/profile/:id", h.GetProfile)
+	r.DELETE("/users/:id", h.DeleteUser)
+
+	test := []struct {
+		name    string
+		method  string
+		url     string
+		body    string
+		code    int
+		errCode string
+	}{
+		{
+			name:   "sing up",
+			method: "POST",
+			url:    "/users/auth/sing-up",
+			body:   `{"name": "Ivan", "phone_number": "+7455456", "email": "ripper@algsdh", "password": "12345"}`,
+			code:   http.StatusCreated,
+		},
+		{
+			name:   "delete",
+			method: "DELETE",
+			url:    "/users/1",
+			code:   http.StatusOK,
+		},
+		{
+			name:    "sing in deleted user",
+			method:  "POST",
+			url:     "/users/auth/sing-in",
+			body:    `{"phone_number": "+7455456", "password": "12345"}`,
+			code:    http.StatusUnprocessableEntity,
+			errCode: "user_deleted",
+		},
+		{
+			name:    "restore with incorrect password",
+			method:  "POST",
+			url:     "/users/auth/sing-in",
+			body:    `{"phone_number": "+7455456", "password": "54321", "restore": true}`,
+			code:    http.StatusForbidden,
+			errCode: "invalid_credentials",
+		},
+		{
+			name:    "deleted profile",
+			method:  "GET",
+			url:     "/users/profile/1",
+			code:    http.StatusNotFound,
+			errCode: "user_does_not_exist",
+		},
+		{
+			name:   "restore",
+			method: "POST",
+			url:    "/users/auth/sing-in",
+			body:   `{"phone_number": "+7455456", "password": "12345", "restore": true}`,
+			code:   http.StatusOK,
+		},
+		{
+			name:   "restored profile",
+			method: "GET",
+			url:    "/users/profile/1",
+			code:   http.StatusOK,
+		},
+		{
+			name:   "sing in restored user",
+			method: "POST",
+			url:    "/users/auth/sing-in",
+			body:   `{"phone_number": "+7455456", "password": "12345"}`,
+			code:   http.StatusOK,
+		},
+		{
+			name:   "delete again",
+			method: "DELETE",
+			url:    "/users/1",
+			code:   http.StatusOK,
+		},
+		{
+			name:   "sing up with the email",
+			method: "POST",
+			url:    "/users/auth/sing-up",
+			body:   `{"name": "Petr", "phone_number": "+7455457", "email": "ripper@algsdh", "password": "12345"}`,
+			code:   http.StatusCreated,
+		},
+		{
+			name:    "restore taken email",
+			method:  "POST",
+			url:     "/users/auth/sing-in",
+			body:    `{"phone_number": "+7455456", "password": "12345", "restore": true}`,
+			code:    http.StatusConflict,
+			errCode: "user_already_exists",
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.url, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, tt.code)
+			if tt.errCode != "" {
+				var problem apperror.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &problem)
+				assert.Equal(t, err, nil)
+				assert.Equal(t, problem.Code, tt.errCode)
+			}
+		})
+	}
+}
+
 func TestDeleteUser(t *testing.T) {
 	h, err := InitHandler()
 	if err != nil {
@@ -118,7 +473,7 @@ func TestDeleteUser(t *testing.T) {
 		{
 			name: "user does not exist",
 			id:   "2",
-			code: http.StatusBadRequest,
+			code: http.StatusNotFound,
 			err:  service.ErrUserDoesNotExists,
 		},
 		{
@@ -131,7 +486,7 @@ func TestDeleteUser(t *testing.T) {
 
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			r := SetUpRouter()
+			r := SetUpRouter(h)
 			r.DELETE("/users/:id", h.DeleteUser)
 
 			req, _ := http.NewRequest("DELETE", "/users/"+tt.id, nil)
@@ -144,3 +499,118 @@ func TestDeleteUser(t *testing.T) {
 		})
 	}
 }
+
+// TestIdempotentSingUp runs after the other user tests, because they expect
+// to find only the first user.
+func TestIdempotentSingUp(t *testing.T) {
+	h, err := InitHandler()
+	if err != nil {
+		t.Errorf("init handler failed: %v", err)
+	}
+
+	test := []struct {
+		name     string
+		key      string
+		body     string
+		code     int
+		replayed string
+	}{
+		{
+			name: "new user",
+			key:  "sing-up-1",
+			body: `{"name": "Ivan", "phone_number": "+7455457", "email": "ripper@idempotent", "password": "12345"}`,
+			code: http.StatusCreated,
+		},
+		{
+			name:     "retry",
+			key:      "sing-up-1",
+			body:     `{"name": "Ivan", "phone_number": "+7455457", "email": "ripper@idempotent", "password": "12345"}`,
+			code:     http.StatusCreated,
+			replayed: "true",
+		},
+		{
+			name: "key reused with different body",
+			key:  "sing-up-1",
+			body: `{"name": "Petr", "phone_number": "+7455458", "email": "ripper@idempotent", "password": "12345"}`,
+			code: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "retry without key",
+			body: `{"name": "Ivan", "phone_number": "+7455457", "email": "ripper@idempotent", "password": "12345"}`,
+			code: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			r := SetUpRouter(h)
+			r.POST("/users/auth/sing-up", h.Idempotency(), h.SingUp)
+
+			req, _ := http.NewRequest("POST", "/users/auth/sing-up", bytes.NewBufferString(tt.body))
+			if tt.key != "" {
+				req.Header.Set(handler.IdempotencyKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, tt.replayed, w.Header().Get(handler.IdempotentReplayHeader))
+		})
+	}
+}
+
+func TestIdempotency(t *testing.T) {
+	h, err := InitHandler()
+	if err != nil {
+		t.Errorf("init handler failed: %v", err)
+	}
+
+	t.Run("in flight", func(t *testing.T) {
+		started, release := make(chan struct{}), make(chan struct{})
+		r := SetUpRouter(h)
+		r.POST("/slow", h.Idempotency(), func(c *gin.Context) {
+			close(started)
+			<-release
+			c.Status(http.StatusCreated)
+		})
+
+		first := httptest.NewRecorder()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			req, _ := http.NewRequest("POST", "/slow", bytes.NewBufferString(`{}`))
+			req.Header.Set(handler.IdempotencyKeyHeader, "in-flight")
+			r.ServeHTTP(first, req)
+		}()
+		<-started
+
+		req, _ := http.NewRequest("POST", "/slow", bytes.NewBufferString(`{}`))
+		req.Header.Set(handler.IdempotencyKeyHeader, "in-flight")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		close(release)
+		<-done
+		assert.Equal(t, http.StatusCreated, first.Code)
+	})
+
+	t.Run("server error is not stored", func(t *testing.T) {
+		code := http.StatusInternalServerError
+		r := SetUpRouter(h)
+		r.DELETE("/flaky", h.Idempotency(), func(c *gin.Context) {
+			c.Status(code)
+		})
+
+		for _, want := range []int{http.StatusInternalServerError, http.StatusNoContent} {
+			code = want
+			req, _ := http.NewRequest("DELETE", "/flaky", nil)
+			req.Header.Set(handler.IdempotencyKeyHeader, "flaky")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, want, w.Code)
+			assert.Equal(t, "", w.Header().Get(handler.IdempotentReplayHeader))
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RipperAcskt/innotaxi/internal/apperror"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestSingUpValidation(t *testing.T) {
+	h, _ := initObservedHandler(t)
+	r := h.InitRouters()
+
+	test := []struct {
+		name   string
+		body   string
+		code   int
+		fields map[string]interface{}
+	}{
+		{
+			name: "invalid fields",
+			body: `{"name": "Ivan1", "phone_number": "+37529", "email": "Ivan <ivan@mail.ru>", "password": "12345"}`,
+			code: http.StatusBadRequest,
+			fields: map[string]interface{}{
+				"name":         "must be at most 30 letters, spaces, hyphens, apostrophes or periods, starting with a letter",
+				"phone_number": "must be a phone number, e.g. +375291234567",
+				"email":        "must be an email address",
+			},
+		},
+		{
+			name: "too long email",
+			body: `{"name": "Ivan", "phone_number": "+375291234567", "email": "ivan@` + string(bytes.Repeat([]byte("a"), 250)) + `.ru", "password": "12345"}`,
+			code: http.StatusBadRequest,
+			fields: map[string]interface{}{
+				"email": "must be an email address",
+			},
+		},
+		{
+			name: "formatted phone",
+			body: `{"name": "Ivan Petrov", "phone_number": "+375 (29) 123-45-67", "email": "ivan@mail.ru", "password": "12345"}`,
+			code: http.StatusCreated,
+		},
+		{
+			name: "same phone in other format",
+			body: `{"name": "Ivan Petrov", "phone_number": "00375291234567", "email": "petrov@mail.ru", "password": "12345"}`,
+			code: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/users/auth/sing-up", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, tt.code)
+			if tt.fields == nil {
+				return
+			}
+
+			var problem apperror.Problem
+			err := json.Unmarshal(w.Body.Bytes(), &problem)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, problem.Code, "invalid_request")
+			assert.Equal(t, problem.Details["fields"], tt.fields)
+		})
+	}
+
+	t.Run("sign in with formatted phone", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/users/auth/sing-in", bytes.NewBufferString(`{"phone_number": "+375-29-123-45-67", "password": "12345"}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, w.Code, http.StatusOK)
+	})
+}
+
+func TestAddPaymentMethodValidation(t *testing.T) {
+	h, _ := initObservedHandler(t)
+	r := SetUpRouter(h)
+	r.POST("/users/profile/:id/payment-methods", h.AddPaymentMethod)
+
+	body := `{"number": "4242", "exp_month": 13, "exp_year": 2030, "holder": "IVAN PETROV"}`
+	req, _ := http.NewRequest("POST", "/users/profile/1/payment-methods", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+
+	var problem apperror.Problem
+	err := json.Unmarshal(w.Body.Bytes(), &problem)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, problem.Code, "invalid_request")
+	assert.Equal(t, problem.Details["fields"], map[string]interface{}{
+		"number":    "must be at least 12 characters",
+		"exp_month": "must be at most 12",
+		"cvc":       "is required",
+	})
+}